@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"market/internal/config"
 	ctrl "market/internal/controller/http"
@@ -10,9 +11,12 @@ import (
 	"market/internal/server"
 	"market/internal/service"
 	"market/pkg/auth"
-	"market/pkg/cloud"
 	"market/pkg/database/postgres"
+	"market/pkg/exchange"
 	"market/pkg/hash"
+	"market/pkg/mail"
+	"market/pkg/storage"
+	"math/big"
 	"os"
 	"os/signal"
 	"syscall"
@@ -24,7 +28,18 @@ import (
 	"go.uber.org/zap"
 )
 
-const timeout = 5 * time.Second
+const (
+	timeout          = 5 * time.Second
+	defaultHMACKeyID = "default"
+	mailDriverSMTP   = "smtp"
+	mailDriverLog    = "log"
+
+	storageDriverLocal      = "local"
+	storageDriverS3         = "s3"
+	storageDriverCloudinary = "cloudinary"
+
+	ratesDriverStatic = "static"
+)
 
 // @title Market API
 // @version 1.0
@@ -58,23 +73,86 @@ func Run(configDir string) {
 		return
 	}
 
-	cld, err := cloud.NewCloudinary(cfg.Cloudinary.Cloud, cfg.Cloudinary.Key, cfg.Cloudinary.Secret)
+	store, err := newStorage(cfg)
+	if err != nil {
+		logger.Errorf("Error occurred while loading storage: %s\n", err.Error())
+		return
+	}
+
+	rates, err := newRates(cfg)
 	if err != nil {
-		logger.Errorf("Error occurred while loading Cloudinary: %s\n", err.Error())
+		logger.Errorf("Error occurred while loading exchange rates: %s\n", err.Error())
 		return
 	}
 
-	hasher := hash.NewArgon2Hasher(cfg.Auth.Argon2.MemoryMegaBytes<<18, cfg.Auth.Argon2.Iterations, cfg.Auth.Argon2.SaltLength, //nolint:gomnd
+	if !model.ValidCurrency(cfg.Money.DefaultCurrency) {
+		logger.Errorf("Unsupported default currency %q\n", cfg.Money.DefaultCurrency)
+		return
+	}
+
+	orderConfig, err := newOrderConfig(cfg)
+	if err != nil {
+		logger.Errorf("Error occurred while loading order config: %s\n", err.Error())
+		return
+	}
+
+	hasher := hash.NewArgon2Hasher(cfg.Auth.Argon2.MemoryMegaBytes<<10, cfg.Auth.Argon2.Iterations, cfg.Auth.Argon2.SaltLength, //nolint:gomnd
 		cfg.Auth.Argon2.KeyLength, cfg.Auth.Argon2.Parallelism)
 
-	tokenManager, err := auth.NewManager(cfg.Auth.JWT.SigningKey)
+	activeKey, verificationKeys, err := loadJWTKeys(cfg.Auth.JWT)
+	if err != nil {
+		logger.Errorf("Error occurred while loading JWT keys: %s\n", err.Error())
+		return
+	}
+
+	tokenManager, err := auth.NewManager(auth.Options{
+		Issuer:   cfg.Auth.JWT.Issuer,
+		Audience: cfg.Auth.JWT.Audience,
+		Leeway:   cfg.Auth.JWT.Leeway,
+	}, activeKey, verificationKeys...)
 	if err != nil {
 		logger.Errorf("Error occurred while creating tokenManager: %s\n", err.Error())
 		return
 	}
 
+	mailer, closeMailer, err := newMailer(cfg.Mail)
+	if err != nil {
+		logger.Errorf("Error occurred while creating mailer: %s\n", err.Error())
+		return
+	}
+	defer closeMailer() //nolint:errcheck
+
 	repos := repository.NewRepository(db)
-	services := service.NewService(repos, cld, hasher, tokenManager, cfg.Auth.JWT.AccessTokenTTL)
+	services := service.NewService(service.Deps{
+		Repos:           repos,
+		Storage:         store,
+		Hasher:          hasher,
+		TokenManager:    tokenManager,
+		AccessTokenTTL:  cfg.Auth.JWT.AccessTokenTTL,
+		RefreshTokenTTL: cfg.Auth.JWT.RefreshTokenTTL,
+		LoginThrottle: service.LoginThrottleConfig{
+			MaxFailures:     cfg.Auth.LoginThrottle.MaxFailures,
+			LockoutDuration: cfg.Auth.LoginThrottle.LockoutDuration,
+			BaseDelay:       cfg.Auth.LoginThrottle.BaseDelay,
+			MaxDelay:        cfg.Auth.LoginThrottle.MaxDelay,
+		},
+		Mailer: mailer,
+		Email: service.EmailConfig{
+			VerificationTTL:  cfg.Auth.EmailTokens.VerificationTTL,
+			ResetTTL:         cfg.Auth.EmailTokens.ResetTTL,
+			VerifyEmailURL:   cfg.Auth.EmailTokens.VerifyEmailURL,
+			ResetPasswordURL: cfg.Auth.EmailTokens.ResetPasswordURL,
+		},
+		TwoFactor: service.TwoFactorConfig{
+			Issuer:       cfg.Auth.TwoFactor.Issuer,
+			ChallengeTTL: cfg.Auth.TwoFactor.ChallengeTTL,
+		},
+		Rates:           rates,
+		DefaultCurrency: cfg.Money.DefaultCurrency,
+		Order:           orderConfig,
+		Cart:            service.CartConfig{ReservationTTL: cfg.Cart.ReservationTTL},
+		Logger:          logger,
+	})
 
 	validate := validator.New()
 	if err = model.RegisterCustomValidations(validate); err != nil {
@@ -82,12 +160,31 @@ func Run(configDir string) {
 		return
 	}
 
-	h := ctrl.NewHandler(services, validate, logger, tokenManager)
+	staticDir := ""
+	if cfg.Storage.Driver == storageDriverLocal {
+		staticDir = cfg.Storage.Local.Dir
+	}
+
+	h := ctrl.NewHandler(services, validate, logger, tokenManager, staticDir)
 
 	mux := h.InitRoutes()
 
 	srv := server.NewServer(cfg, mux)
 
+	outboxCtx, stopOutbox := context.WithCancel(context.Background())
+	outboxDone := make(chan struct{})
+	go func() {
+		runOutbox(outboxCtx, services.Outbox, cfg.Outbox, logger)
+		close(outboxDone)
+	}()
+
+	sweeperCtx, stopSweeper := context.WithCancel(context.Background())
+	sweeperDone := make(chan struct{})
+	go func() {
+		runReservationSweeper(sweeperCtx, services.Cart, cfg.Cart, logger)
+		close(sweeperDone)
+	}()
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGTERM, syscall.SIGINT)
 
@@ -110,7 +207,102 @@ func Run(configDir string) {
 		logger.Error(err.Error())
 	}
 
+	stopOutbox()
+	<-outboxDone
+	stopSweeper()
+	<-sweeperDone
+
 	if err := db.Close(); err != nil {
 		logger.Error(err.Error())
 	}
 }
+
+func loadJWTKeys(cfg config.JWTConfig) (auth.Key, []auth.Key, error) {
+	if cfg.ActiveKey.Path == "" {
+		key, err := auth.NewHMACKey(defaultHMACKeyID, cfg.SigningKey)
+		return key, nil, err
+	}
+
+	activeKey, err := auth.LoadKey(cfg.ActiveKey.ID, cfg.ActiveKey.Path, cfg.ActiveKey.ExpiresAt)
+	if err != nil {
+		return auth.Key{}, nil, err
+	}
+
+	verificationKeys := make([]auth.Key, 0, len(cfg.VerificationKeys))
+	for _, keyCfg := range cfg.VerificationKeys {
+		key, err := auth.LoadKey(keyCfg.ID, keyCfg.Path, keyCfg.ExpiresAt)
+		if err != nil {
+			return auth.Key{}, nil, err
+		}
+		verificationKeys = append(verificationKeys, key)
+	}
+
+	return activeKey, verificationKeys, nil
+}
+
+// newMailer returns the mailer selected in the config and a function releasing
+// its resources.
+func newMailer(cfg config.MailConfig) (mail.Mailer, func() error, error) {
+	switch cfg.Driver {
+	case mailDriverSMTP:
+		return mail.NewSMTPMailer(cfg.Host, cfg.Port, cfg.Username, cfg.Password, cfg.From), func() error { return nil }, nil
+	case mailDriverLog:
+		if cfg.LogPath == "" {
+			return mail.NewLogMailer(os.Stdout), func() error { return nil }, nil
+		}
+		f, err := os.OpenFile(cfg.LogPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600) //nolint:gomnd
+		if err != nil {
+			return nil, nil, err
+		}
+		return mail.NewLogMailer(f), f.Close, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown mail driver %q", cfg.Driver)
+	}
+}
+
+// newStorage returns the file storage selected in the config.
+func newStorage(cfg *config.Config) (storage.Storage, error) {
+	switch cfg.Storage.Driver {
+	case storageDriverLocal:
+		return storage.NewLocal(cfg.Storage.Local.Dir, cfg.Storage.Local.BaseURL)
+	case storageDriverS3:
+		return storage.NewS3(storage.S3Options{
+			Endpoint:  cfg.Storage.S3.Endpoint,
+			Region:    cfg.Storage.S3.Region,
+			Bucket:    cfg.Storage.S3.Bucket,
+			AccessKey: cfg.Storage.S3.AccessKey,
+			SecretKey: cfg.Storage.S3.SecretKey,
+			UseSSL:    cfg.Storage.S3.UseSSL,
+			PublicURL: cfg.Storage.S3.PublicURL,
+		})
+	case storageDriverCloudinary:
+		return storage.NewCloudinary(cfg.Cloudinary.Cloud, cfg.Cloudinary.Key, cfg.Cloudinary.Secret)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Storage.Driver)
+	}
+}
+
+// newRates returns the exchange rate provider selected in the config.
+func newRates(cfg *config.Config) (exchange.Rates, error) {
+	switch cfg.Money.Rates.Driver {
+	case ratesDriverStatic:
+		return exchange.NewStaticFromFile(cfg.Money.Rates.File)
+	default:
+		return nil, fmt.Errorf("unknown exchange rates driver %q", cfg.Money.Rates.Driver)
+	}
+}
+
+// newOrderConfig parses the tax rate and the shipping fee of the config.
+func newOrderConfig(cfg *config.Config) (service.OrderConfig, error) {
+	taxRate, ok := new(big.Rat).SetString(cfg.Order.TaxRate)
+	if !ok || taxRate.Sign() < 0 {
+		return service.OrderConfig{}, fmt.Errorf("invalid tax rate %q", cfg.Order.TaxRate)
+	}
+
+	shippingFee, err := model.ParseMoney(cfg.Order.ShippingFee, cfg.Money.DefaultCurrency)
+	if err != nil || shippingFee.Amount < 0 {
+		return service.OrderConfig{}, fmt.Errorf("invalid shipping fee %q", cfg.Order.ShippingFee)
+	}
+
+	return service.OrderConfig{TaxRate: taxRate, ShippingFee: shippingFee}, nil
+}
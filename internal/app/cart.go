@@ -0,0 +1,44 @@
+package app
+
+import (
+	"context"
+	"market/internal/config"
+	"market/internal/service"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// runReservationSweeper releases the stock of expired cart reservations every
+// sweep interval until ctx is done.
+func runReservationSweeper(ctx context.Context, cart service.Cart, cfg config.CartConfig, logger *zap.SugaredLogger) {
+	sweep := time.NewTicker(cfg.SweepInterval)
+	defer sweep.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sweep.C:
+			releaseExpiredReservations(ctx, cart, cfg.SweepBatchSize, logger)
+		}
+	}
+}
+
+// releaseExpiredReservations releases batches of expired reservations until a
+// batch isn't full.
+func releaseExpiredReservations(ctx context.Context, cart service.Cart, batchSize int, logger *zap.SugaredLogger) {
+	for ctx.Err() == nil {
+		released, err := cart.ReleaseExpiredReservations(batchSize)
+		if err != nil {
+			logger.Errorf("Error occurred while releasing expired reservations: %s", err.Error())
+			return
+		}
+		if released != 0 {
+			logger.Infof("%v expired cart reservations were released", released)
+		}
+		if released < batchSize {
+			return
+		}
+	}
+}
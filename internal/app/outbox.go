@@ -0,0 +1,54 @@
+package app
+
+import (
+	"context"
+	"market/internal/config"
+	"market/internal/service"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// runOutbox performs the outbox messages and reconciles the stored images
+// until ctx is done.
+func runOutbox(ctx context.Context, outbox service.Outbox, cfg config.OutboxConfig, logger *zap.SugaredLogger) {
+	poll := time.NewTicker(cfg.PollInterval)
+	defer poll.Stop()
+	reconcile := time.NewTicker(cfg.ReconcileInterval)
+	defer reconcile.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+			processOutbox(ctx, outbox, cfg.BatchSize, logger)
+		case <-reconcile.C:
+			queued, err := outbox.ReconcileImages(cfg.OrphanMinAge)
+			if err != nil {
+				logger.Errorf("Error occurred while reconciling images: %s", err.Error())
+				continue
+			}
+			if queued != 0 {
+				logger.Infof("%v orphaned images were queued for deletion", queued)
+			}
+		}
+	}
+}
+
+// processOutbox performs batches of due messages until a batch isn't full.
+func processOutbox(ctx context.Context, outbox service.Outbox, batchSize int, logger *zap.SugaredLogger) {
+	for ctx.Err() == nil {
+		done, failed, err := outbox.Process(ctx, batchSize)
+		if err != nil {
+			logger.Errorf("Error occurred while processing outbox: %s", err.Error())
+			return
+		}
+		if failed != 0 {
+			logger.Warnf("%v outbox messages failed and will be retried", failed)
+		}
+		if done+failed < batchSize {
+			return
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/viper"
 )
 
@@ -12,6 +13,34 @@ const (
 	defaultHTTPRWTimeout           = 10 * time.Second
 	defaultHTTPMaxHeaderMegabytes  = 1
 	defaultDatabaseRefreshInterval = 30 * time.Second
+	defaultAccessTokenTTL          = 15 * time.Minute
+	defaultRefreshTokenTTL         = 30 * 24 * time.Hour
+	defaultJWTIssuer               = "market"
+	defaultJWTLeeway               = 30 * time.Second
+	defaultLoginMaxFailures        = 5
+	defaultLoginLockoutDuration    = 15 * time.Minute
+	defaultLoginBaseDelay          = time.Second
+	defaultLoginMaxDelay           = 5 * time.Minute
+	defaultMailDriver              = "log"
+	defaultMailPort                = 587
+	defaultVerificationTokenTTL    = 24 * time.Hour
+	defaultResetTokenTTL           = time.Hour
+	defaultMFAChallengeTTL         = 5 * time.Minute
+	defaultStorageDriver           = "local"
+	defaultStorageLocalDir         = "./uploads"
+	defaultStorageLocalBaseURL     = "http://localhost:8080/static"
+	defaultOutboxPollInterval      = 10 * time.Second
+	defaultOutboxBatchSize         = 50
+	defaultOutboxReconcileInterval = time.Hour
+	defaultOutboxOrphanMinAge      = time.Hour
+	defaultCurrency                = "RUB"
+	defaultRatesDriver             = "static"
+	defaultRatesFile               = "./configs/rates.json"
+	defaultOrderTaxRate            = "0"
+	defaultOrderShippingFee        = "0"
+	defaultCartReservationTTL      = 15 * time.Minute
+	defaultCartSweepInterval       = time.Minute
+	defaultCartSweepBatchSize      = 100
 )
 
 type (
@@ -19,7 +48,13 @@ type (
 		Postgres   PostgresConfig
 		HTTP       HTTPConfig
 		Cloudinary CloudinaryConfig
+		Storage    StorageConfig
+		Outbox     OutboxConfig
+		Money      MoneyConfig
+		Order      OrderConfig
+		Cart       CartConfig
 		Auth       AuthConfig
+		Mail       MailConfig
 	}
 
 	PostgresConfig struct {
@@ -40,23 +75,120 @@ type (
 	}
 
 	AuthConfig struct {
-		JWT    JWTConfig
-		Argon2 Argon2Config
+		JWT           JWTConfig
+		Argon2        Argon2Config
+		LoginThrottle LoginThrottleConfig
+		EmailTokens   EmailTokensConfig
+		TwoFactor     TwoFactorConfig
+	}
+
+	TwoFactorConfig struct {
+		Issuer       string        `mapstructure:"issuer"`
+		ChallengeTTL time.Duration `mapstructure:"challengeTTL"`
+	}
+
+	EmailTokensConfig struct {
+		VerificationTTL  time.Duration `mapstructure:"verificationTTL"`
+		ResetTTL         time.Duration `mapstructure:"resetTTL"`
+		VerifyEmailURL   string        `mapstructure:"verifyEmailURL"`
+		ResetPasswordURL string        `mapstructure:"resetPasswordURL"`
+	}
+
+	MailConfig struct {
+		Driver   string `mapstructure:"driver"`
+		From     string `mapstructure:"from"`
+		Host     string `mapstructure:"host"`
+		Port     int    `mapstructure:"port"`
+		Username string `mapstructure:"username"`
+		Password string
+		LogPath  string `mapstructure:"logPath"`
+	}
+
+	LoginThrottleConfig struct {
+		MaxFailures     int           `mapstructure:"maxFailures"`
+		LockoutDuration time.Duration `mapstructure:"lockoutDuration"`
+		BaseDelay       time.Duration `mapstructure:"baseDelay"`
+		MaxDelay        time.Duration `mapstructure:"maxDelay"`
 	}
 
 	JWTConfig struct {
-		AccessTokenTTL time.Duration `mapstructure:"accessTokenTTL"`
-		SigningKey     string
+		AccessTokenTTL   time.Duration  `mapstructure:"accessTokenTTL"`
+		RefreshTokenTTL  time.Duration  `mapstructure:"refreshTokenTTL"`
+		Issuer           string         `mapstructure:"issuer"`
+		Audience         []string       `mapstructure:"audience"`
+		Leeway           time.Duration  `mapstructure:"leeway"`
+		ActiveKey        JWTKeyConfig   `mapstructure:"activeKey"`
+		VerificationKeys []JWTKeyConfig `mapstructure:"verificationKeys"`
+		SigningKey       string
+	}
+
+	JWTKeyConfig struct {
+		ID        string    `mapstructure:"id"`
+		Path      string    `mapstructure:"path"`
+		ExpiresAt time.Time `mapstructure:"expiresAt"`
 	}
 
 	Argon2Config struct {
-		MemoryMegaBytes uint32 `mapstructure:"memory"`
+		MemoryMegaBytes uint32 `mapstructure:"memoryMegaBytes"`
 		Iterations      uint32 `mapstructure:"iterations"`
 		Parallelism     uint8  `mapstructure:"parallelism"`
 		SaltLength      uint32 `mapstructure:"saltLength"`
 		KeyLength       uint32 `mapstructure:"keyLength"`
 	}
 
+	StorageConfig struct {
+		Driver string             `mapstructure:"driver"`
+		Local  LocalStorageConfig `mapstructure:"local"`
+		S3     S3StorageConfig    `mapstructure:"s3"`
+	}
+
+	LocalStorageConfig struct {
+		Dir     string `mapstructure:"dir"`
+		BaseURL string `mapstructure:"baseURL"`
+	}
+
+	S3StorageConfig struct {
+		Endpoint  string `mapstructure:"endpoint"`
+		Region    string `mapstructure:"region"`
+		Bucket    string `mapstructure:"bucket"`
+		UseSSL    bool   `mapstructure:"useSSL"`
+		PublicURL string `mapstructure:"publicURL"`
+		AccessKey string
+		SecretKey string
+	}
+
+	OutboxConfig struct {
+		PollInterval      time.Duration `mapstructure:"pollInterval"`
+		BatchSize         int           `mapstructure:"batchSize"`
+		ReconcileInterval time.Duration `mapstructure:"reconcileInterval"`
+		OrphanMinAge      time.Duration `mapstructure:"orphanMinAge"`
+	}
+
+	MoneyConfig struct {
+		DefaultCurrency string      `mapstructure:"defaultCurrency"`
+		Rates           RatesConfig `mapstructure:"rates"`
+	}
+
+	RatesConfig struct {
+		Driver string `mapstructure:"driver"`
+		File   string `mapstructure:"file"`
+	}
+
+	// CartConfig sets how long cart lines reserve stock, zero turns
+	// reservations off, and how often expired reservations are released.
+	CartConfig struct {
+		ReservationTTL time.Duration `mapstructure:"reservationTTL"`
+		SweepInterval  time.Duration `mapstructure:"sweepInterval"`
+		SweepBatchSize int           `mapstructure:"sweepBatchSize"`
+	}
+
+	// OrderConfig holds decimal strings: TaxRate is the share of the subtotal,
+	// e.g. 0.2, ShippingFee is in the default currency.
+	OrderConfig struct {
+		TaxRate     string `mapstructure:"taxRate"`
+		ShippingFee string `mapstructure:"shippingFee"`
+	}
+
 	CloudinaryConfig struct {
 		Cloud  string
 		Key    string
@@ -86,7 +218,46 @@ func unmarshal(cfg *Config) error {
 		return err
 	}
 
-	if err := viper.UnmarshalKey("auth", &cfg.Auth.JWT); err != nil {
+	if err := viper.UnmarshalKey("auth", &cfg.Auth.JWT, viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToTimeHookFunc(time.RFC3339),
+	))); err != nil {
+		return err
+	}
+
+	if err := viper.UnmarshalKey("auth.loginThrottle", &cfg.Auth.LoginThrottle); err != nil {
+		return err
+	}
+
+	if err := viper.UnmarshalKey("auth.emailTokens", &cfg.Auth.EmailTokens); err != nil {
+		return err
+	}
+
+	if err := viper.UnmarshalKey("auth.twoFactor", &cfg.Auth.TwoFactor); err != nil {
+		return err
+	}
+
+	if err := viper.UnmarshalKey("mail", &cfg.Mail); err != nil {
+		return err
+	}
+
+	if err := viper.UnmarshalKey("storage", &cfg.Storage); err != nil {
+		return err
+	}
+
+	if err := viper.UnmarshalKey("outbox", &cfg.Outbox); err != nil {
+		return err
+	}
+
+	if err := viper.UnmarshalKey("money", &cfg.Money); err != nil {
+		return err
+	}
+
+	if err := viper.UnmarshalKey("order", &cfg.Order); err != nil {
+		return err
+	}
+
+	if err := viper.UnmarshalKey("cart", &cfg.Cart); err != nil {
 		return err
 	}
 
@@ -107,8 +278,11 @@ func setFromEnv(cfg *Config) {
 	cfg.Cloudinary.Cloud = os.Getenv("CLOUDINARY_CLOUD")
 	cfg.Cloudinary.Key = os.Getenv("CLOUDINARY_KEY")
 	cfg.Cloudinary.Secret = os.Getenv("CLOUDINARY_SECRET")
+	cfg.Storage.S3.AccessKey = os.Getenv("S3_ACCESS_KEY")
+	cfg.Storage.S3.SecretKey = os.Getenv("S3_SECRET_KEY")
 
 	cfg.Auth.JWT.SigningKey = os.Getenv("JWT_SIGNING_KEY")
+	cfg.Mail.Password = os.Getenv("SMTP_PASSWORD")
 }
 
 func parseConfigFile(folder string) error {
@@ -127,4 +301,34 @@ func setDefaults() {
 	viper.SetDefault("http.maxHeaderMegaBytes", defaultHTTPMaxHeaderMegabytes)
 	viper.SetDefault("http.readTimeout", defaultHTTPRWTimeout)
 	viper.SetDefault("http.writeTimeout", defaultHTTPRWTimeout)
+	viper.SetDefault("auth.accessTokenTTL", defaultAccessTokenTTL)
+	viper.SetDefault("auth.refreshTokenTTL", defaultRefreshTokenTTL)
+	viper.SetDefault("auth.issuer", defaultJWTIssuer)
+	viper.SetDefault("auth.audience", []string{defaultJWTIssuer})
+	viper.SetDefault("auth.leeway", defaultJWTLeeway)
+	viper.SetDefault("auth.loginThrottle.maxFailures", defaultLoginMaxFailures)
+	viper.SetDefault("auth.loginThrottle.lockoutDuration", defaultLoginLockoutDuration)
+	viper.SetDefault("auth.loginThrottle.baseDelay", defaultLoginBaseDelay)
+	viper.SetDefault("auth.loginThrottle.maxDelay", defaultLoginMaxDelay)
+	viper.SetDefault("auth.emailTokens.verificationTTL", defaultVerificationTokenTTL)
+	viper.SetDefault("auth.emailTokens.resetTTL", defaultResetTokenTTL)
+	viper.SetDefault("auth.twoFactor.issuer", defaultJWTIssuer)
+	viper.SetDefault("auth.twoFactor.challengeTTL", defaultMFAChallengeTTL)
+	viper.SetDefault("mail.driver", defaultMailDriver)
+	viper.SetDefault("mail.port", defaultMailPort)
+	viper.SetDefault("storage.driver", defaultStorageDriver)
+	viper.SetDefault("storage.local.dir", defaultStorageLocalDir)
+	viper.SetDefault("storage.local.baseURL", defaultStorageLocalBaseURL)
+	viper.SetDefault("outbox.pollInterval", defaultOutboxPollInterval)
+	viper.SetDefault("outbox.batchSize", defaultOutboxBatchSize)
+	viper.SetDefault("outbox.reconcileInterval", defaultOutboxReconcileInterval)
+	viper.SetDefault("outbox.orphanMinAge", defaultOutboxOrphanMinAge)
+	viper.SetDefault("money.defaultCurrency", defaultCurrency)
+	viper.SetDefault("money.rates.driver", defaultRatesDriver)
+	viper.SetDefault("money.rates.file", defaultRatesFile)
+	viper.SetDefault("order.taxRate", defaultOrderTaxRate)
+	viper.SetDefault("order.shippingFee", defaultOrderShippingFee)
+	viper.SetDefault("cart.reservationTTL", defaultCartReservationTTL)
+	viper.SetDefault("cart.sweepInterval", defaultCartSweepInterval)
+	viper.SetDefault("cart.sweepBatchSize", defaultCartSweepBatchSize)
 }
@@ -5,6 +5,7 @@ import (
 	"market/internal/service"
 	"market/pkg/auth"
 	"net/http"
+	"strings"
 
 	_ "market/docs"
 
@@ -19,14 +20,18 @@ type Handler struct {
 	services     *service.Service
 	tokenManager auth.TokenManager
 	validator    *validator.Validate
+	staticDir    string
 }
 
-func NewHandler(services *service.Service, validator *validator.Validate, logger *zap.SugaredLogger, tokenManager auth.TokenManager) *Handler {
+// NewHandler serves the files in staticDir under /static/ unless it's empty.
+func NewHandler(services *service.Service, validator *validator.Validate, logger *zap.SugaredLogger, tokenManager auth.TokenManager,
+	staticDir string) *Handler {
 	return &Handler{
 		services:     services,
 		validator:    validator,
 		logger:       logger,
 		tokenManager: tokenManager,
+		staticDir:    staticDir,
 	}
 }
 
@@ -39,6 +44,18 @@ func (h *Handler) Init() http.Handler {
 	return r
 }
 
+// staticFiles serves the files of dir without listing its directories.
+func staticFiles(dir string) http.Handler {
+	files := http.FileServer(http.Dir(dir))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "" || strings.HasSuffix(r.URL.Path, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		files.ServeHTTP(w, r)
+	})
+}
+
 func (h *Handler) initAPI(router *mux.Router) {
 	handlerV1 := v1.NewHandler(h.services, h.validator, h.logger, h.tokenManager)
 	api := router.PathPrefix("/api").Subrouter()
@@ -49,6 +66,10 @@ func (h *Handler) InitRoutes() http.Handler {
 	r := mux.NewRouter()
 
 	r.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
+	r.HandleFunc("/.well-known/jwks.json", h.jwks).Methods("GET")
+	if h.staticDir != "" {
+		r.PathPrefix("/static/").Handler(http.StripPrefix("/static/", staticFiles(h.staticDir))).Methods("GET")
+	}
 
 	h.initAPI(r)
 
@@ -59,15 +80,34 @@ func (h *Handler) InitRoutes() http.Handler {
 }
 
 //endpoints
+// /.well-known/jwks.json - GET
+
 // /api/v1/products - GET
-// /api/v1/products/category/{categoryName} - GET
+// /api/v1/products/search - GET
+// /api/v1/products/facets - GET
+// /api/v1/products/category/{slug} - GET
 // /api/v1/product/{productId} - GET
 // /api/v1/product/{productId} - DELETE
 // /api/v1/product/{productId} - POST
 // /api/v1/product/{productId} - PUT
+// /api/v1/product/{productId}/options - POST
+// /api/v1/product/{productId}/options/{optionId} - DELETE
+// /api/v1/product/{productId}/variants - GET
+// /api/v1/product/{productId}/variants - POST
+// /api/v1/product/{productId}/variants/{variantId} - PUT
+// /api/v1/product/{productId}/variants/{variantId} - DELETE
+// /api/v1/product/{productId}/images - GET
+// /api/v1/product/{productId}/images - POST
+// /api/v1/product/{productId}/images/order - PUT
+// /api/v1/product/{productId}/images/{imageId}/primary - PUT
+// /api/v1/product/{productId}/images/{imageId} - DELETE
+// /api/v1/product/{productId}/review - GET
 // /api/v1/product/{productId}/review - POST
 // /api/v1/product/{productId}/review/{reviewId} - PUT
-// /api/v1/product/{productId}/review/{reviewId} - POST
+// /api/v1/product/{productId}/review/{reviewId} - DELETE
+
+// /api/v1/categories - GET
+// /api/v1/categories/{slug} - GET
 
 // /api/v1/cart - GET
 // /api/v1/cart - DELETE
@@ -76,9 +116,46 @@ func (h *Handler) InitRoutes() http.Handler {
 // /api/v1/cart/{productId} - DELETE
 
 // /api/v1/orders - GET
-// /api/v1/order - GET
 // /api/v1/order - POST
+// /api/v1/order/{orderId} - GET
+// /api/v1/order/{orderId}/history - GET
+// /api/v1/order/{orderId}/cancel - POST
+// /api/v1/order/{orderId}/status - PUT
 
 // /api/v1/user/sign-up - POST
 // /api/v1/user/sign-in - POST
+// /api/v1/user/sign-in/2fa - POST
+// /api/v1/user/refresh - POST
+// /api/v1/user/verify-email - POST
+// /api/v1/user/forgot-password - POST
+// /api/v1/user/reset-password - POST
+// /api/v1/user/sign-out - POST
+// /api/v1/user/me - GET
+// /api/v1/user/me - PATCH
+// /api/v1/user/me - DELETE
+// /api/v1/user/me/password - PUT
+// /api/v1/user/me/verification - POST
+// /api/v1/user/me/2fa - POST
+// /api/v1/user/me/2fa/confirm - POST
+// /api/v1/user/me/2fa - DELETE
 // /api/v1/user/{userId}/products - GET
+
+// /api/v1/seller/applications - POST
+// /api/v1/seller/{userId} - GET
+
+// /api/v1/admin/users - GET
+// /api/v1/admin/users/{userId} - GET
+// /api/v1/admin/users/{userId}/products - GET
+// /api/v1/admin/users/{userId}/orders - GET
+// /api/v1/admin/users/{userId}/reviews - GET
+// /api/v1/admin/users/{userId}/role - PUT
+// /api/v1/admin/users/{userId}/ban - POST, DELETE
+// /api/v1/admin/users/{userId}/password-reset - POST
+// /api/v1/admin/users/{userId}/unlock - POST
+// /api/v1/admin/audit-log - GET
+// /api/v1/admin/seller-applications - GET
+// /api/v1/admin/seller-applications/{applicationId} - GET
+// /api/v1/admin/seller-applications/{applicationId}/approve - POST
+// /api/v1/admin/seller-applications/{applicationId}/reject - POST
+// /api/v1/admin/categories - POST
+// /api/v1/admin/categories/{categoryId} - PUT, DELETE
@@ -0,0 +1,22 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// @Summary	Public keys used to verify access tokens
+// @Tags		auth
+// @ID			jwks
+// @Produce	json
+// @Success	200	{object}	auth.JWKS
+// @Router		/.well-known/jwks.json [get]
+func (h *Handler) jwks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+
+	if err := json.NewEncoder(w).Encode(h.tokenManager.JWKS()); err != nil {
+		http.Error(w, "server error", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,646 @@
+package v1
+
+import (
+	"encoding/json"
+	"io"
+	"market/internal/model"
+	"market/internal/policy"
+	"market/internal/service"
+	"market/pkg/auth"
+	"market/pkg/database/postgres"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+func (h *Handler) initAdminRoutes(api *mux.Router) {
+	admin := api.PathPrefix("/admin").Subrouter()
+	admin.HandleFunc("/audit-log", queryMiddleware(h.authMiddleware(h.authorize(policy.AuditRead, nil, h.getAuditLog)))).Methods("GET")
+
+	users := admin.PathPrefix("/users").Subrouter()
+	users.Methods("GET").Path("").HandlerFunc(queryMiddleware(h.authMiddleware(h.authorize(policy.UserRead, nil, h.getUsers))))
+	users.HandleFunc("/{userId}", h.authMiddleware(h.authorize(policy.UserRead, nil, h.getUser))).Methods("GET")
+	users.HandleFunc("/{userId}/products", queryMiddleware(h.authMiddleware(h.authorize(policy.UserRead, nil, h.getProductsByUserID)))).Methods("GET")
+	users.HandleFunc("/{userId}/orders", queryMiddleware(h.authMiddleware(h.authorize(policy.UserRead, nil, h.getUserOrders)))).Methods("GET")
+	users.HandleFunc("/{userId}/reviews", queryMiddleware(h.authMiddleware(h.authorize(policy.UserRead, nil, h.getUserReviews)))).Methods("GET")
+	users.HandleFunc("/{userId}/role", h.authMiddleware(h.authorize(policy.UserManage, nil, h.changeUserRole))).Methods("PUT")
+	users.HandleFunc("/{userId}/ban", h.authMiddleware(h.authorize(policy.UserManage, nil, h.banUser))).Methods("POST")
+	users.HandleFunc("/{userId}/ban", h.authMiddleware(h.authorize(policy.UserManage, nil, h.unbanUser))).Methods("DELETE")
+	users.HandleFunc("/{userId}/password-reset", h.authMiddleware(h.authorize(policy.UserManage, nil, h.forcePasswordReset))).Methods("POST")
+	users.HandleFunc("/{userId}/unlock", h.authMiddleware(h.authorize(policy.UserUnlock, nil, h.unlockUser))).Methods("POST")
+
+	applications := admin.PathPrefix("/seller-applications").Subrouter()
+	applications.Methods("GET").HandlerFunc(queryMiddleware(h.authMiddleware(h.authorize(policy.SellerReview, nil, h.getSellerApplications))))
+	applications.HandleFunc("/{applicationId}", h.authMiddleware(h.authorize(policy.SellerReview, nil, h.getSellerApplication))).Methods("GET")
+	applications.HandleFunc("/{applicationId}/approve", h.authMiddleware(h.authorize(policy.SellerReview, nil, h.approveSellerApplication))).Methods("POST")
+	applications.HandleFunc("/{applicationId}/reject", h.authMiddleware(h.authorize(policy.SellerReview, nil, h.rejectSellerApplication))).Methods("POST")
+
+	categories := admin.PathPrefix("/categories").Subrouter()
+	categories.Methods("POST").HandlerFunc(h.authMiddleware(h.authorize(policy.CategoryManage, nil, h.createCategory)))
+	categories.HandleFunc("/{categoryId}", h.authMiddleware(h.authorize(policy.CategoryManage, nil, h.updateCategory))).Methods("PUT")
+	categories.HandleFunc("/{categoryId}", h.authMiddleware(h.authorize(policy.CategoryManage, nil, h.deleteCategory))).Methods("DELETE")
+}
+
+// @Summary	Unlock user account after failed sign-in attempts
+// @Security	ApiKeyAuth
+// @Tags		admin
+// @ID			unlock-user
+// @Produce	json
+// @Param		userId	path		int	true	"User ID"
+// @Success	200		{object}	statusResponse
+// @Failure	400,401	{object}	errorResponse
+// @Failure	403,404	{object}	errorResponse
+// @Failure	500		{object}	errorResponse
+// @Failure	default	{object}	errorResponse
+// @Router		/api/v1/admin/users/{userId}/unlock [post]
+func (h *Handler) unlockUser(w http.ResponseWriter, r *http.Request) {
+	h.manageUser(w, r, "unlocked", h.services.Admin.Unlock)
+}
+
+// @Summary	List users
+// @Security	ApiKeyAuth
+// @Tags		admin
+// @ID			get-users
+// @Produce	json
+// @Param		search		query		string	false	"substring of username or email"
+// @Param		role		query		string	false	"role"	Enums(user, seller, admin)
+// @Param		sort		query		string	false	"comma-separated sort keys, prefixed with - for descending order, e.g. -price,created_at; overrides sort_by and sort_order"
+// @Param		sort_by		query		string	false	"sort by"	Enums(created_at, username)
+// @Param		sort_order	query		string	false	"sort order"	Enums(asc, desc)
+// @Param		limit		query		int		false	"limit"	Enums(10, 25, 50)
+// @Param		page		query		int		false	"page"
+// @Success	200			{object}	getUsersResponse
+// @Failure	400,401		{object}	errorResponse
+// @Failure	403			{object}	errorResponse
+// @Failure	500			{object}	errorResponse
+// @Failure	default		{object}	errorResponse
+// @Router		/api/v1/admin/users [get]
+func (h *Handler) getUsers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+
+	options, err := optionsFromContext(r.Context())
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	q := model.UserQueryInput{
+		QueryInput: model.QueryInput{
+			Limit:  options.Limit,
+			Offset: options.Offset,
+			Sort:   options.Sort,
+		},
+		Search: r.URL.Query().Get("search"),
+		Role:   r.URL.Query().Get("role"),
+	}
+
+	if err = q.Validate(); err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	users, err := h.services.Admin.GetUsers(q)
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	newGetUsersResponse(w, users, http.StatusOK)
+}
+
+// @Summary	Get user
+// @Security	ApiKeyAuth
+// @Tags		admin
+// @ID			get-user
+// @Produce	json
+// @Param		userId	path		int	true	"User ID"
+// @Success	200		{object}	model.UserProfile
+// @Failure	400,401	{object}	errorResponse
+// @Failure	403,404	{object}	errorResponse
+// @Failure	500		{object}	errorResponse
+// @Failure	default	{object}	errorResponse
+// @Router		/api/v1/admin/users/{userId} [get]
+func (h *Handler) getUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+
+	userID, err := strconv.Atoi(mux.Vars(r)["userId"])
+	if err != nil {
+		newErrorResponse(w, "Bad Id", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.services.Admin.GetUser(userID)
+	if err != nil {
+		if err == postgres.ErrNotFound {
+			newErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp, err := json.Marshal(user.Profile())
+	if err != nil {
+		newErrorResponse(w, `can't create payload`, http.StatusInternalServerError)
+		return
+	}
+
+	if _, err = w.Write(resp); err != nil {
+		newErrorResponse(w, `can't write resp`, http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary	Get orders of user
+// @Security	ApiKeyAuth
+// @Tags		admin
+// @ID			get-user-orders
+// @Produce	json
+// @Param		userId		path		int		true	"User ID"
+// @Param		sort_order	query		string	false	"sort order"	Enums(asc, desc)
+// @Param		limit		query		int		false	"limit"	Enums(10, 25, 50)
+// @Param		page		query		int		false	"page"
+// @Param		cursor		query		string	false	"next_cursor or prev_cursor of the previous response, takes precedence over page"
+// @Success	200			{object}	getOrdersResponse
+// @Failure	400,401		{object}	errorResponse
+// @Failure	403			{object}	errorResponse
+// @Failure	500			{object}	errorResponse
+// @Failure	default		{object}	errorResponse
+// @Router		/api/v1/admin/users/{userId}/orders [get]
+func (h *Handler) getUserOrders(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+
+	userID, err := strconv.Atoi(mux.Vars(r)["userId"])
+	if err != nil {
+		newErrorResponse(w, "Bad Id", http.StatusBadRequest)
+		return
+	}
+
+	options, err := optionsFromContext(r.Context())
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	q := model.OrderQueryInput{
+		QueryInput: model.QueryInput{
+			Limit:  options.Limit,
+			Offset: options.Offset,
+			Sort:   options.Sort,
+			Cursor: options.Cursor,
+		},
+	}
+
+	if err = q.Validate(); err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	orders, err := h.services.Admin.GetUserOrders(userID, q)
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	newGetOrdersPageResponse(w, orders, q.QueryInput, http.StatusOK)
+}
+
+// @Summary	Get reviews of user
+// @Security	ApiKeyAuth
+// @Tags		admin
+// @ID			get-user-reviews
+// @Produce	json
+// @Param		userId		path		int		true	"User ID"
+// @Param		sort_order	query		string	false	"sort order"	Enums(asc, desc)
+// @Param		limit		query		int		false	"limit"	Enums(10, 25, 50)
+// @Param		page		query		int		false	"page"
+// @Param		cursor		query		string	false	"next_cursor or prev_cursor of the previous response, takes precedence over page"
+// @Success	200			{object}	getReviewsResponse
+// @Failure	400,401		{object}	errorResponse
+// @Failure	403			{object}	errorResponse
+// @Failure	500			{object}	errorResponse
+// @Failure	default		{object}	errorResponse
+// @Router		/api/v1/admin/users/{userId}/reviews [get]
+func (h *Handler) getUserReviews(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+
+	userID, err := strconv.Atoi(mux.Vars(r)["userId"])
+	if err != nil {
+		newErrorResponse(w, "Bad Id", http.StatusBadRequest)
+		return
+	}
+
+	options, err := optionsFromContext(r.Context())
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	q := model.ReviewQueryInput{
+		QueryInput: model.QueryInput{
+			Limit:  options.Limit,
+			Offset: options.Offset,
+			Sort:   options.Sort,
+			Cursor: options.Cursor,
+		},
+	}
+
+	if err = q.Validate(); err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	reviews, err := h.services.Admin.GetUserReviews(userID, q)
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	newGetReviewsPageResponse(w, reviews, q.QueryInput, http.StatusOK)
+}
+
+// @Summary	Change user role
+// @Description	Signs the user out of all sessions, so the new role applies on the next sign-in.
+// @Security	ApiKeyAuth
+// @Tags		admin
+// @ID			change-user-role
+// @Accept		json
+// @Produce	json
+// @Param		userId	path		int						true	"User ID"
+// @Param		input	body		model.ChangeRoleInput	true	"New role"
+// @Success	200		{object}	statusResponse
+// @Failure	400,401	{object}	errorResponse
+// @Failure	403,404	{object}	errorResponse
+// @Failure	500		{object}	errorResponse
+// @Failure	default	{object}	errorResponse
+// @Router		/api/v1/admin/users/{userId}/role [put]
+func (h *Handler) changeUserRole(w http.ResponseWriter, r *http.Request) {
+	var input model.ChangeRoleInput
+	if !h.decodeJSONInput(w, r, &input) {
+		return
+	}
+
+	h.manageUser(w, r, "role changed to "+input.Role, func(adminID, userID int) error {
+		return h.services.Admin.ChangeRole(adminID, userID, input.Role)
+	})
+}
+
+// @Summary	Ban user
+// @Description	Banned users can't sign in and their sessions are revoked.
+// @Security	ApiKeyAuth
+// @Tags		admin
+// @ID			ban-user
+// @Accept		json
+// @Produce	json
+// @Param		userId	path		int				true	"User ID"
+// @Param		input	body		model.BanInput	true	"Ban reason"
+// @Success	200		{object}	statusResponse
+// @Failure	400,401	{object}	errorResponse
+// @Failure	403,404	{object}	errorResponse
+// @Failure	500		{object}	errorResponse
+// @Failure	default	{object}	errorResponse
+// @Router		/api/v1/admin/users/{userId}/ban [post]
+func (h *Handler) banUser(w http.ResponseWriter, r *http.Request) {
+	var input model.BanInput
+	if !h.decodeJSONInput(w, r, &input) {
+		return
+	}
+
+	h.manageUser(w, r, "banned", func(adminID, userID int) error {
+		return h.services.Admin.Ban(adminID, userID, input.Reason)
+	})
+}
+
+// @Summary	Unban user
+// @Security	ApiKeyAuth
+// @Tags		admin
+// @ID			unban-user
+// @Produce	json
+// @Param		userId	path		int	true	"User ID"
+// @Success	200		{object}	statusResponse
+// @Failure	400,401	{object}	errorResponse
+// @Failure	403,404	{object}	errorResponse
+// @Failure	500		{object}	errorResponse
+// @Failure	default	{object}	errorResponse
+// @Router		/api/v1/admin/users/{userId}/ban [delete]
+func (h *Handler) unbanUser(w http.ResponseWriter, r *http.Request) {
+	h.manageUser(w, r, "unbanned", h.services.Admin.Unban)
+}
+
+// @Summary	Force password reset
+// @Description	Signs the user out and blocks sign-in until the password is reset with the link sent by email.
+// @Security	ApiKeyAuth
+// @Tags		admin
+// @ID			force-password-reset
+// @Produce	json
+// @Param		userId	path		int	true	"User ID"
+// @Success	200		{object}	statusResponse
+// @Failure	400,401	{object}	errorResponse
+// @Failure	403,404	{object}	errorResponse
+// @Failure	500		{object}	errorResponse
+// @Failure	default	{object}	errorResponse
+// @Router		/api/v1/admin/users/{userId}/password-reset [post]
+func (h *Handler) forcePasswordReset(w http.ResponseWriter, r *http.Request) {
+	h.manageUser(w, r, "required to reset password", h.services.Admin.ForcePasswordReset)
+}
+
+// @Summary	Get admin audit log
+// @Security	ApiKeyAuth
+// @Tags		admin
+// @ID			get-audit-log
+// @Produce	json
+// @Param		admin_id	query		int		false	"admin ID"
+// @Param		user_id		query		int		false	"target user ID"
+// @Param		sort_order	query		string	false	"sort order"	Enums(asc, desc)
+// @Param		limit		query		int		false	"limit"	Enums(10, 25, 50)
+// @Param		page		query		int		false	"page"
+// @Success	200			{object}	getAuditLogResponse
+// @Failure	400,401		{object}	errorResponse
+// @Failure	403			{object}	errorResponse
+// @Failure	500			{object}	errorResponse
+// @Failure	default		{object}	errorResponse
+// @Router		/api/v1/admin/audit-log [get]
+func (h *Handler) getAuditLog(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+
+	options, err := optionsFromContext(r.Context())
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	q := model.AuditQueryInput{
+		QueryInput: model.QueryInput{
+			Limit:  options.Limit,
+			Offset: options.Offset,
+			Sort:   options.Sort,
+		},
+	}
+
+	for key, id := range map[string]*int{"admin_id": &q.AdminID, "user_id": &q.TargetUserID} {
+		if value := r.URL.Query().Get(key); value != "" {
+			if *id, err = strconv.Atoi(value); err != nil {
+				newErrorResponse(w, "Bad Id", http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	if err = q.Validate(); err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entries, err := h.services.Admin.GetAuditLog(q)
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	newGetAuditLogResponse(w, entries, http.StatusOK)
+}
+
+// decodeJSONInput reads and validates a JSON body. It writes the error
+// response and returns false if the body is not acceptable.
+func (h *Handler) decodeJSONInput(w http.ResponseWriter, r *http.Request, input interface{}) bool {
+	w.Header().Set("Content-type", appJSON)
+	if r.Header.Get("Content-Type") != appJSON {
+		newErrorResponse(w, "unknown payload", http.StatusBadRequest)
+		return false
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		newErrorResponse(w, "server error", http.StatusBadRequest)
+		return false
+	}
+	r.Body.Close()
+
+	if err = json.Unmarshal(body, input); err != nil {
+		newErrorResponse(w, "cant unpack payload", http.StatusBadRequest)
+		return false
+	}
+
+	if err = h.validator.Struct(input); err != nil {
+		newErrorResponse(w, "invalid input", http.StatusBadRequest)
+		return false
+	}
+
+	return true
+}
+
+// manageUser runs an audited admin action on the user from the path.
+func (h *Handler) manageUser(w http.ResponseWriter, r *http.Request, done string, action func(adminID, userID int) error) {
+	w.Header().Set("Content-type", appJSON)
+
+	token, err := auth.TokenFromContext(r.Context())
+	if err != nil {
+		newErrorResponse(w, "Token Error", http.StatusInternalServerError)
+		return
+	}
+
+	userID, err := strconv.Atoi(mux.Vars(r)["userId"])
+	if err != nil {
+		newErrorResponse(w, "Bad Id", http.StatusBadRequest)
+		return
+	}
+
+	if err = action(token.UserID, userID); err != nil {
+		switch err {
+		case postgres.ErrNotFound:
+			newErrorResponse(w, err.Error(), http.StatusNotFound)
+		case service.ErrSelfAction:
+			newErrorResponse(w, err.Error(), http.StatusForbidden)
+		default:
+			newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.logger.Infof("User %v %s by admin %v", userID, done, token.UserID)
+
+	newStatusReponse(w, "done", http.StatusOK)
+}
+
+// @Summary	List seller applications
+// @Security	ApiKeyAuth
+// @Tags		admin
+// @ID			get-seller-applications
+// @Produce	json
+// @Param		status		query		string	false	"status"	Enums(pending, approved, rejected)
+// @Param		sort_order	query		string	false	"sort order"	Enums(asc, desc)
+// @Param		limit		query		int		false	"limit"	Enums(10, 25, 50)
+// @Param		page		query		int		false	"page"
+// @Success	200			{object}	getSellerApplicationsResponse
+// @Failure	400,401		{object}	errorResponse
+// @Failure	403			{object}	errorResponse
+// @Failure	500			{object}	errorResponse
+// @Failure	default		{object}	errorResponse
+// @Router		/api/v1/admin/seller-applications [get]
+func (h *Handler) getSellerApplications(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+
+	options, err := optionsFromContext(r.Context())
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	q := model.SellerApplicationQueryInput{
+		QueryInput: model.QueryInput{
+			Limit:  options.Limit,
+			Offset: options.Offset,
+			Sort:   options.Sort,
+		},
+		Status: r.URL.Query().Get("status"),
+	}
+
+	if err = q.Validate(); err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	applications, err := h.services.Seller.GetApplications(q)
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	newGetSellerApplicationsResponse(w, applications, http.StatusOK)
+}
+
+// @Summary	Get seller application
+// @Security	ApiKeyAuth
+// @Tags		admin
+// @ID			get-seller-application
+// @Produce	json
+// @Param		applicationId	path		int	true	"Application ID"
+// @Success	200				{object}	model.SellerApplication
+// @Failure	400,401			{object}	errorResponse
+// @Failure	403,404			{object}	errorResponse
+// @Failure	500				{object}	errorResponse
+// @Failure	default			{object}	errorResponse
+// @Router		/api/v1/admin/seller-applications/{applicationId} [get]
+func (h *Handler) getSellerApplication(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+
+	applicationID, err := strconv.Atoi(mux.Vars(r)["applicationId"])
+	if err != nil {
+		newErrorResponse(w, "Bad Id", http.StatusBadRequest)
+		return
+	}
+
+	application, err := h.services.Seller.GetApplicationByID(applicationID)
+	if err != nil {
+		if err == postgres.ErrNotFound {
+			newErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp, err := json.Marshal(application)
+	if err != nil {
+		newErrorResponse(w, `can't create payload`, http.StatusInternalServerError)
+		return
+	}
+
+	if _, err = w.Write(resp); err != nil {
+		newErrorResponse(w, `can't write resp`, http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary	Approve seller application
+// @Description	Upgrades the applicant to the seller role and creates the seller profile.
+// @Security	ApiKeyAuth
+// @Tags		admin
+// @ID			approve-seller-application
+// @Accept		json
+// @Produce	json
+// @Param		applicationId	path		int								true	"Application ID"
+// @Param		input			body		model.ReviewApplicationInput	false	"Comment for the applicant"
+// @Success	200				{object}	statusResponse
+// @Failure	400,401			{object}	errorResponse
+// @Failure	403,404			{object}	errorResponse
+// @Failure	409				{object}	errorResponse
+// @Failure	500				{object}	errorResponse
+// @Failure	default			{object}	errorResponse
+// @Router		/api/v1/admin/seller-applications/{applicationId}/approve [post]
+func (h *Handler) approveSellerApplication(w http.ResponseWriter, r *http.Request) {
+	h.reviewSellerApplication(w, r, h.services.Seller.Approve)
+}
+
+// @Summary	Reject seller application
+// @Security	ApiKeyAuth
+// @Tags		admin
+// @ID			reject-seller-application
+// @Accept		json
+// @Produce	json
+// @Param		applicationId	path		int								true	"Application ID"
+// @Param		input			body		model.ReviewApplicationInput	false	"Comment for the applicant"
+// @Success	200				{object}	statusResponse
+// @Failure	400,401			{object}	errorResponse
+// @Failure	403,404			{object}	errorResponse
+// @Failure	409				{object}	errorResponse
+// @Failure	500				{object}	errorResponse
+// @Failure	default			{object}	errorResponse
+// @Router		/api/v1/admin/seller-applications/{applicationId}/reject [post]
+func (h *Handler) rejectSellerApplication(w http.ResponseWriter, r *http.Request) {
+	h.reviewSellerApplication(w, r, h.services.Seller.Reject)
+}
+
+func (h *Handler) reviewSellerApplication(w http.ResponseWriter, r *http.Request,
+	review func(applicationID, adminID int, input model.ReviewApplicationInput) error) {
+	w.Header().Set("Content-type", appJSON)
+
+	token, err := auth.TokenFromContext(r.Context())
+	if err != nil {
+		newErrorResponse(w, "Token Error", http.StatusInternalServerError)
+		return
+	}
+
+	applicationID, err := strconv.Atoi(mux.Vars(r)["applicationId"])
+	if err != nil {
+		newErrorResponse(w, "Bad Id", http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		newErrorResponse(w, "server error", http.StatusBadRequest)
+		return
+	}
+	r.Body.Close()
+
+	var input model.ReviewApplicationInput
+	if len(body) > 0 {
+		if err = json.Unmarshal(body, &input); err != nil {
+			newErrorResponse(w, "cant unpack payload", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err = h.validator.Struct(input); err != nil {
+		newErrorResponse(w, "invalid input", http.StatusBadRequest)
+		return
+	}
+
+	if err = review(applicationID, token.UserID, input); err != nil {
+		switch err {
+		case postgres.ErrNotFound:
+			newErrorResponse(w, err.Error(), http.StatusNotFound)
+		case service.ErrApplicationReviewed:
+			newErrorResponse(w, err.Error(), http.StatusConflict)
+		default:
+			newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.logger.Infof("Seller application %v reviewed by admin %v", applicationID, token.UserID)
+
+	newStatusReponse(w, "done", http.StatusOK)
+}
@@ -0,0 +1,267 @@
+package v1
+
+import (
+	"bytes"
+	"market/internal/model"
+	"market/internal/service"
+	mock_service "market/internal/service/mocks"
+	"market/pkg/auth"
+	"market/pkg/database/postgres"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/magiconair/properties/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+func TestHandler_approveSellerApplication(t *testing.T) {
+	type mockBehaviour func(r *mock_service.MockSeller)
+
+	comment := "welcome"
+
+	tests := []struct {
+		name                 string
+		path                 string
+		inputBody            string
+		mockBehaviour        mockBehaviour
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			path:      "/api/v1/admin/seller-applications/1/approve",
+			inputBody: `{"comment": "welcome"}`,
+			mockBehaviour: func(r *mock_service.MockSeller) {
+				r.EXPECT().Approve(1, 10, model.ReviewApplicationInput{Comment: &comment}).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"done"}`,
+		},
+		{
+			name: "Empty Body",
+			path: "/api/v1/admin/seller-applications/1/approve",
+			mockBehaviour: func(r *mock_service.MockSeller) {
+				r.EXPECT().Approve(1, 10, model.ReviewApplicationInput{}).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"done"}`,
+		},
+		{
+			name:                 "Bad ID",
+			path:                 "/api/v1/admin/seller-applications/abc/approve",
+			mockBehaviour:        func(r *mock_service.MockSeller) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"Bad Id"}`,
+		},
+		{
+			name: "Not Found",
+			path: "/api/v1/admin/seller-applications/2/approve",
+			mockBehaviour: func(r *mock_service.MockSeller) {
+				r.EXPECT().Approve(2, 10, model.ReviewApplicationInput{}).Return(postgres.ErrNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"not found"}`,
+		},
+		{
+			name: "Already Reviewed",
+			path: "/api/v1/admin/seller-applications/1/approve",
+			mockBehaviour: func(r *mock_service.MockSeller) {
+				r.EXPECT().Approve(1, 10, model.ReviewApplicationInput{}).Return(service.ErrApplicationReviewed)
+			},
+			expectedStatusCode:   409,
+			expectedResponseBody: `{"message":"application is already reviewed"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			seller := mock_service.NewMockSeller(c)
+			test.mockBehaviour(seller)
+
+			h := &Handler{
+				services:  &service.Service{Seller: seller},
+				logger:    zap.NewNop().Sugar(),
+				validator: validator.New(),
+			}
+
+			r := mux.NewRouter()
+			r.HandleFunc("/api/v1/admin/seller-applications/{applicationId}/approve", h.approveSellerApplication).Methods("POST")
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", test.path, bytes.NewBufferString(test.inputBody))
+			req.Header.Set("Content-Type", appJSON)
+			req = req.WithContext(auth.ContextWithToken(req.Context(), &auth.Token{UserID: 10, Role: model.ADMIN}))
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_banUser(t *testing.T) {
+	type mockBehaviour func(r *mock_service.MockAdmin)
+
+	tests := []struct {
+		name                 string
+		path                 string
+		inputBody            string
+		mockBehaviour        mockBehaviour
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			path:      "/api/v1/admin/users/2/ban",
+			inputBody: `{"reason": "spam"}`,
+			mockBehaviour: func(r *mock_service.MockAdmin) {
+				r.EXPECT().Ban(10, 2, "spam").Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"done"}`,
+		},
+		{
+			name:                 "No Reason",
+			path:                 "/api/v1/admin/users/2/ban",
+			inputBody:            `{}`,
+			mockBehaviour:        func(r *mock_service.MockAdmin) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input"}`,
+		},
+		{
+			name:                 "Bad ID",
+			path:                 "/api/v1/admin/users/abc/ban",
+			inputBody:            `{"reason": "spam"}`,
+			mockBehaviour:        func(r *mock_service.MockAdmin) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"Bad Id"}`,
+		},
+		{
+			name:      "Self Ban",
+			path:      "/api/v1/admin/users/10/ban",
+			inputBody: `{"reason": "spam"}`,
+			mockBehaviour: func(r *mock_service.MockAdmin) {
+				r.EXPECT().Ban(10, 10, "spam").Return(service.ErrSelfAction)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"message":"admins can't perform this action on their own account"}`,
+		},
+		{
+			name:      "Not Found",
+			path:      "/api/v1/admin/users/3/ban",
+			inputBody: `{"reason": "spam"}`,
+			mockBehaviour: func(r *mock_service.MockAdmin) {
+				r.EXPECT().Ban(10, 3, "spam").Return(postgres.ErrNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"not found"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			admin := mock_service.NewMockAdmin(c)
+			test.mockBehaviour(admin)
+
+			h := &Handler{
+				services:  &service.Service{Admin: admin},
+				logger:    zap.NewNop().Sugar(),
+				validator: validator.New(),
+			}
+
+			r := mux.NewRouter()
+			r.HandleFunc("/api/v1/admin/users/{userId}/ban", h.banUser).Methods("POST")
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", test.path, bytes.NewBufferString(test.inputBody))
+			req.Header.Set("Content-Type", appJSON)
+			req = req.WithContext(auth.ContextWithToken(req.Context(), &auth.Token{UserID: 10, Role: model.ADMIN}))
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_getUsers(t *testing.T) {
+	type mockBehaviour func(r *mock_service.MockAdmin)
+
+	query := func(search, role string) model.UserQueryInput {
+		return model.UserQueryInput{
+			QueryInput: model.QueryInput{Limit: defaultLimit, Sort: model.Sort{model.Desc(model.SortByDate)}},
+			Search:     search,
+			Role:       role,
+		}
+	}
+
+	tests := []struct {
+		name                 string
+		path                 string
+		mockBehaviour        mockBehaviour
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name: "OK",
+			path: "/api/v1/admin/users?search=bob&role=seller",
+			mockBehaviour: func(r *mock_service.MockAdmin) {
+				r.EXPECT().GetUsers(query("bob", model.SELLER)).Return([]model.User{
+					{ID: 2, Role: model.SELLER, Username: "bob", Email: "bob@mail.com", Password: "hash"},
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"data":[{"id":2,"role":"seller","username":"bob","email":"bob@mail.com",` +
+				`"email_verified":false,"two_factor_enabled":false,"created_at":"0001-01-01T00:00:00Z"}]}`,
+		},
+		{
+			name:                 "Bad Role",
+			path:                 "/api/v1/admin/users?role=root",
+			mockBehaviour:        func(r *mock_service.MockAdmin) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid role"}`,
+		},
+		{
+			name:                 "Bad Sort",
+			path:                 "/api/v1/admin/users?sort_by=password",
+			mockBehaviour:        func(r *mock_service.MockAdmin) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid sort query"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			admin := mock_service.NewMockAdmin(c)
+			test.mockBehaviour(admin)
+
+			h := &Handler{
+				services:  &service.Service{Admin: admin},
+				logger:    zap.NewNop().Sugar(),
+				validator: validator.New(),
+			}
+
+			r := mux.NewRouter()
+			r.HandleFunc("/api/v1/admin/users", queryMiddleware(h.getUsers)).Methods("GET")
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", test.path, nil)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"io"
 	"market/internal/model"
+	"market/internal/service"
 	"market/pkg/auth"
+	"market/pkg/database/postgres"
 	"net/http"
 	"strconv"
 
@@ -22,6 +24,8 @@ func (h *Handler) initCartRoutes(api *mux.Router) {
 
 type cartInput struct {
 	Amount int `json:"amount" validate:"required"`
+	// VariantID is required for products with variants.
+	VariantID *int `json:"variant_id"`
 }
 
 // @Summary Add product to cart
@@ -31,9 +35,10 @@ type cartInput struct {
 // @Accept json
 // @Product json
 // @Param   productId path integer true "ID of product to add to cart"
-// @Param input body cartInput true "Amount of products"
+// @Param input body cartInput true "Amount of products and the variant for products with variants"
 // @Success 200 {object} getProductsResponse
 // @Failure 400,404 {object} errorResponse
+// @Failure 409 {object} errorResponse
 // @Failure 500 {object} errorResponse
 // @Failure default {object} errorResponse
 // @Router /api/cart/{productId} [post]
@@ -81,8 +86,8 @@ func (h *Handler) addProductToCart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if _, err = h.services.Cart.AddProduct(cart.ID, token.UserID, productID, input.Amount); err != nil {
-		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+	if _, err = h.services.Cart.AddProduct(cart.ID, productID, input.VariantID, input.Amount); err != nil {
+		cartErrorResponse(w, err)
 		return
 	}
 
@@ -90,13 +95,12 @@ func (h *Handler) addProductToCart(w http.ResponseWriter, r *http.Request) {
 
 	q := model.ProductQueryInput{
 		QueryInput: model.QueryInput{
-			Limit:     defaultLimit,
-			SortBy:    defaultSortField,
-			SortOrder: model.DESCENDING,
+			Limit: defaultLimit,
+			Sort:  model.Sort{model.Desc(defaultSortField)},
 		},
 	}
 
-	products, err := h.services.Cart.GetAllProducts(token.UserID, cart.ID, q)
+	products, err := h.services.Cart.GetAllProducts(cart.ID, q)
 	if err != nil {
 		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -114,7 +118,8 @@ func (h *Handler) addProductToCart(w http.ResponseWriter, r *http.Request) {
 // @Param   sort_order query string false "sort order" Enums(asc, desc)
 // @Param   limit   query int false "limit" Enums(10, 25, 50)
 // @Param   page  query int false "page"
-// @Success 200 {object} getProductsResponse
+// @Param   currency  query string false "ISO 4217 code of the currency to display prices and the total in"
+// @Success 200 {object} getCartResponse
 // @Failure 400,404 {object} errorResponse
 // @Failure 500 {object} errorResponse
 // @Failure default {object} errorResponse
@@ -141,20 +146,34 @@ func (h *Handler) getProductsFromCart(w http.ResponseWriter, r *http.Request) {
 
 	q := model.ProductQueryInput{
 		QueryInput: model.QueryInput{
-			Limit:     options.Limit,
-			Offset:    options.Offset,
-			SortBy:    options.SortBy,
-			SortOrder: options.SortOrder,
+			Limit:  options.Limit,
+			Offset: options.Offset,
+			Sort:   options.Sort,
 		},
 	}
 
-	products, err := h.services.Cart.GetAllProducts(token.UserID, cart.ID, q)
+	if err = q.Validate(); err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	products, err := h.services.Cart.GetAllProducts(cart.ID, q)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	newGetProductsResponse(w, products, http.StatusOK)
+	if !h.setDisplayPrices(w, products, options.Currency) {
+		return
+	}
+
+	total, err := h.services.Cart.Total(cart.ID, options.Currency)
+	if err != nil {
+		moneyErrorResponse(w, err)
+		return
+	}
+
+	newGetCartResponse(w, products, total, http.StatusOK)
 }
 
 // @Summary Update product amount from cart
@@ -164,7 +183,7 @@ func (h *Handler) getProductsFromCart(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Product json
 // @Param   productId path integer true "ID of product to update"
-// @Param input body cartInput true "Amount of products"
+// @Param input body cartInput true "Amount of products and the variant for products with variants"
 // @Success 200 {object} getProductsResponse
 // @Failure 400,404 {object} errorResponse
 // @Failure 500 {object} errorResponse
@@ -215,8 +234,8 @@ func (h *Handler) updateProductAmountFromCart(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	if err = h.services.Cart.UpdateProductAmount(cart.ID, token.UserID, productID, input.Amount); err != nil {
-		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+	if err = h.services.Cart.UpdateProductAmount(cart.ID, productID, input.VariantID, input.Amount); err != nil {
+		cartErrorResponse(w, err)
 		return
 	}
 
@@ -224,14 +243,13 @@ func (h *Handler) updateProductAmountFromCart(w http.ResponseWriter, r *http.Req
 
 	q := model.ProductQueryInput{
 		QueryInput: model.QueryInput{
-			Limit:     0,
-			Offset:    0,
-			SortBy:    model.SortByDate,
-			SortOrder: model.DESCENDING,
+			Limit:  0,
+			Offset: 0,
+			Sort:   model.Sort{model.Desc(model.SortByDate)},
 		},
 	}
 
-	products, err := h.services.Cart.GetAllProducts(token.UserID, cart.ID, q)
+	products, err := h.services.Cart.GetAllProducts(cart.ID, q)
 	if err != nil {
 		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -246,6 +264,7 @@ func (h *Handler) updateProductAmountFromCart(w http.ResponseWriter, r *http.Req
 // @ID delete-product-from-cart
 // @Product json
 // @Param   productId path integer true "ID of product to delete"
+// @Param   variant_id query integer false "ID of the variant to delete"
 // @Success 200 {object} getProductsResponse
 // @Failure 400,404 {object} errorResponse
 // @Failure 500 {object} errorResponse
@@ -266,13 +285,23 @@ func (h *Handler) deleteProductFromCart(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	var variantID *int
+	if value := r.URL.Query().Get("variant_id"); value != "" {
+		id, err := strconv.Atoi(value) //nolint:govet
+		if err != nil {
+			newErrorResponse(w, "Bad variant_id", http.StatusBadRequest)
+			return
+		}
+		variantID = &id
+	}
+
 	cart, err := h.services.Cart.GetByUserID(token.UserID)
 	if err != nil {
 		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	if err = h.services.Cart.DeleteProduct(cart.ID, token.UserID, productID); err != nil {
+	if err = h.services.Cart.DeleteProduct(cart.ID, productID, variantID); err != nil {
 		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -281,14 +310,13 @@ func (h *Handler) deleteProductFromCart(w http.ResponseWriter, r *http.Request)
 
 	q := model.ProductQueryInput{
 		QueryInput: model.QueryInput{
-			Limit:     0,
-			Offset:    0,
-			SortBy:    model.SortByDate,
-			SortOrder: model.DESCENDING,
+			Limit:  0,
+			Offset: 0,
+			Sort:   model.Sort{model.Desc(model.SortByDate)},
 		},
 	}
 
-	products, err := h.services.Cart.GetAllProducts(token.UserID, cart.ID, q)
+	products, err := h.services.Cart.GetAllProducts(cart.ID, q)
 	if err != nil {
 		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -321,7 +349,7 @@ func (h *Handler) deleteProductsFromCart(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err = h.services.Cart.DeleteAllProducts(cart.ID, token.UserID); err != nil {
+	if err = h.services.Cart.DeleteAllProducts(cart.ID); err != nil {
 		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -330,3 +358,16 @@ func (h *Handler) deleteProductsFromCart(w http.ResponseWriter, r *http.Request)
 
 	newStatusReponse(w, "done", http.StatusOK)
 }
+
+func cartErrorResponse(w http.ResponseWriter, err error) {
+	switch err {
+	case service.ErrInvalidAmount, service.ErrVariantRequired:
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+	case service.ErrVariantNotFound, postgres.ErrNotFound:
+		newErrorResponse(w, err.Error(), http.StatusNotFound)
+	case service.ErrAddDuplicate:
+		newErrorResponse(w, err.Error(), http.StatusConflict)
+	default:
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+	}
+}
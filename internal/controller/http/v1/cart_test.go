@@ -0,0 +1,118 @@
+package v1
+
+import (
+	"bytes"
+	"market/internal/model"
+	"market/internal/service"
+	mock_service "market/internal/service/mocks"
+	"market/pkg/auth"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/magiconair/properties/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+func TestHandler_addProductToCart(t *testing.T) {
+	type mockBehaviour func(r *mock_service.MockCart)
+
+	variantID := 7
+	q := model.ProductQueryInput{
+		QueryInput: model.QueryInput{
+			Limit: defaultLimit,
+			Sort:  model.Sort{model.Desc(defaultSortField)},
+		},
+	}
+
+	tests := []struct {
+		name                 string
+		inputBody            string
+		mockBehaviour        mockBehaviour
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"amount": 2}`,
+			mockBehaviour: func(r *mock_service.MockCart) {
+				r.EXPECT().GetByUserID(10).Return(model.Cart{ID: 3, UserID: 10}, nil)
+				r.EXPECT().AddProduct(3, 1, nil, 2).Return(5, nil)
+				r.EXPECT().GetAllProducts(3, q).Return([]model.Product{{ID: 1, Price: model.Money{Amount: 1999, Currency: "RUB"}, Currency: "RUB", PurchasedAmount: 2}}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"data":[{"id":1,"user_id":0,"title":"","price":{"amount":1999,"currency":"RUB","value":"19.99"},"currency":"RUB","category_id":0,"category":"","amount":0,"available":0,"purchased_amount":2,"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z","views":0,"image_url":"","reviews":null,"related_products":null}]}`,
+		},
+		{
+			name:      "Variant",
+			inputBody: `{"amount": 1, "variant_id": 7}`,
+			mockBehaviour: func(r *mock_service.MockCart) {
+				r.EXPECT().GetByUserID(10).Return(model.Cart{ID: 3, UserID: 10}, nil)
+				r.EXPECT().AddProduct(3, 1, &variantID, 1).Return(5, nil)
+				r.EXPECT().GetAllProducts(3, q).Return([]model.Product{}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"data":[]}`,
+		},
+		{
+			name:      "Variant Required",
+			inputBody: `{"amount": 1}`,
+			mockBehaviour: func(r *mock_service.MockCart) {
+				r.EXPECT().GetByUserID(10).Return(model.Cart{ID: 3, UserID: 10}, nil)
+				r.EXPECT().AddProduct(3, 1, nil, 1).Return(0, service.ErrVariantRequired)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"product has variants, variant_id is required"}`,
+		},
+		{
+			name:      "Foreign Variant",
+			inputBody: `{"amount": 1, "variant_id": 7}`,
+			mockBehaviour: func(r *mock_service.MockCart) {
+				r.EXPECT().GetByUserID(10).Return(model.Cart{ID: 3, UserID: 10}, nil)
+				r.EXPECT().AddProduct(3, 1, &variantID, 1).Return(0, service.ErrVariantNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"variant doesn't exist"}`,
+		},
+		{
+			name:      "Out Of Stock",
+			inputBody: `{"amount": 100, "variant_id": 7}`,
+			mockBehaviour: func(r *mock_service.MockCart) {
+				r.EXPECT().GetByUserID(10).Return(model.Cart{ID: 3, UserID: 10}, nil)
+				r.EXPECT().AddProduct(3, 1, &variantID, 100).Return(0, service.ErrInvalidAmount)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid amount"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			cart := mock_service.NewMockCart(c)
+			test.mockBehaviour(cart)
+
+			h := &Handler{
+				services:  &service.Service{Cart: cart},
+				logger:    zap.NewNop().Sugar(),
+				validator: validator.New(),
+			}
+
+			r := mux.NewRouter()
+			r.HandleFunc("/api/v1/cart/{productId}", h.addProductToCart).Methods("POST")
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/v1/cart/1", bytes.NewBufferString(test.inputBody))
+			req.Header.Set("Content-Type", appJSON)
+			req = req.WithContext(auth.ContextWithToken(req.Context(), &auth.Token{UserID: 10, Role: model.USER}))
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package v1
+
+import (
+	"encoding/json"
+	"market/internal/model"
+	"market/internal/service"
+	"market/pkg/auth"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+func (h *Handler) initCategoryRoutes(api *mux.Router) {
+	categories := api.PathPrefix("/categories").Subrouter()
+	categories.Methods("GET").Path("").HandlerFunc(h.getCategories)
+	categories.HandleFunc("/{slug}", h.getCategory).Methods("GET")
+}
+
+// @Summary	Get category tree
+// @Tags		categories
+// @ID			get-categories
+// @Produce	json
+// @Success	200		{object}	getCategoriesResponse
+// @Failure	500		{object}	errorResponse
+// @Failure	default	{object}	errorResponse
+// @Router		/api/v1/categories [get]
+func (h *Handler) getCategories(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+
+	categories, err := h.services.Category.GetTree()
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	newGetCategoriesResponse(w, categories, http.StatusOK)
+}
+
+// @Summary	Get category with its subcategories
+// @Tags		categories
+// @ID			get-category
+// @Produce	json
+// @Param		slug	path		string	true	"Slug of category"
+// @Success	200		{object}	model.Category
+// @Failure	404		{object}	errorResponse
+// @Failure	500		{object}	errorResponse
+// @Failure	default	{object}	errorResponse
+// @Router		/api/v1/categories/{slug} [get]
+func (h *Handler) getCategory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+
+	category, err := h.services.Category.GetBySlug(mux.Vars(r)["slug"])
+	if err != nil {
+		switch err {
+		case service.ErrCategoryNotFound:
+			newErrorResponse(w, err.Error(), http.StatusNotFound)
+		default:
+			newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(category); err != nil {
+		newErrorResponse(w, "server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary	Create category
+// @Description	The slug is derived from the name when it's omitted.
+// @Security	ApiKeyAuth
+// @Tags		admin
+// @ID			create-category
+// @Accept		json
+// @Produce	json
+// @Param		input	body		model.CategoryInput	true	"Category"
+// @Success	201		{object}	model.Category
+// @Failure	400,401	{object}	errorResponse
+// @Failure	403,409	{object}	errorResponse
+// @Failure	500		{object}	errorResponse
+// @Failure	default	{object}	errorResponse
+// @Router		/api/v1/admin/categories [post]
+func (h *Handler) createCategory(w http.ResponseWriter, r *http.Request) {
+	var input model.CategoryInput
+	if !h.decodeJSONInput(w, r, &input) {
+		return
+	}
+
+	token, err := auth.TokenFromContext(r.Context())
+	if err != nil {
+		newErrorResponse(w, "Token Error", http.StatusInternalServerError)
+		return
+	}
+
+	categoryID, err := h.services.Category.Create(input)
+	if err != nil {
+		categoryErrorResponse(w, err)
+		return
+	}
+
+	h.logger.Infof("Category %v was created by admin %v", categoryID, token.UserID)
+
+	category, err := h.services.Category.GetBySlug(categorySlug(input))
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(category); err != nil {
+		newErrorResponse(w, "server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary	Update category
+// @Description	A parent_id of 0 moves the category to the root of the tree.
+// @Security	ApiKeyAuth
+// @Tags		admin
+// @ID			update-category
+// @Accept		json
+// @Produce	json
+// @Param		categoryId	path		int							true	"Category ID"
+// @Param		input		body		model.UpdateCategoryInput	true	"Changed fields"
+// @Success	200			{object}	statusResponse
+// @Failure	400,401		{object}	errorResponse
+// @Failure	403,404		{object}	errorResponse
+// @Failure	409			{object}	errorResponse
+// @Failure	500			{object}	errorResponse
+// @Failure	default		{object}	errorResponse
+// @Router		/api/v1/admin/categories/{categoryId} [put]
+func (h *Handler) updateCategory(w http.ResponseWriter, r *http.Request) {
+	var input model.UpdateCategoryInput
+	if !h.decodeJSONInput(w, r, &input) {
+		return
+	}
+
+	categoryID, err := strconv.Atoi(mux.Vars(r)["categoryId"])
+	if err != nil {
+		newErrorResponse(w, "Bad Id", http.StatusBadRequest)
+		return
+	}
+
+	if err = h.services.Category.Update(categoryID, input); err != nil {
+		categoryErrorResponse(w, err)
+		return
+	}
+
+	newStatusReponse(w, "done", http.StatusOK)
+}
+
+// @Summary	Delete category
+// @Description	Only categories without subcategories and products can be deleted.
+// @Security	ApiKeyAuth
+// @Tags		admin
+// @ID			delete-category
+// @Produce	json
+// @Param		categoryId	path		int	true	"Category ID"
+// @Success	200			{object}	statusResponse
+// @Failure	400,401		{object}	errorResponse
+// @Failure	403,404		{object}	errorResponse
+// @Failure	409			{object}	errorResponse
+// @Failure	500			{object}	errorResponse
+// @Failure	default		{object}	errorResponse
+// @Router		/api/v1/admin/categories/{categoryId} [delete]
+func (h *Handler) deleteCategory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+
+	categoryID, err := strconv.Atoi(mux.Vars(r)["categoryId"])
+	if err != nil {
+		newErrorResponse(w, "Bad Id", http.StatusBadRequest)
+		return
+	}
+
+	if err = h.services.Category.Delete(categoryID); err != nil {
+		categoryErrorResponse(w, err)
+		return
+	}
+
+	newStatusReponse(w, "done", http.StatusOK)
+}
+
+func categoryErrorResponse(w http.ResponseWriter, err error) {
+	switch err {
+	case model.ErrInvalidSlug, service.ErrCategoryCycle:
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+	case service.ErrCategoryNotFound:
+		newErrorResponse(w, err.Error(), http.StatusNotFound)
+	case service.ErrCategoryExists, service.ErrCategoryInUse:
+		newErrorResponse(w, err.Error(), http.StatusConflict)
+	default:
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func categorySlug(input model.CategoryInput) string {
+	if input.Slug != "" {
+		return input.Slug
+	}
+	return model.Slugify(input.Name)
+}
@@ -0,0 +1,109 @@
+package v1
+
+import (
+	"bytes"
+	"market/internal/model"
+	"market/internal/service"
+	mock_service "market/internal/service/mocks"
+	"market/pkg/auth"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/magiconair/properties/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+func TestHandler_updateCategory(t *testing.T) {
+	type mockBehaviour func(r *mock_service.MockCategory)
+
+	parentID := 3
+
+	tests := []struct {
+		name                 string
+		path                 string
+		inputBody            string
+		mockBehaviour        mockBehaviour
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			path:      "/api/v1/admin/categories/2",
+			inputBody: `{"parent_id": 3}`,
+			mockBehaviour: func(r *mock_service.MockCategory) {
+				r.EXPECT().Update(2, model.UpdateCategoryInput{ParentID: &parentID}).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"done"}`,
+		},
+		{
+			name:                 "Bad ID",
+			path:                 "/api/v1/admin/categories/abc",
+			inputBody:            `{"parent_id": 3}`,
+			mockBehaviour:        func(r *mock_service.MockCategory) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"Bad Id"}`,
+		},
+		{
+			name:      "Cycle",
+			path:      "/api/v1/admin/categories/2",
+			inputBody: `{"parent_id": 3}`,
+			mockBehaviour: func(r *mock_service.MockCategory) {
+				r.EXPECT().Update(2, model.UpdateCategoryInput{ParentID: &parentID}).Return(service.ErrCategoryCycle)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"category can't be moved under itself or its subcategory"}`,
+		},
+		{
+			name:      "Not Found",
+			path:      "/api/v1/admin/categories/2",
+			inputBody: `{"parent_id": 3}`,
+			mockBehaviour: func(r *mock_service.MockCategory) {
+				r.EXPECT().Update(2, model.UpdateCategoryInput{ParentID: &parentID}).Return(service.ErrCategoryNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"category doesn't exist"}`,
+		},
+		{
+			name:      "Slug Taken",
+			path:      "/api/v1/admin/categories/2",
+			inputBody: `{"parent_id": 3}`,
+			mockBehaviour: func(r *mock_service.MockCategory) {
+				r.EXPECT().Update(2, model.UpdateCategoryInput{ParentID: &parentID}).Return(service.ErrCategoryExists)
+			},
+			expectedStatusCode:   409,
+			expectedResponseBody: `{"message":"category with this slug already exists"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			category := mock_service.NewMockCategory(c)
+			test.mockBehaviour(category)
+
+			h := &Handler{
+				services:  &service.Service{Category: category},
+				logger:    zap.NewNop().Sugar(),
+				validator: validator.New(),
+			}
+
+			r := mux.NewRouter()
+			r.HandleFunc("/api/v1/admin/categories/{categoryId}", h.updateCategory).Methods("PUT")
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", test.path, bytes.NewBufferString(test.inputBody))
+			req.Header.Set("Content-Type", appJSON)
+			req = req.WithContext(auth.ContextWithToken(req.Context(), &auth.Token{UserID: 10, Role: model.ADMIN}))
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
@@ -35,7 +35,10 @@ func (h *Handler) Init(api *mux.Router) {
 	h.initCartRoutes(r)
 	h.initProductRoutes(r)
 	h.initProductsRoutes(r)
+	h.initCategoryRoutes(r)
 	h.initOrderRoutes(r)
 	h.initOrdersRoutes(r)
 	h.initUserRoutes(r)
+	h.initSellerRoutes(r)
+	h.initAdminRoutes(r)
 }
@@ -0,0 +1,301 @@
+package v1
+
+import (
+	"context"
+	"market/internal/model"
+	"market/internal/service"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+)
+
+// @Summary	Get images of product
+// @Tags		products
+// @ID			get-product-images
+// @Produce	json
+// @Param		productId	path		integer	true	"ID of product"
+// @Success	200			{object}	getImagesResponse
+// @Failure	400			{object}	errorResponse
+// @Failure	500			{object}	errorResponse
+// @Failure	default		{object}	errorResponse
+// @Router		/api/v1/product/{productId}/images [get]
+func (h *Handler) getProductImages(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+
+	productID, err := idFromPath(r, "productId")
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	images, err := h.services.ProductImage.GetAll(productID)
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	newGetImagesResponse(w, images, http.StatusOK)
+}
+
+// @Summary	Add images to product
+// @Description	Appends the files to the end of the gallery. Responds with all images of the product.
+// @Security	ApiKeyAuth
+// @Tags		products
+// @ID			add-product-images
+// @Accept		mpfd
+// @Produce	json
+// @Param		productId	path		integer	true	"ID of product"
+// @Param		file		formData	file	true	"Images to upload, the field may be repeated"
+// @Param		primary		formData	bool	false	"Make the first uploaded image the primary one"
+// @Success	201			{object}	getImagesResponse
+// @Failure	400,401		{object}	errorResponse
+// @Failure	403,404		{object}	errorResponse
+// @Failure	500			{object}	errorResponse
+// @Failure	default		{object}	errorResponse
+// @Router		/api/v1/product/{productId}/images [post]
+func (h *Handler) addProductImages(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+
+	productID, err := idFromPath(r, "productId")
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = r.ParseMultipartForm(limitFileBytes); err != nil {
+		newErrorResponse(w, "Failed to Parse MultipartForm", http.StatusBadRequest)
+		return
+	}
+
+	files := r.MultipartForm.File["file"]
+	if len(files) == 0 {
+		newErrorResponse(w, "Error Retrieving the File", http.StatusBadRequest)
+		return
+	}
+
+	primary, _ := strconv.ParseBool(r.PostForm.Get("primary")) //nolint:errcheck
+
+	images, err := h.uploadImages(files)
+	if err != nil {
+		uploadErrorResponse(w, err)
+		return
+	}
+
+	if err = h.services.ProductImage.Add(productID, images, primary); err != nil {
+		// A full gallery rejects the batch before anything is written, so the
+		// uploads can go right away. After other errors the batch may have been
+		// committed anyway. Uploads nothing refers to are left to the reconciler.
+		if err == service.ErrTooManyImages {
+			h.deleteImages(storedImageIDs(images))
+		}
+		imageErrorResponse(w, err)
+		return
+	}
+
+	h.logger.Infof("%v images were added to product %v", len(images), productID)
+
+	gallery, err := h.services.ProductImage.GetAll(productID)
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	newGetImagesResponse(w, gallery, http.StatusCreated)
+}
+
+// @Summary	Reorder images of product
+// @Security	ApiKeyAuth
+// @Tags		products
+// @ID			reorder-product-images
+// @Accept		json
+// @Produce	json
+// @Param		productId	path		integer						true	"ID of product"
+// @Param		input		body		model.ReorderImagesInput	true	"Every image ID of the product in the new order"
+// @Success	200			{object}	getImagesResponse
+// @Failure	400,401		{object}	errorResponse
+// @Failure	403,404		{object}	errorResponse
+// @Failure	500			{object}	errorResponse
+// @Failure	default		{object}	errorResponse
+// @Router		/api/v1/product/{productId}/images/order [put]
+func (h *Handler) reorderProductImages(w http.ResponseWriter, r *http.Request) {
+	var input model.ReorderImagesInput
+	if !h.decodeJSONInput(w, r, &input) {
+		return
+	}
+
+	productID, err := idFromPath(r, "productId")
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = h.services.ProductImage.Reorder(productID, input.ImageIDs); err != nil {
+		imageErrorResponse(w, err)
+		return
+	}
+
+	gallery, err := h.services.ProductImage.GetAll(productID)
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	newGetImagesResponse(w, gallery, http.StatusOK)
+}
+
+// @Summary	Set primary image of product
+// @Security	ApiKeyAuth
+// @Tags		products
+// @ID			set-primary-product-image
+// @Produce	json
+// @Param		productId	path		integer	true	"ID of product"
+// @Param		imageId		path		integer	true	"ID of image"
+// @Success	200			{object}	getImagesResponse
+// @Failure	400,401		{object}	errorResponse
+// @Failure	403,404		{object}	errorResponse
+// @Failure	500			{object}	errorResponse
+// @Failure	default		{object}	errorResponse
+// @Router		/api/v1/product/{productId}/images/{imageId}/primary [put]
+func (h *Handler) setPrimaryProductImage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+
+	productID, err := idFromPath(r, "productId")
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	imageID, err := idFromPath(r, "imageId")
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = h.services.ProductImage.SetPrimary(productID, imageID); err != nil {
+		imageErrorResponse(w, err)
+		return
+	}
+
+	gallery, err := h.services.ProductImage.GetAll(productID)
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	newGetImagesResponse(w, gallery, http.StatusOK)
+}
+
+// @Summary	Delete image of product
+// @Description	The last image of a product can't be deleted. The next image becomes primary when the primary one is deleted.
+// @Security	ApiKeyAuth
+// @Tags		products
+// @ID			delete-product-image
+// @Produce	json
+// @Param		productId	path		integer	true	"ID of product"
+// @Param		imageId		path		integer	true	"ID of image"
+// @Success	200			{object}	statusResponse
+// @Failure	400,401		{object}	errorResponse
+// @Failure	403,404		{object}	errorResponse
+// @Failure	500			{object}	errorResponse
+// @Failure	default		{object}	errorResponse
+// @Router		/api/v1/product/{productId}/images/{imageId} [delete]
+func (h *Handler) deleteProductImage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+
+	productID, err := idFromPath(r, "productId")
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	imageID, err := idFromPath(r, "imageId")
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = h.services.ProductImage.Delete(productID, imageID); err != nil {
+		imageErrorResponse(w, err)
+		return
+	}
+
+	h.logger.Infof("Image %v of product %v was deleted", imageID, productID)
+
+	newStatusReponse(w, "done", http.StatusOK)
+}
+
+// uploadImages stores the files in the image service. If an upload fails, the
+// files uploaded before it aren't referenced by anything and are left to the
+// orphan reconciler.
+func (h *Handler) uploadImages(files []*multipart.FileHeader) ([]model.ProductImage, error) {
+	images := make([]model.ProductImage, 0, len(files))
+	for _, header := range files {
+		data, err := h.uploadImage(header)
+		if err != nil {
+			return nil, err
+		}
+
+		images = append(images, model.ProductImage{ImageURL: data.ImageURL, ImageID: data.ImageID, Thumbnails: data.Thumbnails})
+	}
+
+	return images, nil
+}
+
+func (h *Handler) uploadImage(header *multipart.FileHeader) (service.ImageData, error) {
+	file, err := header.Open()
+	if err != nil {
+		return service.ImageData{}, err
+	}
+	defer file.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), imageUploadTimeout)
+	defer cancel()
+
+	return h.services.Image.Upload(ctx, file)
+}
+
+// deleteImages removes uploaded images once the write that was to reference
+// them has been rejected. After any other error the write may have gone
+// through, so the uploads are left to the orphan reconciler instead. Failures
+// are only logged, the reconciler deletes the images later.
+func (h *Handler) deleteImages(imageIDs []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), imageUploadTimeout)
+	defer cancel()
+
+	for _, imageID := range imageIDs {
+		if err := h.services.Image.Delete(ctx, imageID); err != nil {
+			h.logger.Errorf("can't delete image %v: %v", imageID, err)
+		}
+	}
+}
+
+func storedImageIDs(images []model.ProductImage) []string {
+	imageIDs := make([]string, 0, len(images))
+	for _, image := range images {
+		imageIDs = append(imageIDs, image.ImageID)
+	}
+
+	return imageIDs
+}
+
+// uploadErrorResponse reports files that aren't acceptable images as bad
+// requests and any other upload failure as an error of the image service.
+func uploadErrorResponse(w http.ResponseWriter, err error) {
+	switch err {
+	case service.ErrInvalidImage, service.ErrImageTooLarge:
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+	default:
+		newErrorResponse(w, `ImageService Error`, http.StatusInternalServerError)
+	}
+}
+
+func imageErrorResponse(w http.ResponseWriter, err error) {
+	switch err {
+	case service.ErrTooManyImages, service.ErrNoImages, service.ErrInvalidImageOrder:
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+	case service.ErrImageNotFound:
+		newErrorResponse(w, err.Error(), http.StatusNotFound)
+	default:
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,90 @@
+package v1
+
+import (
+	"bytes"
+	"market/internal/model"
+	"market/internal/service"
+	mock_service "market/internal/service/mocks"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/magiconair/properties/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+func TestHandler_reorderProductImages(t *testing.T) {
+	type mockBehaviour func(r *mock_service.MockProductImage)
+
+	tests := []struct {
+		name                 string
+		path                 string
+		inputBody            string
+		mockBehaviour        mockBehaviour
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			path:      "/api/v1/product/1/images/order",
+			inputBody: `{"image_ids": [2, 1]}`,
+			mockBehaviour: func(r *mock_service.MockProductImage) {
+				r.EXPECT().Reorder(1, []int{2, 1}).Return(nil)
+				r.EXPECT().GetAll(1).Return([]model.ProductImage{
+					{ID: 2, ProductID: 1, ImageURL: "b", Position: 1},
+					{ID: 1, ProductID: 1, ImageURL: "a", Position: 2, Primary: true},
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"data":[{"id":2,"product_id":1,"image_url":"b","position":1,"primary":false,"created_at":"0001-01-01T00:00:00Z"},` +
+				`{"id":1,"product_id":1,"image_url":"a","position":2,"primary":true,"created_at":"0001-01-01T00:00:00Z"}]}`,
+		},
+		{
+			name:                 "Empty Order",
+			path:                 "/api/v1/product/1/images/order",
+			inputBody:            `{"image_ids": []}`,
+			mockBehaviour:        func(r *mock_service.MockProductImage) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input"}`,
+		},
+		{
+			name:      "Not A Permutation",
+			path:      "/api/v1/product/1/images/order",
+			inputBody: `{"image_ids": [1, 1]}`,
+			mockBehaviour: func(r *mock_service.MockProductImage) {
+				r.EXPECT().Reorder(1, []int{1, 1}).Return(service.ErrInvalidImageOrder)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"` + service.ErrInvalidImageOrder.Error() + `"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			images := mock_service.NewMockProductImage(c)
+			test.mockBehaviour(images)
+
+			h := &Handler{
+				services:  &service.Service{ProductImage: images},
+				logger:    zap.NewNop().Sugar(),
+				validator: validator.New(),
+			}
+
+			r := mux.NewRouter()
+			r.HandleFunc("/api/v1/product/{productId}/images/order", h.reorderProductImages).Methods("PUT")
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", test.path, bytes.NewBufferString(test.inputBody))
+			req.Header.Set("Content-Type", appJSON)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
@@ -5,10 +5,17 @@ import (
 	"errors"
 	"fmt"
 	"market/internal/model"
+	"market/internal/policy"
+	"market/internal/service"
 	"market/pkg/auth"
+	"market/pkg/database/postgres"
+	"net"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+
+	"github.com/gorilla/mux"
 )
 
 type OptionsKey string
@@ -27,8 +34,21 @@ const (
 
 var (
 	ErrNoQuery = errors.New("no query")
+	ErrBadID   = errors.New("bad id")
 )
 
+// clientIP returns the remote address of the request without the port.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ownerResolver returns the ID of the user owning the resource addressed by the request.
+type ownerResolver func(r *http.Request) (int, error)
+
 func (h *Handler) authMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		fmt.Println("auth middleware", r.URL.Path)
@@ -51,6 +71,15 @@ func (h *Handler) authMiddleware(next http.HandlerFunc) http.HandlerFunc {
 
 		token, err := h.tokenManager.Parse(headerParts[1])
 		if err != nil {
+			newErrorCodeResponse(w, tokenErrorCode(err), err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		if err = h.services.User.CheckSession(token.SessionID); err != nil {
+			if err == service.ErrUserBanned {
+				newErrorCodeResponse(w, "user_banned", err.Error(), http.StatusForbidden)
+				return
+			}
 			newErrorResponse(w, err.Error(), http.StatusUnauthorized)
 			return
 		}
@@ -59,12 +88,59 @@ func (h *Handler) authMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	})
 }
 
+func (h *Handler) authorize(action policy.Action, owner ownerResolver, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, err := auth.TokenFromContext(r.Context())
+		if err != nil {
+			newErrorResponse(w, "Token Error", http.StatusInternalServerError)
+			return
+		}
+
+		var resource policy.Resource
+		if owner != nil {
+			resource.OwnerID, err = owner(r)
+			if err != nil {
+				switch err {
+				case ErrBadID:
+					newErrorResponse(w, err.Error(), http.StatusBadRequest)
+				case postgres.ErrNotFound:
+					newErrorResponse(w, err.Error(), http.StatusNotFound)
+				default:
+					newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+				}
+				return
+			}
+		}
+
+		subject := policy.Subject{UserID: token.UserID, Role: token.Role}
+		if err = policy.Authorize(action, subject, resource); err != nil {
+			newErrorResponse(w, err.Error(), http.StatusForbidden)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+func tokenErrorCode(err error) string {
+	switch {
+	case errors.Is(err, auth.ErrTokenExpired):
+		return "token_expired"
+	case errors.Is(err, auth.ErrTokenMalformed):
+		return "token_malformed"
+	case errors.Is(err, auth.ErrTokenSignature):
+		return "token_signature_invalid"
+	case errors.Is(err, auth.ErrTokenClaims):
+		return "token_claims_invalid"
+	default:
+		return "token_invalid"
+	}
+}
+
 func queryMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		fmt.Println("queryMiddleware", r.URL.Path)
 
-		sortBy := strings.ToLower(r.URL.Query().Get("sort_by"))
-		sortOrder := strings.ToUpper(r.URL.Query().Get("sort_order"))
 		limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
 		if err != nil {
 			limit = defaultLimit
@@ -74,14 +150,6 @@ func queryMiddleware(next http.HandlerFunc) http.HandlerFunc {
 			page = defaultPage
 		}
 
-		if sortBy == "" {
-			sortBy = defaultSortField
-		}
-
-		if sortOrder == "" {
-			sortOrder = model.DESCENDING
-		}
-
 		if limit < 1 {
 			limit = defaultLimit
 		}
@@ -94,12 +162,36 @@ func queryMiddleware(next http.HandlerFunc) http.HandlerFunc {
 			page = defaultPage
 		}
 
+		sort, err := sortFromQuery(r.URL.Query())
+		if err != nil {
+			newErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		currency := strings.ToUpper(r.URL.Query().Get("currency"))
+		if currency != "" && !model.ValidCurrency(currency) {
+			newErrorResponse(w, service.ErrUnsupportedCurrency.Error(), http.StatusBadRequest)
+			return
+		}
+
 		options := &Options{
-			SortBy:    sortBy,
-			SortOrder: sortOrder,
-			Limit:     limit,
-			Offset:    (page - 1) * limit,
+			Sort:     sort,
+			Limit:    limit,
+			Offset:   (page - 1) * limit,
+			Currency: currency,
+		}
+
+		// A cursor takes precedence over the page number.
+		if value := r.URL.Query().Get("cursor"); value != "" {
+			cursor, err := model.DecodeCursor(value)
+			if err != nil {
+				newErrorResponse(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			options.Cursor = cursor
+			options.Offset = 0
 		}
+
 		ctx := contextWithOptions(r.Context(), options)
 
 		next(w, r.WithContext(ctx))
@@ -107,9 +199,35 @@ func queryMiddleware(next http.HandlerFunc) http.HandlerFunc {
 }
 
 type Options struct {
-	SortBy, SortOrder string
-	Limit             int
-	Offset            int
+	Sort   model.Sort
+	Limit  int
+	Offset int
+	Cursor *model.Cursor
+	// Currency is the currency prices are displayed in, besides their own.
+	Currency string
+}
+
+// sortFromQuery reads the sort parameter, e.g. sort=-price,created_at. The
+// older sort_by and sort_order parameters are still accepted for a single key.
+// Keys are checked against the listing's allowed keys later on.
+func sortFromQuery(values url.Values) (model.Sort, error) {
+	if sort := values.Get("sort"); sort != "" {
+		return model.ParseSort(sort)
+	}
+
+	sortBy := strings.ToLower(values.Get("sort_by"))
+	if sortBy == "" {
+		sortBy = defaultSortField
+	}
+
+	switch strings.ToUpper(values.Get("sort_order")) {
+	case "", model.DESCENDING:
+		return model.Sort{model.Desc(sortBy)}, nil
+	case model.ASCENDING:
+		return model.Sort{model.Asc(sortBy)}, nil
+	default:
+		return nil, model.ErrInvalidSort
+	}
 }
 
 func optionsFromContext(ctx context.Context) (*Options, error) {
@@ -123,3 +241,11 @@ func optionsFromContext(ctx context.Context) (*Options, error) {
 func contextWithOptions(ctx context.Context, opts *Options) context.Context {
 	return context.WithValue(ctx, OptionsContextKey, opts)
 }
+
+func idFromPath(r *http.Request, key string) (int, error) {
+	id, err := strconv.Atoi(mux.Vars(r)[key])
+	if err != nil {
+		return 0, ErrBadID
+	}
+	return id, nil
+}
@@ -0,0 +1,119 @@
+package v1
+
+import (
+	"encoding/json"
+	"market/internal/service"
+	mock_service "market/internal/service/mocks"
+	"market/pkg/auth"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/magiconair/properties/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+func TestHandler_authMiddleware(t *testing.T) {
+	key, err := auth.NewHMACKey("default", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager, err := auth.NewManager(auth.Options{Issuer: "market", Audience: []string{"market"}}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expired, err := manager.NewJWT(auth.Token{UserID: 1}, -time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name               string
+		header             string
+		expectedStatusCode int
+		expectedCode       string
+	}{
+		{
+			name:               "Expired Token",
+			header:             "Bearer " + expired,
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedCode:       "token_expired",
+		},
+		{
+			name:               "Malformed Token",
+			header:             "Bearer not.a.token",
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedCode:       "token_malformed",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h := &Handler{
+				logger:       zap.NewNop().Sugar(),
+				tokenManager: manager,
+			}
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/api/protected", nil)
+			req.Header.Set(authorizationHeader, test.header)
+			h.authMiddleware(func(w http.ResponseWriter, r *http.Request) {
+				t.Fatal("handler must not be called")
+			})(w, req)
+
+			var resp errorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, resp.Code, test.expectedCode)
+		})
+	}
+}
+
+func TestHandler_authMiddleware_bannedUser(t *testing.T) {
+	key, err := auth.NewHMACKey("default", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager, err := auth.NewManager(auth.Options{Issuer: "market", Audience: []string{"market"}}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := manager.NewJWT(auth.Token{UserID: 1, SessionID: 7}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	user := mock_service.NewMockUser(c)
+	user.EXPECT().CheckSession(7).Return(service.ErrUserBanned)
+
+	h := &Handler{
+		services:     &service.Service{User: user},
+		logger:       zap.NewNop().Sugar(),
+		tokenManager: manager,
+	}
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api/protected", nil)
+	req.Header.Set(authorizationHeader, "Bearer "+token)
+	h.authMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("handler must not be called")
+	})(w, req)
+
+	var resp errorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, w.Code, http.StatusForbidden)
+	assert.Equal(t, resp.Code, "user_banned")
+}
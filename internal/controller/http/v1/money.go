@@ -0,0 +1,70 @@
+package v1
+
+import (
+	"errors"
+	"market/internal/model"
+	"market/internal/service"
+	"net/http"
+	"net/url"
+)
+
+var errInvalidPrice = errors.New("price must be a positive amount with no more decimal places than the currency has")
+
+// priceFromForm parses the price field of the form as an amount of the
+// currency.
+func priceFromForm(form url.Values, currency string) (model.Money, error) {
+	price, err := model.ParseMoney(form.Get("price"), currency)
+	if err != nil || price.Amount <= 0 {
+		return model.Money{}, errInvalidPrice
+	}
+
+	return price, nil
+}
+
+// productPriceFromForm parses the price field of the form, if any, in the
+// currency of the product.
+func (h *Handler) productPriceFromForm(w http.ResponseWriter, productID int, form url.Values) (*model.Money, bool) {
+	if !form.Has("price") {
+		return nil, true
+	}
+
+	product, err := h.services.Product.GetByID(productID)
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+
+	price, err := priceFromForm(form, product.Currency)
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	return &price, true
+}
+
+// setDisplayPrices converts the prices of the products into the currency of
+// the currency query parameter. Nothing is converted without it.
+func (h *Handler) setDisplayPrices(w http.ResponseWriter, products []model.Product, currency string) bool {
+	if currency == "" {
+		return true
+	}
+
+	for i := range products {
+		if err := h.services.Money.SetDisplayPrice(&products[i], currency); err != nil {
+			moneyErrorResponse(w, err)
+			return false
+		}
+	}
+
+	return true
+}
+
+func moneyErrorResponse(w http.ResponseWriter, err error) {
+	switch err {
+	case service.ErrUnsupportedCurrency:
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+	default:
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+	}
+}
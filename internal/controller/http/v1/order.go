@@ -2,24 +2,30 @@ package v1
 
 import (
 	"encoding/json"
+	"errors"
 	"market/internal/model"
+	"market/internal/policy"
+	"market/internal/service"
 	"market/pkg/auth"
+	"market/pkg/database/postgres"
 	"net/http"
 	"strconv"
-	"time"
 
 	"github.com/gorilla/mux"
 )
 
 func (h *Handler) initOrderRoutes(api *mux.Router) {
 	order := api.PathPrefix("/order").Subrouter()
-	order.Methods("POST").HandlerFunc(h.authMiddleware(h.createOrder))
-	order.HandleFunc("/{orderId}", h.authMiddleware(h.getOrder)).Methods("GET")
+	order.Methods("POST").HandlerFunc(h.authMiddleware(h.authorize(policy.OrderCreate, nil, h.createOrder)))
+	order.HandleFunc("/{orderId}", h.authMiddleware(h.authorize(policy.OrderRead, h.orderOwner, h.getOrder))).Methods("GET")
+	order.HandleFunc("/{orderId}/history", h.authMiddleware(h.authorize(policy.OrderRead, h.orderOwner, h.getOrderHistory))).Methods("GET")
+	order.HandleFunc("/{orderId}/cancel", h.authMiddleware(h.authorize(policy.OrderCancel, h.orderOwner, h.cancelOrder))).Methods("POST")
+	order.HandleFunc("/{orderId}/status", h.authMiddleware(h.authorize(policy.OrderManage, nil, h.changeOrderStatus))).Methods("PUT")
 }
 
 func (h *Handler) initOrdersRoutes(api *mux.Router) {
 	orders := api.PathPrefix("/orders").Subrouter()
-	orders.Methods("GET").HandlerFunc(h.authMiddleware(h.getOrders))
+	orders.Methods("GET").HandlerFunc(queryMiddleware(h.authMiddleware(h.getOrders)))
 }
 
 // @Summary	Create order
@@ -27,11 +33,13 @@ func (h *Handler) initOrdersRoutes(api *mux.Router) {
 // @Tags		order
 // @ID			create-order
 // @Product	json
-// @Success	200		{object}	getOrdersResponse
+// @Success	201		{object}	getOrdersResponse
 // @Failure	400,404	{object}	errorResponse
+// @Failure	403		{object}	errorResponse
+// @Failure	409		{object}	outOfStockResponse
 // @Failure	500		{object}	errorResponse
 // @Failure	default	{object}	errorResponse
-// @Router		/api/order [get]
+// @Router		/api/v1/order [post]
 func (h *Handler) createOrder(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-type", appJSON)
 
@@ -41,14 +49,9 @@ func (h *Handler) createOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	order := model.Order{
-		CreatedAt:   time.Now(),
-		DeliveredAt: time.Now().Add(4 * 24 * time.Hour),
-	}
-
-	lastID, err := h.services.Order.Create(token.UserID, order)
+	lastID, err := h.services.Order.Create(token.UserID)
 	if err != nil {
-		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		orderErrorResponse(w, err)
 		return
 	}
 
@@ -56,9 +59,8 @@ func (h *Handler) createOrder(w http.ResponseWriter, r *http.Request) {
 
 	q := model.OrderQueryInput{
 		QueryInput: model.QueryInput{
-			Limit:     defaultLimit,
-			SortBy:    defaultSortField,
-			SortOrder: model.DESCENDING,
+			Limit: defaultLimit,
+			Sort:  model.Sort{model.Desc(defaultSortField)},
 		},
 	}
 
@@ -77,11 +79,7 @@ func (h *Handler) createOrder(w http.ResponseWriter, r *http.Request) {
 // @ID			get-order
 // @Product	json
 // @Param		orderId	path		integer	true	"ID of order to get"
-// @Param   sort_by query   string false "sort by" Enums(views, price, created_at)
-// @Param   sort_order query string false "sort order" Enums(asc, desc)
-// @Param   limit   query int false "limit" Enums(10, 25, 50)
-// @Param   page  query int false "page"
-// @Success	201			{object}	model.Order
+// @Success	200			{object}	model.Order
 // @Failure	400,404		{object}	errorResponse
 // @Failure	500			{object}	errorResponse
 // @Failure	default		{object}	errorResponse
@@ -89,51 +87,148 @@ func (h *Handler) createOrder(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) getOrder(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-type", appJSON)
 
-	token, err := auth.TokenFromContext(r.Context())
+	vars := mux.Vars(r)
+	orderID, err := strconv.Atoi(vars["orderId"])
 	if err != nil {
-		newErrorResponse(w, "Token Error", http.StatusInternalServerError)
+		newErrorResponse(w, "Bad Id", http.StatusBadRequest)
 		return
 	}
 
-	options, err := optionsFromContext(r.Context())
+	selectedOrder, err := h.services.Order.GetByID(orderID)
+	if err != nil {
+		orderErrorResponse(w, err)
+		return
+	}
+
+	selectedOrder.Lines, err = h.services.Order.GetLines(orderID)
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(selectedOrder); err != nil {
+		newErrorResponse(w, "server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary	Get order status history
+// @Security	ApiKeyAuth
+// @Tags		order
+// @ID			get-order-history
+// @Product	json
+// @Param		orderId	path		integer	true	"ID of order"
+// @Success	200			{object}	getOrderHistoryResponse
+// @Failure	400,404		{object}	errorResponse
+// @Failure	500			{object}	errorResponse
+// @Failure	default		{object}	errorResponse
+// @Router		/api/v1/order/{orderId}/history [get]
+func (h *Handler) getOrderHistory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+
+	orderID, err := idFromPath(r, "orderId")
 	if err != nil {
 		newErrorResponse(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	vars := mux.Vars(r)
-	orderID, err := strconv.Atoi(vars["orderId"])
+	history, err := h.services.Order.GetStatusHistory(orderID)
 	if err != nil {
-		newErrorResponse(w, "Bad Id", http.StatusBadRequest)
+		orderErrorResponse(w, err)
 		return
 	}
 
-	selectedOrder, err := h.services.Order.GetByID(token.UserID, orderID)
+	newGetOrderHistoryResponse(w, history, http.StatusOK)
+}
+
+// @Summary	Cancel pending order
+// @Security	ApiKeyAuth
+// @Tags		order
+// @ID			cancel-order
+// @Accept		json
+// @Product	json
+// @Param		orderId	path		integer					true	"ID of order"
+// @Param		input	body		model.CancelOrderInput	false	"reason of cancellation"
+// @Success	200		{object}	statusResponse
+// @Failure	400,404	{object}	errorResponse
+// @Failure	403		{object}	errorResponse
+// @Failure	409		{object}	errorResponse
+// @Failure	500		{object}	errorResponse
+// @Failure	default	{object}	errorResponse
+// @Router		/api/v1/order/{orderId}/cancel [post]
+func (h *Handler) cancelOrder(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+
+	token, err := auth.TokenFromContext(r.Context())
 	if err != nil {
-		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		newErrorResponse(w, "Token Error", http.StatusInternalServerError)
 		return
 	}
 
-	q := model.ProductQueryInput{
-		QueryInput: model.QueryInput{
-			Limit:     options.Limit,
-			Offset:    options.Offset,
-			SortBy:    options.SortBy,
-			SortOrder: options.SortOrder,
-		},
+	orderID, err := idFromPath(r, "orderId")
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var input model.CancelOrderInput
+	if r.ContentLength != 0 && !h.decodeJSONInput(w, r, &input) {
+		return
+	}
+
+	if err = h.services.Order.Cancel(orderID, token.UserID, input); err != nil {
+		orderErrorResponse(w, err)
+		return
 	}
 
-	selectedOrder.Products, err = h.services.Order.GetProductsByOrderID(token.UserID, orderID, q)
+	h.logger.Infof("Order %v was cancelled by user %v", orderID, token.UserID)
+
+	newStatusReponse(w, "done", http.StatusOK)
+}
+
+// @Summary	Change order status
+// @Description	Moves the order along pending -> paid -> shipped -> delivered; pending orders may be cancelled, paid and delivered ones refunded.
+// @Security	ApiKeyAuth
+// @Tags		order
+// @ID			change-order-status
+// @Accept		json
+// @Product	json
+// @Param		orderId	path		integer							true	"ID of order"
+// @Param		input	body		model.ChangeOrderStatusInput	true	"new status"
+// @Success	200		{object}	statusResponse
+// @Failure	400,404	{object}	errorResponse
+// @Failure	403		{object}	errorResponse
+// @Failure	409		{object}	errorResponse
+// @Failure	500		{object}	errorResponse
+// @Failure	default	{object}	errorResponse
+// @Router		/api/v1/order/{orderId}/status [put]
+func (h *Handler) changeOrderStatus(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.TokenFromContext(r.Context())
 	if err != nil {
-		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		newErrorResponse(w, "Token Error", http.StatusInternalServerError)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	if err = json.NewEncoder(w).Encode(selectedOrder); err != nil {
-		newErrorResponse(w, "server error", http.StatusInternalServerError)
+	orderID, err := idFromPath(r, "orderId")
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var input model.ChangeOrderStatusInput
+	if !h.decodeJSONInput(w, r, &input) {
 		return
 	}
+
+	if err = h.services.Order.ChangeStatus(orderID, token.UserID, input); err != nil {
+		orderErrorResponse(w, err)
+		return
+	}
+
+	h.logger.Infof("Order %v moved to %s by user %v", orderID, input.Status, token.UserID)
+
+	newStatusReponse(w, "done", http.StatusOK)
 }
 
 // @Summary	Get orders
@@ -145,6 +240,7 @@ func (h *Handler) getOrder(w http.ResponseWriter, r *http.Request) {
 // @Param   sort_order query string false "sort order" Enums(asc, desc)
 // @Param   limit   query int false "limit" Enums(10, 25, 50)
 // @Param   page  query int false "page"
+// @Param   cursor  query string false "next_cursor or prev_cursor of the previous response, takes precedence over page"
 // @Success	200		{object}	getOrdersResponse
 // @Failure	400,404	{object}	errorResponse
 // @Failure	500		{object}	errorResponse
@@ -167,18 +263,58 @@ func (h *Handler) getOrders(w http.ResponseWriter, r *http.Request) {
 
 	orderQuery := model.OrderQueryInput{
 		QueryInput: model.QueryInput{
-			Limit:     options.Limit,
-			Offset:    options.Offset,
-			SortBy:    options.SortBy,
-			SortOrder: options.SortOrder,
+			Limit:  options.Limit,
+			Offset: options.Offset,
+			Sort:   options.Sort,
+			Cursor: options.Cursor,
 		},
 	}
 
+	if err = orderQuery.Validate(); err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	orders, err := h.services.Order.GetAll(token.UserID, orderQuery)
 	if err != nil {
 		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	newGetOrdersResponse(w, orders, http.StatusOK)
+	newGetOrdersPageResponse(w, orders, orderQuery.QueryInput, http.StatusOK)
+}
+
+func (h *Handler) orderOwner(r *http.Request) (int, error) {
+	orderID, err := idFromPath(r, "orderId")
+	if err != nil {
+		return 0, err
+	}
+
+	order, err := h.services.Order.GetByID(orderID)
+	if err != nil {
+		return 0, err
+	}
+
+	return order.UserID, nil
+}
+
+func orderErrorResponse(w http.ResponseWriter, err error) {
+	var outOfStock *model.OutOfStockError
+	if errors.As(err, &outOfStock) {
+		newOutOfStockResponse(w, outOfStock)
+		return
+	}
+
+	switch err {
+	case postgres.ErrNotFound:
+		newErrorResponse(w, err.Error(), http.StatusNotFound)
+	case service.ErrEmailNotVerified:
+		newErrorResponse(w, err.Error(), http.StatusForbidden)
+	case service.ErrNoProducts:
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+	case service.ErrInvalidTransition, service.ErrOrderStatusChanged:
+		newErrorResponse(w, err.Error(), http.StatusConflict)
+	default:
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+	}
 }
@@ -0,0 +1,76 @@
+package v1
+
+import (
+	"market/internal/model"
+	"market/internal/service"
+	mock_service "market/internal/service/mocks"
+	"market/pkg/auth"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/magiconair/properties/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+func TestHandler_createOrder(t *testing.T) {
+	type mockBehaviour func(r *mock_service.MockOrder)
+
+	variantID := 7
+
+	tests := []struct {
+		name                 string
+		mockBehaviour        mockBehaviour
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name: "Out Of Stock",
+			mockBehaviour: func(r *mock_service.MockOrder) {
+				r.EXPECT().Create(10).Return(0, &model.OutOfStockError{Lines: []model.StockShortage{
+					{ProductID: 1, Title: "Mug", Requested: 2, Available: 1},
+					{ProductID: 3, VariantID: &variantID, Title: "Tee", Requested: 1, Available: 0},
+				}})
+			},
+			expectedStatusCode: 409,
+			expectedResponseBody: `{"message":"not enough items in stock","code":"out_of_stock","lines":[` +
+				`{"product_id":1,"title":"Mug","requested":2,"available":1},` +
+				`{"product_id":3,"variant_id":7,"title":"Tee","requested":1,"available":0}]}`,
+		},
+		{
+			name: "Empty Cart",
+			mockBehaviour: func(r *mock_service.MockOrder) {
+				r.EXPECT().Create(10).Return(0, service.ErrNoProducts)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"no products in cart"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			order := mock_service.NewMockOrder(c)
+			test.mockBehaviour(order)
+
+			h := &Handler{
+				services: &service.Service{Order: order},
+				logger:   zap.NewNop().Sugar(),
+			}
+
+			r := mux.NewRouter()
+			r.HandleFunc("/api/v1/order", h.createOrder).Methods("POST")
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/v1/order", nil)
+			req = req.WithContext(auth.ContextWithToken(req.Context(), &auth.Token{UserID: 10, Role: model.USER}))
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package v1
+
+import "market/internal/model"
+
+type cursorKeyer interface {
+	CursorKey(sortBy string) (string, int)
+}
+
+// pageCursors returns the cursors of the pages after and before items. A page
+// shorter than the limit is the last one in its direction, so there is no
+// cursor past it. Offset-paginated pages get cursors as well, which lets
+// clients switch to cursors from any page.
+func pageCursors[T cursorKeyer](q model.QueryInput, items []T) (string, string) {
+	if len(items) == 0 {
+		return "", ""
+	}
+
+	backward := q.Cursor != nil && q.Cursor.Backward
+	full := len(items) == q.Limit
+
+	var next, prev string
+	if full || backward {
+		next = newCursor(q, items[len(items)-1], false)
+	}
+	if q.Cursor != nil && (full || !backward) || q.Cursor == nil && q.Offset > 0 {
+		prev = newCursor(q, items[0], true)
+	}
+
+	return next, prev
+}
+
+func newCursor(q model.QueryInput, item cursorKeyer, backward bool) string {
+	cursor := model.Cursor{
+		Sort:     q.Sort.String(),
+		Values:   make([]string, len(q.Sort)),
+		Backward: backward,
+	}
+	for i, field := range q.Sort {
+		cursor.Values[i], cursor.ID = item.CursorKey(field.Key)
+	}
+	return cursor.Encode()
+}
@@ -1,12 +1,18 @@
 package v1
 
 import (
-	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"market/internal/model"
+	"market/internal/policy"
+	"market/internal/service"
 	"market/pkg/auth"
+	"market/pkg/database/postgres"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -14,28 +20,48 @@ import (
 )
 
 const (
-	imageUploadTimeout   = 5 * time.Second
+	imageUploadTimeout   = 20 * time.Second
 	limitRelatedProducts = 5
 	limitFileBytes       = 10 << 20
 )
 
 func (h *Handler) initProductRoutes(api *mux.Router) {
 	product := api.PathPrefix("/product").Subrouter()
-	product.Methods("POST").HandlerFunc(h.authMiddleware(h.createProduct))
+	product.Methods("POST").HandlerFunc(h.authMiddleware(h.authorize(policy.ProductCreate, nil, h.createProduct)))
 	product.HandleFunc("/{productId}", queryMiddleware(h.getProductByID)).Methods("GET")
-	product.HandleFunc("/{productId}", h.authMiddleware(h.updateProduct)).Methods("PUT")
-	product.HandleFunc("/{productId}", h.authMiddleware(h.deleteProduct)).Methods("DELETE")
-
-	review := product.PathPrefix("/review").Subrouter()
-	review.Methods("POST").HandlerFunc(h.authMiddleware(h.createReview))
-	review.HandleFunc("/{reviewId}", h.authMiddleware(h.updateReview)).Methods("PUT")
-	review.HandleFunc("/{reviewId}", h.authMiddleware(h.deleteReview)).Methods("DELETE")
+	product.HandleFunc("/{productId}", h.authMiddleware(h.authorize(policy.ProductUpdate, h.productOwner, h.updateProduct))).Methods("PUT")
+	product.HandleFunc("/{productId}", h.authMiddleware(h.authorize(policy.ProductDelete, h.productOwner, h.deleteProduct))).Methods("DELETE")
+
+	images := product.PathPrefix("/{productId}/images").Subrouter()
+	images.Methods("GET").HandlerFunc(h.getProductImages)
+	images.Methods("POST").HandlerFunc(h.authMiddleware(h.authorize(policy.ProductUpdate, h.productOwner, h.addProductImages)))
+	images.HandleFunc("/order", h.authMiddleware(h.authorize(policy.ProductUpdate, h.productOwner, h.reorderProductImages))).Methods("PUT")
+	images.HandleFunc("/{imageId}/primary", h.authMiddleware(h.authorize(policy.ProductUpdate, h.productOwner, h.setPrimaryProductImage))).Methods("PUT")
+	images.HandleFunc("/{imageId}", h.authMiddleware(h.authorize(policy.ProductUpdate, h.productOwner, h.deleteProductImage))).Methods("DELETE")
+
+	options := product.PathPrefix("/{productId}/options").Subrouter()
+	options.Methods("POST").HandlerFunc(h.authMiddleware(h.authorize(policy.ProductUpdate, h.productOwner, h.createOption)))
+	options.HandleFunc("/{optionId}", h.authMiddleware(h.authorize(policy.ProductUpdate, h.productOwner, h.deleteOption))).Methods("DELETE")
+
+	variants := product.PathPrefix("/{productId}/variants").Subrouter()
+	variants.Methods("GET").HandlerFunc(h.getVariants)
+	variants.Methods("POST").HandlerFunc(h.authMiddleware(h.authorize(policy.ProductUpdate, h.productOwner, h.createVariant)))
+	variants.HandleFunc("/{variantId}", h.authMiddleware(h.authorize(policy.ProductUpdate, h.productOwner, h.updateVariant))).Methods("PUT")
+	variants.HandleFunc("/{variantId}", h.authMiddleware(h.authorize(policy.ProductUpdate, h.productOwner, h.deleteVariant))).Methods("DELETE")
+
+	review := product.PathPrefix("/{productId}/review").Subrouter()
+	review.Methods("GET").HandlerFunc(queryMiddleware(h.getReviews))
+	review.Methods("POST").HandlerFunc(h.authMiddleware(h.authorize(policy.ReviewCreate, nil, h.createReview)))
+	review.HandleFunc("/{reviewId}", h.authMiddleware(h.authorize(policy.ReviewUpdate, h.reviewOwner, h.updateReview))).Methods("PUT")
+	review.HandleFunc("/{reviewId}", h.authMiddleware(h.authorize(policy.ReviewDelete, h.reviewOwner, h.deleteReview))).Methods("DELETE")
 }
 
 func (h *Handler) initProductsRoutes(api *mux.Router) {
-	products := api.PathPrefix("products").Subrouter()
+	products := api.PathPrefix("/products").Subrouter()
+	products.HandleFunc("/search", queryMiddleware(h.searchProducts)).Methods("GET")
+	products.HandleFunc("/facets", h.getProductFacets).Methods("GET")
+	products.HandleFunc("/category/{slug}", queryMiddleware(h.getProductsByCategory)).Methods("GET")
 	products.Methods("GET").HandlerFunc(queryMiddleware(h.getAllProducts))
-	products.HandleFunc("/products/category/{categoryName}", queryMiddleware(h.getProductsByCategory)).Methods("GET")
 }
 
 // @Summary	Add a new product to the market
@@ -44,11 +70,12 @@ func (h *Handler) initProductsRoutes(api *mux.Router) {
 // @ID			create-product
 // @Accept		mpfd
 // @Product	json
-// @Param		file		formData	file	true	"Image to Upload"
+// @Param		file		formData	file	true	"Images to upload, the field may be repeated, the first one becomes primary"
 // @Param		title		formData	string	true	"Title of product"
-// @Param		price		formData	number	true	"Price of product"
+// @Param		price		formData	string	true	"Price of product in major units, e.g. 19.99"
+// @Param		currency	formData	string	false	"ISO 4217 code of the currency of the price, the seller's currency if omitted"
 // @Param		tag			formData	string	false	"Tag of product"
-// @Param		category	formData	string	true	"Category of product"
+// @Param		category_id	formData	integer	true	"ID of product category"
 // @Param		description	formData	string	false	"Description of product"
 // @Param		amount		formData	integer	true	"Amount of products"
 // @Success	201			{object}	model.Product
@@ -81,41 +108,62 @@ func (h *Handler) createProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	file, _, err := r.FormFile("file")
-	if err != nil {
+	if product.Currency == "" {
+		if product.Currency, err = h.services.Money.SellerCurrency(token.UserID); err != nil {
+			newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if product.Price, err = priceFromForm(r.PostForm, product.Currency); err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	files := r.MultipartForm.File["file"]
+	if len(files) == 0 {
 		newErrorResponse(w, "Error Retrieving the File", http.StatusBadRequest)
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), imageUploadTimeout)
-	defer cancel()
-	data, err := h.services.Image.Upload(ctx, file)
+	if len(files) > model.MaxProductImages {
+		newErrorResponse(w, service.ErrTooManyImages.Error(), http.StatusBadRequest)
+		return
+	}
+
+	product.Images, err = h.uploadImages(files)
 	if err != nil {
-		newErrorResponse(w, `ImageService Error`, http.StatusInternalServerError)
+		uploadErrorResponse(w, err)
 		return
 	}
 
 	product.UserID = token.UserID
-	product.ImageURL = data.ImageURL
-	product.ImageID = data.ImageID
 	product.CreatedAt = time.Now()
 	product.UpdatedAt = time.Now()
 
-	defer file.Close()
-
 	productID, err := h.services.Product.Create(product)
 	if err != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), imageUploadTimeout)
-		defer cancel()
-		if err = h.services.Image.Delete(ctx, product.ImageID); err != nil {
-			newErrorResponse(w, `ImageServer Error`, http.StatusInternalServerError)
-			return
+		switch err {
+		case service.ErrCategoryNotFound, service.ErrNoImages, service.ErrTooManyImages:
+			h.deleteImages(storedImageIDs(product.Images))
+			newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		default:
+			newErrorResponse(w, err.Error(), http.StatusInternalServerError)
 		}
+		return
+	}
+
+	product, err = h.services.Product.GetByID(productID)
+	if err != nil {
 		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	product.ID = productID
+	product.Images, err = h.services.ProductImage.GetAll(productID)
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
 	h.logger.Infof("Product was created with id LastInsertId: %v", productID)
 
@@ -130,10 +178,21 @@ func (h *Handler) createProduct(w http.ResponseWriter, r *http.Request) {
 // @Tags		products
 // @ID			get-all-products
 // @Product	json
+// @Param   sort query   string false "comma-separated sort keys, prefixed with - for descending order, e.g. -price,created_at; overrides sort_by and sort_order"
 // @Param   sort_by query   string false "sort by" Enums(views, price, created_at)
 // @Param   sort_order query string false "sort order" Enums(asc, desc)
 // @Param   limit   query int false "limit" Enums(10, 25, 50)
 // @Param   page  query int false "page"
+// @Param   cursor  query string false "next_cursor or prev_cursor of the previous response, takes precedence over page"
+// @Param		category		query		string	false	"category slug, subcategories included"
+// @Param		seller_id		query		int		false	"seller ID"
+// @Param		min_price		query		number	false	"minimum price in the currency of the currency parameter, or in the default currency"
+// @Param		max_price		query		number	false	"maximum price in the currency of the currency parameter, or in the default currency"
+// @Param		tag				query		[]string	false	"tags, any of"	collectionFormat(multi)
+// @Param		in_stock		query		bool	false	"only products in stock"
+// @Param		created_after	query		string	false	"RFC 3339 time or YYYY-MM-DD date"
+// @Param		min_rating		query		number	false	"minimum average review score from 1 to 5"
+// @Param		currency	query		string	false	"ISO 4217 code of the currency to display prices in as well, and to filter and sort by price in"
 // @Success	200		{object}	getProductsResponse
 // @Failure	400,404	{object}	errorResponse
 // @Failure	500		{object}	errorResponse
@@ -148,17 +207,24 @@ func (h *Handler) getAllProducts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	filter, err := h.productFilterFromQuery(r.URL.Query())
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	q := model.ProductQueryInput{
 		QueryInput: model.QueryInput{
-			Limit:     options.Limit,
-			Offset:    options.Offset,
-			SortBy:    options.SortBy,
-			SortOrder: options.SortOrder,
+			Limit:  options.Limit,
+			Offset: options.Offset,
+			Sort:   options.Sort,
+			Cursor: options.Cursor,
 		},
+		ProductFilter: filter,
 	}
 
 	if err = q.Validate(); err != nil {
-		newErrorResponse(w, "Bad query", http.StatusBadRequest)
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
@@ -168,7 +234,126 @@ func (h *Handler) getAllProducts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	newGetProductsResponse(w, products, http.StatusOK)
+	if !h.setDisplayPrices(w, products, options.Currency) {
+		return
+	}
+
+	newGetProductsPageResponse(w, products, q.QueryInput, http.StatusOK)
+}
+
+// @Summary	Search products
+// @Description	Full-text search over title, tag, category and description. Every word also matches as a prefix.
+// @Tags		products
+// @ID			search-products
+// @Produce	json
+// @Param		q			query		string	true	"search query"
+// @Param		sort		query		string	false	"comma-separated sort keys, prefixed with - for descending order, e.g. -price,created_at; overrides sort_by and sort_order"
+// @Param		sort_by		query		string	false	"sort by, rank when omitted"	Enums(rank, views, price, created_at)
+// @Param		sort_order	query		string	false	"sort order"	Enums(asc, desc)
+// @Param		limit		query		int		false	"limit"	Enums(10, 25, 50)
+// @Param		page		query		int		false	"page"
+// @Param		currency	query		string	false	"ISO 4217 code of the currency to display prices in as well, and to filter and sort by price in"
+// @Success	200			{object}	searchProductsResponse
+// @Failure	400			{object}	errorResponse
+// @Failure	500			{object}	errorResponse
+// @Failure	default		{object}	errorResponse
+// @Router		/api/v1/products/search [get]
+func (h *Handler) searchProducts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+
+	options, err := optionsFromContext(r.Context())
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	q := model.ProductSearchInput{
+		QueryInput: model.QueryInput{
+			Limit:  options.Limit,
+			Offset: options.Offset,
+			Sort:   options.Sort,
+		},
+		Query:  r.URL.Query().Get("q"),
+		Prices: model.PriceScale{Currency: options.Currency},
+	}
+
+	// Search results are ordered by relevance unless the client asks otherwise.
+	if r.URL.Query().Get("sort") == "" && r.URL.Query().Get("sort_by") == "" {
+		q.Sort = model.Sort{{Key: model.SortByRank, Desc: q.Sort[0].Desc}}
+	}
+
+	if err = q.Validate(); err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results, err := h.services.Product.Search(q)
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if options.Currency != "" {
+		for i := range results {
+			if err = h.services.Money.SetDisplayPrice(&results[i].Product, options.Currency); err != nil {
+				moneyErrorResponse(w, err)
+				return
+			}
+		}
+	}
+
+	newSearchProductsResponse(w, results, http.StatusOK)
+}
+
+// @Summary	Get product facets
+// @Description	Counts products matching the filters per category, per tag and per price bucket.
+// @Tags		products
+// @ID			get-product-facets
+// @Produce	json
+// @Param		category		query		string	false	"category slug, subcategories included"
+// @Param		seller_id		query		int		false	"seller ID"
+// @Param		min_price		query		number	false	"minimum price in the currency of the currency parameter, or in the default currency"
+// @Param		max_price		query		number	false	"maximum price in the currency of the currency parameter, or in the default currency"
+// @Param		tag				query		[]string	false	"tags, any of"	collectionFormat(multi)
+// @Param		in_stock		query		bool	false	"only products in stock"
+// @Param		created_after	query		string	false	"RFC 3339 time or YYYY-MM-DD date"
+// @Param		min_rating		query		number	false	"minimum average review score from 1 to 5"
+// @Param		currency		query		string	false	"ISO 4217 code of the currency to filter by price and to bucket prices in"
+// @Success	200				{object}	model.ProductFacets
+// @Failure	400				{object}	errorResponse
+// @Failure	500				{object}	errorResponse
+// @Failure	default			{object}	errorResponse
+// @Router		/api/v1/products/facets [get]
+func (h *Handler) getProductFacets(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+
+	filter, err := h.productFilterFromQuery(r.URL.Query())
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = filter.Validate(); err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	facets, err := h.services.Product.GetFacets(filter)
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp, err := json.Marshal(facets)
+	if err != nil {
+		newErrorResponse(w, `can't create payload`, http.StatusInternalServerError)
+		return
+	}
+
+	if _, err = w.Write(resp); err != nil {
+		newErrorResponse(w, `can't write resp`, http.StatusInternalServerError)
+		return
+	}
 }
 
 // @Summary	Get products by UserID
@@ -176,10 +361,13 @@ func (h *Handler) getAllProducts(w http.ResponseWriter, r *http.Request) {
 // @ID			get-products-by-userId
 // @Product	json
 // @Param		userId	path		integer	true	"ID of user"
+// @Param   sort query   string false "comma-separated sort keys, prefixed with - for descending order, e.g. -price,created_at; overrides sort_by and sort_order"
 // @Param   sort_by query   string false "sort by" Enums(views, price, created_at)
 // @Param   sort_order query string false "sort order" Enums(asc, desc)
 // @Param   limit   query int false "limit" Enums(10, 25, 50)
 // @Param   page  query int false "page"
+// @Param   cursor  query string false "next_cursor or prev_cursor of the previous response, takes precedence over page"
+// @Param		currency	query		string	false	"ISO 4217 code of the currency to display prices in as well, and to filter and sort by price in"
 // @Success	200		{object}	getProductsResponse
 // @Failure	400,404	{object}	errorResponse
 // @Failure	500		{object}	errorResponse
@@ -203,11 +391,12 @@ func (h *Handler) getProductsByUserID(w http.ResponseWriter, r *http.Request) {
 
 	q := model.ProductQueryInput{
 		QueryInput: model.QueryInput{
-			Limit:     options.Limit,
-			Offset:    options.Offset,
-			SortBy:    options.SortBy,
-			SortOrder: options.SortOrder,
+			Limit:  options.Limit,
+			Offset: options.Offset,
+			Sort:   options.Sort,
+			Cursor: options.Cursor,
 		},
+		ProductFilter: model.ProductFilter{Prices: model.PriceScale{Currency: options.Currency}},
 	}
 
 	if err = q.Validate(); err != nil {
@@ -221,28 +410,42 @@ func (h *Handler) getProductsByUserID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	newGetProductsResponse(w, products, http.StatusOK)
+	if !h.setDisplayPrices(w, products, options.Currency) {
+		return
+	}
+
+	newGetProductsPageResponse(w, products, q.QueryInput, http.StatusOK)
 }
 
 // @Summary	Get all products by category from the market
+// @Description	Lists the products of the category and of all its subcategories.
 // @Tags		products
 // @ID			get-products-by-category
 // @Product	json
-// @Param		categoryName	path		string	true	"Name of category"
+// @Param		slug	path		string	true	"Slug of category"
+// @Param   sort query   string false "comma-separated sort keys, prefixed with - for descending order, e.g. -price,created_at; overrides sort_by and sort_order"
 // @Param   sort_by query   string false "sort by" Enums(views, price, created_at)
 // @Param   sort_order query string false "sort order" Enums(asc, desc)
 // @Param   limit   query int false "limit" Enums(10, 25, 50)
 // @Param   page  query int false "page"
+// @Param   cursor  query string false "next_cursor or prev_cursor of the previous response, takes precedence over page"
+// @Param		seller_id		query		int		false	"seller ID"
+// @Param		min_price		query		number	false	"minimum price in the currency of the currency parameter, or in the default currency"
+// @Param		max_price		query		number	false	"maximum price in the currency of the currency parameter, or in the default currency"
+// @Param		tag				query		[]string	false	"tags, any of"	collectionFormat(multi)
+// @Param		in_stock		query		bool	false	"only products in stock"
+// @Param		created_after	query		string	false	"RFC 3339 time or YYYY-MM-DD date"
+// @Param		min_rating		query		number	false	"minimum average review score from 1 to 5"
+// @Param		currency	query		string	false	"ISO 4217 code of the currency to display prices in as well, and to filter and sort by price in"
 // @Success	200		{object}	getProductsResponse
 // @Failure	400,404	{object}	errorResponse
 // @Failure	500		{object}	errorResponse
 // @Failure	default	{object}	errorResponse
-// @Router		/api/products/{categoryName} [get]
+// @Router		/api/v1/products/category/{slug} [get]
 func (h *Handler) getProductsByCategory(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-type", appJSON)
 
-	vars := mux.Vars(r)
-	categoryName := vars["categoryName"]
+	slug := mux.Vars(r)["slug"]
 
 	options, err := optionsFromContext(r.Context())
 	if err != nil {
@@ -250,27 +453,43 @@ func (h *Handler) getProductsByCategory(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	filter, err := h.productFilterFromQuery(r.URL.Query())
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	q := model.ProductQueryInput{
 		QueryInput: model.QueryInput{
-			Limit:     options.Limit,
-			Offset:    options.Offset,
-			SortBy:    options.SortBy,
-			SortOrder: options.SortOrder,
+			Limit:  options.Limit,
+			Offset: options.Offset,
+			Sort:   options.Sort,
+			Cursor: options.Cursor,
 		},
+		ProductFilter: filter,
 	}
 
 	if err = q.Validate(); err != nil {
-		newErrorResponse(w, "Bad query", http.StatusBadRequest)
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	products, err := h.services.Product.GetProductsByCategory(categoryName, q)
+	products, err := h.services.Product.GetProductsByCategory(slug, q)
 	if err != nil {
-		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		switch err {
+		case service.ErrCategoryNotFound:
+			newErrorResponse(w, err.Error(), http.StatusNotFound)
+		default:
+			newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
 
-	newGetProductsResponse(w, products, http.StatusOK)
+	if !h.setDisplayPrices(w, products, options.Currency) {
+		return
+	}
+
+	newGetProductsPageResponse(w, products, q.QueryInput, http.StatusOK)
 }
 
 // @Summary	Get product by id from the market
@@ -282,6 +501,7 @@ func (h *Handler) getProductsByCategory(w http.ResponseWriter, r *http.Request)
 // @Param   sort_order query string false "sort order" Enums(asc, desc)
 // @Param   limit   query int false "limit" Enums(10, 25, 50)
 // @Param   page  query int false "page"
+// @Param		currency	query		string	false	"ISO 4217 code of the currency to display prices in as well, and to filter and sort by price in"
 // @Success	200			{object}	model.Product
 // @Failure	400,404		{object}	errorResponse
 // @Failure	500			{object}	errorResponse
@@ -314,12 +534,29 @@ func (h *Handler) getProductByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	selectedProduct.Images, err = h.services.ProductImage.GetAll(productID)
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	selectedProduct.Options, err = h.services.Variant.GetOptions(productID)
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	selectedProduct.Variants, err = h.services.Variant.GetAll(productID)
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	reviewQuery := model.ReviewQueryInput{
 		QueryInput: model.QueryInput{
-			Limit:     options.Limit,
-			Offset:    options.Offset,
-			SortBy:    options.SortBy,
-			SortOrder: options.SortOrder,
+			Limit:  options.Limit,
+			Offset: options.Offset,
+			Sort:   options.Sort,
 		},
 	}
 
@@ -336,19 +573,30 @@ func (h *Handler) getProductByID(w http.ResponseWriter, r *http.Request) {
 
 	productQuery := model.ProductQueryInput{
 		QueryInput: model.QueryInput{
-			Limit:     limitRelatedProducts,
-			SortBy:    model.SortByViews,
-			SortOrder: model.DESCENDING,
+			Limit: limitRelatedProducts,
+			Sort:  model.Sort{model.Desc(model.SortByViews)},
 		},
-		ProductID: productID,
+		ProductFilter: model.ProductFilter{CategoryID: selectedProduct.CategoryID},
+		ProductID:     productID,
 	}
 
-	selectedProduct.RelatedProducts, err = h.services.Product.GetProductsByCategory(selectedProduct.Category, productQuery)
+	selectedProduct.RelatedProducts, err = h.services.Product.GetAll(productQuery)
 	if err != nil {
 		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	if options.Currency != "" {
+		if err = h.services.Money.SetDisplayPrice(&selectedProduct, options.Currency); err != nil {
+			moneyErrorResponse(w, err)
+			return
+		}
+	}
+
+	if !h.setDisplayPrices(w, selectedProduct.RelatedProducts, options.Currency) {
+		return
+	}
+
 	w.WriteHeader(http.StatusOK)
 	if err = json.NewEncoder(w).Encode(selectedProduct); err != nil {
 		newErrorResponse(w, "server error", http.StatusInternalServerError)
@@ -362,12 +610,12 @@ func (h *Handler) getProductByID(w http.ResponseWriter, r *http.Request) {
 // @ID			update-product
 // @Accept		mpfd
 // @Product	json
+// @Description	Images are managed with the /api/v1/product/{productId}/images endpoints.
 // @Param		productId	path		integer	false	"ID of product to update"
-// @Param		file		formData	file	false	"Image to Upload"
 // @Param		title		formData	string	false	"Title of product"
-// @Param		price		formData	number	false	"Price of product"
+// @Param		price		formData	string	false	"Price of product in major units of its currency, e.g. 19.99"
 // @Param		tag			formData	string	false	"Tag of product"
-// @Param		category	formData	string	false	"Category of product"
+// @Param		category_id	formData	integer	false	"ID of product category"
 // @Param		description	formData	string	false	"Description of product"
 // @Param		amount		formData	integer	false	"Amount of products"
 // @Success	200			{object}	model.Product
@@ -378,12 +626,6 @@ func (h *Handler) getProductByID(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) updateProduct(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-type", appJSON)
 
-	token, err := auth.TokenFromContext(r.Context())
-	if err != nil {
-		newErrorResponse(w, "Token Error", http.StatusInternalServerError)
-		return
-	}
-
 	vars := mux.Vars(r)
 	productID, err := strconv.Atoi(vars["productId"])
 	if err != nil {
@@ -403,58 +645,22 @@ func (h *Handler) updateProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	file, _, err := r.FormFile("file")
-	fileExists := !(err == http.ErrMissingFile)
-	if err != nil && fileExists {
-		newErrorResponse(w, "Error Retrieving the File", http.StatusBadRequest)
+	// The price is in the currency of the product, which doesn't change.
+	var ok bool
+	if input.Price, ok = h.productPriceFromForm(w, productID, r.PostForm); !ok {
 		return
 	}
 
-	if fileExists {
-		ctx, cancel := context.WithTimeout(context.Background(), imageUploadTimeout)
-		defer cancel()
-		data, err := h.services.Image.Upload(ctx, file) //nolint:govet
-		if err != nil {
-			newErrorResponse(w, `ImageService Error`, http.StatusInternalServerError)
-			return
-		}
-		input.ImageURL = &data.ImageURL
-		input.ImageID = &data.ImageID
-		defer file.Close()
-	}
-
 	currentTime := time.Now()
 	input.UpdatedAt = &currentTime
 
-	oldProduct, err := h.services.Product.GetByID(productID)
-	if err != nil {
-		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	if err = h.services.Product.Update(token.UserID, productID, input); err != nil {
-		switch fileExists {
-		case true:
-			ctx, cancel := context.WithTimeout(context.Background(), imageUploadTimeout)
-			defer cancel()
-			if err = h.services.Image.Delete(ctx, *input.ImageID); err != nil {
-				newErrorResponse(w, `ImageService Error`, http.StatusInternalServerError)
-				return
-			}
-			fallthrough
-		default:
-			newErrorResponse(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-	}
-
-	if fileExists {
-		ctx, cancel := context.WithTimeout(context.Background(), imageUploadTimeout)
-		defer cancel()
-		if err = h.services.Image.Delete(ctx, oldProduct.ImageID); err != nil {
-			newErrorResponse(w, `ImageService Error`, http.StatusInternalServerError)
-			return
+	if err = h.services.Product.Update(productID, input); err != nil {
+		status := http.StatusInternalServerError
+		if err == service.ErrCategoryNotFound || err == model.ErrBelowReserved {
+			status = http.StatusBadRequest
 		}
+		newErrorResponse(w, err.Error(), status)
+		return
 	}
 
 	product, err := h.services.Product.GetByID(productID)
@@ -504,18 +710,121 @@ func (h *Handler) deleteProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err = h.services.Product.Delete(token.UserID, productID); err != nil {
+	if err = h.services.Product.Delete(productID); err != nil {
+		if err == postgres.ErrNotFound {
+			newErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		}
 		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	h.logger.Infof("Product was deleted: %v", product)
+	h.logger.Infof("Product was deleted by user %v: %v", token.UserID, product)
 
-	ctx, cancel := context.WithTimeout(context.Background(), imageUploadTimeout)
-	defer cancel()
-	if err = h.services.Image.Delete(ctx, product.ImageID); err != nil {
-		newErrorResponse(w, "ImageService Error", http.StatusInternalServerError)
-		return
+	newStatusReponse(w, "done", http.StatusOK)
+}
+
+func (h *Handler) productOwner(r *http.Request) (int, error) {
+	productID, err := idFromPath(r, "productId")
+	if err != nil {
+		return 0, err
 	}
 
-	newStatusReponse(w, "done", http.StatusOK)
+	product, err := h.services.Product.GetByID(productID)
+	if err != nil {
+		return 0, err
+	}
+
+	return product.UserID, nil
+}
+
+// productFilterFromQuery reads the listing filters from the query string. Tags
+// may be repeated or comma separated. Prices are compared in the currency of the
+// currency parameter, or in the default currency of the market, which is the
+// currency of min_price and max_price as well.
+func (h *Handler) productFilterFromQuery(values url.Values) (model.ProductFilter, error) {
+	filter := model.ProductFilter{
+		Category: values.Get("category"),
+		Prices:   model.PriceScale{Currency: strings.ToUpper(values.Get("currency"))},
+	}
+
+	if filter.Prices.Currency != "" && !model.ValidCurrency(filter.Prices.Currency) {
+		return model.ProductFilter{}, service.ErrUnsupportedCurrency
+	}
+
+	var err error
+	if value := values.Get("seller_id"); value != "" {
+		if filter.SellerID, err = strconv.Atoi(value); err != nil {
+			return model.ProductFilter{}, errors.New("invalid seller_id")
+		}
+	}
+
+	if values.Get("min_price") != "" || values.Get("max_price") != "" {
+		if filter.Prices.Currency == "" {
+			filter.Prices.Currency = h.services.Money.DefaultCurrency()
+		}
+		if filter.MinPrice, err = priceParam(values, "min_price", filter.Prices.Currency); err != nil {
+			return model.ProductFilter{}, err
+		}
+		if filter.MaxPrice, err = priceParam(values, "max_price", filter.Prices.Currency); err != nil {
+			return model.ProductFilter{}, err
+		}
+	}
+	if filter.MinRating, err = floatParam(values, "min_rating"); err != nil {
+		return model.ProductFilter{}, err
+	}
+
+	for _, value := range values["tag"] {
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				filter.Tags = append(filter.Tags, tag)
+			}
+		}
+	}
+
+	if value := values.Get("in_stock"); value != "" {
+		if filter.InStock, err = strconv.ParseBool(value); err != nil {
+			return model.ProductFilter{}, errors.New("invalid in_stock")
+		}
+	}
+
+	if value := values.Get("created_after"); value != "" {
+		createdAfter, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			if createdAfter, err = time.Parse(time.DateOnly, value); err != nil {
+				return model.ProductFilter{}, errors.New("invalid created_after")
+			}
+		}
+		filter.CreatedAfter = &createdAfter
+	}
+
+	return filter, nil
+}
+
+func priceParam(values url.Values, key, currency string) (*model.Money, error) {
+	value := values.Get(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	price, err := model.ParseMoney(value, currency)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s", key)
+	}
+
+	return &price, nil
+}
+
+func floatParam(values url.Values, key string) (*float32, error) {
+	value := values.Get(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	number, err := strconv.ParseFloat(value, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s", key)
+	}
+
+	f := float32(number)
+	return &f, nil
 }
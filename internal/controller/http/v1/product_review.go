@@ -17,6 +17,58 @@ type reviewInput struct {
 	Category string `json:"category" validate:"review_category,required"`
 }
 
+// @Summary	Get reviews of product
+// @Tags		review
+// @ID			get-reviews
+// @Produce	json
+// @Param		productId	path		integer	true	"ID of product"
+// @Param		sort_order	query		string	false	"sort order"	Enums(asc, desc)
+// @Param		limit		query		int		false	"limit"	Enums(10, 25, 50)
+// @Param		page		query		int		false	"page"
+// @Param		cursor		query		string	false	"next_cursor or prev_cursor of the previous response, takes precedence over page"
+// @Success	200			{object}	getReviewsResponse
+// @Failure	400			{object}	errorResponse
+// @Failure	500			{object}	errorResponse
+// @Failure	default		{object}	errorResponse
+// @Router		/api/v1/product/{productId}/review [get]
+func (h *Handler) getReviews(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+
+	productID, err := strconv.Atoi(mux.Vars(r)["productId"])
+	if err != nil {
+		newErrorResponse(w, "Bad Id", http.StatusBadRequest)
+		return
+	}
+
+	options, err := optionsFromContext(r.Context())
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	q := model.ReviewQueryInput{
+		QueryInput: model.QueryInput{
+			Limit:  options.Limit,
+			Offset: options.Offset,
+			Sort:   options.Sort,
+			Cursor: options.Cursor,
+		},
+	}
+
+	if err = q.Validate(); err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	reviews, err := h.services.Review.GetAll(productID, q)
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	newGetReviewsPageResponse(w, reviews, q.QueryInput, http.StatusOK)
+}
+
 // @Summary	Create review
 // @Security	ApiKeyAuth
 // @Tags		review
@@ -29,7 +81,7 @@ type reviewInput struct {
 // @Failure	400,404		{object}	errorResponse
 // @Failure	500			{object}	errorResponse
 // @Failure	default		{object}	errorResponse
-// @Router		/api/product/{productId}/addReview [post]
+// @Router		/api/product/{productId}/review [post]
 func (h *Handler) createReview(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-type", appJSON)
 	if r.Header.Get("Content-Type") != appJSON {
@@ -92,9 +144,8 @@ func (h *Handler) createReview(w http.ResponseWriter, r *http.Request) {
 
 	reviewQuery := model.ReviewQueryInput{
 		QueryInput: model.QueryInput{
-			Limit:     defaultLimit,
-			SortBy:    defaultSortField,
-			SortOrder: model.DESCENDING,
+			Limit: defaultLimit,
+			Sort:  model.Sort{model.Desc(defaultSortField)},
 		},
 	}
 
@@ -106,14 +157,14 @@ func (h *Handler) createReview(w http.ResponseWriter, r *http.Request) {
 
 	productQuery := model.ProductQueryInput{
 		QueryInput: model.QueryInput{
-			Limit:     limitRelatedProducts,
-			SortBy:    model.SortByViews,
-			SortOrder: model.DESCENDING,
+			Limit: limitRelatedProducts,
+			Sort:  model.Sort{model.Desc(model.SortByViews)},
 		},
-		ProductID: productID,
+		ProductFilter: model.ProductFilter{CategoryID: product.CategoryID},
+		ProductID:     productID,
 	}
 
-	product.RelatedProducts, err = h.services.Product.GetProductsByCategory(product.Category, productQuery)
+	product.RelatedProducts, err = h.services.Product.GetAll(productQuery)
 	if err != nil {
 		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -139,7 +190,7 @@ func (h *Handler) createReview(w http.ResponseWriter, r *http.Request) {
 // @Failure	400,404		{object}	errorResponse
 // @Failure	500			{object}	errorResponse
 // @Failure	default		{object}	errorResponse
-// @Router		/api/product/{productId}/updateReview/{reviewId} [put]
+// @Router		/api/product/{productId}/review/{reviewId} [put]
 func (h *Handler) updateReview(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-type", appJSON)
 	if r.Header.Get("Content-Type") != appJSON {
@@ -158,6 +209,11 @@ func (h *Handler) updateReview(w http.ResponseWriter, r *http.Request) {
 		newErrorResponse(w, "Bad id", http.StatusBadRequest)
 		return
 	}
+	reviewID, err := strconv.Atoi(vars["reviewId"])
+	if err != nil {
+		newErrorResponse(w, "Bad id", http.StatusBadRequest)
+		return
+	}
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
@@ -181,12 +237,12 @@ func (h *Handler) updateReview(w http.ResponseWriter, r *http.Request) {
 	currentTime := time.Now()
 	input.UpdatedAt = &currentTime
 
-	if err = h.services.Review.Update(token.UserID, productID, input); err != nil {
+	if err = h.services.Review.Update(reviewID, input); err != nil {
 		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	h.logger.Infof("Review by userID [%v] to productID [%v] was updated: %v", token.UserID, productID)
+	h.logger.Infof("Review [%v] by userID [%v] to productID [%v] was updated", reviewID, token.UserID, productID)
 
 	product, err := h.services.Product.GetByID(productID)
 	if err != nil {
@@ -196,9 +252,8 @@ func (h *Handler) updateReview(w http.ResponseWriter, r *http.Request) {
 
 	reviewQuery := model.ReviewQueryInput{
 		QueryInput: model.QueryInput{
-			Limit:     defaultLimit,
-			SortBy:    defaultSortField,
-			SortOrder: model.DESCENDING,
+			Limit: defaultLimit,
+			Sort:  model.Sort{model.Desc(defaultSortField)},
 		},
 	}
 
@@ -210,14 +265,14 @@ func (h *Handler) updateReview(w http.ResponseWriter, r *http.Request) {
 
 	productQuery := model.ProductQueryInput{
 		QueryInput: model.QueryInput{
-			Limit:     limitRelatedProducts,
-			SortBy:    model.SortByViews,
-			SortOrder: model.DESCENDING,
+			Limit: limitRelatedProducts,
+			Sort:  model.Sort{model.Desc(model.SortByViews)},
 		},
-		ProductID: productID,
+		ProductFilter: model.ProductFilter{CategoryID: product.CategoryID},
+		ProductID:     productID,
 	}
 
-	product.RelatedProducts, err = h.services.Product.GetProductsByCategory(product.Category, productQuery)
+	product.RelatedProducts, err = h.services.Product.GetAll(productQuery)
 	if err != nil {
 		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -241,7 +296,7 @@ func (h *Handler) updateReview(w http.ResponseWriter, r *http.Request) {
 // @Failure	400,404		{object}	errorResponse
 // @Failure	500			{object}	errorResponse
 // @Failure	default		{object}	errorResponse
-// @Router		/api/product/{productId}/deleteReview/{reviewId} [delete]
+// @Router		/api/product/{productId}/review/{reviewId} [delete]
 func (h *Handler) deleteReview(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-type", appJSON)
 	token, err := auth.TokenFromContext(r.Context())
@@ -256,7 +311,7 @@ func (h *Handler) deleteReview(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err = h.services.Review.Delete(token.UserID, reviewID); err != nil {
+	if err = h.services.Review.Delete(reviewID); err != nil {
 		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -277,9 +332,8 @@ func (h *Handler) deleteReview(w http.ResponseWriter, r *http.Request) {
 
 	reviewQuery := model.ReviewQueryInput{
 		QueryInput: model.QueryInput{
-			Limit:     defaultLimit,
-			SortBy:    defaultSortField,
-			SortOrder: model.DESCENDING,
+			Limit: defaultLimit,
+			Sort:  model.Sort{model.Desc(defaultSortField)},
 		},
 	}
 
@@ -291,14 +345,14 @@ func (h *Handler) deleteReview(w http.ResponseWriter, r *http.Request) {
 
 	productQuery := model.ProductQueryInput{
 		QueryInput: model.QueryInput{
-			Limit:     limitRelatedProducts,
-			SortBy:    model.SortByViews,
-			SortOrder: model.DESCENDING,
+			Limit: limitRelatedProducts,
+			Sort:  model.Sort{model.Desc(model.SortByViews)},
 		},
-		ProductID: productID,
+		ProductFilter: model.ProductFilter{CategoryID: product.CategoryID},
+		ProductID:     productID,
 	}
 
-	product.RelatedProducts, err = h.services.Product.GetProductsByCategory(product.Category, productQuery)
+	product.RelatedProducts, err = h.services.Product.GetAll(productQuery)
 	if err != nil {
 		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -309,3 +363,24 @@ func (h *Handler) deleteReview(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+// reviewOwner only finds reviews of the product in the path, so a review can't
+// be changed through the URL of another product.
+func (h *Handler) reviewOwner(r *http.Request) (int, error) {
+	productID, err := idFromPath(r, "productId")
+	if err != nil {
+		return 0, err
+	}
+
+	reviewID, err := idFromPath(r, "reviewId")
+	if err != nil {
+		return 0, err
+	}
+
+	review, err := h.services.Review.GetByID(productID, reviewID)
+	if err != nil {
+		return 0, err
+	}
+
+	return review.UserID, nil
+}
@@ -0,0 +1,85 @@
+package v1
+
+import (
+	"market/internal/model"
+	"market/internal/policy"
+	"market/internal/service"
+	mock_service "market/internal/service/mocks"
+	"market/pkg/auth"
+	"market/pkg/database/postgres"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/magiconair/properties/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+func TestHandler_reviewOwner(t *testing.T) {
+	type mockBehaviour func(r *mock_service.MockReview)
+
+	tests := []struct {
+		name                 string
+		path                 string
+		mockBehaviour        mockBehaviour
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name: "OK",
+			path: "/api/v1/product/2/review/5",
+			mockBehaviour: func(r *mock_service.MockReview) {
+				r.EXPECT().GetByID(2, 5).Return(model.Review{ID: 5, ProductID: 2, UserID: 1}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: "ok",
+		},
+		{
+			name: "Review Of Other Product",
+			path: "/api/v1/product/3/review/5",
+			mockBehaviour: func(r *mock_service.MockReview) {
+				r.EXPECT().GetByID(3, 5).Return(model.Review{}, postgres.ErrNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"` + postgres.ErrNotFound.Error() + `"}`,
+		},
+		{
+			name:                 "Bad Product ID",
+			path:                 "/api/v1/product/x/review/5",
+			mockBehaviour:        func(r *mock_service.MockReview) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"` + ErrBadID.Error() + `"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			review := mock_service.NewMockReview(c)
+			test.mockBehaviour(review)
+
+			h := &Handler{
+				services: &service.Service{Review: review},
+				logger:   zap.NewNop().Sugar(),
+			}
+
+			r := mux.NewRouter()
+			r.HandleFunc("/api/v1/product/{productId}/review/{reviewId}", h.authorize(policy.ReviewUpdate, h.reviewOwner,
+				func(w http.ResponseWriter, r *http.Request) {
+					w.Write([]byte("ok")) //nolint:errcheck
+				}))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", test.path, nil)
+			req = req.WithContext(auth.ContextWithToken(req.Context(), &auth.Token{UserID: 1, Role: model.USER}))
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
@@ -0,0 +1,427 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"market/internal/model"
+	"market/internal/service"
+	mock_service "market/internal/service/mocks"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/magiconair/properties/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+func TestHandler_searchProducts(t *testing.T) {
+	type mockBehaviour func(r *mock_service.MockProduct)
+
+	query := func(text, sortBy string) model.ProductSearchInput {
+		return model.ProductSearchInput{
+			QueryInput: model.QueryInput{Limit: defaultLimit, Sort: model.Sort{model.Desc(sortBy)}},
+			Query:      text,
+		}
+	}
+
+	tests := []struct {
+		name                 string
+		path                 string
+		mockBehaviour        mockBehaviour
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name: "OK",
+			path: "/api/v1/products/search?q=phone",
+			mockBehaviour: func(r *mock_service.MockProduct) {
+				r.EXPECT().Search(query("phone", model.SortByRank)).Return([]model.ProductSearchResult{}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"data":[]}`,
+		},
+		{
+			name: "Sort By Price",
+			path: "/api/v1/products/search?q=phone&sort_by=price",
+			mockBehaviour: func(r *mock_service.MockProduct) {
+				r.EXPECT().Search(query("phone", model.SortByPrice)).Return([]model.ProductSearchResult{}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"data":[]}`,
+		},
+		{
+			name:                 "Empty Query",
+			path:                 "/api/v1/products/search?q=%20%26%7C",
+			mockBehaviour:        func(r *mock_service.MockProduct) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"empty search query"}`,
+		},
+		{
+			name:                 "Bad Sort",
+			path:                 "/api/v1/products/search?q=phone&sort_by=title",
+			mockBehaviour:        func(r *mock_service.MockProduct) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid sort query"}`,
+		},
+		{
+			name: "Service Error",
+			path: "/api/v1/products/search?q=phone",
+			mockBehaviour: func(r *mock_service.MockProduct) {
+				r.EXPECT().Search(query("phone", model.SortByRank)).Return(nil, errors.New("something went wrong"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"something went wrong"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			product := mock_service.NewMockProduct(c)
+			test.mockBehaviour(product)
+
+			h := &Handler{
+				services: &service.Service{Product: product},
+				logger:   zap.NewNop().Sugar(),
+			}
+
+			r := mux.NewRouter()
+			h.initProductsRoutes(r.PathPrefix("/api/v1").Subrouter())
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", test.path, nil)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_getAllProducts(t *testing.T) {
+	type mockBehaviour func(r *mock_service.MockProduct)
+
+	minPrice, maxPrice := model.Money{Amount: 1000, Currency: "RUB"}, model.Money{Amount: 9950, Currency: "RUB"}
+	minPriceUSD := model.Money{Amount: 1000, Currency: "USD"}
+	createdAfter := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name                 string
+		path                 string
+		mockBehaviour        mockBehaviour
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name: "Filters",
+			path: "/api/v1/products?min_price=10&max_price=99.5&tag=new,sale&tag=hot&in_stock=true&created_after=2024-01-02&seller_id=3",
+			mockBehaviour: func(r *mock_service.MockProduct) {
+				r.EXPECT().GetAll(model.ProductQueryInput{
+					QueryInput: model.QueryInput{Limit: defaultLimit, Sort: model.Sort{model.Desc(model.SortByDate)}},
+					ProductFilter: model.ProductFilter{
+						SellerID:     3,
+						MinPrice:     &minPrice,
+						MaxPrice:     &maxPrice,
+						Prices:       model.PriceScale{Currency: "RUB"},
+						Tags:         []string{"new", "sale", "hot"},
+						InStock:      true,
+						CreatedAfter: &createdAfter,
+					},
+				}).Return([]model.Product{}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"data":[]}`,
+		},
+		{
+			name: "Price In Currency",
+			path: "/api/v1/products?min_price=10&currency=usd",
+			mockBehaviour: func(r *mock_service.MockProduct) {
+				r.EXPECT().GetAll(model.ProductQueryInput{
+					QueryInput: model.QueryInput{Limit: defaultLimit, Sort: model.Sort{model.Desc(model.SortByDate)}},
+					ProductFilter: model.ProductFilter{
+						MinPrice: &minPriceUSD,
+						Prices:   model.PriceScale{Currency: "USD"},
+					},
+				}).Return([]model.Product{}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"data":[]}`,
+		},
+		{
+			name:                 "Price Finer Than Currency",
+			path:                 "/api/v1/products?min_price=10.5&currency=JPY",
+			mockBehaviour:        func(r *mock_service.MockProduct) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid min_price"}`,
+		},
+		{
+			name:                 "Bad Price",
+			path:                 "/api/v1/products?min_price=cheap",
+			mockBehaviour:        func(r *mock_service.MockProduct) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid min_price"}`,
+		},
+		{
+			name:                 "Inverted Price Range",
+			path:                 "/api/v1/products?min_price=100&max_price=10",
+			mockBehaviour:        func(r *mock_service.MockProduct) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"min price is greater than max price"}`,
+		},
+		{
+			name:                 "Bad Rating",
+			path:                 "/api/v1/products?min_rating=7",
+			mockBehaviour:        func(r *mock_service.MockProduct) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"rating must be between 1 and 5"}`,
+		},
+		{
+			name: "Multi-Column Sort",
+			path: "/api/v1/products?sort=-price,created_at&sort_by=views",
+			mockBehaviour: func(r *mock_service.MockProduct) {
+				r.EXPECT().GetAll(model.ProductQueryInput{
+					QueryInput: model.QueryInput{
+						Limit: defaultLimit,
+						Sort:  model.Sort{model.Desc(model.SortByPrice), model.Asc(model.SortByDate)},
+					},
+				}).Return([]model.Product{}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"data":[]}`,
+		},
+		{
+			name:                 "Unknown Sort Key",
+			path:                 "/api/v1/products?sort=price,title",
+			mockBehaviour:        func(r *mock_service.MockProduct) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid sort query"}`,
+		},
+		{
+			name:                 "Repeated Sort Key",
+			path:                 "/api/v1/products?sort=price,-price",
+			mockBehaviour:        func(r *mock_service.MockProduct) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid sort query"}`,
+		},
+		{
+			name:                 "Bad Sort Order",
+			path:                 "/api/v1/products?sort_by=price&sort_order=sideways",
+			mockBehaviour:        func(r *mock_service.MockProduct) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid sort query"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			product := mock_service.NewMockProduct(c)
+			test.mockBehaviour(product)
+			money := mock_service.NewMockMoney(c)
+			money.EXPECT().DefaultCurrency().Return("RUB").AnyTimes()
+
+			h := &Handler{
+				services: &service.Service{Product: product, Money: money},
+				logger:   zap.NewNop().Sugar(),
+			}
+
+			r := mux.NewRouter()
+			h.initProductsRoutes(r.PathPrefix("/api/v1").Subrouter())
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", test.path, nil)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_getAllProducts_cursor(t *testing.T) {
+	type mockBehaviour func(r *mock_service.MockProduct)
+
+	after := &model.Cursor{Sort: "price", Values: []string{"10"}, ID: 4}
+	page := []model.Product{{ID: 5, Price: model.Money{Amount: 1200, Currency: "USD"}}, {ID: 9, Price: model.Money{Amount: 1250, Currency: "USD"}}}
+
+	tests := []struct {
+		name               string
+		path               string
+		mockBehaviour      mockBehaviour
+		expectedStatusCode int
+		expectedNext       string
+		expectedPrev       string
+		expectedMessage    string
+	}{
+		{
+			name: "Full Page",
+			path: "/api/v1/products?sort_by=price&sort_order=asc&limit=2&page=3&cursor=" + after.Encode(),
+			mockBehaviour: func(r *mock_service.MockProduct) {
+				r.EXPECT().GetAll(model.ProductQueryInput{
+					QueryInput: model.QueryInput{Limit: 2, Sort: model.Sort{model.Asc(model.SortByPrice)}, Cursor: after},
+				}).Return(page, nil)
+			},
+			expectedStatusCode: 200,
+			expectedNext:       model.Cursor{Sort: "price", Values: []string{"12.50"}, ID: 9}.Encode(),
+			expectedPrev:       model.Cursor{Sort: "price", Values: []string{"12.00"}, ID: 5, Backward: true}.Encode(),
+		},
+		{
+			name: "Last Page",
+			path: "/api/v1/products?sort_by=price&sort_order=asc&limit=3&cursor=" + after.Encode(),
+			mockBehaviour: func(r *mock_service.MockProduct) {
+				r.EXPECT().GetAll(model.ProductQueryInput{
+					QueryInput: model.QueryInput{Limit: 3, Sort: model.Sort{model.Asc(model.SortByPrice)}, Cursor: after},
+				}).Return(page, nil)
+			},
+			expectedStatusCode: 200,
+			expectedPrev:       model.Cursor{Sort: "price", Values: []string{"12.00"}, ID: 5, Backward: true}.Encode(),
+		},
+		{
+			name: "First Page",
+			path: "/api/v1/products?sort_by=price&sort_order=asc&limit=2",
+			mockBehaviour: func(r *mock_service.MockProduct) {
+				r.EXPECT().GetAll(model.ProductQueryInput{
+					QueryInput: model.QueryInput{Limit: 2, Sort: model.Sort{model.Asc(model.SortByPrice)}},
+				}).Return(page, nil)
+			},
+			expectedStatusCode: 200,
+			expectedNext:       model.Cursor{Sort: "price", Values: []string{"12.50"}, ID: 9}.Encode(),
+		},
+		{
+			name:               "Invalid Cursor",
+			path:               "/api/v1/products?cursor=garbage",
+			mockBehaviour:      func(r *mock_service.MockProduct) {},
+			expectedStatusCode: 400,
+			expectedMessage:    "invalid cursor",
+		},
+		{
+			name:               "Cursor For Other Sort",
+			path:               "/api/v1/products?sort_by=views&cursor=" + after.Encode(),
+			mockBehaviour:      func(r *mock_service.MockProduct) {},
+			expectedStatusCode: 400,
+			expectedMessage:    "cursor doesn't match the sort query",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			product := mock_service.NewMockProduct(c)
+			test.mockBehaviour(product)
+
+			h := &Handler{
+				services: &service.Service{Product: product},
+				logger:   zap.NewNop().Sugar(),
+			}
+
+			r := mux.NewRouter()
+			h.initProductsRoutes(r.PathPrefix("/api/v1").Subrouter())
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", test.path, nil)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+
+			if test.expectedMessage != "" {
+				var resp errorResponse
+				assert.Equal(t, json.Unmarshal(w.Body.Bytes(), &resp), nil)
+				assert.Equal(t, resp.Message, test.expectedMessage)
+				return
+			}
+
+			var resp getProductsResponse
+			assert.Equal(t, json.Unmarshal(w.Body.Bytes(), &resp), nil)
+			assert.Equal(t, resp.NextCursor, test.expectedNext)
+			assert.Equal(t, resp.PrevCursor, test.expectedPrev)
+		})
+	}
+}
+
+func TestHandler_getAllProducts_currency(t *testing.T) {
+	type mockBehaviour func(p *mock_service.MockProduct, m *mock_service.MockMoney)
+
+	price := model.Money{Amount: 1999, Currency: "RUB"}
+	// Prices are compared in the display currency as well.
+	q := func(currency string) model.ProductQueryInput {
+		return model.ProductQueryInput{
+			QueryInput:    model.QueryInput{Limit: defaultLimit, Sort: model.Sort{model.Desc(model.SortByDate)}},
+			ProductFilter: model.ProductFilter{Prices: model.PriceScale{Currency: currency}},
+		}
+	}
+
+	tests := []struct {
+		name                 string
+		path                 string
+		mockBehaviour        mockBehaviour
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name: "Display Currency",
+			path: "/api/v1/products?currency=usd",
+			mockBehaviour: func(p *mock_service.MockProduct, m *mock_service.MockMoney) {
+				p.EXPECT().GetAll(q("USD")).Return([]model.Product{{ID: 1, Price: price, Currency: "RUB"}}, nil)
+				m.EXPECT().SetDisplayPrice(gomock.Any(), "USD").DoAndReturn(func(product *model.Product, currency string) error {
+					product.DisplayPrice = &model.Money{Amount: 21, Currency: currency}
+					return nil
+				})
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"data":[{"id":1,"user_id":0,"title":"","price":{"amount":1999,"currency":"RUB","value":"19.99"},"currency":"RUB",` +
+				`"display_price":{"amount":21,"currency":"USD","value":"0.21"},"category_id":0,"category":"","amount":0,"available":0,` +
+				`"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z","views":0,"image_url":"","reviews":null,"related_products":null}]}`,
+		},
+		{
+			name:                 "Unknown Currency",
+			path:                 "/api/v1/products?currency=doubloons",
+			mockBehaviour:        func(p *mock_service.MockProduct, m *mock_service.MockMoney) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"unsupported currency"}`,
+		},
+		{
+			name: "No Exchange Rate",
+			path: "/api/v1/products?currency=GBP",
+			mockBehaviour: func(p *mock_service.MockProduct, m *mock_service.MockMoney) {
+				p.EXPECT().GetAll(q("GBP")).Return([]model.Product{{ID: 1, Price: price, Currency: "RUB"}}, nil)
+				m.EXPECT().SetDisplayPrice(gomock.Any(), "GBP").Return(service.ErrUnsupportedCurrency)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"unsupported currency"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			product := mock_service.NewMockProduct(c)
+			money := mock_service.NewMockMoney(c)
+			test.mockBehaviour(product, money)
+
+			h := &Handler{
+				services: &service.Service{Product: product, Money: money},
+				logger:   zap.NewNop().Sugar(),
+			}
+
+			r := mux.NewRouter()
+			h.initProductsRoutes(r.PathPrefix("/api/v1").Subrouter())
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", test.path, nil)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
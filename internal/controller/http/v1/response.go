@@ -8,6 +8,14 @@ import (
 
 type errorResponse struct {
 	Message string `json:"message"`
+	Code    string `json:"code,omitempty"`
+}
+
+// outOfStockResponse tells which lines of the cart can't be ordered anymore.
+type outOfStockResponse struct {
+	Message string                `json:"message"`
+	Code    string                `json:"code"`
+	Lines   []model.StockShortage `json:"lines"`
 }
 
 type statusResponse struct {
@@ -15,19 +23,83 @@ type statusResponse struct {
 }
 
 type getProductsResponse struct {
-	Data []model.Product `json:"data"`
+	Data       []model.Product `json:"data"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+	PrevCursor string          `json:"prev_cursor,omitempty"`
+}
+
+// getCartResponse adds the total of all lines of the cart to a page of them.
+type getCartResponse struct {
+	Data  []model.Product `json:"data"`
+	Total model.Money     `json:"total"`
+}
+
+type searchProductsResponse struct {
+	Data []model.ProductSearchResult `json:"data"`
 }
 
 type getOrdersResponse struct {
-	Data []model.Order `json:"data"`
+	Data       []model.Order `json:"data"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+	PrevCursor string        `json:"prev_cursor,omitempty"`
+}
+
+type getOrderHistoryResponse struct {
+	Data []model.OrderStatusChange `json:"data"`
+}
+
+type getSellerApplicationsResponse struct {
+	Data []model.SellerApplication `json:"data"`
+}
+
+type getUsersResponse struct {
+	Data []model.UserProfile `json:"data"`
+}
+
+type getReviewsResponse struct {
+	Data       []model.Review `json:"data"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+	PrevCursor string         `json:"prev_cursor,omitempty"`
+}
+
+type getAuditLogResponse struct {
+	Data []model.AuditEntry `json:"data"`
+}
+
+type getCategoriesResponse struct {
+	Data []model.Category `json:"data"`
+}
+
+type getImagesResponse struct {
+	Data []model.ProductImage `json:"data"`
+}
+
+type getOptionsResponse struct {
+	Data []model.ProductOption `json:"data"`
+}
+
+type getVariantsResponse struct {
+	Data []model.ProductVariant `json:"data"`
 }
 
 func newErrorResponse(w http.ResponseWriter, msg string, status int) {
-	resp, _ := json.Marshal(errorResponse{msg}) //nolint:errcheck
+	resp, _ := json.Marshal(errorResponse{Message: msg}) //nolint:errcheck
 	w.WriteHeader(status)
 	w.Write(resp) //nolint:errcheck
 }
 
+func newErrorCodeResponse(w http.ResponseWriter, code, msg string, status int) {
+	resp, _ := json.Marshal(errorResponse{Message: msg, Code: code}) //nolint:errcheck
+	w.WriteHeader(status)
+	w.Write(resp) //nolint:errcheck
+}
+
+func newOutOfStockResponse(w http.ResponseWriter, err *model.OutOfStockError) {
+	resp, _ := json.Marshal(outOfStockResponse{Message: err.Error(), Code: "out_of_stock", Lines: err.Lines}) //nolint:errcheck
+	w.WriteHeader(http.StatusConflict)
+	w.Write(resp) //nolint:errcheck
+}
+
 func newStatusReponse(w http.ResponseWriter, msg string, status int) {
 	resp, _ := json.Marshal(statusResponse{msg}) //nolint:errcheck
 	w.WriteHeader(status)
@@ -35,13 +107,100 @@ func newStatusReponse(w http.ResponseWriter, msg string, status int) {
 }
 
 func newGetProductsResponse(w http.ResponseWriter, products []model.Product, status int) {
-	resp, _ := json.Marshal(getProductsResponse{products}) //nolint:errcheck
+	resp, _ := json.Marshal(getProductsResponse{Data: products}) //nolint:errcheck
+	w.WriteHeader(status)
+	w.Write(resp) //nolint:errcheck
+}
+
+func newGetCartResponse(w http.ResponseWriter, products []model.Product, total model.Money, status int) {
+	resp, _ := json.Marshal(getCartResponse{Data: products, Total: total}) //nolint:errcheck
+	w.WriteHeader(status)
+	w.Write(resp) //nolint:errcheck
+}
+
+func newSearchProductsResponse(w http.ResponseWriter, results []model.ProductSearchResult, status int) {
+	resp, _ := json.Marshal(searchProductsResponse{results}) //nolint:errcheck
+	w.WriteHeader(status)
+	w.Write(resp) //nolint:errcheck
+}
+
+// newGetProductsPageResponse adds the cursors of the neighbouring pages.
+func newGetProductsPageResponse(w http.ResponseWriter, products []model.Product, q model.QueryInput, status int) {
+	next, prev := pageCursors(q, products)
+	resp, _ := json.Marshal(getProductsResponse{Data: products, NextCursor: next, PrevCursor: prev}) //nolint:errcheck
 	w.WriteHeader(status)
 	w.Write(resp) //nolint:errcheck
 }
 
 func newGetOrdersResponse(w http.ResponseWriter, orders []model.Order, status int) {
-	resp, _ := json.Marshal(getOrdersResponse{orders}) //nolint:errcheck
+	resp, _ := json.Marshal(getOrdersResponse{Data: orders}) //nolint:errcheck
+	w.WriteHeader(status)
+	w.Write(resp) //nolint:errcheck
+}
+
+func newGetOrdersPageResponse(w http.ResponseWriter, orders []model.Order, q model.QueryInput, status int) {
+	next, prev := pageCursors(q, orders)
+	resp, _ := json.Marshal(getOrdersResponse{Data: orders, NextCursor: next, PrevCursor: prev}) //nolint:errcheck
+	w.WriteHeader(status)
+	w.Write(resp) //nolint:errcheck
+}
+
+func newGetOrderHistoryResponse(w http.ResponseWriter, history []model.OrderStatusChange, status int) {
+	resp, _ := json.Marshal(getOrderHistoryResponse{history}) //nolint:errcheck
+	w.WriteHeader(status)
+	w.Write(resp) //nolint:errcheck
+}
+
+func newGetSellerApplicationsResponse(w http.ResponseWriter, applications []model.SellerApplication, status int) {
+	resp, _ := json.Marshal(getSellerApplicationsResponse{applications}) //nolint:errcheck
+	w.WriteHeader(status)
+	w.Write(resp) //nolint:errcheck
+}
+
+func newGetUsersResponse(w http.ResponseWriter, users []model.User, status int) {
+	profiles := make([]model.UserProfile, 0, len(users))
+	for _, user := range users {
+		profiles = append(profiles, user.Profile())
+	}
+
+	resp, _ := json.Marshal(getUsersResponse{profiles}) //nolint:errcheck
+	w.WriteHeader(status)
+	w.Write(resp) //nolint:errcheck
+}
+
+func newGetReviewsPageResponse(w http.ResponseWriter, reviews []model.Review, q model.QueryInput, status int) {
+	next, prev := pageCursors(q, reviews)
+	resp, _ := json.Marshal(getReviewsResponse{Data: reviews, NextCursor: next, PrevCursor: prev}) //nolint:errcheck
+	w.WriteHeader(status)
+	w.Write(resp) //nolint:errcheck
+}
+
+func newGetAuditLogResponse(w http.ResponseWriter, entries []model.AuditEntry, status int) {
+	resp, _ := json.Marshal(getAuditLogResponse{entries}) //nolint:errcheck
+	w.WriteHeader(status)
+	w.Write(resp) //nolint:errcheck
+}
+
+func newGetCategoriesResponse(w http.ResponseWriter, categories []model.Category, status int) {
+	resp, _ := json.Marshal(getCategoriesResponse{categories}) //nolint:errcheck
+	w.WriteHeader(status)
+	w.Write(resp) //nolint:errcheck
+}
+
+func newGetOptionsResponse(w http.ResponseWriter, options []model.ProductOption, status int) {
+	resp, _ := json.Marshal(getOptionsResponse{options}) //nolint:errcheck
+	w.WriteHeader(status)
+	w.Write(resp) //nolint:errcheck
+}
+
+func newGetVariantsResponse(w http.ResponseWriter, variants []model.ProductVariant, status int) {
+	resp, _ := json.Marshal(getVariantsResponse{variants}) //nolint:errcheck
+	w.WriteHeader(status)
+	w.Write(resp) //nolint:errcheck
+}
+
+func newGetImagesResponse(w http.ResponseWriter, images []model.ProductImage, status int) {
+	resp, _ := json.Marshal(getImagesResponse{images}) //nolint:errcheck
 	w.WriteHeader(status)
 	w.Write(resp) //nolint:errcheck
 }
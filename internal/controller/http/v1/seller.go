@@ -0,0 +1,138 @@
+package v1
+
+import (
+	"encoding/json"
+	"io"
+	"market/internal/model"
+	"market/internal/policy"
+	"market/internal/service"
+	"market/pkg/auth"
+	"market/pkg/database/postgres"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+func (h *Handler) initSellerRoutes(api *mux.Router) {
+	seller := api.PathPrefix("/seller").Subrouter()
+	seller.HandleFunc("/applications", h.authMiddleware(h.authorize(policy.SellerApply, nil, h.applyForSeller))).Methods("POST")
+	seller.HandleFunc("/{userId}", h.getSellerProfile).Methods("GET")
+}
+
+// @Summary	Apply to become a seller
+// @Security	ApiKeyAuth
+// @Tags		seller
+// @ID			apply-seller
+// @Accept		json
+// @Produce	json
+// @Param		input	body		model.SellerApplication	true	"Shop name and contact details"
+// @Success	201		{object}	model.SellerApplication
+// @Failure	400,401	{object}	errorResponse
+// @Failure	403,409	{object}	errorResponse
+// @Failure	500		{object}	errorResponse
+// @Failure	default	{object}	errorResponse
+// @Router		/api/v1/seller/applications [post]
+func (h *Handler) applyForSeller(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+	if r.Header.Get("Content-Type") != appJSON {
+		newErrorResponse(w, "unknown payload", http.StatusBadRequest)
+		return
+	}
+
+	token, err := auth.TokenFromContext(r.Context())
+	if err != nil {
+		newErrorResponse(w, "Token Error", http.StatusInternalServerError)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		newErrorResponse(w, "server error", http.StatusBadRequest)
+		return
+	}
+	r.Body.Close()
+
+	var application model.SellerApplication
+	if err = json.Unmarshal(body, &application); err != nil {
+		newErrorResponse(w, "cant unpack payload", http.StatusBadRequest)
+		return
+	}
+
+	if err = h.validator.Struct(application); err != nil {
+		newErrorResponse(w, "invalid input", http.StatusBadRequest)
+		return
+	}
+
+	applicationID, err := h.services.Seller.Apply(token.UserID, application)
+	if err != nil {
+		switch err {
+		case service.ErrAlreadySeller, service.ErrApplicationPending:
+			newErrorResponse(w, err.Error(), http.StatusConflict)
+		default:
+			newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.logger.Infof("User %v applied to become a seller: %v", token.UserID, applicationID)
+
+	application, err = h.services.Seller.GetApplicationByID(applicationID)
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp, err := json.Marshal(application)
+	if err != nil {
+		newErrorResponse(w, `can't create payload`, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	if _, err = w.Write(resp); err != nil {
+		newErrorResponse(w, `can't write resp`, http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary	Get seller profile
+// @Tags		seller
+// @ID			get-seller
+// @Produce	json
+// @Param		userId	path		int	true	"Seller user ID"
+// @Success	200		{object}	model.SellerProfile
+// @Failure	400,404	{object}	errorResponse
+// @Failure	500		{object}	errorResponse
+// @Failure	default	{object}	errorResponse
+// @Router		/api/v1/seller/{userId} [get]
+func (h *Handler) getSellerProfile(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+
+	userID, err := strconv.Atoi(mux.Vars(r)["userId"])
+	if err != nil {
+		newErrorResponse(w, "Bad Id", http.StatusBadRequest)
+		return
+	}
+
+	profile, err := h.services.Seller.GetProfile(userID)
+	if err != nil {
+		if err == postgres.ErrNotFound {
+			newErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp, err := json.Marshal(profile)
+	if err != nil {
+		newErrorResponse(w, `can't create payload`, http.StatusInternalServerError)
+		return
+	}
+
+	if _, err = w.Write(resp); err != nil {
+		newErrorResponse(w, `can't write resp`, http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,250 @@
+package v1
+
+import (
+	"encoding/json"
+	"io"
+	"market/internal/service"
+	"market/pkg/auth"
+	"net/http"
+)
+
+type twoFactorSignInInput struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+type twoFactorCodeInput struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// @Summary	Complete sign-in with a TOTP or recovery code
+// @Tags		user
+// @ID			login-2fa
+// @Accept		json
+// @Produce	json
+// @Param		input	body		twoFactorSignInInput	true	"MFA token from sign-in and one-time code"
+// @Success	200		{object}	model.Tokens
+// @Failure	400,401	{object}	errorResponse
+// @Failure	403		{object}	errorResponse
+// @Failure	423,429	{object}	errorResponse
+// @Failure	500		{object}	errorResponse
+// @Failure	default	{object}	errorResponse
+// @Router		/api/v1/user/sign-in/2fa [post]
+func (h *Handler) signInTwoFactor(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+	if r.Header.Get("Content-Type") != appJSON {
+		newErrorResponse(w, "unknown payload", http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		newErrorResponse(w, "server error", http.StatusBadRequest)
+		return
+	}
+	r.Body.Close()
+
+	var input twoFactorSignInInput
+	if err = json.Unmarshal(body, &input); err != nil {
+		newErrorResponse(w, "cant unpack payload", http.StatusBadRequest)
+		return
+	}
+
+	if err = h.validator.Struct(input); err != nil {
+		newErrorResponse(w, "invalid input", http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := h.services.User.CompleteSignIn(input.MFAToken, input.Code, clientIP(r))
+	if err != nil {
+		newSignInErrorResponse(w, err)
+		return
+	}
+
+	resp, err := json.Marshal(tokens)
+	if err != nil {
+		newErrorResponse(w, `can't create payload`, http.StatusInternalServerError)
+		return
+	}
+
+	if _, err = w.Write(resp); err != nil {
+		newErrorResponse(w, `can't write resp`, http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary	Start two-factor authentication enrollment
+// @Description	Returns a TOTP secret and an otpauth URI for authenticator apps. 2FA is enabled after confirmation.
+// @Security	ApiKeyAuth
+// @Tags		user
+// @ID			enroll-2fa
+// @Produce	json
+// @Success	200		{object}	model.TOTPEnrollment
+// @Failure	401,409	{object}	errorResponse
+// @Failure	500		{object}	errorResponse
+// @Failure	default	{object}	errorResponse
+// @Router		/api/v1/user/me/2fa [post]
+func (h *Handler) enrollTwoFactor(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+
+	token, err := auth.TokenFromContext(r.Context())
+	if err != nil {
+		newErrorResponse(w, "Token Error", http.StatusInternalServerError)
+		return
+	}
+
+	enrollment, err := h.services.User.EnrollTOTP(token.UserID)
+	if err != nil {
+		if err == service.ErrTwoFactorEnabled {
+			newErrorResponse(w, err.Error(), http.StatusConflict)
+			return
+		}
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp, err := json.Marshal(enrollment)
+	if err != nil {
+		newErrorResponse(w, `can't create payload`, http.StatusInternalServerError)
+		return
+	}
+
+	if _, err = w.Write(resp); err != nil {
+		newErrorResponse(w, `can't write resp`, http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary	Confirm two-factor authentication enrollment
+// @Description	Enables 2FA and returns recovery codes. The codes are shown only once.
+// @Security	ApiKeyAuth
+// @Tags		user
+// @ID			confirm-2fa
+// @Accept		json
+// @Produce	json
+// @Param		input	body		twoFactorCodeInput	true	"Code from the authenticator app"
+// @Success	200		{object}	recoveryCodesResponse
+// @Failure	400,401	{object}	errorResponse
+// @Failure	409		{object}	errorResponse
+// @Failure	500		{object}	errorResponse
+// @Failure	default	{object}	errorResponse
+// @Router		/api/v1/user/me/2fa/confirm [post]
+func (h *Handler) confirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+	if r.Header.Get("Content-Type") != appJSON {
+		newErrorResponse(w, "unknown payload", http.StatusBadRequest)
+		return
+	}
+
+	token, err := auth.TokenFromContext(r.Context())
+	if err != nil {
+		newErrorResponse(w, "Token Error", http.StatusInternalServerError)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		newErrorResponse(w, "server error", http.StatusBadRequest)
+		return
+	}
+	r.Body.Close()
+
+	var input twoFactorCodeInput
+	if err = json.Unmarshal(body, &input); err != nil {
+		newErrorResponse(w, "cant unpack payload", http.StatusBadRequest)
+		return
+	}
+
+	if err = h.validator.Struct(input); err != nil {
+		newErrorResponse(w, "invalid input", http.StatusBadRequest)
+		return
+	}
+
+	codes, err := h.services.User.ConfirmTOTP(token.UserID, input.Code)
+	if err != nil {
+		switch err {
+		case service.ErrInvalidOTP, service.ErrTwoFactorNotEnrolled:
+			newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		case service.ErrTwoFactorEnabled:
+			newErrorResponse(w, err.Error(), http.StatusConflict)
+		default:
+			newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.logger.Infof("User %v enabled two-factor authentication", token.UserID)
+
+	resp, err := json.Marshal(recoveryCodesResponse{RecoveryCodes: codes})
+	if err != nil {
+		newErrorResponse(w, `can't create payload`, http.StatusInternalServerError)
+		return
+	}
+
+	if _, err = w.Write(resp); err != nil {
+		newErrorResponse(w, `can't write resp`, http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary	Disable two-factor authentication
+// @Security	ApiKeyAuth
+// @Tags		user
+// @ID			disable-2fa
+// @Accept		json
+// @Produce	json
+// @Param		input	body		twoFactorCodeInput	true	"TOTP or recovery code"
+// @Success	200		{object}	statusResponse
+// @Failure	400,401	{object}	errorResponse
+// @Failure	500		{object}	errorResponse
+// @Failure	default	{object}	errorResponse
+// @Router		/api/v1/user/me/2fa [delete]
+func (h *Handler) disableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+	if r.Header.Get("Content-Type") != appJSON {
+		newErrorResponse(w, "unknown payload", http.StatusBadRequest)
+		return
+	}
+
+	token, err := auth.TokenFromContext(r.Context())
+	if err != nil {
+		newErrorResponse(w, "Token Error", http.StatusInternalServerError)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		newErrorResponse(w, "server error", http.StatusBadRequest)
+		return
+	}
+	r.Body.Close()
+
+	var input twoFactorCodeInput
+	if err = json.Unmarshal(body, &input); err != nil {
+		newErrorResponse(w, "cant unpack payload", http.StatusBadRequest)
+		return
+	}
+
+	if err = h.validator.Struct(input); err != nil {
+		newErrorResponse(w, "invalid input", http.StatusBadRequest)
+		return
+	}
+
+	if err = h.services.User.DisableTOTP(token.UserID, input.Code); err != nil {
+		switch err {
+		case service.ErrInvalidOTP, service.ErrTwoFactorDisabled:
+			newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		default:
+			newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.logger.Infof("User %v disabled two-factor authentication", token.UserID)
+
+	newStatusReponse(w, "done", http.StatusOK)
+}
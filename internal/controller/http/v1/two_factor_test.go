@@ -0,0 +1,95 @@
+package v1
+
+import (
+	"bytes"
+	"market/internal/model"
+	"market/internal/service"
+	mock_service "market/internal/service/mocks"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/magiconair/properties/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+func TestHandler_signInTwoFactor(t *testing.T) {
+	type mockBehaviour func(r *mock_service.MockUser, inp twoFactorSignInInput)
+
+	tests := []struct {
+		name                 string
+		inputBody            string
+		input                twoFactorSignInInput
+		mockBehaviour        mockBehaviour
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"mfa_token": "mfa", "code": "123456"}`,
+			input:     twoFactorSignInInput{MFAToken: "mfa", Code: "123456"},
+			mockBehaviour: func(r *mock_service.MockUser, inp twoFactorSignInInput) {
+				r.EXPECT().CompleteSignIn(inp.MFAToken, inp.Code, "192.0.2.1").Return(model.Tokens{AccessToken: "token", RefreshToken: "refresh"}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"token":"token","refresh_token":"refresh"}`,
+		},
+		{
+			name:                 "Wrong Input",
+			inputBody:            `{"mfa_token": "mfa"}`,
+			mockBehaviour:        func(r *mock_service.MockUser, inp twoFactorSignInInput) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input"}`,
+		},
+		{
+			name:      "Wrong Code",
+			inputBody: `{"mfa_token": "mfa", "code": "000000"}`,
+			input:     twoFactorSignInInput{MFAToken: "mfa", Code: "000000"},
+			mockBehaviour: func(r *mock_service.MockUser, inp twoFactorSignInInput) {
+				r.EXPECT().CompleteSignIn(inp.MFAToken, inp.Code, "192.0.2.1").Return(model.Tokens{}, service.ErrInvalidOTP)
+			},
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"message":"invalid one-time code"}`,
+		},
+		{
+			name:      "Expired MFA Token",
+			inputBody: `{"mfa_token": "mfa", "code": "123456"}`,
+			input:     twoFactorSignInInput{MFAToken: "mfa", Code: "123456"},
+			mockBehaviour: func(r *mock_service.MockUser, inp twoFactorSignInInput) {
+				r.EXPECT().CompleteSignIn(inp.MFAToken, inp.Code, "192.0.2.1").Return(model.Tokens{}, service.ErrInvalidMFAToken)
+			},
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"message":"invalid or expired MFA token"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repoUser := mock_service.NewMockUser(c)
+			test.mockBehaviour(repoUser, test.input)
+
+			h := &Handler{
+				services:  &service.Service{User: repoUser},
+				logger:    zap.NewNop().Sugar(),
+				validator: validator.New(),
+			}
+
+			r := mux.NewRouter()
+			r.HandleFunc("/api/v1/user/sign-in/2fa", h.signInTwoFactor).Methods("POST")
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/v1/user/sign-in/2fa",
+				bytes.NewBufferString(test.inputBody))
+			req.Header.Set("Content-Type", appJSON)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
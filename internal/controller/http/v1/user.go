@@ -2,9 +2,15 @@ package v1
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"market/internal/model"
+	"market/internal/service"
+	"market/pkg/auth"
+	"market/pkg/database/postgres"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -12,7 +18,21 @@ import (
 func (h *Handler) initUserRoutes(api *mux.Router) {
 	user := api.PathPrefix("/user").Subrouter()
 	user.HandleFunc("/sign-in", h.signIn).Methods("POST")
+	user.HandleFunc("/sign-in/2fa", h.signInTwoFactor).Methods("POST")
 	user.HandleFunc("/sign-up", h.signUp).Methods("POST")
+	user.HandleFunc("/refresh", h.refresh).Methods("POST")
+	user.HandleFunc("/verify-email", h.verifyEmail).Methods("POST")
+	user.HandleFunc("/forgot-password", h.forgotPassword).Methods("POST")
+	user.HandleFunc("/reset-password", h.resetPassword).Methods("POST")
+	user.HandleFunc("/sign-out", h.authMiddleware(h.signOut)).Methods("POST")
+	user.HandleFunc("/me", h.authMiddleware(h.getMe)).Methods("GET")
+	user.HandleFunc("/me", h.authMiddleware(h.updateMe)).Methods("PATCH")
+	user.HandleFunc("/me", h.authMiddleware(h.deleteMe)).Methods("DELETE")
+	user.HandleFunc("/me/password", h.authMiddleware(h.changePassword)).Methods("PUT")
+	user.HandleFunc("/me/verification", h.authMiddleware(h.resendVerification)).Methods("POST")
+	user.HandleFunc("/me/2fa", h.authMiddleware(h.enrollTwoFactor)).Methods("POST")
+	user.HandleFunc("/me/2fa/confirm", h.authMiddleware(h.confirmTwoFactor)).Methods("POST")
+	user.HandleFunc("/me/2fa", h.authMiddleware(h.disableTwoFactor)).Methods("DELETE")
 	user.HandleFunc("/{userId}/products", queryMiddleware(h.getProductsByUserID)).Methods("GET")
 }
 
@@ -22,8 +42,9 @@ func (h *Handler) initUserRoutes(api *mux.Router) {
 // @Accept		json
 // @Produce	json
 // @Param		input	body		model.User	true	"Account info"
-// @Success	200		{string}	string		"token"
+// @Success	200		{object}	model.Tokens
 // @Failure	400,404	{object}	errorResponse
+// @Failure	409		{object}	errorResponse
 // @Failure	500		{object}	errorResponse
 // @Failure	default	{object}	errorResponse
 // @Router		/api/register [post]
@@ -56,6 +77,10 @@ func (h *Handler) signUp(w http.ResponseWriter, r *http.Request) {
 
 	userID, err := h.services.User.CreateUser(user)
 	if err != nil {
+		if err == service.ErrUserExists {
+			newErrorResponse(w, err.Error(), http.StatusConflict)
+			return
+		}
 		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -65,15 +90,19 @@ func (h *Handler) signUp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := h.services.User.GenerateToken(user.Username, user.Password)
+	// The account is usable without a verified email, so a failed delivery
+	// doesn't fail the sign-up; the user can request another link.
+	if err = h.services.User.SendVerification(userID); err != nil {
+		h.logger.Errorf("can't send verification email to user %v: %v", userID, err)
+	}
+
+	tokens, err := h.services.User.GenerateToken(user.Username, user.Password, clientIP(r))
 	if err != nil {
 		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	resp, err := json.Marshal(map[string]interface{}{
-		"token": token,
-	})
+	resp, err := json.Marshal(tokens)
 	if err != nil {
 		newErrorResponse(w, `can't create payload`, http.StatusInternalServerError)
 		return
@@ -91,13 +120,16 @@ type signInInput struct {
 }
 
 // @Summary	Login into market
+// @Description	If two-factor authentication is enabled, only mfa_token is returned; exchange it at /api/v1/user/sign-in/2fa.
 // @Tags		user
 // @ID			login
 // @Accept		json
 // @Produce	json
 // @Param		input	body		signInInput	true	"Username and password"
-// @Success	200		{string}	string		"token"
-// @Failure	400,404	{object}	errorResponse
+// @Success	200		{object}	model.Tokens
+// @Failure	400,401	{object}	errorResponse
+// @Failure	403		{object}	errorResponse
+// @Failure	423,429	{object}	errorResponse
 // @Failure	500		{object}	errorResponse
 // @Failure	default	{object}	errorResponse
 // @Router		/api/login [post]
@@ -126,15 +158,284 @@ func (h *Handler) signIn(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := h.services.User.GenerateToken(input.Username, input.Password)
+	tokens, err := h.services.User.GenerateToken(input.Username, input.Password, clientIP(r))
+	if err != nil {
+		newSignInErrorResponse(w, err)
+		return
+	}
+
+	resp, err := json.Marshal(tokens)
+	if err != nil {
+		newErrorResponse(w, `can't create payload`, http.StatusInternalServerError)
+		return
+	}
+
+	if _, err = w.Write(resp); err != nil {
+		newErrorResponse(w, `can't write resp`, http.StatusInternalServerError)
+		return
+	}
+}
+
+type refreshInput struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// @Summary	Refresh access token
+// @Tags		user
+// @ID			refresh
+// @Accept		json
+// @Produce	json
+// @Param		input	body		refreshInput	true	"Refresh token"
+// @Success	200		{object}	model.Tokens
+// @Failure	400,401	{object}	errorResponse
+// @Failure	403		{object}	errorResponse
+// @Failure	500		{object}	errorResponse
+// @Failure	default	{object}	errorResponse
+// @Router		/api/v1/user/refresh [post]
+func (h *Handler) refresh(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+	if r.Header.Get("Content-Type") != appJSON {
+		newErrorResponse(w, "unknown payload", http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		newErrorResponse(w, "server error", http.StatusBadRequest)
+		return
+	}
+	r.Body.Close()
+
+	var input refreshInput
+	if err = json.Unmarshal(body, &input); err != nil {
+		newErrorResponse(w, "cant unpack payload", http.StatusBadRequest)
+		return
+	}
+
+	if err = h.validator.Struct(input); err != nil {
+		newErrorResponse(w, "invalid input", http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := h.services.User.RefreshTokens(input.RefreshToken)
+	if err != nil {
+		switch err {
+		case service.ErrInvalidRefresh, service.ErrRefreshExpired, service.ErrRefreshTokenReuse:
+			newErrorResponse(w, err.Error(), http.StatusUnauthorized)
+		case service.ErrUserBanned:
+			newErrorCodeResponse(w, "user_banned", err.Error(), http.StatusForbidden)
+		case service.ErrPasswordReset:
+			newErrorCodeResponse(w, "password_reset_required", err.Error(), http.StatusForbidden)
+		default:
+			newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	resp, err := json.Marshal(tokens)
+	if err != nil {
+		newErrorResponse(w, `can't create payload`, http.StatusInternalServerError)
+		return
+	}
+
+	if _, err = w.Write(resp); err != nil {
+		newErrorResponse(w, `can't write resp`, http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary	Sign out and revoke the current session
+// @Security	ApiKeyAuth
+// @Tags		user
+// @ID			sign-out
+// @Produce	json
+// @Success	200		{object}	statusResponse
+// @Failure	400,401	{object}	errorResponse
+// @Failure	500		{object}	errorResponse
+// @Failure	default	{object}	errorResponse
+// @Router		/api/v1/user/sign-out [post]
+func (h *Handler) signOut(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+
+	token, err := auth.TokenFromContext(r.Context())
+	if err != nil {
+		newErrorResponse(w, "Token Error", http.StatusInternalServerError)
+		return
+	}
+
+	if err = h.services.User.SignOut(token.SessionID); err != nil {
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.logger.Infof("User %v signed out from session %v", token.UserID, token.SessionID)
+
+	newStatusReponse(w, "done", http.StatusOK)
+}
+
+// @Summary	Get current user profile
+// @Security	ApiKeyAuth
+// @Tags		user
+// @ID			get-me
+// @Produce	json
+// @Success	200		{object}	model.UserProfile
+// @Failure	400,401	{object}	errorResponse
+// @Failure	404		{object}	errorResponse
+// @Failure	500		{object}	errorResponse
+// @Failure	default	{object}	errorResponse
+// @Router		/api/v1/user/me [get]
+func (h *Handler) getMe(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+
+	token, err := auth.TokenFromContext(r.Context())
+	if err != nil {
+		newErrorResponse(w, "Token Error", http.StatusInternalServerError)
+		return
+	}
+
+	user, err := h.services.User.GetByID(token.UserID)
+	if err != nil {
+		if err == postgres.ErrNotFound {
+			newErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp, err := json.Marshal(user.Profile())
+	if err != nil {
+		newErrorResponse(w, `can't create payload`, http.StatusInternalServerError)
+		return
+	}
+
+	if _, err = w.Write(resp); err != nil {
+		newErrorResponse(w, `can't write resp`, http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary	Update current user profile
+// @Security	ApiKeyAuth
+// @Tags		user
+// @ID			update-me
+// @Accept		json
+// @Produce	json
+// @Param		input	body		model.UpdateUserInput	true	"Profile fields"
+// @Success	200		{object}	statusResponse
+// @Failure	400,401	{object}	errorResponse
+// @Failure	404,409	{object}	errorResponse
+// @Failure	500		{object}	errorResponse
+// @Failure	default	{object}	errorResponse
+// @Router		/api/v1/user/me [patch]
+func (h *Handler) updateMe(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+	if r.Header.Get("Content-Type") != appJSON {
+		newErrorResponse(w, "unknown payload", http.StatusBadRequest)
+		return
+	}
+
+	token, err := auth.TokenFromContext(r.Context())
+	if err != nil {
+		newErrorResponse(w, "Token Error", http.StatusInternalServerError)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		newErrorResponse(w, "server error", http.StatusBadRequest)
+		return
+	}
+	r.Body.Close()
+
+	var input model.UpdateUserInput
+	if err = json.Unmarshal(body, &input); err != nil {
+		newErrorResponse(w, "cant unpack payload", http.StatusBadRequest)
+		return
+	}
+
+	if err = input.Validate(); err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = h.services.User.Update(token.UserID, input); err != nil {
+		switch err {
+		case service.ErrUserExists:
+			newErrorResponse(w, err.Error(), http.StatusConflict)
+		case postgres.ErrNotFound:
+			newErrorResponse(w, err.Error(), http.StatusNotFound)
+		default:
+			newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	newStatusReponse(w, "done", http.StatusOK)
+}
+
+type changePasswordInput struct {
+	OldPassword string `json:"old_password" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required"`
+}
+
+// @Summary	Change password and sign out of all other sessions
+// @Security	ApiKeyAuth
+// @Tags		user
+// @ID			change-password
+// @Accept		json
+// @Produce	json
+// @Param		input	body		changePasswordInput	true	"Old and new password"
+// @Success	200		{object}	model.Tokens
+// @Failure	400,401	{object}	errorResponse
+// @Failure	403		{object}	errorResponse
+// @Failure	500		{object}	errorResponse
+// @Failure	default	{object}	errorResponse
+// @Router		/api/v1/user/me/password [put]
+func (h *Handler) changePassword(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+	if r.Header.Get("Content-Type") != appJSON {
+		newErrorResponse(w, "unknown payload", http.StatusBadRequest)
+		return
+	}
+
+	token, err := auth.TokenFromContext(r.Context())
+	if err != nil {
+		newErrorResponse(w, "Token Error", http.StatusInternalServerError)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		newErrorResponse(w, "server error", http.StatusBadRequest)
+		return
+	}
+	r.Body.Close()
+
+	var input changePasswordInput
+	if err = json.Unmarshal(body, &input); err != nil {
+		newErrorResponse(w, "cant unpack payload", http.StatusBadRequest)
+		return
+	}
+
+	if err = h.validator.Struct(input); err != nil {
+		newErrorResponse(w, "invalid input", http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := h.services.User.ChangePassword(token.UserID, input.OldPassword, input.NewPassword)
 	if err != nil {
+		if err == service.ErrBadPass {
+			newErrorResponse(w, err.Error(), http.StatusForbidden)
+			return
+		}
 		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	resp, err := json.Marshal(map[string]interface{}{
-		"token": token,
-	})
+	h.logger.Infof("User %v changed password", token.UserID)
+
+	resp, err := json.Marshal(tokens)
 	if err != nil {
 		newErrorResponse(w, `can't create payload`, http.StatusInternalServerError)
 		return
@@ -145,3 +446,254 @@ func (h *Handler) signIn(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+// @Summary	Delete current user account with products, cart, orders and reviews
+// @Security	ApiKeyAuth
+// @Tags		user
+// @ID			delete-me
+// @Produce	json
+// @Success	200		{object}	statusResponse
+// @Failure	400,401	{object}	errorResponse
+// @Failure	404		{object}	errorResponse
+// @Failure	500		{object}	errorResponse
+// @Failure	default	{object}	errorResponse
+// @Router		/api/v1/user/me [delete]
+func (h *Handler) deleteMe(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+
+	token, err := auth.TokenFromContext(r.Context())
+	if err != nil {
+		newErrorResponse(w, "Token Error", http.StatusInternalServerError)
+		return
+	}
+
+	if err = h.services.User.Delete(token.UserID); err != nil {
+		if err == postgres.ErrNotFound {
+			newErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.logger.Infof("User %v deleted account", token.UserID)
+
+	newStatusReponse(w, "done", http.StatusOK)
+}
+
+type verifyEmailInput struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// @Summary	Confirm email with the token from the verification link
+// @Tags		user
+// @ID			verify-email
+// @Accept		json
+// @Produce	json
+// @Param		input	body		verifyEmailInput	true	"Verification token"
+// @Success	200		{object}	statusResponse
+// @Failure	400		{object}	errorResponse
+// @Failure	500		{object}	errorResponse
+// @Failure	default	{object}	errorResponse
+// @Router		/api/v1/user/verify-email [post]
+func (h *Handler) verifyEmail(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+	if r.Header.Get("Content-Type") != appJSON {
+		newErrorResponse(w, "unknown payload", http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		newErrorResponse(w, "server error", http.StatusBadRequest)
+		return
+	}
+	r.Body.Close()
+
+	var input verifyEmailInput
+	if err = json.Unmarshal(body, &input); err != nil {
+		newErrorResponse(w, "cant unpack payload", http.StatusBadRequest)
+		return
+	}
+
+	if err = h.validator.Struct(input); err != nil {
+		newErrorResponse(w, "invalid input", http.StatusBadRequest)
+		return
+	}
+
+	if err = h.services.User.VerifyEmail(input.Token); err != nil {
+		if err == service.ErrInvalidEmailToken {
+			newErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	newStatusReponse(w, "done", http.StatusOK)
+}
+
+// @Summary	Send a new email verification link
+// @Security	ApiKeyAuth
+// @Tags		user
+// @ID			resend-verification
+// @Produce	json
+// @Success	200		{object}	statusResponse
+// @Failure	401,409	{object}	errorResponse
+// @Failure	500		{object}	errorResponse
+// @Failure	default	{object}	errorResponse
+// @Router		/api/v1/user/me/verification [post]
+func (h *Handler) resendVerification(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+
+	token, err := auth.TokenFromContext(r.Context())
+	if err != nil {
+		newErrorResponse(w, "Token Error", http.StatusInternalServerError)
+		return
+	}
+
+	if err = h.services.User.SendVerification(token.UserID); err != nil {
+		if err == service.ErrEmailAlreadyVerified {
+			newErrorResponse(w, err.Error(), http.StatusConflict)
+			return
+		}
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	newStatusReponse(w, "done", http.StatusOK)
+}
+
+type forgotPasswordInput struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// @Summary	Send a password reset link
+// @Description	Always responds with success, whether the email is registered or not.
+// @Tags		user
+// @ID			forgot-password
+// @Accept		json
+// @Produce	json
+// @Param		input	body		forgotPasswordInput	true	"Account email"
+// @Success	200		{object}	statusResponse
+// @Failure	400		{object}	errorResponse
+// @Failure	500		{object}	errorResponse
+// @Failure	default	{object}	errorResponse
+// @Router		/api/v1/user/forgot-password [post]
+func (h *Handler) forgotPassword(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+	if r.Header.Get("Content-Type") != appJSON {
+		newErrorResponse(w, "unknown payload", http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		newErrorResponse(w, "server error", http.StatusBadRequest)
+		return
+	}
+	r.Body.Close()
+
+	var input forgotPasswordInput
+	if err = json.Unmarshal(body, &input); err != nil {
+		newErrorResponse(w, "cant unpack payload", http.StatusBadRequest)
+		return
+	}
+
+	if err = h.validator.Struct(input); err != nil {
+		newErrorResponse(w, "invalid input", http.StatusBadRequest)
+		return
+	}
+
+	if err = h.services.User.ForgotPassword(input.Email); err != nil {
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	newStatusReponse(w, "done", http.StatusOK)
+}
+
+type resetPasswordInput struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+// @Summary	Set a new password with the token from the reset link
+// @Tags		user
+// @ID			reset-password
+// @Accept		json
+// @Produce	json
+// @Param		input	body		resetPasswordInput	true	"Reset token and new password"
+// @Success	200		{object}	statusResponse
+// @Failure	400		{object}	errorResponse
+// @Failure	500		{object}	errorResponse
+// @Failure	default	{object}	errorResponse
+// @Router		/api/v1/user/reset-password [post]
+func (h *Handler) resetPassword(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+	if r.Header.Get("Content-Type") != appJSON {
+		newErrorResponse(w, "unknown payload", http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		newErrorResponse(w, "server error", http.StatusBadRequest)
+		return
+	}
+	r.Body.Close()
+
+	var input resetPasswordInput
+	if err = json.Unmarshal(body, &input); err != nil {
+		newErrorResponse(w, "cant unpack payload", http.StatusBadRequest)
+		return
+	}
+
+	if err = h.validator.Struct(input); err != nil {
+		newErrorResponse(w, "invalid input", http.StatusBadRequest)
+		return
+	}
+
+	if err = h.services.User.ResetPassword(input.Token, input.Password); err != nil {
+		if err == service.ErrInvalidEmailToken {
+			newErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	newStatusReponse(w, "done", http.StatusOK)
+}
+
+// newSignInErrorResponse maps sign-in errors, including login throttling, to responses.
+func newSignInErrorResponse(w http.ResponseWriter, err error) {
+	var throttleErr *service.ThrottleError
+	switch {
+	case errors.As(err, &throttleErr):
+		w.Header().Set("Retry-After", retryAfter(throttleErr.RetryAt))
+		if errors.Is(err, service.ErrAccountLocked) {
+			newErrorResponse(w, err.Error(), http.StatusLocked)
+		} else {
+			newErrorResponse(w, err.Error(), http.StatusTooManyRequests)
+		}
+	case errors.Is(err, service.ErrNoUser), errors.Is(err, service.ErrBadPass):
+		newErrorResponse(w, "invalid username or password", http.StatusUnauthorized)
+	case errors.Is(err, service.ErrInvalidOTP), errors.Is(err, service.ErrInvalidMFAToken):
+		newErrorResponse(w, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, service.ErrUserBanned):
+		newErrorCodeResponse(w, "user_banned", err.Error(), http.StatusForbidden)
+	case errors.Is(err, service.ErrPasswordReset):
+		newErrorCodeResponse(w, "password_reset_required", err.Error(), http.StatusForbidden)
+	default:
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// retryAfter formats the Retry-After header value in whole seconds, rounded up.
+func retryAfter(at time.Time) string {
+	seconds := int(time.Until(at).Seconds())
+	if time.Until(at) > time.Duration(seconds)*time.Second {
+		seconds++
+	}
+	return strconv.Itoa(seconds)
+}
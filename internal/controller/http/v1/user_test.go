@@ -6,8 +6,10 @@ import (
 	"market/internal/model"
 	"market/internal/service"
 	mock_service "market/internal/service/mocks"
+	"market/pkg/auth"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
@@ -29,19 +31,21 @@ func TestHandler_signUp(t *testing.T) {
 	}{
 		{
 			name:      "OK",
-			inputBody: `{"username": "testname", "password": "testpassword"}`,
+			inputBody: `{"username": "testname", "email": "test@example.com", "password": "testpassword"}`,
 			inputUser: model.User{
 				Role:     model.USER,
 				Username: "testname",
+				Email:    "test@example.com",
 				Password: "testpassword",
 			},
 			mockBehaviour: func(ru *mock_service.MockUser, rc *mock_service.MockCart, user model.User) {
 				ru.EXPECT().CreateUser(user).Return(1, nil)
 				rc.EXPECT().Create(1).Return(1, nil)
-				ru.EXPECT().GenerateToken(user.Username, user.Password).Return("token", nil)
+				ru.EXPECT().SendVerification(1).Return(nil)
+				ru.EXPECT().GenerateToken(user.Username, user.Password, gomock.Any()).Return(model.Tokens{AccessToken: "token", RefreshToken: "refresh"}, nil)
 			},
 			expectedStatusCode:   200,
-			expectedResponseBody: `{"token":"token"}`,
+			expectedResponseBody: `{"token":"token","refresh_token":"refresh"}`,
 		},
 		{
 			name:      "Wrong Input",
@@ -55,10 +59,11 @@ func TestHandler_signUp(t *testing.T) {
 		},
 		{
 			name:      "Service Error",
-			inputBody: `{"username": "testname", "password": "testpassword"}`,
+			inputBody: `{"username": "testname", "email": "test@example.com", "password": "testpassword"}`,
 			inputUser: model.User{
 				Role:     model.USER,
 				Username: "testname",
+				Email:    "test@example.com",
 				Password: "testpassword",
 			},
 			mockBehaviour: func(r *mock_service.MockUser, rc *mock_service.MockCart, user model.User) {
@@ -67,6 +72,21 @@ func TestHandler_signUp(t *testing.T) {
 			expectedStatusCode:   500,
 			expectedResponseBody: `{"message":"something went wrong"}`,
 		},
+		{
+			name:      "User Exists",
+			inputBody: `{"username": "testname", "email": "test@example.com", "password": "testpassword"}`,
+			inputUser: model.User{
+				Role:     model.USER,
+				Username: "testname",
+				Email:    "test@example.com",
+				Password: "testpassword",
+			},
+			mockBehaviour: func(r *mock_service.MockUser, rc *mock_service.MockCart, user model.User) {
+				r.EXPECT().CreateUser(user).Return(0, service.ErrUserExists)
+			},
+			expectedStatusCode:   409,
+			expectedResponseBody: `{"message":"user already exists"}`,
+		},
 	}
 
 	for _, test := range tests {
@@ -123,10 +143,10 @@ func TestHandler_signIn(t *testing.T) {
 				Password: "testpassword",
 			},
 			mockBehaviour: func(r *mock_service.MockUser, inp signInInput) {
-				r.EXPECT().GenerateToken(inp.Username, inp.Password).Return("token", nil)
+				r.EXPECT().GenerateToken(inp.Username, inp.Password, gomock.Any()).Return(model.Tokens{AccessToken: "token", RefreshToken: "refresh"}, nil)
 			},
 			expectedStatusCode:   200,
-			expectedResponseBody: `{"token":"token"}`,
+			expectedResponseBody: `{"token":"token","refresh_token":"refresh"}`,
 		},
 		{
 			name:      "Wrong Input",
@@ -146,11 +166,78 @@ func TestHandler_signIn(t *testing.T) {
 				Password: "testpassword",
 			},
 			mockBehaviour: func(r *mock_service.MockUser, inp signInInput) {
-				r.EXPECT().GenerateToken(inp.Username, inp.Password).Return("", errors.New("something went wrong"))
+				r.EXPECT().GenerateToken(inp.Username, inp.Password, gomock.Any()).Return(model.Tokens{}, errors.New("something went wrong"))
 			},
 			expectedStatusCode:   500,
 			expectedResponseBody: `{"message":"something went wrong"}`,
 		},
+		{
+			name:      "Two-Factor Required",
+			inputBody: `{"username": "testname", "password": "testpassword"}`,
+			inputUser: signInInput{
+				Username: "testname",
+				Password: "testpassword",
+			},
+			mockBehaviour: func(r *mock_service.MockUser, inp signInInput) {
+				r.EXPECT().GenerateToken(inp.Username, inp.Password, gomock.Any()).Return(model.Tokens{MFAToken: "mfa"}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"mfa_token":"mfa"}`,
+		},
+		{
+			name:      "Wrong Password",
+			inputBody: `{"username": "testname", "password": "testpassword"}`,
+			inputUser: signInInput{
+				Username: "testname",
+				Password: "testpassword",
+			},
+			mockBehaviour: func(r *mock_service.MockUser, inp signInInput) {
+				r.EXPECT().GenerateToken(inp.Username, inp.Password, "192.0.2.1").Return(model.Tokens{}, service.ErrBadPass)
+			},
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"message":"invalid username or password"}`,
+		},
+		{
+			name:      "Banned",
+			inputBody: `{"username": "testname", "password": "testpassword"}`,
+			inputUser: signInInput{
+				Username: "testname",
+				Password: "testpassword",
+			},
+			mockBehaviour: func(r *mock_service.MockUser, inp signInInput) {
+				r.EXPECT().GenerateToken(inp.Username, inp.Password, "192.0.2.1").Return(model.Tokens{}, service.ErrUserBanned)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"message":"user is banned","code":"user_banned"}`,
+		},
+		{
+			name:      "Too Many Attempts",
+			inputBody: `{"username": "testname", "password": "testpassword"}`,
+			inputUser: signInInput{
+				Username: "testname",
+				Password: "testpassword",
+			},
+			mockBehaviour: func(r *mock_service.MockUser, inp signInInput) {
+				r.EXPECT().GenerateToken(inp.Username, inp.Password, "192.0.2.1").
+					Return(model.Tokens{}, &service.ThrottleError{Err: service.ErrTooManyAttempts, RetryAt: time.Now().Add(time.Minute)})
+			},
+			expectedStatusCode:   429,
+			expectedResponseBody: `{"message":"too many failed login attempts, try again later"}`,
+		},
+		{
+			name:      "Account Locked",
+			inputBody: `{"username": "testname", "password": "testpassword"}`,
+			inputUser: signInInput{
+				Username: "testname",
+				Password: "testpassword",
+			},
+			mockBehaviour: func(r *mock_service.MockUser, inp signInInput) {
+				r.EXPECT().GenerateToken(inp.Username, inp.Password, "192.0.2.1").
+					Return(model.Tokens{}, &service.ThrottleError{Err: service.ErrAccountLocked, RetryAt: time.Now().Add(time.Minute)})
+			},
+			expectedStatusCode:   423,
+			expectedResponseBody: `{"message":"account is temporarily locked"}`,
+		},
 	}
 
 	for _, test := range tests {
@@ -186,3 +273,242 @@ func TestHandler_signIn(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_refresh(t *testing.T) {
+	type mockBehaviour func(r *mock_service.MockUser, inp refreshInput)
+
+	tests := []struct {
+		name                 string
+		inputBody            string
+		input                refreshInput
+		mockBehaviour        mockBehaviour
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"refresh_token": "refresh"}`,
+			input:     refreshInput{RefreshToken: "refresh"},
+			mockBehaviour: func(r *mock_service.MockUser, inp refreshInput) {
+				r.EXPECT().RefreshTokens(inp.RefreshToken).Return(model.Tokens{AccessToken: "token", RefreshToken: "new_refresh"}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"token":"token","refresh_token":"new_refresh"}`,
+		},
+		{
+			name:                 "Wrong Input",
+			inputBody:            `{}`,
+			mockBehaviour:        func(r *mock_service.MockUser, inp refreshInput) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input"}`,
+		},
+		{
+			name:      "Token Reuse",
+			inputBody: `{"refresh_token": "refresh"}`,
+			input:     refreshInput{RefreshToken: "refresh"},
+			mockBehaviour: func(r *mock_service.MockUser, inp refreshInput) {
+				r.EXPECT().RefreshTokens(inp.RefreshToken).Return(model.Tokens{}, service.ErrRefreshTokenReuse)
+			},
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"message":"refresh token reuse detected"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repoUser := mock_service.NewMockUser(c)
+			test.mockBehaviour(repoUser, test.input)
+
+			services := &service.Service{User: repoUser}
+
+			validate := validator.New()
+			model.RegisterCustomValidations(validate) //nolint:errcheck
+			logger := zap.NewNop().Sugar()
+			h := &Handler{
+				services:  services,
+				logger:    logger,
+				validator: validate,
+			}
+
+			r := mux.NewRouter()
+			r.HandleFunc("/api/refresh", h.refresh).Methods("POST")
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/refresh",
+				bytes.NewBufferString(test.inputBody))
+			req.Header.Set("Content-Type", appJSON)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_changePassword(t *testing.T) {
+	type mockBehaviour func(r *mock_service.MockUser, inp changePasswordInput)
+
+	tests := []struct {
+		name                 string
+		inputBody            string
+		input                changePasswordInput
+		mockBehaviour        mockBehaviour
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"old_password": "old", "new_password": "new"}`,
+			input:     changePasswordInput{OldPassword: "old", NewPassword: "new"},
+			mockBehaviour: func(r *mock_service.MockUser, inp changePasswordInput) {
+				r.EXPECT().ChangePassword(1, inp.OldPassword, inp.NewPassword).Return(model.Tokens{AccessToken: "token", RefreshToken: "refresh"}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"token":"token","refresh_token":"refresh"}`,
+		},
+		{
+			name:                 "Wrong Input",
+			inputBody:            `{"old_password": "old"}`,
+			mockBehaviour:        func(r *mock_service.MockUser, inp changePasswordInput) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input"}`,
+		},
+		{
+			name:      "Wrong Old Password",
+			inputBody: `{"old_password": "old", "new_password": "new"}`,
+			input:     changePasswordInput{OldPassword: "old", NewPassword: "new"},
+			mockBehaviour: func(r *mock_service.MockUser, inp changePasswordInput) {
+				r.EXPECT().ChangePassword(1, inp.OldPassword, inp.NewPassword).Return(model.Tokens{}, service.ErrBadPass)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"message":"wrong password"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repoUser := mock_service.NewMockUser(c)
+			test.mockBehaviour(repoUser, test.input)
+
+			services := &service.Service{User: repoUser}
+
+			logger := zap.NewNop().Sugar()
+			h := &Handler{
+				services:  services,
+				logger:    logger,
+				validator: validator.New(),
+			}
+
+			r := mux.NewRouter()
+			r.HandleFunc("/api/v1/user/me/password", h.changePassword).Methods("PUT")
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", "/api/v1/user/me/password",
+				bytes.NewBufferString(test.inputBody))
+			req.Header.Set("Content-Type", appJSON)
+			req = req.WithContext(auth.ContextWithToken(req.Context(), &auth.Token{UserID: 1, SessionID: 1}))
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_getMe(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	repoUser := mock_service.NewMockUser(c)
+	repoUser.EXPECT().GetByID(1).Return(model.User{ID: 1, Role: model.USER, Username: "testname", Email: "test@example.com", Password: "hash"}, nil)
+
+	h := &Handler{
+		services:  &service.Service{User: repoUser},
+		logger:    zap.NewNop().Sugar(),
+		validator: validator.New(),
+	}
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api/v1/user/me", nil)
+	req = req.WithContext(auth.ContextWithToken(req.Context(), &auth.Token{UserID: 1, SessionID: 1}))
+	h.getMe(w, req)
+
+	assert.Equal(t, w.Code, 200)
+	assert.Equal(t, w.Body.String(), `{"id":1,"role":"user","username":"testname","email":"test@example.com","email_verified":false,"two_factor_enabled":false,`+
+		`"created_at":"0001-01-01T00:00:00Z"}`)
+}
+
+func TestHandler_resetPassword(t *testing.T) {
+	type mockBehaviour func(r *mock_service.MockUser, inp resetPasswordInput)
+
+	tests := []struct {
+		name                 string
+		inputBody            string
+		input                resetPasswordInput
+		mockBehaviour        mockBehaviour
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"token": "token", "password": "new"}`,
+			input:     resetPasswordInput{Token: "token", Password: "new"},
+			mockBehaviour: func(r *mock_service.MockUser, inp resetPasswordInput) {
+				r.EXPECT().ResetPassword(inp.Token, inp.Password).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"done"}`,
+		},
+		{
+			name:                 "Wrong Input",
+			inputBody:            `{"token": "token"}`,
+			mockBehaviour:        func(r *mock_service.MockUser, inp resetPasswordInput) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input"}`,
+		},
+		{
+			name:      "Used Token",
+			inputBody: `{"token": "token", "password": "new"}`,
+			input:     resetPasswordInput{Token: "token", Password: "new"},
+			mockBehaviour: func(r *mock_service.MockUser, inp resetPasswordInput) {
+				r.EXPECT().ResetPassword(inp.Token, inp.Password).Return(service.ErrInvalidEmailToken)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid or expired token"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repoUser := mock_service.NewMockUser(c)
+			test.mockBehaviour(repoUser, test.input)
+
+			h := &Handler{
+				services:  &service.Service{User: repoUser},
+				logger:    zap.NewNop().Sugar(),
+				validator: validator.New(),
+			}
+
+			r := mux.NewRouter()
+			r.HandleFunc("/api/v1/user/reset-password", h.resetPassword).Methods("POST")
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/v1/user/reset-password",
+				bytes.NewBufferString(test.inputBody))
+			req.Header.Set("Content-Type", appJSON)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
@@ -0,0 +1,399 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"market/internal/model"
+	"market/internal/service"
+	"net/http"
+
+	"github.com/gorilla/schema"
+)
+
+// @Summary	Get variants of product
+// @Tags		products
+// @ID			get-variants
+// @Produce	json
+// @Param		productId	path		integer	true	"ID of product"
+// @Success	200			{object}	getVariantsResponse
+// @Failure	400			{object}	errorResponse
+// @Failure	500			{object}	errorResponse
+// @Failure	default		{object}	errorResponse
+// @Router		/api/v1/product/{productId}/variants [get]
+func (h *Handler) getVariants(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+
+	productID, err := idFromPath(r, "productId")
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	variants, err := h.services.Variant.GetAll(productID)
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	newGetVariantsResponse(w, variants, http.StatusOK)
+}
+
+// @Summary	Add option to product
+// @Description	Options can only be added while the product has no variants. Responds with all options of the product.
+// @Security	ApiKeyAuth
+// @Tags		products
+// @ID			create-option
+// @Accept		json
+// @Produce	json
+// @Param		productId	path		integer				true	"ID of product"
+// @Param		input		body		model.OptionInput	true	"Option, e.g. size or color"
+// @Success	201			{object}	getOptionsResponse
+// @Failure	400,401		{object}	errorResponse
+// @Failure	403,404		{object}	errorResponse
+// @Failure	409			{object}	errorResponse
+// @Failure	500			{object}	errorResponse
+// @Failure	default		{object}	errorResponse
+// @Router		/api/v1/product/{productId}/options [post]
+func (h *Handler) createOption(w http.ResponseWriter, r *http.Request) {
+	var input model.OptionInput
+	if !h.decodeJSONInput(w, r, &input) {
+		return
+	}
+
+	productID, err := idFromPath(r, "productId")
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	optionID, err := h.services.Variant.CreateOption(productID, input)
+	if err != nil {
+		variantErrorResponse(w, err)
+		return
+	}
+
+	h.logger.Infof("Option %v of product %v was created", optionID, productID)
+
+	options, err := h.services.Variant.GetOptions(productID)
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	newGetOptionsResponse(w, options, http.StatusCreated)
+}
+
+// @Summary	Delete option of product
+// @Description	Options can't be deleted while variants have a value for them.
+// @Security	ApiKeyAuth
+// @Tags		products
+// @ID			delete-option
+// @Produce	json
+// @Param		productId	path		integer	true	"ID of product"
+// @Param		optionId	path		integer	true	"ID of option"
+// @Success	200			{object}	statusResponse
+// @Failure	400,401		{object}	errorResponse
+// @Failure	403,404		{object}	errorResponse
+// @Failure	409			{object}	errorResponse
+// @Failure	500			{object}	errorResponse
+// @Failure	default		{object}	errorResponse
+// @Router		/api/v1/product/{productId}/options/{optionId} [delete]
+func (h *Handler) deleteOption(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+
+	productID, err := idFromPath(r, "productId")
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	optionID, err := idFromPath(r, "optionId")
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = h.services.Variant.DeleteOption(productID, optionID); err != nil {
+		variantErrorResponse(w, err)
+		return
+	}
+
+	newStatusReponse(w, "done", http.StatusOK)
+}
+
+// @Summary	Add variant to product
+// @Description	Attributes are passed as attributes.N.option and attributes.N.value fields, one per option of the product.
+// @Security	ApiKeyAuth
+// @Tags		products
+// @ID			create-variant
+// @Accept		mpfd
+// @Produce	json
+// @Param		productId			path		integer	true	"ID of product"
+// @Param		file				formData	file	false	"Image of variant"
+// @Param		sku					formData	string	true	"SKU of variant"
+// @Param		price				formData	string	false	"Price of variant in the product's currency, the product's price if omitted"
+// @Param		amount				formData	integer	true	"Amount of items in stock"
+// @Param		attributes.0.option	formData	string	false	"Name of option"
+// @Param		attributes.0.value	formData	string	false	"Value of option"
+// @Success	201					{object}	model.ProductVariant
+// @Failure	400,401				{object}	errorResponse
+// @Failure	403,404				{object}	errorResponse
+// @Failure	409					{object}	errorResponse
+// @Failure	500					{object}	errorResponse
+// @Failure	default				{object}	errorResponse
+// @Router		/api/v1/product/{productId}/variants [post]
+func (h *Handler) createVariant(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+
+	productID, err := idFromPath(r, "productId")
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = r.ParseMultipartForm(limitFileBytes); err != nil {
+		newErrorResponse(w, "Failed to Parse MultipartForm", http.StatusBadRequest)
+		return
+	}
+
+	var variant model.ProductVariant
+	decoder := schema.NewDecoder()
+	decoder.IgnoreUnknownKeys(true)
+	if err = decoder.Decode(&variant, r.PostForm); err != nil {
+		newErrorResponse(w, `Bad form`, http.StatusBadRequest)
+		return
+	}
+
+	if err = h.validator.Struct(variant); err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var ok bool
+	if variant.Price, ok = h.productPriceFromForm(w, productID, r.PostForm); !ok {
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	fileExists := err != http.ErrMissingFile
+	if err != nil && fileExists {
+		newErrorResponse(w, "Error Retrieving the File", http.StatusBadRequest)
+		return
+	}
+
+	if fileExists {
+		defer file.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), imageUploadTimeout)
+		defer cancel()
+		data, err := h.services.Image.Upload(ctx, file) //nolint:govet
+		if err != nil {
+			uploadErrorResponse(w, err)
+			return
+		}
+		variant.ImageURL = &data.ImageURL
+		variant.ImageID = &data.ImageID
+		variant.Thumbnails = data.Thumbnails
+	}
+
+	variantID, err := h.services.Variant.Create(productID, variant)
+	if err != nil {
+		if variant.ImageID != nil && variantRejected(err) {
+			h.deleteImages([]string{*variant.ImageID})
+		}
+		variantErrorResponse(w, err)
+		return
+	}
+
+	variant, err = h.services.Variant.GetByID(productID, variantID)
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.logger.Infof("Variant %v of product %v was created", variantID, productID)
+
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(variant); err != nil {
+		newErrorResponse(w, "server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary	Update variant of product
+// @Description	Attributes, when passed, replace all attribute values of the variant.
+// @Security	ApiKeyAuth
+// @Tags		products
+// @ID			update-variant
+// @Accept		mpfd
+// @Produce	json
+// @Param		productId			path		integer	true	"ID of product"
+// @Param		variantId			path		integer	true	"ID of variant"
+// @Param		file				formData	file	false	"Image of variant"
+// @Param		sku					formData	string	false	"SKU of variant"
+// @Param		price				formData	string	false	"Price of variant in the product's currency"
+// @Param		amount				formData	integer	false	"Amount of items in stock"
+// @Param		attributes.0.option	formData	string	false	"Name of option"
+// @Param		attributes.0.value	formData	string	false	"Value of option"
+// @Success	200					{object}	model.ProductVariant
+// @Failure	400,401				{object}	errorResponse
+// @Failure	403,404				{object}	errorResponse
+// @Failure	409					{object}	errorResponse
+// @Failure	500					{object}	errorResponse
+// @Failure	default				{object}	errorResponse
+// @Router		/api/v1/product/{productId}/variants/{variantId} [put]
+func (h *Handler) updateVariant(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+
+	productID, err := idFromPath(r, "productId")
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	variantID, err := idFromPath(r, "variantId")
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = r.ParseMultipartForm(limitFileBytes); err != nil {
+		newErrorResponse(w, "Failed to Parse MultipartForm", http.StatusBadRequest)
+		return
+	}
+
+	var input model.UpdateVariantInput
+	decoder := schema.NewDecoder()
+	decoder.IgnoreUnknownKeys(true)
+	if err = decoder.Decode(&input, r.PostForm); err != nil {
+		newErrorResponse(w, `Bad form`, http.StatusBadRequest)
+		return
+	}
+
+	if err = h.validator.Struct(input); err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var ok bool
+	if input.Price, ok = h.productPriceFromForm(w, productID, r.PostForm); !ok {
+		return
+	}
+
+	if _, err = h.services.Variant.GetByID(productID, variantID); err != nil {
+		variantErrorResponse(w, err)
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	fileExists := err != http.ErrMissingFile
+	if err != nil && fileExists {
+		newErrorResponse(w, "Error Retrieving the File", http.StatusBadRequest)
+		return
+	}
+
+	if fileExists {
+		defer file.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), imageUploadTimeout)
+		defer cancel()
+		data, err := h.services.Image.Upload(ctx, file) //nolint:govet
+		if err != nil {
+			uploadErrorResponse(w, err)
+			return
+		}
+		input.ImageURL = &data.ImageURL
+		input.ImageID = &data.ImageID
+		input.Thumbnails = data.Thumbnails
+	}
+
+	if err = input.Validate(); err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = h.services.Variant.Update(productID, variantID, input); err != nil {
+		if fileExists && variantRejected(err) {
+			h.deleteImages([]string{*input.ImageID})
+		}
+		variantErrorResponse(w, err)
+		return
+	}
+
+	variant, err := h.services.Variant.GetByID(productID, variantID)
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.logger.Infof("Variant %v of product %v was updated", variantID, productID)
+
+	if err = json.NewEncoder(w).Encode(variant); err != nil {
+		newErrorResponse(w, "server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary	Delete variant of product
+// @Security	ApiKeyAuth
+// @Tags		products
+// @ID			delete-variant
+// @Produce	json
+// @Param		productId	path		integer	true	"ID of product"
+// @Param		variantId	path		integer	true	"ID of variant"
+// @Success	200			{object}	statusResponse
+// @Failure	400,401		{object}	errorResponse
+// @Failure	403,404		{object}	errorResponse
+// @Failure	500			{object}	errorResponse
+// @Failure	default		{object}	errorResponse
+// @Router		/api/v1/product/{productId}/variants/{variantId} [delete]
+func (h *Handler) deleteVariant(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", appJSON)
+
+	productID, err := idFromPath(r, "productId")
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	variantID, err := idFromPath(r, "variantId")
+	if err != nil {
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = h.services.Variant.Delete(productID, variantID); err != nil {
+		variantErrorResponse(w, err)
+		return
+	}
+
+	h.logger.Infof("Variant %v of product %v was deleted", variantID, productID)
+
+	newStatusReponse(w, "done", http.StatusOK)
+}
+
+// variantRejected reports whether the service turned the variant down, so
+// nothing refers to an image uploaded for it.
+func variantRejected(err error) bool {
+	switch err {
+	case service.ErrInvalidAttributes, model.ErrBelowReserved,
+		service.ErrVariantNotFound, service.ErrOptionNotFound,
+		service.ErrVariantExists, service.ErrSKUExists, service.ErrOptionExists, service.ErrOptionInUse, service.ErrProductHasVariants:
+		return true
+	default:
+		return false
+	}
+}
+
+func variantErrorResponse(w http.ResponseWriter, err error) {
+	switch err {
+	case service.ErrInvalidAttributes, model.ErrBelowReserved:
+		newErrorResponse(w, err.Error(), http.StatusBadRequest)
+	case service.ErrVariantNotFound, service.ErrOptionNotFound:
+		newErrorResponse(w, err.Error(), http.StatusNotFound)
+	case service.ErrVariantExists, service.ErrSKUExists, service.ErrOptionExists, service.ErrOptionInUse, service.ErrProductHasVariants:
+		newErrorResponse(w, err.Error(), http.StatusConflict)
+	default:
+		newErrorResponse(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+const (
+	AuditChangeRole    = "change_role"
+	AuditBan           = "ban"
+	AuditUnban         = "unban"
+	AuditForceReset    = "force_password_reset"
+	AuditUnlock        = "unlock"
+	AuditApproveSeller = "approve_seller"
+	AuditRejectSeller  = "reject_seller"
+)
+
+// AuditEntry records an action an admin performed on a user account. The IDs
+// are kept without foreign keys so entries outlive deleted accounts.
+type AuditEntry struct {
+	ID           int       `db:"id" json:"id"`
+	AdminID      int       `db:"admin_id" json:"admin_id"`
+	TargetUserID int       `db:"target_user_id" json:"target_user_id"`
+	Action       string    `db:"action" json:"action"`
+	Details      *string   `db:"details" json:"details,omitempty"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+}
+
+type AuditQueryInput struct {
+	QueryInput
+	AdminID      int
+	TargetUserID int
+}
+
+func (i AuditQueryInput) Validate() error {
+	if err := i.Sort.Validate(SortByDate); err != nil {
+		return err
+	}
+
+	return nil
+}
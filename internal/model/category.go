@@ -0,0 +1,92 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Category is a node of the category tree. Root categories have no parent.
+type Category struct {
+	ID          int        `db:"id" json:"id"`
+	ParentID    *int       `db:"parent_id" json:"parent_id,omitempty"`
+	Name        string     `db:"name" json:"name"`
+	Slug        string     `db:"slug" json:"slug"`
+	Description *string    `db:"description" json:"description,omitempty"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	Children    []Category `json:"children,omitempty"`
+}
+
+// CategoryInput creates a category. The slug is derived from the name when
+// it's omitted.
+type CategoryInput struct {
+	Name        string  `json:"name" validate:"required,max=255"`
+	Slug        string  `json:"slug" validate:"max=255"`
+	ParentID    *int    `json:"parent_id"`
+	Description *string `json:"description" validate:"omitempty,max=255"`
+}
+
+// UpdateCategoryInput changes a category. A zero ParentID moves the category
+// to the root of the tree.
+type UpdateCategoryInput struct {
+	Name        *string `json:"name" validate:"omitempty,min=1,max=255"`
+	Slug        *string `json:"slug" validate:"omitempty,max=255"`
+	ParentID    *int    `json:"parent_id"`
+	Description *string `json:"description" validate:"omitempty,max=255"`
+}
+
+func (i UpdateCategoryInput) Validate() error {
+	if i.Name == nil && i.Slug == nil && i.ParentID == nil && i.Description == nil {
+		return errors.New("update structure has no values")
+	}
+
+	if i.Slug != nil && !ValidSlug(*i.Slug) {
+		return ErrInvalidSlug
+	}
+
+	return nil
+}
+
+var ErrInvalidSlug = errors.New("slug must consist of lowercase letters, digits and single dashes")
+
+// Slugify turns a category name into a slug: lowercase words of letters and
+// digits joined with dashes, e.g. "Phones & Tablets" becomes "phones-tablets".
+func Slugify(name string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), "-")
+}
+
+func ValidSlug(slug string) bool {
+	return slug != "" && Slugify(slug) == slug
+}
+
+// CategoryTree nests the categories under their parents. Categories whose
+// parent isn't in the list become roots. The order of siblings is kept.
+func CategoryTree(categories []Category) []Category {
+	children := make(map[int][]Category)
+	known := make(map[int]bool, len(categories))
+	for _, category := range categories {
+		known[category.ID] = true
+	}
+
+	roots := make([]Category, 0)
+	for _, category := range categories {
+		if category.ParentID == nil || !known[*category.ParentID] {
+			roots = append(roots, category)
+			continue
+		}
+		children[*category.ParentID] = append(children[*category.ParentID], category)
+	}
+
+	var attach func(nodes []Category) []Category
+	attach = func(nodes []Category) []Category {
+		for i := range nodes {
+			nodes[i].Children = attach(children[nodes[i].ID])
+		}
+		return nodes
+	}
+
+	return attach(roots)
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor points at an item of a sorted listing. The next page starts right after
+// the item, or right before it when Backward is set. Cursors are handed to
+// clients as opaque strings.
+type Cursor struct {
+	// Sort is the sort the cursor was issued for, and Values hold the item's
+	// value of every sort key in the same order.
+	Sort     string   `json:"s"`
+	Values   []string `json:"v"`
+	ID       int      `json:"id"`
+	Backward bool     `json:"b,omitempty"`
+}
+
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c) //nolint:errchkjson
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeCursor(s string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if err = json.Unmarshal(b, &c); err != nil || c.ID == 0 {
+		return nil, ErrInvalidCursor
+	}
+
+	return &c, nil
+}
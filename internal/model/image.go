@@ -0,0 +1,60 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// MaxProductImages limits the size of a product's gallery.
+const MaxProductImages = 10
+
+// ProductImage is an image of a product's gallery. Images are shown in the
+// order of Position, and the primary image represents the product in listings.
+type ProductImage struct {
+	ID         int        `db:"id" json:"id"`
+	ProductID  int        `db:"product_id" json:"product_id"`
+	ImageURL   string     `db:"image_url" json:"image_url"`
+	ImageID    string     `db:"image_id" json:"-"`
+	Position   int        `db:"position" json:"position"`
+	Primary    bool       `db:"is_primary" json:"primary"`
+	Thumbnails Thumbnails `db:"thumbnails" json:"thumbnails,omitempty"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+}
+
+// Thumbnails maps the names of thumbnail sizes to the URLs of the thumbnails.
+// It's stored as a JSON object.
+type Thumbnails map[string]string
+
+func (t Thumbnails) Value() (driver.Value, error) {
+	if t == nil {
+		return "{}", nil
+	}
+
+	data, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+func (t *Thumbnails) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*t = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, t)
+	case string:
+		return json.Unmarshal([]byte(v), t)
+	default:
+		return fmt.Errorf("can't scan %T into Thumbnails", src)
+	}
+}
+
+// ReorderImagesInput lists all image IDs of a product in the new order.
+type ReorderImagesInput struct {
+	ImageIDs []int `json:"image_ids" validate:"required,min=1"`
+}
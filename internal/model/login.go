@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+type LoginAttempts struct {
+	Key           string     `db:"attempt_key"`
+	Failures      int        `db:"failures"`
+	LastFailureAt time.Time  `db:"last_failure_at"`
+	LockedUntil   *time.Time `db:"locked_until"`
+}
+
+type FailedLogin struct {
+	ID        int       `db:"id" json:"id"`
+	Username  string    `db:"username" json:"username"`
+	IP        string    `db:"ip" json:"ip"`
+	Reason    string    `db:"reason" json:"reason"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
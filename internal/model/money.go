@@ -0,0 +1,247 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	ErrUnknownCurrency  = errors.New("unknown currency")
+	ErrInvalidAmount    = errors.New("invalid amount of money")
+	ErrCurrencyMismatch = errors.New("amounts of money are in different currencies")
+)
+
+// currencyExponents holds the number of digits after the decimal point of the
+// minor unit of the supported ISO 4217 currencies.
+var currencyExponents = map[string]int{
+	"RUB": 2,
+	"USD": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"CNY": 2,
+	"KZT": 2,
+	"BYN": 2,
+	"TRY": 2,
+	"JPY": 0,
+	"KRW": 0,
+}
+
+// Money is an exact amount of money in the minor units of its currency, e.g.
+// 1999 USD is $19.99.
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// moneyJSON carries the amount in minor units along with its decimal notation,
+// so clients don't need to know the exponent of the currency.
+type moneyJSON struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+	Value    string `json:"value"`
+}
+
+// Currencies returns the codes of the supported currencies in alphabetical
+// order.
+func Currencies() []string {
+	currencies := make([]string, 0, len(currencyExponents))
+	for currency := range currencyExponents {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	return currencies
+}
+
+// Exponent returns the number of digits of the minor unit of the currency.
+func Exponent(currency string) int {
+	return currencyExponents[currency]
+}
+
+// MajorUnits returns a whole number of major units of the currency, e.g. 10
+// USD as 1000 cents.
+func MajorUnits(n int64, currency string) Money {
+	return Money{Amount: n * pow10(currencyExponents[currency]).Int64(), Currency: currency}
+}
+
+// ValidCurrency reports whether the currency code is supported.
+func ValidCurrency(currency string) bool {
+	_, ok := currencyExponents[currency]
+	return ok
+}
+
+// ValidateCurrency is the validator of the currency tag.
+func ValidateCurrency(fl validator.FieldLevel) bool {
+	return ValidCurrency(fl.Field().String())
+}
+
+// ParseMoney parses a decimal amount of major units, e.g. "19.99", without
+// rounding. Amounts with more fractional digits than the currency has are
+// rejected.
+func ParseMoney(value, currency string) (Money, error) {
+	exponent, ok := currencyExponents[currency]
+	if !ok {
+		return Money{}, ErrUnknownCurrency
+	}
+
+	value = strings.TrimSpace(value)
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimPrefix(value, "-")
+
+	whole, fraction, _ := strings.Cut(value, ".")
+	if whole == "" || len(fraction) > exponent || !digits(whole) || !digits(fraction) {
+		return Money{}, ErrInvalidAmount
+	}
+
+	amount, err := strconv.ParseInt(whole+fraction+strings.Repeat("0", exponent-len(fraction)), 10, 64)
+	if err != nil {
+		return Money{}, ErrInvalidAmount
+	}
+
+	if negative {
+		amount = -amount
+	}
+
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+func digits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Decimal formats the amount in major units with all digits of the minor unit,
+// e.g. "19.90".
+func (m Money) Decimal() string {
+	exponent := currencyExponents[m.Currency]
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+
+	s := strconv.FormatInt(amount, 10)
+	if exponent == 0 {
+		return sign + s
+	}
+
+	if len(s) <= exponent {
+		s = strings.Repeat("0", exponent-len(s)+1) + s
+	}
+
+	return sign + s[:len(s)-exponent] + "." + s[len(s)-exponent:]
+}
+
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// Add sums amounts of the same currency.
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}, nil
+}
+
+// Mul multiplies the amount, e.g. the price of an item by the quantity.
+func (m Money) Mul(n int) Money {
+	return Money{Amount: m.Amount * int64(n), Currency: m.Currency}
+}
+
+// Convert exchanges the amount into the currency at the rate, the price of one
+// major unit of m's currency in major units of the other one. The result is
+// rounded half away from zero to the minor unit of the currency.
+func (m Money) Convert(currency string, rate *big.Rat) (Money, error) {
+	exponent, ok := currencyExponents[currency]
+	if !ok {
+		return Money{}, ErrUnknownCurrency
+	}
+
+	amount := new(big.Rat).SetFrac(big.NewInt(m.Amount), pow10(currencyExponents[m.Currency]))
+	amount.Mul(amount, rate)
+	amount.Mul(amount, new(big.Rat).SetInt(pow10(exponent)))
+
+	rounded, remainder := new(big.Int).QuoRem(amount.Num(), amount.Denom(), new(big.Int))
+	if new(big.Int).Mul(remainder.Abs(remainder), big.NewInt(2)).Cmp(amount.Denom()) >= 0 {
+		rounded.Add(rounded, big.NewInt(int64(amount.Sign())))
+	}
+
+	if !rounded.IsInt64() {
+		return Money{}, ErrInvalidAmount
+	}
+
+	return Money{Amount: rounded.Int64(), Currency: currency}, nil
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.Amount, Currency: m.Currency, Value: m.Decimal()})
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var v moneyJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	if !ValidCurrency(v.Currency) {
+		return ErrUnknownCurrency
+	}
+
+	*m = Money{Amount: v.Amount, Currency: v.Currency}
+	return nil
+}
+
+// Scan reads a row of a numeric amount in major units and a currency code,
+// e.g. selected as ROW(price, currency).
+func (m *Money) Scan(src interface{}) error {
+	var row string
+	switch v := src.(type) {
+	case []byte:
+		row = string(v)
+	case string:
+		row = v
+	default:
+		return fmt.Errorf("can't scan %T into Money", src)
+	}
+
+	value, currency, ok := strings.Cut(strings.TrimSuffix(strings.TrimPrefix(row, "("), ")"), ",")
+	if !ok {
+		return fmt.Errorf("can't scan %q into Money", row)
+	}
+
+	// numeric columns with a fixed scale may pad the fraction with zeros.
+	if strings.Contains(value, ".") {
+		value = strings.TrimSuffix(strings.TrimRight(value, "0"), ".")
+	}
+
+	money, err := ParseMoney(value, strings.TrimSpace(currency))
+	if err != nil {
+		return fmt.Errorf("can't scan %q into Money: %w", row, err)
+	}
+
+	*m = money
+	return nil
+}
+
+// Value stores the amount as a decimal in major units. The currency is stored
+// in a column of its own.
+func (m Money) Value() (driver.Value, error) {
+	return m.Decimal(), nil
+}
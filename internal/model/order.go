@@ -5,22 +5,128 @@ import (
 	"time"
 )
 
+var ErrOutOfStock = errors.New("not enough items in stock")
+
+// Statuses of an order. An order is created pending and moves along
+// orderTransitions, cancelled and refunded orders are final.
+const (
+	OrderPending   = "pending"
+	OrderPaid      = "paid"
+	OrderShipped   = "shipped"
+	OrderDelivered = "delivered"
+	OrderCancelled = "cancelled"
+	OrderRefunded  = "refunded"
+)
+
+var orderTransitions = map[string][]string{
+	OrderPending:   {OrderPaid, OrderCancelled},
+	OrderPaid:      {OrderShipped, OrderRefunded},
+	OrderShipped:   {OrderDelivered},
+	OrderDelivered: {OrderRefunded},
+}
+
+// CanTransition reports whether an order may move from one status to another.
+func CanTransition(from, to string) bool {
+	for _, status := range orderTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ReturnsStock reports whether the purchased items go back to stock when the
+// order moves from one status to another, i.e. it ends before being shipped.
+func ReturnsStock(from, to string) bool {
+	return (to == OrderCancelled || to == OrderRefunded) && (from == OrderPending || from == OrderPaid)
+}
+
+// Order keeps the amounts charged at checkout in the currency of the order.
+// Total is the sum of Subtotal, Tax and Shipping.
 type Order struct {
-	ID          int       `db:"id" json:"id"`
-	UserID      int       `db:"user_id" json:"user_id"`
-	CreatedAt   time.Time `db:"created_at" json:"created_at"`
-	DeliveredAt time.Time `db:"delivered_at" json:"delivered_at"`
-	Products    []Product `json:"products"`
+	ID          int         `db:"id" json:"id"`
+	UserID      int         `db:"user_id" json:"user_id"`
+	Status      string      `db:"status" json:"status"`
+	CreatedAt   time.Time   `db:"created_at" json:"created_at"`
+	DeliveredAt *time.Time  `db:"delivered_at" json:"delivered_at,omitempty"`
+	Subtotal    Money       `db:"subtotal" json:"subtotal"`
+	Tax         Money       `db:"tax" json:"tax"`
+	Shipping    Money       `db:"shipping" json:"shipping"`
+	Total       Money       `db:"total" json:"total"`
+	Lines       []OrderLine `json:"lines,omitempty"`
+}
+
+// OrderLine is a purchased product as it was at checkout. The unit price is
+// converted into the currency of the order. The product, its variant and the
+// seller are nil once deleted, the rest of the line stays as it was.
+type OrderLine struct {
+	ID        int     `db:"id" json:"id"`
+	OrderID   int     `db:"order_id" json:"order_id"`
+	ProductID *int    `db:"product_id" json:"product_id"`
+	VariantID *int    `db:"variant_id" json:"variant_id,omitempty"`
+	SellerID  *int    `db:"seller_id" json:"seller_id"`
+	Title     string  `db:"title" json:"title"`
+	SKU       *string `db:"sku" json:"sku,omitempty"`
+	UnitPrice Money   `db:"unit_price" json:"unit_price"`
+	Quantity  int     `db:"purchased_amount" json:"quantity"`
+}
+
+// StockShortage is an order line whose stock ran out before checkout.
+type StockShortage struct {
+	ProductID int    `json:"product_id"`
+	VariantID *int   `json:"variant_id,omitempty"`
+	Title     string `json:"title"`
+	Requested int    `json:"requested"`
+	Available int    `json:"available"`
+}
+
+// OutOfStockError fails an order when the stock of some of its lines can't
+// cover them. The order isn't placed at all.
+type OutOfStockError struct {
+	Lines []StockShortage
+}
+
+func (e *OutOfStockError) Error() string {
+	return ErrOutOfStock.Error()
+}
+
+func (e *OutOfStockError) Unwrap() error {
+	return ErrOutOfStock
+}
+
+// OrderStatusChange is an entry of the status history of an order. The first
+// entry of an order has no FromStatus. ChangedBy is nil once the user is deleted.
+type OrderStatusChange struct {
+	ID         int       `db:"id" json:"id"`
+	OrderID    int       `db:"order_id" json:"order_id"`
+	FromStatus *string   `db:"from_status" json:"from_status"`
+	ToStatus   string    `db:"to_status" json:"to_status"`
+	ChangedBy  *int      `db:"changed_by" json:"changed_by"`
+	Comment    *string   `db:"comment" json:"comment,omitempty"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+}
+
+type ChangeOrderStatusInput struct {
+	Status  string  `json:"status" validate:"required,oneof=pending paid shipped delivered cancelled refunded"`
+	Comment *string `json:"comment" validate:"omitempty,max=255"`
+}
+
+type CancelOrderInput struct {
+	Comment *string `json:"comment" validate:"omitempty,max=255"`
 }
 
 type OrderQueryInput struct {
 	QueryInput
 }
 
+func (o Order) CursorKey(string) (string, int) {
+	return o.CreatedAt.Format(time.RFC3339Nano), o.ID
+}
+
 func (i OrderQueryInput) Validate() error {
-	if i.SortBy != SortByDate || (i.SortOrder != ASCENDING && i.SortOrder != DESCENDING) {
-		return errors.New("invalid sort query")
+	if err := i.Sort.Validate(SortByDate); err != nil {
+		return err
 	}
 
-	return nil
+	return i.ValidateCursor()
 }
@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// OutboxImageDelete asks to delete the stored image whose ID is the payload.
+const OutboxImageDelete = "image.delete"
+
+// OutboxMessage is a side effect outside of the database, recorded in the same
+// transaction as the change causing it and performed afterwards by a worker.
+type OutboxMessage struct {
+	ID          int       `db:"id"`
+	Kind        string    `db:"kind"`
+	Payload     string    `db:"payload"`
+	Attempts    int       `db:"attempts"`
+	LastError   *string   `db:"last_error"`
+	AvailableAt time.Time `db:"available_at"`
+	CreatedAt   time.Time `db:"created_at"`
+}
@@ -2,57 +2,140 @@ package model
 
 import (
 	"errors"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
 )
 
+const maxSearchQueryLength = 200
+
+// ErrBelowReserved is returned when the stock is set below the amount carts
+// have reserved, which would make the available stock negative.
+var ErrBelowReserved = errors.New("amount can't be less than the amount reserved in carts")
+
+// Product is also a line of a cart or an order. Available is the part of the
+// stock that isn't reserved in carts, ReservedUntil tells until when a cart
+// line holds its reservation. PriceKey is the price listings sorted by price
+// compare, converted into one currency, and goes into their cursors.
 type Product struct {
-	ID              int       `db:"id" json:"id"`
-	UserID          int       `db:"user_id" json:"user_id"`
-	Title           string    `db:"title" json:"title" schema:"title" validate:"required"`
-	Price           float32   `db:"price" json:"price" schema:"price" validate:"required"`
-	Tag             *string   `db:"tag" json:"tag,omitempty" schema:"tag"`
-	Category        string    `db:"category" json:"category" schema:"category" validate:"required"`
-	Description     *string   `db:"description" json:"description,omitempty" schema:"description"`
-	Amount          int       `db:"amount" json:"amount" schema:"amount" validate:"required"`
-	PurchasedAmount int       `db:"purchased_amount" json:"purchased_amount,omitempty"`
-	OrderID         int       `db:"order_id" json:"order_id,omitempty"`
-	CreatedAt       time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt       time.Time `db:"updated_at" json:"updated_at"`
-	Views           int       `db:"views" json:"views"`
-	ImageURL        string    `db:"image_url" json:"image_url"`
-	ImageID         string    `db:"image_id"`
-	Reviews         []Review  `json:"reviews"`
-	RelatedProducts []Product `json:"related_products"`
+	ID              int              `db:"id" json:"id"`
+	UserID          int              `db:"user_id" json:"user_id"`
+	Title           string           `db:"title" json:"title" schema:"title" validate:"required"`
+	Price           Money            `db:"price" json:"price" schema:"-"`
+	Currency        string           `db:"currency" json:"currency" schema:"currency" validate:"omitempty,currency"`
+	DisplayPrice    *Money           `json:"display_price,omitempty" schema:"-"`
+	Tag             *string          `db:"tag" json:"tag,omitempty" schema:"tag"`
+	CategoryID      int              `db:"category_id" json:"category_id" schema:"category_id" validate:"required"`
+	Category        string           `db:"category" json:"category" schema:"-"`
+	Description     *string          `db:"description" json:"description,omitempty" schema:"description"`
+	Amount          int              `db:"amount" json:"amount" schema:"amount" validate:"required"`
+	Available       int              `db:"available" json:"available" schema:"-"`
+	PurchasedAmount int              `db:"purchased_amount" json:"purchased_amount,omitempty"`
+	ReservedUntil   *time.Time       `db:"reserved_until" json:"reserved_until,omitempty" schema:"-"`
+	PriceKey        *string          `db:"price_key" json:"-" schema:"-"`
+	VariantID       *int             `db:"variant_id" json:"variant_id,omitempty"`
+	SKU             *string          `db:"sku" json:"sku,omitempty"`
+	OrderID         int              `db:"order_id" json:"order_id,omitempty"`
+	CreatedAt       time.Time        `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time        `db:"updated_at" json:"updated_at"`
+	Views           int              `db:"views" json:"views"`
+	ImageURL        string           `db:"image_url" json:"image_url"`
+	Thumbnails      Thumbnails       `db:"thumbnails" json:"thumbnails,omitempty"`
+	Images          []ProductImage   `json:"images,omitempty"`
+	Options         []ProductOption  `json:"options,omitempty"`
+	Variants        []ProductVariant `json:"variants,omitempty"`
+	Reviews         []Review         `json:"reviews"`
+	RelatedProducts []Product        `json:"related_products"`
 }
 
 type UpdateProductInput struct {
 	Title       *string    `json:"title"`
-	Price       *float32   `json:"price"`
+	Price       *Money     `json:"price" schema:"-"`
 	Tag         *string    `json:"tag"`
-	Type        *string    `json:"type"`
+	CategoryID  *int       `json:"category_id" schema:"category_id"`
 	Description *string    `json:"description"`
 	UpdatedAt   *time.Time `json:"updated_at"`
 	Amount      *int       `json:"amount"`
 	Views       *int       `json:"views"`
-	ImageURL    *string    `json:"image_url"`
-	ImageID     *string
 }
 
 type ProductQueryInput struct {
 	QueryInput
+	ProductFilter
+	// ProductID excludes a product from the listing, e.g. from its related products.
 	ProductID int
 }
 
+type ProductSearchInput struct {
+	QueryInput
+	Query  string
+	Prices PriceScale
+}
+
+// ProductSearchResult is a product matching a search query. Snippet holds the
+// matched fragments of the title and description as HTML, escaped, with terms
+// wrapped in <b> tags.
+type ProductSearchResult struct {
+	Product
+	Rank    float32 `db:"rank" json:"rank"`
+	Snippet string  `db:"snippet" json:"snippet"`
+}
+
+// CursorKey returns the value of the sort key and the ID a cursor pointing at
+// the product holds.
+func (p Product) CursorKey(sortBy string) (string, int) {
+	switch sortBy {
+	case SortByViews:
+		return strconv.Itoa(p.Views), p.ID
+	case SortByPrice:
+		if p.PriceKey != nil {
+			return *p.PriceKey, p.ID
+		}
+		return p.Price.Decimal(), p.ID
+	default:
+		return p.CreatedAt.Format(time.RFC3339Nano), p.ID
+	}
+}
+
 func (i ProductQueryInput) Validate() error {
-	if i.SortBy != SortByViews && i.SortBy != SortByPrice && i.SortBy != SortByDate || (i.SortOrder != ASCENDING && i.SortOrder != DESCENDING) {
-		return errors.New("invalid sort query")
+	if err := i.Sort.Validate(SortByViews, SortByPrice, SortByDate); err != nil {
+		return err
+	}
+
+	if err := i.ValidateCursor(); err != nil {
+		return err
+	}
+
+	return i.ProductFilter.Validate()
+}
+
+func (i ProductSearchInput) Validate() error {
+	if len(i.Query) > maxSearchQueryLength {
+		return errors.New("search query is too long")
+	}
+
+	if len(i.Terms()) == 0 {
+		return errors.New("empty search query")
+	}
+
+	if err := i.Sort.Validate(SortByRank, SortByViews, SortByPrice, SortByDate); err != nil {
+		return err
 	}
 
 	return nil
 }
 
+// Terms splits the search query into lowercase words made of letters and digits.
+// Everything else, including full-text search operators, separates words.
+func (i ProductSearchInput) Terms() []string {
+	return strings.FieldsFunc(strings.ToLower(i.Query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
 func (i UpdateProductInput) Validate() error {
-	if i.Title == nil && i.Price == nil && i.Tag == nil && i.Type == nil && i.Description == nil && i.Amount == nil && i.Views == nil && i.UpdatedAt == nil && (i.ImageURL == nil && i.ImageURL != i.ImageID) {
+	if i.Title == nil && i.Price == nil && i.Tag == nil && i.CategoryID == nil && i.Description == nil && i.Amount == nil && i.Views == nil && i.UpdatedAt == nil {
 		return errors.New("update structure has no values")
 	}
 
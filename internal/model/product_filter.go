@@ -0,0 +1,90 @@
+package model
+
+import (
+	"errors"
+	"math/big"
+	"time"
+)
+
+const (
+	MinRating = 1
+	MaxRating = 5
+)
+
+// PriceBucketBounds splits prices into the buckets reported by product facets,
+// in major units of the currency prices are compared in: [0, 10), [10, 50),
+// [50, 100), [100, 500) and [500, ...).
+var PriceBucketBounds = []int64{10, 50, 100, 500}
+
+// PriceScale makes prices of products in different currencies comparable.
+// Rates holds the price of one major unit of each product currency in
+// Currency. Products in a currency without a rate have no comparable price and
+// are left out of listings filtered or sorted by price. Without a Currency
+// prices are compared as they are.
+type PriceScale struct {
+	Currency string
+	Rates    map[string]*big.Rat
+}
+
+// ProductFilter narrows product listings. Zero values don't filter.
+type ProductFilter struct {
+	// Category and CategoryID select a category by slug or by ID. Products of
+	// its descendants match as well.
+	Category   string
+	CategoryID int
+	SellerID   int
+	// MinPrice and MaxPrice are in the currency of Prices, which price
+	// facets and sorting by price use as well.
+	MinPrice     *Money
+	MaxPrice     *Money
+	Prices       PriceScale
+	Tags         []string
+	InStock      bool
+	CreatedAfter *time.Time
+	// MinRating is compared with the average review score, where positive,
+	// neutral and negative reviews score 5, 3 and 1.
+	MinRating *float32
+}
+
+type FacetCount struct {
+	Value string `db:"value" json:"value"`
+	Count int    `db:"count" json:"count"`
+}
+
+type PriceBucket struct {
+	Min   Money  `json:"min"`
+	Max   *Money `json:"max,omitempty"`
+	Count int    `json:"count"`
+}
+
+// ProductFacets counts matching products. Categories are counted by slug and
+// only include the category products are directly assigned to.
+type ProductFacets struct {
+	Categories   []FacetCount  `json:"categories"`
+	Tags         []FacetCount  `json:"tags"`
+	PriceBuckets []PriceBucket `json:"price_buckets"`
+}
+
+func (f ProductFilter) Validate() error {
+	for _, price := range []*Money{f.MinPrice, f.MaxPrice} {
+		if price == nil {
+			continue
+		}
+		if price.Amount < 0 {
+			return errors.New("price can't be negative")
+		}
+		if price.Currency != f.Prices.Currency {
+			return ErrCurrencyMismatch
+		}
+	}
+
+	if f.MinPrice != nil && f.MaxPrice != nil && f.MinPrice.Amount > f.MaxPrice.Amount {
+		return errors.New("min price is greater than max price")
+	}
+
+	if f.MinRating != nil && (*f.MinRating < MinRating || *f.MinRating > MaxRating) {
+		return errors.New("rating must be between 1 and 5")
+	}
+
+	return nil
+}
@@ -1,25 +1,142 @@
 package model
 
-import "github.com/go-playground/validator/v10"
+import (
+	"errors"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
 
 const (
-	SortByViews = "views"
-	SortByPrice = "price"
-	SortByDate  = "created_at"
-	ASCENDING   = "ASC"
-	DESCENDING  = "DESC"
+	SortByViews    = "views"
+	SortByPrice    = "price"
+	SortByDate     = "created_at"
+	SortByUsername = "username"
+	SortByRank     = "rank"
+	ASCENDING      = "ASC"
+	DESCENDING     = "DESC"
+
+	maxSortFields = 3
 )
 
+var ErrInvalidSort = errors.New("invalid sort query")
+
 func RegisterCustomValidations(v *validator.Validate) error {
 	if err := v.RegisterValidation("user_role", ValidateRole); err != nil {
 		return err
 	}
-	return v.RegisterValidation("review_category", ValidateReviewCategory)
+	if err := v.RegisterValidation("review_category", ValidateReviewCategory); err != nil {
+		return err
+	}
+	return v.RegisterValidation("currency", ValidateCurrency)
 }
 
 type QueryInput struct {
-	Limit     int
-	Offset    int
-	SortBy    string
-	SortOrder string
+	Limit  int
+	Offset int
+	Sort   Sort
+	// Cursor switches from offset to keyset pagination. Offset is ignored then.
+	Cursor *Cursor
+}
+
+// ValidateCursor rejects cursors issued for a different sort, because they
+// can't be compared with the current sort keys.
+func (i QueryInput) ValidateCursor() error {
+	if i.Cursor != nil && (i.Cursor.Sort != i.Sort.String() || len(i.Cursor.Values) != len(i.Sort)) {
+		return errors.New("cursor doesn't match the sort query")
+	}
+
+	return nil
+}
+
+// SortField is a single key of a sort, e.g. "-price" in sort=-price,created_at.
+type SortField struct {
+	Key  string
+	Desc bool
+}
+
+func Asc(key string) SortField {
+	return SortField{Key: key}
+}
+
+func Desc(key string) SortField {
+	return SortField{Key: key, Desc: true}
+}
+
+func (f SortField) Order() string {
+	if f.Desc {
+		return DESCENDING
+	}
+	return ASCENDING
+}
+
+func (f SortField) String() string {
+	if f.Desc {
+		return "-" + f.Key
+	}
+	return f.Key
+}
+
+// Sort lists the keys rows are ordered by, most significant first.
+type Sort []SortField
+
+// ParseSort parses a comma-separated list of sort keys. A leading "-" sorts
+// by the key in descending order.
+func ParseSort(s string) (Sort, error) {
+	var sort Sort
+	for _, key := range strings.Split(s, ",") {
+		key = strings.ToLower(strings.TrimSpace(key))
+		desc := strings.HasPrefix(key, "-")
+		key = strings.TrimPrefix(key, "-")
+		if key == "" {
+			return nil, ErrInvalidSort
+		}
+		sort = append(sort, SortField{Key: key, Desc: desc})
+	}
+
+	return sort, nil
+}
+
+func (s Sort) String() string {
+	keys := make([]string, len(s))
+	for i, field := range s {
+		keys[i] = field.String()
+	}
+	return strings.Join(keys, ",")
+}
+
+// Has reports whether the sort has the key.
+func (s Sort) Has(key string) bool {
+	for _, field := range s {
+		if field.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks that the sort is made of distinct keys from allowed.
+func (s Sort) Validate(allowed ...string) error {
+	if len(s) == 0 || len(s) > maxSortFields {
+		return ErrInvalidSort
+	}
+
+	seen := make(map[string]bool, len(s))
+	for _, field := range s {
+		if seen[field.Key] || !contains(allowed, field.Key) {
+			return ErrInvalidSort
+		}
+		seen[field.Key] = true
+	}
+
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
 }
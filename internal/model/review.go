@@ -39,12 +39,16 @@ func ValidateReviewCategory(fl validator.FieldLevel) bool {
 	return !(category != POSITIVE && category != NEGATIVE && category != NEUTRAL)
 }
 
+func (r Review) CursorKey(string) (string, int) {
+	return r.CreatedAt.Format(time.RFC3339Nano), r.ID
+}
+
 func (i ReviewQueryInput) Validate() error {
-	if i.SortBy != SortByDate || (i.SortOrder != ASCENDING && i.SortOrder != DESCENDING) {
-		return errors.New("invalid sort query")
+	if err := i.Sort.Validate(SortByDate); err != nil {
+		return err
 	}
 
-	return nil
+	return i.ValidateCursor()
 }
 
 func (i UpdateReviewInput) Validate() error {
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	ApplicationPending  = "pending"
+	ApplicationApproved = "approved"
+	ApplicationRejected = "rejected"
+)
+
+type SellerApplication struct {
+	ID           int        `db:"id" json:"id"`
+	UserID       int        `db:"user_id" json:"user_id"`
+	ShopName     string     `db:"shop_name" json:"shop_name" validate:"required,max=255"`
+	ContactEmail string     `db:"contact_email" json:"contact_email" validate:"required,email"`
+	ContactPhone string     `db:"contact_phone" json:"contact_phone" validate:"required,max=32"`
+	Description  *string    `db:"description" json:"description,omitempty" validate:"omitempty,max=255"`
+	Currency     *string    `db:"currency" json:"currency,omitempty" validate:"omitempty,currency"`
+	Status       string     `db:"status" json:"status"`
+	Comment      *string    `db:"comment" json:"comment,omitempty"`
+	ReviewedBy   *int       `db:"reviewed_by" json:"reviewed_by,omitempty"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+	ReviewedAt   *time.Time `db:"reviewed_at" json:"reviewed_at,omitempty"`
+}
+
+type SellerProfile struct {
+	ID           int       `db:"id" json:"id"`
+	UserID       int       `db:"user_id" json:"user_id"`
+	ShopName     string    `db:"shop_name" json:"shop_name"`
+	ContactEmail string    `db:"contact_email" json:"contact_email"`
+	ContactPhone string    `db:"contact_phone" json:"contact_phone"`
+	Description  *string   `db:"description" json:"description,omitempty"`
+	Currency     *string   `db:"currency" json:"currency,omitempty"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+}
+
+type ReviewApplicationInput struct {
+	Comment *string `json:"comment" validate:"omitempty,max=255"`
+}
+
+type SellerApplicationQueryInput struct {
+	QueryInput
+	Status string
+}
+
+func (i SellerApplicationQueryInput) Validate() error {
+	if err := i.Sort.Validate(SortByDate); err != nil {
+		return err
+	}
+
+	if i.Status != "" && i.Status != ApplicationPending && i.Status != ApplicationApproved && i.Status != ApplicationRejected {
+		return errors.New("invalid status")
+	}
+
+	return nil
+}
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+type Session struct {
+	ID           int       `db:"id" json:"id"`
+	UserID       int       `db:"user_id" json:"user_id"`
+	FamilyID     string    `db:"family_id" json:"-"`
+	RefreshToken string    `db:"refresh_token" json:"-"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+	ExpiresAt    time.Time `db:"expires_at" json:"expires_at"`
+	Revoked      bool      `db:"revoked" json:"revoked"`
+}
+
+// Tokens is the result of a sign-in. When the user has two-factor
+// authentication enabled, only MFAToken is set and must be exchanged for
+// access and refresh tokens together with a one-time code.
+type Tokens struct {
+	AccessToken  string `json:"token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	MFAToken     string `json:"mfa_token,omitempty"`
+}
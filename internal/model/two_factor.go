@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+type RecoveryCode struct {
+	ID     int        `db:"id"`
+	UserID int        `db:"user_id"`
+	Code   string     `db:"code"`
+	UsedAt *time.Time `db:"used_at"`
+}
@@ -1,6 +1,11 @@
 package model
 
-import "github.com/go-playground/validator/v10"
+import (
+	"errors"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
 
 const (
 	ADMIN  string = "admin"
@@ -9,13 +14,95 @@ const (
 )
 
 type User struct {
-	ID       int    `db:"id" json:"-"`
-	Role     string `db:"role" json:"role" validate:"user_role,required"`
-	Username string `db:"username" json:"username" validate:"required"`
-	Password string `db:"password" json:"password" validate:"required"`
+	ID            int     `db:"id" json:"-"`
+	Role          string  `db:"role" json:"role" validate:"user_role,required"`
+	Username      string  `db:"username" json:"username" validate:"required"`
+	Email         string  `db:"email" json:"email" validate:"required,email"`
+	EmailVerified bool    `db:"email_verified" json:"-"`
+	Password      string  `db:"password" json:"password" validate:"required"`
+	TOTPSecret    *string `db:"totp_secret" json:"-"`
+	TOTPEnabled   bool    `db:"totp_enabled" json:"-"`
+	// TOTPLastStep is the time step of the last accepted code, codes of the
+	// same or an earlier step are rejected.
+	TOTPLastStep *int64 `db:"totp_last_step" json:"-"`
+	// PasswordResetRequired blocks sign-in until the password is reset by email.
+	PasswordResetRequired bool       `db:"password_reset_required" json:"-"`
+	BannedAt              *time.Time `db:"banned_at" json:"-"`
+	BanReason             *string    `db:"ban_reason" json:"-"`
+	CreatedAt             time.Time  `db:"created_at" json:"-"`
+}
+
+type UserProfile struct {
+	ID               int        `json:"id"`
+	Role             string     `json:"role"`
+	Username         string     `json:"username"`
+	Email            string     `json:"email"`
+	EmailVerified    bool       `json:"email_verified"`
+	TwoFactorEnabled bool       `json:"two_factor_enabled"`
+	CreatedAt        time.Time  `json:"created_at"`
+	BannedAt         *time.Time `json:"banned_at,omitempty"`
+	BanReason        *string    `json:"ban_reason,omitempty"`
+}
+
+type UserQueryInput struct {
+	QueryInput
+	// Search matches usernames and emails by substring.
+	Search string
+	Role   string
+}
+
+type ChangeRoleInput struct {
+	Role string `json:"role" validate:"required,oneof=user seller admin"`
+}
+
+type BanInput struct {
+	Reason string `json:"reason" validate:"required,max=255"`
+}
+
+type UpdateUserInput struct {
+	Username *string `json:"username"`
+}
+
+func (i UpdateUserInput) Validate() error {
+	if i.Username == nil {
+		return errors.New("update structure has no values")
+	}
+
+	if *i.Username == "" {
+		return errors.New("username can't be empty")
+	}
+
+	return nil
+}
+
+func (u User) Profile() UserProfile {
+	return UserProfile{
+		ID:               u.ID,
+		Role:             u.Role,
+		Username:         u.Username,
+		Email:            u.Email,
+		EmailVerified:    u.EmailVerified,
+		TwoFactorEnabled: u.TOTPEnabled,
+		CreatedAt:        u.CreatedAt,
+		BannedAt:         u.BannedAt,
+		BanReason:        u.BanReason,
+	}
+}
+
+func (i UserQueryInput) Validate() error {
+	if err := i.Sort.Validate(SortByDate, SortByUsername); err != nil {
+		return err
+	}
+
+	if i.Role != "" && i.Role != ADMIN && i.Role != SELLER && i.Role != USER {
+		return errors.New("invalid role")
+	}
+
+	return nil
 }
 
+// ValidateRole only accepts the user role: accounts can't register as sellers
+// or admins, sellers are approved through a seller application.
 func ValidateRole(fl validator.FieldLevel) bool {
-	role := fl.Field().String()
-	return !(role != SELLER && role != USER)
+	return fl.Field().String() == USER
 }
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+const (
+	TokenPurposeVerifyEmail   = "verify_email"
+	TokenPurposeResetPassword = "reset_password"
+	TokenPurposeMFA           = "mfa"
+)
+
+// UserToken is a single-use token sent to the user by email, or the ID of an
+// MFA challenge. Only the SHA-256 hash of the token is stored.
+type UserToken struct {
+	ID        int        `db:"id"`
+	UserID    int        `db:"user_id"`
+	Token     string     `db:"token"`
+	Purpose   string     `db:"purpose"`
+	CreatedAt time.Time  `db:"created_at"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+}
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+// ProductOption is a dimension the variants of a product differ in, e.g. size or color.
+type ProductOption struct {
+	ID        int    `db:"id" json:"id"`
+	ProductID int    `db:"product_id" json:"product_id"`
+	Name      string `db:"name" json:"name"`
+	Position  int    `db:"position" json:"position"`
+}
+
+type OptionInput struct {
+	Name     string `json:"name" validate:"required,max=255"`
+	Position int    `json:"position" validate:"min=0"`
+}
+
+// ProductVariant is a purchasable version of a product with its own stock.
+// Available is the part of the stock that isn't reserved in carts.
+// A nil Price or ImageURL falls back to the product's. Prices of variants are
+// in the currency of the product.
+type ProductVariant struct {
+	ID           int                `db:"id" json:"id"`
+	ProductID    int                `db:"product_id" json:"product_id"`
+	SKU          string             `db:"sku" json:"sku" schema:"sku" validate:"required,max=64"`
+	Price        *Money             `db:"price" json:"price,omitempty" schema:"-"`
+	DisplayPrice *Money             `json:"display_price,omitempty" schema:"-"`
+	Amount       int                `db:"amount" json:"amount" schema:"amount" validate:"min=0"`
+	Available    int                `db:"available" json:"available" schema:"-"`
+	ImageURL     *string            `db:"image_url" json:"image_url,omitempty" schema:"-"`
+	ImageID      *string            `db:"image_id" json:"-" schema:"-"`
+	Thumbnails   Thumbnails         `db:"thumbnails" json:"thumbnails,omitempty" schema:"-"`
+	Attributes   []VariantAttribute `json:"attributes" schema:"attributes" validate:"dive"`
+	CreatedAt    time.Time          `db:"created_at" json:"created_at" schema:"-"`
+	UpdatedAt    time.Time          `db:"updated_at" json:"updated_at" schema:"-"`
+}
+
+// VariantAttribute is the value of one option of the product for a variant,
+// e.g. size M.
+type VariantAttribute struct {
+	VariantID int    `db:"variant_id" json:"-" schema:"-"`
+	OptionID  int    `db:"option_id" json:"option_id" schema:"-"`
+	Option    string `db:"option" json:"option" schema:"option" validate:"required"`
+	Value     string `db:"value" json:"value" schema:"value" validate:"required,max=255"`
+}
+
+// UpdateVariantInput changes the set fields of a variant. Non-empty Attributes
+// replace all attribute values of the variant.
+type UpdateVariantInput struct {
+	SKU        *string            `json:"sku" schema:"sku" validate:"omitempty,max=64"`
+	Price      *Money             `json:"price" schema:"-"`
+	Amount     *int               `json:"amount" schema:"amount" validate:"omitempty,min=0"`
+	Attributes []VariantAttribute `json:"attributes" schema:"attributes" validate:"dive"`
+	ImageURL   *string            `json:"-" schema:"-"`
+	ImageID    *string            `json:"-" schema:"-"`
+	Thumbnails Thumbnails         `json:"-" schema:"-"`
+	UpdatedAt  *time.Time         `json:"-" schema:"-"`
+}
+
+func (i UpdateVariantInput) Validate() error {
+	if i.SKU == nil && i.Price == nil && i.Amount == nil && len(i.Attributes) == 0 && i.ImageURL == nil {
+		return errors.New("update structure has no values")
+	}
+
+	return nil
+}
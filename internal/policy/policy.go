@@ -0,0 +1,120 @@
+package policy
+
+import (
+	"errors"
+	"market/internal/model"
+)
+
+type Action string
+
+const (
+	ProductCreate  Action = "product:create"
+	ProductUpdate  Action = "product:update"
+	ProductDelete  Action = "product:delete"
+	ReviewCreate   Action = "review:create"
+	ReviewUpdate   Action = "review:update"
+	ReviewDelete   Action = "review:delete"
+	OrderCreate    Action = "order:create"
+	OrderRead      Action = "order:read"
+	OrderCancel    Action = "order:cancel"
+	OrderManage    Action = "order:manage"
+	UserUnlock     Action = "user:unlock"
+	UserRead       Action = "user:read"
+	UserManage     Action = "user:manage"
+	AuditRead      Action = "audit:read"
+	SellerApply    Action = "seller:apply"
+	SellerReview   Action = "seller:review"
+	CategoryManage Action = "category:manage"
+)
+
+var (
+	ErrForbidden     = errors.New("you have no access")
+	ErrUnknownAction = errors.New("unknown action")
+)
+
+// Subject is the authenticated caller, Resource is the object the action is applied to.
+// OwnerID is zero for actions that are not bound to an existing resource.
+type (
+	Subject struct {
+		UserID int
+		Role   string
+	}
+
+	Resource struct {
+		OwnerID int
+	}
+)
+
+type Rule func(sub Subject, res Resource) bool
+
+var permissions = map[Action]Rule{
+	ProductCreate:  Role(model.SELLER, model.ADMIN),
+	ProductUpdate:  Any(Role(model.ADMIN), All(Role(model.SELLER), Owner())),
+	ProductDelete:  Any(Role(model.ADMIN), All(Role(model.SELLER), Owner())),
+	ReviewCreate:   Role(model.USER, model.SELLER, model.ADMIN),
+	ReviewUpdate:   Owner(),
+	ReviewDelete:   Any(Role(model.ADMIN), Owner()),
+	OrderCreate:    Role(model.USER, model.SELLER, model.ADMIN),
+	OrderRead:      Any(Role(model.ADMIN), Owner()),
+	OrderCancel:    Any(Role(model.ADMIN), Owner()),
+	OrderManage:    Role(model.ADMIN),
+	UserUnlock:     Role(model.ADMIN),
+	UserRead:       Role(model.ADMIN),
+	UserManage:     Role(model.ADMIN),
+	AuditRead:      Role(model.ADMIN),
+	SellerApply:    Role(model.USER),
+	SellerReview:   Role(model.ADMIN),
+	CategoryManage: Role(model.ADMIN),
+}
+
+func Authorize(action Action, sub Subject, res Resource) error {
+	rule, ok := permissions[action]
+	if !ok {
+		return ErrUnknownAction
+	}
+
+	if !rule(sub, res) {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+func Role(roles ...string) Rule {
+	return func(sub Subject, _ Resource) bool {
+		for _, role := range roles {
+			if sub.Role == role {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func Owner() Rule {
+	return func(sub Subject, res Resource) bool {
+		return res.OwnerID != 0 && res.OwnerID == sub.UserID
+	}
+}
+
+func Any(rules ...Rule) Rule {
+	return func(sub Subject, res Resource) bool {
+		for _, rule := range rules {
+			if rule(sub, res) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func All(rules ...Rule) Rule {
+	return func(sub Subject, res Resource) bool {
+		for _, rule := range rules {
+			if !rule(sub, res) {
+				return false
+			}
+		}
+		return true
+	}
+}
@@ -0,0 +1,101 @@
+package policy
+
+import (
+	"market/internal/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorize(t *testing.T) {
+	tests := []struct {
+		name     string
+		action   Action
+		subject  Subject
+		resource Resource
+		wantErr  error
+	}{
+		{
+			name:     "Seller Updates Own Product",
+			action:   ProductUpdate,
+			subject:  Subject{UserID: 1, Role: model.SELLER},
+			resource: Resource{OwnerID: 1},
+		},
+		{
+			name:     "Seller Updates Foreign Product",
+			action:   ProductUpdate,
+			subject:  Subject{UserID: 1, Role: model.SELLER},
+			resource: Resource{OwnerID: 2},
+			wantErr:  ErrForbidden,
+		},
+		{
+			name:     "Admin Updates Foreign Product",
+			action:   ProductUpdate,
+			subject:  Subject{UserID: 1, Role: model.ADMIN},
+			resource: Resource{OwnerID: 2},
+		},
+		{
+			name:    "User Creates Product",
+			action:  ProductCreate,
+			subject: Subject{UserID: 1, Role: model.USER},
+			wantErr: ErrForbidden,
+		},
+		{
+			name:     "Admin Updates Foreign Review",
+			action:   ReviewUpdate,
+			subject:  Subject{UserID: 1, Role: model.ADMIN},
+			resource: Resource{OwnerID: 2},
+			wantErr:  ErrForbidden,
+		},
+		{
+			name:     "User Reads Foreign Order",
+			action:   OrderRead,
+			subject:  Subject{UserID: 1, Role: model.USER},
+			resource: Resource{OwnerID: 2},
+			wantErr:  ErrForbidden,
+		},
+		{
+			name:     "User Cancels Own Order",
+			action:   OrderCancel,
+			subject:  Subject{UserID: 1, Role: model.USER},
+			resource: Resource{OwnerID: 1},
+		},
+		{
+			name:     "User Changes Status Of Own Order",
+			action:   OrderManage,
+			subject:  Subject{UserID: 1, Role: model.USER},
+			resource: Resource{OwnerID: 1},
+			wantErr:  ErrForbidden,
+		},
+		{
+			name:    "Seller Unlocks User",
+			action:  UserUnlock,
+			subject: Subject{UserID: 1, Role: model.SELLER},
+			wantErr: ErrForbidden,
+		},
+		{
+			name:    "Seller Applies Again",
+			action:  SellerApply,
+			subject: Subject{UserID: 1, Role: model.SELLER},
+			wantErr: ErrForbidden,
+		},
+		{
+			name:    "User Reviews Application",
+			action:  SellerReview,
+			subject: Subject{UserID: 1, Role: model.USER},
+			wantErr: ErrForbidden,
+		},
+		{
+			name:    "Unknown Action",
+			action:  Action("product:sell"),
+			subject: Subject{UserID: 1, Role: model.ADMIN},
+			wantErr: ErrUnknownAction,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantErr, Authorize(tt.action, tt.subject, tt.resource))
+		})
+	}
+}
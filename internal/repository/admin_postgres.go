@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"fmt"
+	"market/internal/model"
+	"market/pkg/database/postgres"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// AdminPostgresqlRepository changes user accounts on behalf of admins. Every
+// change is written in one transaction with its audit log entry, so a change
+// never happens without being recorded.
+type AdminPostgresqlRepository struct {
+	db *sqlx.DB
+}
+
+func NewAdminPostgresqlRepo(db *sqlx.DB) *AdminPostgresqlRepository {
+	return &AdminPostgresqlRepository{db: db}
+}
+
+// ChangeRole also revokes the user's sessions, so the new role is carried by
+// the next access token.
+func (repo *AdminPostgresqlRepository) ChangeRole(userID int, role string, entry model.AuditEntry) error {
+	return repo.audited(entry, func(tx *sqlx.Tx) error {
+		query := fmt.Sprintf("UPDATE %s SET role = $1 WHERE id = $2", usersTable)
+		if err := execAffected(tx, query, role, userID); err != nil {
+			return err
+		}
+
+		return revokeSessions(tx, userID)
+	})
+}
+
+// Ban bans the user at the time of the entry and revokes their sessions.
+func (repo *AdminPostgresqlRepository) Ban(userID int, reason string, entry model.AuditEntry) error {
+	return repo.audited(entry, func(tx *sqlx.Tx) error {
+		query := fmt.Sprintf("UPDATE %s SET banned_at = $1, ban_reason = $2 WHERE id = $3", usersTable)
+		if err := execAffected(tx, query, entry.CreatedAt, reason, userID); err != nil {
+			return err
+		}
+
+		return revokeSessions(tx, userID)
+	})
+}
+
+func (repo *AdminPostgresqlRepository) Unban(userID int, entry model.AuditEntry) error {
+	return repo.audited(entry, func(tx *sqlx.Tx) error {
+		query := fmt.Sprintf("UPDATE %s SET banned_at = NULL, ban_reason = NULL WHERE id = $1", usersTable)
+		return execAffected(tx, query, userID)
+	})
+}
+
+// RequirePasswordReset blocks sign-in until the password is reset and revokes
+// the user's sessions.
+func (repo *AdminPostgresqlRepository) RequirePasswordReset(userID int, entry model.AuditEntry) error {
+	return repo.audited(entry, func(tx *sqlx.Tx) error {
+		query := fmt.Sprintf("UPDATE %s SET password_reset_required = true WHERE id = $1", usersTable)
+		if err := execAffected(tx, query, userID); err != nil {
+			return err
+		}
+
+		return revokeSessions(tx, userID)
+	})
+}
+
+// Unlock resets the login throttle key in attempts. When attempts is the
+// Postgres store, the reset is written in the transaction of the audit entry.
+// Any other store is reset before the entry is committed, so a failed reset
+// isn't recorded.
+func (repo *AdminPostgresqlRepository) Unlock(attempts LoginAttemptRepo, key string, entry model.AuditEntry) error {
+	return repo.audited(entry, func(tx *sqlx.Tx) error {
+		if store, ok := attempts.(txLoginAttemptRepo); ok {
+			attempts = store.withTx(tx)
+		}
+		return attempts.Reset(key)
+	})
+}
+
+// audited runs change and writes entry to the audit log in one transaction.
+func (repo *AdminPostgresqlRepository) audited(entry model.AuditEntry, change func(tx *sqlx.Tx) error) error {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if err = change(tx); err != nil {
+		return err
+	}
+
+	if _, err = createAuditEntry(tx, entry); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+	return nil
+}
+
+// txLoginAttemptRepo is a login attempt store that can join a transaction.
+type txLoginAttemptRepo interface {
+	withTx(tx *sqlx.Tx) LoginAttemptRepo
+}
+
+func revokeSessions(tx *sqlx.Tx, userID int) error {
+	query := fmt.Sprintf("UPDATE %s SET revoked = true WHERE user_id = $1", sessionsTable)
+
+	if _, err := tx.Exec(query, userID); err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+	return nil
+}
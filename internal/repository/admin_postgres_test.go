@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"market/internal/model"
+	"market/pkg/database/postgres"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminPostgres_Ban(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %s", err)
+	}
+	defer db.Close()
+
+	r := NewAdminPostgresqlRepo(sqlx.NewDb(db, "sqlmock"))
+
+	reason := "spam"
+	entry := model.AuditEntry{AdminID: 1, TargetUserID: 2, Action: model.AuditBan, Details: &reason, CreatedAt: time.Now()}
+	ban := fmt.Sprintf("UPDATE %s SET banned_at = \\$1, ban_reason = \\$2 WHERE id = \\$3", usersTable)
+	revoke := fmt.Sprintf("UPDATE %s SET revoked = true WHERE user_id = \\$1", sessionsTable)
+	audit := fmt.Sprintf("INSERT INTO %s", auditLogTable)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr bool
+	}{{
+		name: "OK",
+		mock: func() {
+			mock.ExpectBegin()
+			mock.ExpectExec(ban).WithArgs(entry.CreatedAt, reason, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(revoke).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 3))
+			mock.ExpectQuery(audit).WithArgs(1, 2, model.AuditBan, &reason, entry.CreatedAt).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+			mock.ExpectCommit()
+		},
+	}, {
+		// The ban is rolled back, since it can't be recorded.
+		name: "Audit Failed",
+		mock: func() {
+			mock.ExpectBegin()
+			mock.ExpectExec(ban).WithArgs(entry.CreatedAt, reason, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(revoke).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 3))
+			mock.ExpectQuery(audit).WillReturnError(errors.New("connection reset"))
+			mock.ExpectRollback()
+		},
+		wantErr: true,
+	}, {
+		name: "Not Found",
+		mock: func() {
+			mock.ExpectBegin()
+			mock.ExpectExec(ban).WithArgs(entry.CreatedAt, reason, 2).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectRollback()
+		},
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			err := r.Ban(2, reason, entry)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAdminPostgres_Unlock(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %s", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	r := NewAdminPostgresqlRepo(sqlxDB)
+	entry := model.AuditEntry{AdminID: 1, TargetUserID: 2, Action: model.AuditUnlock, CreatedAt: time.Now()}
+
+	t.Run("Postgres Store", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(fmt.Sprintf("DELETE FROM %s WHERE attempt_key = \\$1", loginAttemptsTable)).
+			WithArgs("username:user").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s", auditLogTable)).
+			WillReturnError(errors.New("connection reset"))
+		mock.ExpectRollback()
+
+		assert.Error(t, r.Unlock(NewLoginAttemptPostgresqlRepo(sqlxDB), "username:user", entry))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Memory Store", func(t *testing.T) {
+		attempts := NewLoginAttemptMemoryRepo()
+		_, err := attempts.RegisterFailure("username:user", time.Now(), time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s", auditLogTable)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectCommit()
+
+		require.NoError(t, r.Unlock(attempts, "username:user", entry))
+		assert.NoError(t, mock.ExpectationsWereMet())
+
+		_, err = attempts.Get("username:user")
+		assert.Equal(t, postgres.ErrNotFound, err)
+	})
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"fmt"
+	"market/internal/model"
+	"market/pkg/database/postgres"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type AuditPostgresqlRepository struct {
+	db *sqlx.DB
+}
+
+func NewAuditPostgresqlRepo(db *sqlx.DB) *AuditPostgresqlRepository {
+	return &AuditPostgresqlRepository{db: db}
+}
+
+func (repo *AuditPostgresqlRepository) Create(entry model.AuditEntry) (int, error) {
+	return createAuditEntry(repo.db, entry)
+}
+
+func (repo *AuditPostgresqlRepository) GetAll(q model.AuditQueryInput) ([]model.AuditEntry, error) {
+	var entries []model.AuditEntry
+	orderBy, err := auditSortColumns.orderBy(q.Sort, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`SELECT * FROM %s WHERE ($1 = 0 OR admin_id = $1) AND ($2 = 0 OR target_user_id = $2)
+		ORDER BY %s LIMIT $3 OFFSET $4`, auditLogTable, orderBy)
+
+	if err := repo.db.Select(&entries, query, q.AdminID, q.TargetUserID, q.Limit, q.Offset); err != nil {
+		return []model.AuditEntry{}, postgres.ParsePostgresError(err)
+	}
+
+	return entries, nil
+}
+
+func createAuditEntry(q sqlx.Queryer, entry model.AuditEntry) (int, error) {
+	var id int
+	query := fmt.Sprintf(`INSERT INTO %s (admin_id, target_user_id, action, details, created_at)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id`, auditLogTable)
+
+	row := q.QueryRowx(query, entry.AdminID, entry.TargetUserID, entry.Action, entry.Details, entry.CreatedAt)
+	if err := row.Scan(&id); err != nil {
+		return 0, postgres.ParsePostgresError(err)
+	}
+
+	return id, nil
+}
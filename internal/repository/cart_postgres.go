@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"market/internal/model"
 	"market/pkg/database/postgres"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -28,16 +29,32 @@ func (repo *CartPostgresqlRepository) Create(userID int) (int, error) {
 	return id, nil
 }
 
-func (repo *CartPostgresqlRepository) AddProduct(cartID, productID, amount int) (int, error) {
+// AddProduct puts the product, or its variant, into the cart. With
+// reservedUntil set the purchased amount is reserved until then, and
+// model.ErrOutOfStock is returned when less is available.
+func (repo *CartPostgresqlRepository) AddProduct(cartID, productID int, variantID *int, amount int, reservedUntil *time.Time) (int, error) {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return 0, postgres.ParsePostgresError(err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if reservedUntil != nil {
+		if err = reserveStock(tx, newStockRow(productID, variantID), amount); err != nil {
+			return 0, err
+		}
+	}
+
 	var id int
-	query := fmt.Sprintf("INSERT INTO %s (product_id, cart_id, purchased_amount) VALUES ($1, $2, $3) RETURNING id", productsCartsTable)
+	query := fmt.Sprintf(`INSERT INTO %s (product_id, variant_id, cart_id, purchased_amount, reserved_until)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id`, productsCartsTable)
 
-	row := repo.db.QueryRow(query, productID, cartID, amount)
-	if err := row.Scan(&id); err != nil {
+	row := tx.QueryRow(query, productID, variantID, cartID, amount, reservedUntil)
+	if err = row.Scan(&id); err != nil {
 		return 0, postgres.ParsePostgresError(err)
 	}
 
-	return id, nil
+	return id, postgres.ParsePostgresError(tx.Commit())
 }
 
 func (repo *CartPostgresqlRepository) GetByUserID(userID int) (model.Cart, error) {
@@ -53,6 +70,11 @@ func (repo *CartPostgresqlRepository) GetByUserID(userID int) (model.Cart, error
 
 func (repo *CartPostgresqlRepository) GetAllProducts(cartID int, q model.ProductQueryInput) ([]model.Product, error) {
 	var products []model.Product
+	orderBy, err := joinedProductSortColumns.withPrice(convertedPrice(q.Prices, "p")).orderBy(q.Sort, "p.id")
+	if err != nil {
+		return nil, err
+	}
+
 	var limitValue string
 	argID := 2
 	args := make([]interface{}, 0)
@@ -65,10 +87,11 @@ func (repo *CartPostgresqlRepository) GetAllProducts(cartID int, q model.Product
 
 	args = append(args, q.Offset)
 
-	query := fmt.Sprintf(`SELECT p.id, p.user_id, p.title, p.price, p.tag, p.category, p.description, p.amount, pc.purchased_amount, p.created_at, p.updated_at, p.views, p.image_url FROM %s p 
+	query := fmt.Sprintf(`SELECT %s FROM %s p 
 			  			  INNER JOIN %s pc on pc.product_id = p.id
 			  			  INNER JOIN %s c on pc.cart_id = c.id
-			 			  WHERE c.id = $1 ORDER BY %s %s %s OFFSET $%d`, productsTable, productsCartsTable, cartsTable, q.SortBy, q.SortOrder, limitValue, argID)
+			  			  LEFT JOIN %s v on pc.variant_id = v.id
+			 			  WHERE c.id = $1 ORDER BY %s %s OFFSET $%d`, productLineColumns("pc"), productsTable, productsCartsTable, cartsTable, productVariantsTable, orderBy, limitValue, argID)
 
 	if err := repo.db.Select(&products, query, args...); err != nil {
 		return []model.Product{}, postgres.ParsePostgresError(err)
@@ -77,40 +100,133 @@ func (repo *CartPostgresqlRepository) GetAllProducts(cartID int, q model.Product
 	return products, nil
 }
 
-func (repo *CartPostgresqlRepository) GetProductByID(cartID, productID int) (model.Product, error) {
+// GetProductByID returns the cart line of the product, or of its variant when
+// variantID is set, with the price and stock of the variant.
+func (repo *CartPostgresqlRepository) GetProductByID(cartID, productID int, variantID *int) (model.Product, error) {
 	var product model.Product
-	query := fmt.Sprintf(`SELECT p.id, p.user_id, p.title, p.price, p.tag, p.category, p.description, p.amount, pc.purchased_amount, p.created_at, p.updated_at, p.views, p.image_url FROM %s p 
+	query := fmt.Sprintf(`SELECT %s FROM %s p 
 			  			  INNER JOIN %s pc on pc.product_id = p.id
 			  			  INNER JOIN %s c on pc.cart_id = c.id
-			 			  WHERE c.id = $1 AND p.id = $2`, productsTable, productsCartsTable, cartsTable)
+			  			  LEFT JOIN %s v on pc.variant_id = v.id
+			 			  WHERE c.id = $1 AND p.id = $2 AND pc.variant_id IS NOT DISTINCT FROM $3`,
+		productLineColumns("pc"), productsTable, productsCartsTable, cartsTable, productVariantsTable)
 
-	if err := repo.db.Get(&product, query, cartID, productID); err != nil {
+	if err := repo.db.Get(&product, query, cartID, productID, variantID); err != nil {
 		return model.Product{}, postgres.ParsePostgresError(err)
 	}
 
 	return product, nil
 }
 
-func (repo *CartPostgresqlRepository) UpdateProductAmount(cartID, productID, amount int) error {
-	query := fmt.Sprintf(`UPDATE %s SET purchased_amount = $1 WHERE cart_id = $2`, productsCartsTable)
-	if _, err := repo.db.Exec(query, amount, cartID); err != nil {
+// UpdateProductAmount changes the purchased amount of the cart line. With
+// reservedUntil set the reservation of the line grows or shrinks to the new
+// amount and is extended until then, model.ErrOutOfStock is returned when
+// not enough is available. Without it a reservation the line holds is
+// released.
+func (repo *CartPostgresqlRepository) UpdateProductAmount(cartID, productID int, variantID *int, amount int, reservedUntil *time.Time) error {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	var line struct {
+		Amount   int  `db:"purchased_amount"`
+		Reserved bool `db:"reserved"`
+	}
+	query := fmt.Sprintf(`SELECT purchased_amount, reserved_until IS NOT NULL AS reserved FROM %s
+		WHERE cart_id = $1 AND product_id = $2 AND variant_id IS NOT DISTINCT FROM $3 FOR UPDATE`, productsCartsTable)
+	if err = tx.Get(&line, query, cartID, productID, variantID); err != nil {
 		return postgres.ParsePostgresError(err)
 	}
-	return nil
-}
 
-func (repo *CartPostgresqlRepository) DeleteProduct(cartID, productID int) error {
-	query := fmt.Sprintf(`DELETE FROM %s WHERE cart_id = $1 AND product_id = $2`, productsCartsTable)
-	if _, err := repo.db.Exec(query, cartID, productID); err != nil {
+	held := 0
+	if line.Reserved {
+		held = line.Amount
+	}
+
+	want := 0
+	if reservedUntil != nil {
+		want = amount
+	}
+
+	if want != held {
+		if err = reserveStock(tx, newStockRow(productID, variantID), want-held); err != nil {
+			return err
+		}
+	}
+
+	query = fmt.Sprintf(`UPDATE %s SET purchased_amount = $1, reserved_until = $2
+		WHERE cart_id = $3 AND product_id = $4 AND variant_id IS NOT DISTINCT FROM $5`, productsCartsTable)
+	if _, err = tx.Exec(query, amount, reservedUntil, cartID, productID, variantID); err != nil {
 		return postgres.ParsePostgresError(err)
 	}
-	return nil
+
+	return postgres.ParsePostgresError(tx.Commit())
+}
+
+// DeleteProduct removes the line from the cart and releases its reservation.
+func (repo *CartPostgresqlRepository) DeleteProduct(cartID, productID int, variantID *int) error {
+	return repo.deleteLines("cart_id = $1 AND product_id = $2 AND variant_id IS NOT DISTINCT FROM $3", cartID, productID, variantID)
 }
 
 func (repo *CartPostgresqlRepository) DeleteAllProducts(cartID int) error {
-	query := fmt.Sprintf(`DELETE FROM %s WHERE cart_id = $1`, productsCartsTable)
-	if _, err := repo.db.Exec(query, cartID); err != nil {
+	return repo.deleteLines("cart_id = $1", cartID)
+}
+
+func (repo *CartPostgresqlRepository) deleteLines(condition string, args ...interface{}) error {
+	tx, err := repo.db.Beginx()
+	if err != nil {
 		return postgres.ParsePostgresError(err)
 	}
-	return nil
+	defer tx.Rollback() //nolint:errcheck
+
+	held, err := deleteCartLines(tx, condition, args...)
+	if err != nil {
+		return err
+	}
+
+	if err = releaseStock(tx, held); err != nil {
+		return err
+	}
+
+	return postgres.ParsePostgresError(tx.Commit())
+}
+
+// ReleaseExpired releases up to limit reservations that expired before the
+// time. The lines stay in their carts without a reservation. Lines locked by a
+// checkout or a change of the cart are left for the next run.
+func (repo *CartPostgresqlRepository) ReleaseExpired(before time.Time, limit int) (int, error) {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return 0, postgres.ParsePostgresError(err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	var expired []reservation
+	query := fmt.Sprintf(`UPDATE %[1]s SET reserved_until = NULL WHERE id IN (
+		SELECT id FROM %[1]s WHERE reserved_until <= $1 ORDER BY reserved_until LIMIT $2 FOR UPDATE SKIP LOCKED
+	) RETURNING product_id, variant_id, purchased_amount`, productsCartsTable)
+	if err = tx.Select(&expired, query, before, limit); err != nil {
+		return 0, postgres.ParsePostgresError(err)
+	}
+
+	if err = releaseStock(tx, expired); err != nil {
+		return 0, err
+	}
+
+	return len(expired), postgres.ParsePostgresError(tx.Commit())
+}
+
+// deleteCartLines removes the cart lines matching the condition and returns
+// the reservations they held, which the caller must release or take over.
+func deleteCartLines(tx *sqlx.Tx, condition string, args ...interface{}) ([]reservation, error) {
+	var held []reservation
+	query := fmt.Sprintf(`WITH deleted AS (DELETE FROM %s WHERE %s RETURNING product_id, variant_id, purchased_amount, reserved_until)
+		SELECT product_id, variant_id, purchased_amount FROM deleted WHERE reserved_until IS NOT NULL`, productsCartsTable, condition)
+	if err := tx.Select(&held, query, args...); err != nil {
+		return nil, postgres.ParsePostgresError(err)
+	}
+
+	return held, nil
 }
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"fmt"
+	"market/internal/model"
+	"sync"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCartPostgres_AddProduct(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %s", err)
+	}
+	defer db.Close()
+
+	r := NewCartPostgresqlRepo(sqlx.NewDb(db, "sqlmock"))
+
+	variantID := 7
+	until := time.Date(2024, 5, 1, 12, 15, 0, 0, time.UTC)
+	reserve := "UPDATE %s SET reserved = reserved \\+ \\$1 WHERE id = \\$2 AND \\(\\$1 <= 0 OR amount - reserved >= \\$1\\)"
+
+	tests := []struct {
+		name          string
+		mock          func()
+		variantID     *int
+		reservedUntil *time.Time
+		want          int
+		wantErr       error
+	}{{
+		name: "Reserved",
+		mock: func() {
+			mock.ExpectBegin()
+			mock.ExpectExec(fmt.Sprintf(reserve, productVariantsTable)).WithArgs(2, 7).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s", productsCartsTable)).WithArgs(3, &variantID, 5, 2, &until).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11))
+			mock.ExpectCommit()
+		},
+		variantID:     &variantID,
+		reservedUntil: &until,
+		want:          11,
+	}, {
+		name: "Out Of Stock",
+		mock: func() {
+			mock.ExpectBegin()
+			mock.ExpectExec(fmt.Sprintf(reserve, productsTable)).WithArgs(2, 3).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectRollback()
+		},
+		reservedUntil: &until,
+		wantErr:       model.ErrOutOfStock,
+	}, {
+		name: "Reservations Off",
+		mock: func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s", productsCartsTable)).WithArgs(3, nil, 5, 2, nil).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(12))
+			mock.ExpectCommit()
+		},
+		want: 12,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, err := r.AddProduct(5, 3, tt.variantID, 2, tt.reservedUntil)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCartPostgres_ReleaseExpired(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %s", err)
+	}
+	defer db.Close()
+
+	r := NewCartPostgresqlRepo(sqlx.NewDb(db, "sqlmock"))
+
+	now := time.Now()
+	release := "UPDATE %s SET reserved = reserved - \\$1 WHERE id = \\$2"
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(fmt.Sprintf("UPDATE %[1]s SET reserved_until = NULL WHERE id IN \\(\\s*SELECT id FROM %[1]s WHERE reserved_until <= \\$1", productsCartsTable)).
+		WithArgs(now, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"product_id", "variant_id", "purchased_amount"}).
+			AddRow(3, 7, 2).AddRow(4, nil, 1).AddRow(1, nil, 5))
+	// Products go before variants, both by id.
+	mock.ExpectExec(fmt.Sprintf(release, productsTable)).WithArgs(5, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(fmt.Sprintf(release, productsTable)).WithArgs(1, 4).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(fmt.Sprintf(release, productVariantsTable)).WithArgs(2, 7).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	released, err := r.ReleaseExpired(now, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, released)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestCartPostgres_AddProduct_Concurrent adds the product to more carts than
+// there are items in stock at once. Only as many lines as there are items must
+// be added, and expired reservations must give the stock back.
+func TestCartPostgres_AddProduct_Concurrent(t *testing.T) {
+	db := newTestPostgres(t)
+	r := NewCartPostgresqlRepo(db)
+
+	const stock, buyers = 3, 20
+
+	var categoryID, productID int
+	require.NoError(t, db.Get(&categoryID, "INSERT INTO categories (name, slug, created_at) VALUES ('Mugs', 'mugs', now()) RETURNING id"))
+	require.NoError(t, db.Get(&productID, `INSERT INTO products (user_id, title, price, currency, category_id, amount, created_at, updated_at, views)
+		VALUES (1, 'Mug', 5, 'RUB', $1, $2, now(), now(), 0) RETURNING id`, categoryID, stock))
+
+	carts := make([]int, buyers)
+	for i := range carts {
+		var userID int
+		require.NoError(t, db.Get(&userID, "INSERT INTO users (role, username, email, password) VALUES ($1, $2, $3, '') RETURNING id",
+			model.USER, fmt.Sprintf("buyer%d", i), fmt.Sprintf("buyer%d@market.local", i)))
+		require.NoError(t, db.Get(&carts[i], "INSERT INTO carts (user_id) VALUES ($1) RETURNING id", userID))
+	}
+
+	until := time.Now().Add(time.Minute)
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	errs := make([]error, buyers)
+	for i := range carts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			_, errs[i] = r.AddProduct(carts[i], productID, nil, 1, &until)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	added := 0
+	for _, err := range errs {
+		if err == nil {
+			added++
+			continue
+		}
+		require.Equal(t, model.ErrOutOfStock, err)
+	}
+	assert.Equal(t, stock, added)
+
+	var reserved int
+	require.NoError(t, db.Get(&reserved, "SELECT reserved FROM products WHERE id = $1", productID))
+	assert.Equal(t, stock, reserved)
+
+	released, err := r.ReleaseExpired(until.Add(time.Second), buyers)
+	require.NoError(t, err)
+	assert.Equal(t, stock, released)
+
+	require.NoError(t, db.Get(&reserved, "SELECT reserved FROM products WHERE id = $1", productID))
+	assert.Equal(t, 0, reserved)
+}
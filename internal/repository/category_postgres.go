@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"fmt"
+	"market/internal/model"
+	"market/pkg/database/postgres"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type CategoryPostgresqlRepository struct {
+	db *sqlx.DB
+}
+
+func NewCategoryPostgresqlRepo(db *sqlx.DB) *CategoryPostgresqlRepository {
+	return &CategoryPostgresqlRepository{db: db}
+}
+
+func (repo *CategoryPostgresqlRepository) Create(category model.Category) (int, error) {
+	var id int
+	query := fmt.Sprintf(`INSERT INTO %s (parent_id, name, slug, description, created_at)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id`, categoriesTable)
+
+	row := repo.db.QueryRow(query, category.ParentID, category.Name, category.Slug, category.Description, category.CreatedAt)
+	if err := row.Scan(&id); err != nil {
+		return 0, postgres.ParsePostgresError(err)
+	}
+
+	return id, nil
+}
+
+// GetAll returns every category ordered by name, without nesting.
+func (repo *CategoryPostgresqlRepository) GetAll() ([]model.Category, error) {
+	var categories []model.Category
+	query := fmt.Sprintf("SELECT id, parent_id, name, slug, description, created_at FROM %s ORDER BY name, id", categoriesTable)
+
+	if err := repo.db.Select(&categories, query); err != nil {
+		return nil, postgres.ParsePostgresError(err)
+	}
+
+	return categories, nil
+}
+
+func (repo *CategoryPostgresqlRepository) GetByID(categoryID int) (model.Category, error) {
+	var category model.Category
+	query := fmt.Sprintf("SELECT id, parent_id, name, slug, description, created_at FROM %s WHERE id = $1", categoriesTable)
+
+	if err := repo.db.Get(&category, query, categoryID); err != nil {
+		return model.Category{}, postgres.ParsePostgresError(err)
+	}
+
+	return category, nil
+}
+
+func (repo *CategoryPostgresqlRepository) GetBySlug(slug string) (model.Category, error) {
+	var category model.Category
+	query := fmt.Sprintf("SELECT id, parent_id, name, slug, description, created_at FROM %s WHERE slug = $1", categoriesTable)
+
+	if err := repo.db.Get(&category, query, slug); err != nil {
+		return model.Category{}, postgres.ParsePostgresError(err)
+	}
+
+	return category, nil
+}
+
+// Update applies the set fields of the input. A zero ParentID clears the parent.
+func (repo *CategoryPostgresqlRepository) Update(categoryID int, input model.UpdateCategoryInput) error {
+	setValues := make([]string, 0)
+	args := make([]interface{}, 0)
+	set := func(column string, arg interface{}) {
+		args = append(args, arg)
+		setValues = append(setValues, fmt.Sprintf("%s=$%d", column, len(args)))
+	}
+
+	if input.Name != nil {
+		set("name", *input.Name)
+	}
+	if input.Slug != nil {
+		set("slug", *input.Slug)
+	}
+	if input.Description != nil {
+		set("description", *input.Description)
+	}
+	if input.ParentID != nil {
+		if *input.ParentID == 0 {
+			setValues = append(setValues, "parent_id=NULL")
+		} else {
+			set("parent_id", *input.ParentID)
+		}
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE id = $%d", categoriesTable, strings.Join(setValues, ", "), len(args)+1)
+
+	return execAffected(repo.db, query, append(args, categoryID)...)
+}
+
+// Delete fails with postgres.ErrForeignKey while the category has children or
+// products.
+func (repo *CategoryPostgresqlRepository) Delete(categoryID int) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1", categoriesTable)
+
+	return execAffected(repo.db, query, categoryID)
+}
+
+// categorySubtree is a subquery selecting the IDs of the category matched by
+// the condition and of all its descendants.
+func categorySubtree(condition string) string {
+	return fmt.Sprintf(`WITH RECURSIVE tree AS (
+			SELECT id FROM %s WHERE %s
+			UNION ALL
+			SELECT c.id FROM %s c INNER JOIN tree t ON c.parent_id = t.id
+		) SELECT id FROM tree`, categoriesTable, condition, categoriesTable)
+}
@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"market/internal/model"
+	"market/pkg/database/postgres"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// productImageColumns select the URL and the thumbnails of the primary image in
+// queries on the products table.
+const productImageColumns = "coalesce((SELECT image_url FROM " + productImagesTable + " WHERE product_id = " + productsTable + ".id AND is_primary), '') AS image_url, " +
+	"(SELECT thumbnails FROM " + productImagesTable + " WHERE product_id = " + productsTable + ".id AND is_primary) AS thumbnails"
+
+var (
+	ErrTooManyImages = errors.New("gallery would exceed the image limit")
+	ErrLastImage     = errors.New("last image of the product")
+)
+
+type ProductImagePostgresqlRepository struct {
+	db *sqlx.DB
+}
+
+func NewProductImagePostgresqlRepo(db *sqlx.DB) *ProductImagePostgresqlRepository {
+	return &ProductImagePostgresqlRepository{db: db}
+}
+
+// Add appends the images to the end of the gallery in one transaction. The
+// product row is locked first, so concurrent uploads can't exceed
+// model.MaxProductImages together or take the same positions. With primary
+// set, or for a product without images, the first of them becomes the primary
+// image. ErrTooManyImages is returned when the images don't fit.
+func (repo *ProductImagePostgresqlRepository) Add(productID int, images []model.ProductImage, primary bool) error {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if err = lockProduct(tx, productID); err != nil {
+		return err
+	}
+
+	var gallery struct {
+		Count    int `db:"count"`
+		Position int `db:"position"`
+	}
+	query := fmt.Sprintf("SELECT count(*) AS count, coalesce(max(position) + 1, 0) AS position FROM %s WHERE product_id = $1", productImagesTable)
+	if err = tx.Get(&gallery, query, productID); err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+
+	if gallery.Count+len(images) > model.MaxProductImages {
+		return ErrTooManyImages
+	}
+
+	primary = primary || gallery.Count == 0
+	if primary {
+		query = fmt.Sprintf("UPDATE %s SET is_primary = false WHERE product_id = $1 AND is_primary", productImagesTable)
+		if _, err = tx.Exec(query, productID); err != nil {
+			return postgres.ParsePostgresError(err)
+		}
+	}
+
+	for i, image := range images {
+		image.ProductID = productID
+		image.Position = gallery.Position + i
+		image.Primary = primary && i == 0
+		if _, err = insertProductImage(tx, image); err != nil {
+			return err
+		}
+	}
+
+	return postgres.ParsePostgresError(tx.Commit())
+}
+
+// GetAll returns the gallery of the product in display order.
+func (repo *ProductImagePostgresqlRepository) GetAll(productID int) ([]model.ProductImage, error) {
+	images := make([]model.ProductImage, 0)
+	query := fmt.Sprintf("SELECT * FROM %s WHERE product_id = $1 ORDER BY position, id", productImagesTable)
+
+	if err := repo.db.Select(&images, query, productID); err != nil {
+		return nil, postgres.ParsePostgresError(err)
+	}
+
+	return images, nil
+}
+
+func (repo *ProductImagePostgresqlRepository) GetByID(imageID int) (model.ProductImage, error) {
+	var image model.ProductImage
+	query := fmt.Sprintf("SELECT * FROM %s WHERE id = $1", productImagesTable)
+
+	if err := repo.db.Get(&image, query, imageID); err != nil {
+		return model.ProductImage{}, postgres.ParsePostgresError(err)
+	}
+
+	return image, nil
+}
+
+// SetPrimary makes the image the only primary image of the product.
+func (repo *ProductImagePostgresqlRepository) SetPrimary(productID, imageID int) error {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	// The unique index on primary images is checked row by row, so the old
+	// primary image has to be cleared in a statement of its own.
+	query := fmt.Sprintf("UPDATE %s SET is_primary = false WHERE product_id = $1 AND is_primary AND id != $2", productImagesTable)
+	if _, err = tx.Exec(query, productID, imageID); err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+
+	query = fmt.Sprintf("UPDATE %s SET is_primary = true WHERE id = $1 AND product_id = $2", productImagesTable)
+	if err = execAffected(tx, query, imageID, productID); err != nil {
+		return err
+	}
+
+	return postgres.ParsePostgresError(tx.Commit())
+}
+
+// Reorder numbers the images of the product in the order of imageIDs.
+func (repo *ProductImagePostgresqlRepository) Reorder(productID int, imageIDs []int) error {
+	query := fmt.Sprintf(`UPDATE %s i SET position = o.position
+		FROM unnest($1::int[]) WITH ORDINALITY AS o(id, position)
+		WHERE i.id = o.id AND i.product_id = $2`, productImagesTable)
+
+	return execAffected(repo.db, query, pq.Array(imageIDs), productID)
+}
+
+// Delete removes the image from the gallery of the product and queues its
+// deletion from the image storage. The product row is locked, so concurrent
+// deletes can't remove the last image together: ErrLastImage is returned for
+// it. When the primary image is deleted, the next one in order takes its
+// place in the same transaction.
+func (repo *ProductImagePostgresqlRepository) Delete(productID, imageID int) error {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if err = lockProduct(tx, productID); err != nil {
+		return err
+	}
+
+	var gallery []model.ProductImage
+	query := fmt.Sprintf("SELECT * FROM %s WHERE product_id = $1 ORDER BY position, id", productImagesTable)
+	if err = tx.Select(&gallery, query, productID); err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+
+	var (
+		image model.ProductImage
+		found bool
+		next  int
+	)
+	for _, item := range gallery {
+		switch {
+		case item.ID == imageID:
+			image, found = item, true
+		case next == 0:
+			next = item.ID
+		}
+	}
+
+	if !found {
+		return postgres.ErrNotFound
+	}
+
+	if next == 0 {
+		return ErrLastImage
+	}
+
+	query = fmt.Sprintf("SELECT image_id FROM %s WHERE id = $1", productImagesTable)
+	if _, err = enqueueImageDeletions(tx, query, imageID); err != nil {
+		return err
+	}
+
+	query = fmt.Sprintf("DELETE FROM %s WHERE id = $1", productImagesTable)
+	if err = execAffected(tx, query, imageID); err != nil {
+		return err
+	}
+
+	if image.Primary {
+		query = fmt.Sprintf("UPDATE %s SET is_primary = true WHERE id = $1", productImagesTable)
+		if err = execAffected(tx, query, next); err != nil {
+			return err
+		}
+	}
+
+	return postgres.ParsePostgresError(tx.Commit())
+}
+
+// lockProduct locks the product row until the end of the transaction, which
+// serializes changes to the product's gallery.
+func lockProduct(tx *sqlx.Tx, productID int) error {
+	var id int
+	query := fmt.Sprintf("SELECT id FROM %s WHERE id = $1 FOR UPDATE", productsTable)
+
+	if err := tx.Get(&id, query, productID); err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+	return nil
+}
+
+func insertProductImage(db sqlx.Queryer, image model.ProductImage) (int, error) {
+	var id int
+	query := fmt.Sprintf(`INSERT INTO %s (product_id, image_url, image_id, position, is_primary, thumbnails, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`, productImagesTable)
+
+	row := db.QueryRowx(query, image.ProductID, image.ImageURL, image.ImageID, image.Position, image.Primary, image.Thumbnails, image.CreatedAt)
+	if err := row.Scan(&id); err != nil {
+		return 0, postgres.ParsePostgresError(err)
+	}
+
+	return id, nil
+}
+
+// productImagesQuery selects the IDs of the stored images of the products
+// matching the condition, gallery and variant images alike.
+func productImagesQuery(condition string) string {
+	return fmt.Sprintf(`SELECT image_id FROM %[1]s WHERE product_id IN (SELECT id FROM %[3]s WHERE %[4]s)
+		UNION ALL
+		SELECT image_id FROM %[2]s WHERE image_id IS NOT NULL AND product_id IN (SELECT id FROM %[3]s WHERE %[4]s)`,
+		productImagesTable, productVariantsTable, productsTable, condition)
+}
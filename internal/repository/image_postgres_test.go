@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"market/internal/model"
+	"market/pkg/database/postgres"
+	"sync"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProductImagePostgres_Add(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %s", err)
+	}
+	defer db.Close()
+
+	r := NewProductImagePostgresqlRepo(sqlx.NewDb(db, "sqlmock"))
+
+	now := time.Now()
+	images := []model.ProductImage{
+		{ImageURL: "a.jpg", ImageID: "a", CreatedAt: now},
+		{ImageURL: "b.jpg", ImageID: "b", CreatedAt: now},
+	}
+	lock := fmt.Sprintf("SELECT id FROM %s WHERE id = \\$1 FOR UPDATE", productsTable)
+	count := fmt.Sprintf("SELECT count\\(\\*\\) AS count, coalesce\\(max\\(position\\) \\+ 1, 0\\) AS position FROM %s", productImagesTable)
+	clearPrimary := fmt.Sprintf("UPDATE %s SET is_primary = false WHERE product_id = \\$1 AND is_primary", productImagesTable)
+	insert := fmt.Sprintf("INSERT INTO %s", productImagesTable)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		primary bool
+		wantErr error
+	}{{
+		name: "Appended",
+		mock: func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(lock).WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+			mock.ExpectQuery(count).WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"count", "position"}).AddRow(2, 5))
+			mock.ExpectQuery(insert).WithArgs(3, "a.jpg", "a", 5, false, sqlmock.AnyArg(), now).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+			mock.ExpectQuery(insert).WithArgs(3, "b.jpg", "b", 6, false, sqlmock.AnyArg(), now).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11))
+			mock.ExpectCommit()
+		},
+	}, {
+		name: "Primary",
+		mock: func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(lock).WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+			mock.ExpectQuery(count).WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"count", "position"}).AddRow(2, 5))
+			mock.ExpectExec(clearPrimary).WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectQuery(insert).WithArgs(3, "a.jpg", "a", 5, true, sqlmock.AnyArg(), now).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+			mock.ExpectQuery(insert).WithArgs(3, "b.jpg", "b", 6, false, sqlmock.AnyArg(), now).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11))
+			mock.ExpectCommit()
+		},
+		primary: true,
+	}, {
+		name: "Too Many",
+		mock: func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(lock).WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+			mock.ExpectQuery(count).WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"count", "position"}).AddRow(model.MaxProductImages-1, 9))
+			mock.ExpectRollback()
+		},
+		wantErr: ErrTooManyImages,
+	}, {
+		// Nothing of the batch stays when an image can't be inserted.
+		name: "Insert Failed",
+		mock: func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(lock).WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+			mock.ExpectQuery(count).WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"count", "position"}).AddRow(0, 0))
+			mock.ExpectExec(clearPrimary).WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(insert).WithArgs(3, "a.jpg", "a", 0, true, sqlmock.AnyArg(), now).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+			mock.ExpectQuery(insert).WillReturnError(errors.New("connection reset"))
+			mock.ExpectRollback()
+		},
+		wantErr: errors.New("connection reset"),
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			err := r.Add(3, images, tt.primary)
+			assert.Equal(t, tt.wantErr, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestProductImagePostgres_Delete(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %s", err)
+	}
+	defer db.Close()
+
+	r := NewProductImagePostgresqlRepo(sqlx.NewDb(db, "sqlmock"))
+
+	lock := fmt.Sprintf("SELECT id FROM %s WHERE id = \\$1 FOR UPDATE", productsTable)
+	gallery := fmt.Sprintf("SELECT \\* FROM %s WHERE product_id = \\$1 ORDER BY position, id", productImagesTable)
+	columns := []string{"id", "product_id", "is_primary"}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		imageID int
+		wantErr error
+	}{{
+		name: "Primary Promotes Next",
+		mock: func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(lock).WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+			mock.ExpectQuery(gallery).WithArgs(3).WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 3, true).AddRow(2, 3, false))
+			mock.ExpectExec(fmt.Sprintf("INSERT INTO %s", outboxTable)).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(fmt.Sprintf("DELETE FROM %s WHERE id = \\$1", productImagesTable)).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(fmt.Sprintf("UPDATE %s SET is_primary = true WHERE id = \\$1", productImagesTable)).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+		},
+		imageID: 1,
+	}, {
+		name: "Last Image",
+		mock: func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(lock).WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+			mock.ExpectQuery(gallery).WithArgs(3).WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 3, true))
+			mock.ExpectRollback()
+		},
+		imageID: 1,
+		wantErr: ErrLastImage,
+	}, {
+		name: "Other Product",
+		mock: func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(lock).WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+			mock.ExpectQuery(gallery).WithArgs(3).WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 3, true).AddRow(2, 3, false))
+			mock.ExpectRollback()
+		},
+		imageID: 7,
+		wantErr: postgres.ErrNotFound,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			err := r.Delete(3, tt.imageID)
+			assert.Equal(t, tt.wantErr, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// TestProductImagePostgres_Add_Concurrent uploads more images at once than fit
+// into the gallery. Only one of the batches may be added, at positions no
+// other image has.
+func TestProductImagePostgres_Add_Concurrent(t *testing.T) {
+	db := newTestPostgres(t)
+	r := NewProductImagePostgresqlRepo(db)
+
+	var categoryID, productID int
+	require.NoError(t, db.Get(&categoryID, "INSERT INTO categories (name, slug, created_at) VALUES ('Mugs', 'mugs', now()) RETURNING id"))
+	require.NoError(t, db.Get(&productID, `INSERT INTO products (user_id, title, price, currency, category_id, amount, created_at, updated_at, views)
+		VALUES (1, 'Mug', 5, 'RUB', $1, 1, now(), now(), 0) RETURNING id`, categoryID))
+
+	const uploads = 2
+	batch := model.MaxProductImages/2 + 1
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	errs := make([]error, uploads)
+	for i := 0; i < uploads; i++ {
+		images := make([]model.ProductImage, batch)
+		for j := range images {
+			id := fmt.Sprintf("upload-%d-%d", i, j)
+			images[j] = model.ProductImage{ImageURL: id + ".jpg", ImageID: id, CreatedAt: time.Now()}
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			errs[i] = r.Add(productID, images, false)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	added := 0
+	for _, err := range errs {
+		if err == nil {
+			added++
+			continue
+		}
+		require.Equal(t, ErrTooManyImages, err)
+	}
+	assert.Equal(t, 1, added)
+
+	gallery, err := r.GetAll(productID)
+	require.NoError(t, err)
+	assert.Len(t, gallery, batch)
+
+	positions := make(map[int]bool, len(gallery))
+	primary := 0
+	for _, image := range gallery {
+		assert.False(t, positions[image.Position], "position %d is taken twice", image.Position)
+		positions[image.Position] = true
+		if image.Primary {
+			primary++
+		}
+	}
+	assert.Equal(t, 1, primary)
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"market/internal/model"
+	"market/pkg/database/postgres"
+	"sync"
+	"time"
+)
+
+// LoginAttemptMemoryRepository keeps login attempts in process memory.
+// It is meant for tests and single-instance development setups.
+type LoginAttemptMemoryRepository struct {
+	mu           sync.Mutex
+	attempts     map[string]model.LoginAttempts
+	FailedLogins []model.FailedLogin
+}
+
+func NewLoginAttemptMemoryRepo() *LoginAttemptMemoryRepository {
+	return &LoginAttemptMemoryRepository{attempts: make(map[string]model.LoginAttempts)}
+}
+
+func (repo *LoginAttemptMemoryRepository) Get(key string) (model.LoginAttempts, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	attempts, ok := repo.attempts[key]
+	if !ok {
+		return model.LoginAttempts{}, postgres.ErrNotFound
+	}
+	return attempts, nil
+}
+
+func (repo *LoginAttemptMemoryRepository) RegisterFailure(key string, at, since time.Time) (model.LoginAttempts, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	attempts := repo.attempts[key]
+	if attempts.LastFailureAt.Before(since) {
+		attempts.Failures = 0
+	}
+	attempts.Key = key
+	attempts.Failures++
+	attempts.LastFailureAt = at
+	repo.attempts[key] = attempts
+
+	return attempts, nil
+}
+
+func (repo *LoginAttemptMemoryRepository) Lock(key string, until time.Time) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	attempts, ok := repo.attempts[key]
+	if !ok {
+		return nil
+	}
+	attempts.LockedUntil = &until
+	repo.attempts[key] = attempts
+
+	return nil
+}
+
+func (repo *LoginAttemptMemoryRepository) Reset(key string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	delete(repo.attempts, key)
+	return nil
+}
+
+func (repo *LoginAttemptMemoryRepository) CreateFailedLogin(login model.FailedLogin) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	login.ID = len(repo.FailedLogins) + 1
+	repo.FailedLogins = append(repo.FailedLogins, login)
+	return nil
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"fmt"
+	"market/internal/model"
+	"market/pkg/database/postgres"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// LoginAttemptPostgresqlRepository works on the database or, when bound with
+// withTx, inside a transaction of another repository.
+type LoginAttemptPostgresqlRepository struct {
+	db sqlx.Ext
+}
+
+func NewLoginAttemptPostgresqlRepo(db *sqlx.DB) *LoginAttemptPostgresqlRepository {
+	return &LoginAttemptPostgresqlRepository{db: db}
+}
+
+func (repo *LoginAttemptPostgresqlRepository) withTx(tx *sqlx.Tx) LoginAttemptRepo {
+	return &LoginAttemptPostgresqlRepository{db: tx}
+}
+
+func (repo *LoginAttemptPostgresqlRepository) Get(key string) (model.LoginAttempts, error) {
+	var attempts model.LoginAttempts
+	query := fmt.Sprintf("SELECT * FROM %s WHERE attempt_key = $1", loginAttemptsTable)
+
+	if err := sqlx.Get(repo.db, &attempts, query, key); err != nil {
+		return model.LoginAttempts{}, postgres.ParsePostgresError(err)
+	}
+
+	return attempts, nil
+}
+
+// RegisterFailure counts a failure under the key. Failures before since are
+// forgotten, so the count starts over after a quiet period.
+func (repo *LoginAttemptPostgresqlRepository) RegisterFailure(key string, at, since time.Time) (model.LoginAttempts, error) {
+	var attempts model.LoginAttempts
+	query := fmt.Sprintf(`INSERT INTO %[1]s (attempt_key, failures, last_failure_at) VALUES ($1, 1, $2)
+						  ON CONFLICT (attempt_key) DO UPDATE SET last_failure_at = $2,
+						  failures = CASE WHEN %[1]s.last_failure_at < $3 THEN 1 ELSE %[1]s.failures + 1 END
+						  RETURNING *`, loginAttemptsTable)
+
+	if err := sqlx.Get(repo.db, &attempts, query, key, at, since); err != nil {
+		return model.LoginAttempts{}, postgres.ParsePostgresError(err)
+	}
+
+	return attempts, nil
+}
+
+func (repo *LoginAttemptPostgresqlRepository) Lock(key string, until time.Time) error {
+	query := fmt.Sprintf("UPDATE %s SET locked_until = $1 WHERE attempt_key = $2", loginAttemptsTable)
+
+	if _, err := repo.db.Exec(query, until, key); err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+	return nil
+}
+
+func (repo *LoginAttemptPostgresqlRepository) Reset(key string) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE attempt_key = $1", loginAttemptsTable)
+
+	if _, err := repo.db.Exec(query, key); err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+	return nil
+}
+
+func (repo *LoginAttemptPostgresqlRepository) CreateFailedLogin(login model.FailedLogin) error {
+	query := fmt.Sprintf("INSERT INTO %s (username, ip, reason, created_at) VALUES ($1, $2, $3, $4)", failedLoginsTable)
+
+	if _, err := repo.db.Exec(query, login.Username, login.IP, login.Reason, login.CreatedAt); err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+	return nil
+}
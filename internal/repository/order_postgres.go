@@ -5,10 +5,21 @@ import (
 	"market/internal/model"
 	"market/pkg/database/postgres"
 	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
 
+// orderColumns lists the columns of orders aliased as o selected into
+// model.Order.
+const orderColumns = `o.id, o.user_id, o.status, o.created_at, o.delivered_at, ROW(o.subtotal, o.currency) AS subtotal,
+	ROW(o.tax, o.currency) AS tax, ROW(o.shipping, o.currency) AS shipping, ROW(o.total, o.currency) AS total`
+
+// orderLineColumns lists the columns of products_orders selected into
+// model.OrderLine.
+const orderLineColumns = `id, order_id, product_id, variant_id, seller_id, title, sku,
+	ROW(unit_price, currency) AS unit_price, purchased_amount`
+
 type OrderPostgresqlRepository struct {
 	db *sqlx.DB
 }
@@ -17,36 +28,36 @@ func NewOrderPostgresqlRepo(db *sqlx.DB) *OrderPostgresqlRepository {
 	return &OrderPostgresqlRepository{db: db}
 }
 
+// Create stores the order with its lines and the first entry of its status
+// history, takes the purchased items from stock and empties the cart. Lines
+// that are out of stock by now fail the order with model.OutOfStockError.
 func (repo *OrderPostgresqlRepository) Create(cartID, userID int, order model.Order) (int, error) {
-	tx, err := repo.db.Begin()
+	tx, err := repo.db.Beginx()
 	if err != nil {
 		return 0, postgres.ParsePostgresError(err)
 	}
+	defer tx.Rollback() //nolint:errcheck
 
-	defer func() (int, error) {
-		if err := tx.Rollback(); err != nil {
-			return 0, postgres.ParsePostgresError(err)
-		}
-		return 0, nil
-	}()
-
-	query := fmt.Sprintf("INSERT INTO %s (created_at, delivered_at, user_id) VALUES ($1, $2, $3) RETURNING id", ordersTable)
-	row := tx.QueryRow(query, order.CreatedAt, order.DeliveredAt, userID)
+	query := fmt.Sprintf(`INSERT INTO %s (user_id, status, created_at, subtotal, tax, shipping, total, currency)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`, ordersTable)
+	row := tx.QueryRow(query, userID, order.Status, order.CreatedAt, order.Subtotal, order.Tax, order.Shipping, order.Total, order.Total.Currency)
 	if err = row.Scan(&order.ID); err != nil {
 		return 0, postgres.ParsePostgresError(err)
 	}
 
-	if len(order.Products) != 0 {
+	if len(order.Lines) != 0 {
 		var insertQueryBuilder strings.Builder
 
-		insertQueryBuilder.WriteString(fmt.Sprintf("INSERT INTO %s (order_id, product_id, purchased_amount)", productsOrdersTable))
+		insertQueryBuilder.WriteString(fmt.Sprintf(`INSERT INTO %s (order_id, product_id, variant_id, purchased_amount, seller_id, title, sku, unit_price, currency)
+			VALUES `, productsOrdersTable))
 
 		args := []interface{}{}
 		argID := 1
-		for _, prod := range order.Products {
-			args = append(args, order.ID, prod.ID, prod.PurchasedAmount)
-			insertQueryBuilder.WriteString(fmt.Sprintf(`($%d,$%d,$%d),`, argID, argID+1, argID+2)) //nolint:gomnd
-			argID += 3
+		for _, line := range order.Lines {
+			args = append(args, order.ID, line.ProductID, line.VariantID, line.Quantity, line.SellerID, line.Title, line.SKU, line.UnitPrice, line.UnitPrice.Currency)
+			insertQueryBuilder.WriteString(fmt.Sprintf(`($%d,$%d,$%d,$%d,$%d,$%d,$%d,$%d,$%d),`,
+				argID, argID+1, argID+2, argID+3, argID+4, argID+5, argID+6, argID+7, argID+8)) //nolint:gomnd
+			argID += 9
 		}
 
 		query = strings.TrimSuffix(insertQueryBuilder.String(), ",")
@@ -56,17 +67,20 @@ func (repo *OrderPostgresqlRepository) Create(cartID, userID int, order model.Or
 		}
 	}
 
-	query = fmt.Sprintf(`UPDATE %s AS p
-						 SET amount = p.amount - pc.purchased_amount
-					     FROM %s AS pc 
-						 WHERE pc.product_id = p.id AND pc.cart_id = $1`, productsTable, productsCartsTable)
-	if _, err = tx.Exec(query, cartID); err != nil {
+	query = fmt.Sprintf("INSERT INTO %s (order_id, to_status, changed_by, created_at) VALUES ($1, $2, $3, $4)", orderHistoryTable)
+	if _, err = tx.Exec(query, order.ID, order.Status, userID, order.CreatedAt); err != nil {
 		return 0, postgres.ParsePostgresError(err)
 	}
 
-	query = fmt.Sprintf(`DELETE FROM %s WHERE cart_id = $1`, productsCartsTable)
-	if _, err = tx.Exec(query, cartID); err != nil {
-		return 0, postgres.ParsePostgresError(err)
+	// Emptying the cart first keeps the sweeper from releasing the
+	// reservations of its lines meanwhile, they're released here.
+	held, err := deleteCartLines(tx, "cart_id = $1", cartID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = takeStock(tx, order.Lines, held); err != nil {
+		return 0, err
 	}
 
 	return order.ID, postgres.ParsePostgresError(tx.Commit())
@@ -74,20 +88,30 @@ func (repo *OrderPostgresqlRepository) Create(cartID, userID int, order model.Or
 
 func (repo *OrderPostgresqlRepository) GetAll(userID int, q model.OrderQueryInput) ([]model.Order, error) {
 	var orders []model.Order
-	query := fmt.Sprintf(`SELECT o.id, o.created_at, o.delivered_at FROM %s o
+	conditions := []string{"u.id = $1"}
+
+	condition, orderBy, args, err := keyset(q.QueryInput, orderSortColumns, "o.id", []interface{}{userID})
+	if err != nil {
+		return nil, err
+	}
+	if condition != "" {
+		conditions = append(conditions, condition)
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM %s o
 			              INNER JOIN %s u on o.user_id = u.id
-			              WHERE u.id = $1 ORDER BY %s %s LIMIT $2 OFFSET $3`, ordersTable, usersTable, q.SortBy, q.SortOrder)
+			              %s ORDER BY %s LIMIT $%d OFFSET $%d`, orderColumns, ordersTable, usersTable, whereClause(conditions), orderBy, len(args)+1, len(args)+2)
 
-	if err := repo.db.Select(&orders, query, userID, q.Limit, q.Offset); err != nil {
+	if err := repo.db.Select(&orders, query, append(args, q.Limit, pageOffset(q.QueryInput))...); err != nil {
 		return []model.Order{}, postgres.ParsePostgresError(err)
 	}
 
-	return orders, nil
+	return restoreOrder(q.QueryInput, orders), nil
 }
 
 func (repo *OrderPostgresqlRepository) GetByID(orderID int) (model.Order, error) {
 	var order model.Order
-	query := fmt.Sprintf("SELECT * FROM %s WHERE id = $1", ordersTable)
+	query := fmt.Sprintf("SELECT %s FROM %s o WHERE o.id = $1", orderColumns, ordersTable)
 
 	if err := repo.db.Get(&order, query, orderID); err != nil {
 		return model.Order{}, postgres.ParsePostgresError(err)
@@ -96,16 +120,70 @@ func (repo *OrderPostgresqlRepository) GetByID(orderID int) (model.Order, error)
 	return order, nil
 }
 
-func (repo *OrderPostgresqlRepository) GetProductsByOrderID(orderID int, q model.ProductQueryInput) ([]model.Product, error) {
-	var products []model.Product
-	query := fmt.Sprintf(`SELECT p.id, p.user_id, p.title, p.price, p.tag, p.category, p.description, p.amount, p.created_at, p.updated_at, p.views, p.image_url FROM %s p 
-			              INNER JOIN %s po on po.product_id = p.id
-			              INNER JOIN %s o on po.order_id = o.id
-			              WHERE o.id = $1 ORDER BY %s %s LIMIT $2 OFFSET $3`, productsTable, productsOrdersTable, ordersTable, q.SortBy, q.SortOrder)
+func (repo *OrderPostgresqlRepository) GetLines(orderID int) ([]model.OrderLine, error) {
+	var lines []model.OrderLine
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE order_id = $1 ORDER BY id", orderLineColumns, productsOrdersTable)
+
+	if err := repo.db.Select(&lines, query, orderID); err != nil {
+		return []model.OrderLine{}, postgres.ParsePostgresError(err)
+	}
+
+	return lines, nil
+}
+
+// ChangeStatus moves the order from change.FromStatus to change.ToStatus and
+// records the change in the history. Items of orders that end before being
+// shipped go back to stock. An order that is missing or no longer in
+// FromStatus gives postgres.ErrNotFound.
+func (repo *OrderPostgresqlRepository) ChangeStatus(change model.OrderStatusChange) error {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	var deliveredAt *time.Time
+	if change.ToStatus == model.OrderDelivered {
+		deliveredAt = &change.CreatedAt
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET status = $1, delivered_at = coalesce($2, delivered_at) WHERE id = $3 AND status = $4", ordersTable)
+	if err = execAffected(tx, query, change.ToStatus, deliveredAt, change.OrderID, change.FromStatus); err != nil {
+		return err
+	}
+
+	if change.FromStatus != nil && model.ReturnsStock(*change.FromStatus, change.ToStatus) {
+		// Lines of variants keep their SKU after the variant is deleted, their
+		// items don't go back to the stock of the product.
+		query = fmt.Sprintf(`UPDATE %s AS p SET amount = p.amount + po.purchased_amount FROM %s AS po
+			WHERE po.product_id = p.id AND po.order_id = $1 AND po.variant_id IS NULL AND po.sku IS NULL`, productsTable, productsOrdersTable)
+		if _, err = tx.Exec(query, change.OrderID); err != nil {
+			return postgres.ParsePostgresError(err)
+		}
+
+		query = fmt.Sprintf(`UPDATE %s AS v SET amount = v.amount + po.purchased_amount FROM %s AS po
+			WHERE po.variant_id = v.id AND po.order_id = $1`, productVariantsTable, productsOrdersTable)
+		if _, err = tx.Exec(query, change.OrderID); err != nil {
+			return postgres.ParsePostgresError(err)
+		}
+	}
+
+	query = fmt.Sprintf(`INSERT INTO %s (order_id, from_status, to_status, changed_by, comment, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`, orderHistoryTable)
+	if _, err = tx.Exec(query, change.OrderID, change.FromStatus, change.ToStatus, change.ChangedBy, change.Comment, change.CreatedAt); err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+
+	return postgres.ParsePostgresError(tx.Commit())
+}
+
+func (repo *OrderPostgresqlRepository) GetStatusHistory(orderID int) ([]model.OrderStatusChange, error) {
+	var history []model.OrderStatusChange
+	query := fmt.Sprintf("SELECT * FROM %s WHERE order_id = $1 ORDER BY created_at, id", orderHistoryTable)
 
-	if err := repo.db.Select(&products, query, orderID, q.Limit, q.Offset); err != nil {
-		return []model.Product{}, postgres.ParsePostgresError(err)
+	if err := repo.db.Select(&history, query, orderID); err != nil {
+		return []model.OrderStatusChange{}, postgres.ParsePostgresError(err)
 	}
 
-	return products, nil
+	return history, nil
 }
@@ -0,0 +1,295 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"market/internal/model"
+	"market/pkg/database/postgres"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPostgresDSN names the environment variable with a key=value connection
+// string of a Postgres database for the tests that need a real one, e.g.
+// "host=localhost port=5436 user=postgres password=qwerty dbname=marketDb sslmode=disable".
+// The tests are skipped without it.
+const testPostgresDSN = "TEST_POSTGRES_DSN"
+
+// newTestPostgres loads init_db.sql into a new schema of the test database,
+// which is dropped once the test is over.
+func newTestPostgres(t *testing.T) *sqlx.DB {
+	dsn := os.Getenv(testPostgresDSN)
+	if dsn == "" {
+		t.Skipf("%s is not set", testPostgresDSN)
+	}
+
+	admin, err := sqlx.Connect("postgres", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { admin.Close() })
+
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	_, err = admin.Exec("CREATE SCHEMA " + schema)
+	require.NoError(t, err)
+	t.Cleanup(func() { admin.Exec("DROP SCHEMA " + schema + " CASCADE") }) //nolint:errcheck
+
+	db, err := sqlx.Connect("postgres", dsn+" search_path="+schema)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	initDB, err := os.ReadFile("../../schema/init_db.sql")
+	require.NoError(t, err)
+	_, err = db.Exec(string(initDB))
+	require.NoError(t, err)
+
+	return db
+}
+
+func TestOrderPostgres_ChangeStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %s", err)
+	}
+	defer db.Close()
+
+	r := NewOrderPostgresqlRepo(sqlx.NewDb(db, "sqlmock"))
+
+	status := func(s string) *string { return &s }
+	adminID := 10
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		change  model.OrderStatusChange
+		wantErr error
+	}{{
+		name: "Ship",
+		mock: func() {
+			mock.ExpectBegin()
+			mock.ExpectExec(fmt.Sprintf("UPDATE %s SET status", ordersTable)).
+				WithArgs(model.OrderShipped, nil, 1, model.OrderPaid).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(fmt.Sprintf("INSERT INTO %s", orderHistoryTable)).
+				WithArgs(1, model.OrderPaid, model.OrderShipped, adminID, nil, at).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectCommit()
+		},
+		change: model.OrderStatusChange{OrderID: 1, FromStatus: status(model.OrderPaid), ToStatus: model.OrderShipped, ChangedBy: &adminID, CreatedAt: at},
+	}, {
+		name: "Deliver",
+		mock: func() {
+			mock.ExpectBegin()
+			mock.ExpectExec(fmt.Sprintf("UPDATE %s SET status", ordersTable)).
+				WithArgs(model.OrderDelivered, at, 1, model.OrderShipped).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(fmt.Sprintf("INSERT INTO %s", orderHistoryTable)).
+				WithArgs(1, model.OrderShipped, model.OrderDelivered, adminID, nil, at).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectCommit()
+		},
+		change: model.OrderStatusChange{OrderID: 1, FromStatus: status(model.OrderShipped), ToStatus: model.OrderDelivered, ChangedBy: &adminID, CreatedAt: at},
+	}, {
+		name: "Cancel Returns Stock",
+		mock: func() {
+			mock.ExpectBegin()
+			mock.ExpectExec(fmt.Sprintf("UPDATE %s SET status", ordersTable)).
+				WithArgs(model.OrderCancelled, nil, 1, model.OrderPending).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(fmt.Sprintf("UPDATE %s AS p SET amount = p.amount \\+ po.purchased_amount", productsTable)).
+				WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 2))
+			mock.ExpectExec(fmt.Sprintf("UPDATE %s AS v SET amount = v.amount \\+ po.purchased_amount", productVariantsTable)).
+				WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(fmt.Sprintf("INSERT INTO %s", orderHistoryTable)).
+				WithArgs(1, model.OrderPending, model.OrderCancelled, adminID, nil, at).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectCommit()
+		},
+		change: model.OrderStatusChange{OrderID: 1, FromStatus: status(model.OrderPending), ToStatus: model.OrderCancelled, ChangedBy: &adminID, CreatedAt: at},
+	}, {
+		name: "Status Changed Meanwhile",
+		mock: func() {
+			mock.ExpectBegin()
+			mock.ExpectExec(fmt.Sprintf("UPDATE %s SET status", ordersTable)).
+				WithArgs(model.OrderPaid, nil, 1, model.OrderPending).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectRollback()
+		},
+		change:  model.OrderStatusChange{OrderID: 1, FromStatus: status(model.OrderPending), ToStatus: model.OrderPaid, ChangedBy: &adminID, CreatedAt: at},
+		wantErr: postgres.ErrNotFound,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			err := r.ChangeStatus(tt.change)
+			assert.Equal(t, tt.wantErr, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestOrderPostgres_Create(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %s", err)
+	}
+	defer db.Close()
+
+	r := NewOrderPostgresqlRepo(sqlx.NewDb(db, "sqlmock"))
+
+	productID, otherProductID, variantID := 3, 1, 7
+	price := model.Money{Amount: 500, Currency: "RUB"}
+	order := model.Order{
+		Status: model.OrderPending,
+		Total:  price,
+		Lines: []model.OrderLine{
+			{ProductID: &productID, VariantID: &variantID, Title: "Tee", UnitPrice: price, Quantity: 2},
+			{ProductID: &productID, Title: "Mug", UnitPrice: price, Quantity: 1},
+			{ProductID: &otherProductID, Title: "Cup", UnitPrice: price, Quantity: 4},
+		},
+	}
+
+	expectInserts := func() {
+		mock.ExpectBegin()
+		mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s", ordersTable)).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+		mock.ExpectExec(fmt.Sprintf("INSERT INTO %s", productsOrdersTable)).WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec(fmt.Sprintf("INSERT INTO %s", orderHistoryTable)).WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+	// The cart holds reservations for the cup and for a product dropped from
+	// the order, which is only released.
+	deleteCart := fmt.Sprintf("WITH deleted AS \\(DELETE FROM %s WHERE cart_id = \\$1", productsCartsTable)
+	held := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"product_id", "variant_id", "purchased_amount"}).AddRow(1, nil, 4).AddRow(5, nil, 1)
+	}
+	take := "UPDATE %s SET amount = amount - \\$1, reserved = reserved - \\$2 WHERE id = \\$3 AND amount - reserved \\+ \\$2 >= \\$1"
+	takeProduct, takeVariant := fmt.Sprintf(take, productsTable), fmt.Sprintf(take, productVariantsTable)
+	available := "SELECT greatest\\(amount - reserved, 0\\) \\+ \\$2 FROM %s WHERE id"
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    int
+		wantErr error
+	}{{
+		name: "OK",
+		mock: func() {
+			expectInserts()
+			mock.ExpectQuery(deleteCart).WithArgs(5).WillReturnRows(held())
+			// Products go before variants, both by id.
+			mock.ExpectExec(takeProduct).WithArgs(4, 4, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(takeProduct).WithArgs(1, 0, 3).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(fmt.Sprintf("UPDATE %s SET reserved = reserved - \\$1 WHERE id = \\$2", productsTable)).
+				WithArgs(1, 5).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(takeVariant).WithArgs(2, 0, 7).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+		},
+		want: 9,
+	}, {
+		name: "Out Of Stock",
+		mock: func() {
+			expectInserts()
+			mock.ExpectQuery(deleteCart).WithArgs(5).WillReturnRows(held())
+			mock.ExpectExec(takeProduct).WithArgs(4, 4, 1).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(fmt.Sprintf(available, productsTable)).WithArgs(1, 4).
+				WillReturnRows(sqlmock.NewRows([]string{"available"}).AddRow(3))
+			mock.ExpectExec(takeProduct).WithArgs(1, 0, 3).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(fmt.Sprintf("UPDATE %s SET reserved = reserved - \\$1 WHERE id = \\$2", productsTable)).
+				WithArgs(1, 5).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(takeVariant).WithArgs(2, 0, 7).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(fmt.Sprintf(available, productVariantsTable)).WithArgs(7, 0).
+				WillReturnRows(sqlmock.NewRows([]string{"available"}))
+			mock.ExpectRollback()
+		},
+		wantErr: &model.OutOfStockError{Lines: []model.StockShortage{
+			{ProductID: 1, Title: "Cup", Requested: 4, Available: 3},
+			{ProductID: 3, VariantID: &variantID, Title: "Tee", Requested: 2, Available: 0},
+		}},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, err := r.Create(5, 10, order)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// TestOrderPostgres_Create_Concurrent checks out more carts than there are
+// items in stock at once. Exactly as many orders as there are items must be
+// placed, the rest must fail as out of stock.
+func TestOrderPostgres_Create_Concurrent(t *testing.T) {
+	db := newTestPostgres(t)
+	r := NewOrderPostgresqlRepo(db)
+
+	const stock, buyers = 5, 30
+
+	var categoryID, productID int
+	require.NoError(t, db.Get(&categoryID, "INSERT INTO categories (name, slug, created_at) VALUES ('Mugs', 'mugs', now()) RETURNING id"))
+	require.NoError(t, db.Get(&productID, `INSERT INTO products (user_id, title, price, currency, category_id, amount, created_at, updated_at, views)
+		VALUES (1, 'Mug', 5, 'RUB', $1, $2, now(), now(), 0) RETURNING id`, categoryID, stock))
+
+	users, carts := make([]int, buyers), make([]int, buyers)
+	for i := range users {
+		require.NoError(t, db.Get(&users[i], "INSERT INTO users (role, username, email, password) VALUES ($1, $2, $3, '') RETURNING id",
+			model.USER, fmt.Sprintf("buyer%d", i), fmt.Sprintf("buyer%d@market.local", i)))
+		require.NoError(t, db.Get(&carts[i], "INSERT INTO carts (user_id) VALUES ($1) RETURNING id", users[i]))
+		_, err := db.Exec("INSERT INTO products_carts (product_id, cart_id, purchased_amount) VALUES ($1, $2, 1)", productID, carts[i])
+		require.NoError(t, err)
+	}
+
+	sellerID := 1
+	price := model.Money{Amount: 500, Currency: "RUB"}
+	zero := model.Money{Currency: "RUB"}
+	order := model.Order{
+		Status:    model.OrderPending,
+		CreatedAt: time.Now(),
+		Subtotal:  price,
+		Tax:       zero,
+		Shipping:  zero,
+		Total:     price,
+		Lines:     []model.OrderLine{{ProductID: &productID, SellerID: &sellerID, Title: "Mug", UnitPrice: price, Quantity: 1}},
+	}
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	errs := make([]error, buyers)
+	for i := range users {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			_, errs[i] = r.Create(carts[i], users[i], order)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	placed := 0
+	for _, err := range errs {
+		if err == nil {
+			placed++
+			continue
+		}
+
+		var outOfStock *model.OutOfStockError
+		require.True(t, errors.As(err, &outOfStock), "unexpected error: %v", err)
+		assert.Equal(t, []model.StockShortage{{ProductID: productID, Title: "Mug", Requested: 1, Available: 0}}, outOfStock.Lines)
+	}
+	assert.Equal(t, stock, placed)
+
+	var left, orders, lines, cartLines int
+	require.NoError(t, db.Get(&left, "SELECT amount FROM products WHERE id = $1", productID))
+	require.NoError(t, db.Get(&orders, "SELECT count(*) FROM orders"))
+	require.NoError(t, db.Get(&lines, "SELECT count(*) FROM products_orders"))
+	require.NoError(t, db.Get(&cartLines, "SELECT count(*) FROM products_carts"))
+	assert.Equal(t, 0, left)
+	assert.Equal(t, stock, orders)
+	assert.Equal(t, stock, lines)
+	// Carts of failed checkouts keep their lines.
+	assert.Equal(t, buyers-stock, cartLines)
+}
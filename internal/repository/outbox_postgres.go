@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"fmt"
+	"market/internal/model"
+	"market/pkg/database/postgres"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// The outbox and stored_images tables keep their times in the database clock,
+// so several application instances agree on when a message is due.
+
+type OutboxPostgresqlRepository struct {
+	db *sqlx.DB
+}
+
+func NewOutboxPostgresqlRepo(db *sqlx.DB) *OutboxPostgresqlRepository {
+	return &OutboxPostgresqlRepository{db: db}
+}
+
+// Claim returns up to limit due messages and hides them from other workers for
+// the lease. A message whose worker dies is claimed again once the lease is
+// over.
+func (repo *OutboxPostgresqlRepository) Claim(limit int, lease time.Duration) ([]model.OutboxMessage, error) {
+	messages := make([]model.OutboxMessage, 0)
+	query := fmt.Sprintf(`UPDATE %[1]s SET attempts = attempts + 1, available_at = now() + make_interval(secs => $2)
+		WHERE id IN (SELECT id FROM %[1]s WHERE available_at <= now() ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED)
+		RETURNING id, kind, payload, attempts, last_error, available_at, created_at`, outboxTable)
+
+	if err := repo.db.Select(&messages, query, limit, lease.Seconds()); err != nil {
+		return nil, postgres.ParsePostgresError(err)
+	}
+
+	return messages, nil
+}
+
+// Retry records the failure of the message and makes it due again after delay.
+func (repo *OutboxPostgresqlRepository) Retry(messageID int, lastError string, delay time.Duration) error {
+	query := fmt.Sprintf("UPDATE %s SET last_error = $1, available_at = now() + make_interval(secs => $2) WHERE id = $3", outboxTable)
+
+	return execAffected(repo.db, query, lastError, delay.Seconds(), messageID)
+}
+
+func (repo *OutboxPostgresqlRepository) Delete(messageID int) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1", outboxTable)
+
+	return execAffected(repo.db, query, messageID)
+}
+
+// enqueueImageDeletions adds a deletion message for every image ID selected by
+// the query and returns their number. Call it in the transaction removing the
+// references to the images.
+func enqueueImageDeletions(db sqlx.Execer, imageIDs string, args ...interface{}) (int, error) {
+	query := fmt.Sprintf("INSERT INTO %s (kind, payload) SELECT '%s', image_id FROM (%s) images",
+		outboxTable, model.OutboxImageDelete, imageIDs)
+
+	res, err := db.Exec(query, args...)
+	if err != nil {
+		return 0, postgres.ParsePostgresError(err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, postgres.ParsePostgresError(err)
+	}
+
+	return int(affected), nil
+}
+
+// StoredImagePostgresqlRepository keeps a ledger of the images in the image
+// storage, so images that no row references can be found.
+type StoredImagePostgresqlRepository struct {
+	db *sqlx.DB
+}
+
+func NewStoredImagePostgresqlRepo(db *sqlx.DB) *StoredImagePostgresqlRepository {
+	return &StoredImagePostgresqlRepository{db: db}
+}
+
+func (repo *StoredImagePostgresqlRepository) Add(imageID string) error {
+	query := fmt.Sprintf("INSERT INTO %s (image_id) VALUES ($1) ON CONFLICT DO NOTHING", storedImagesTable)
+
+	if _, err := repo.db.Exec(query, imageID); err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+
+	return nil
+}
+
+func (repo *StoredImagePostgresqlRepository) Remove(imageID string) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE image_id = $1", storedImagesTable)
+
+	if _, err := repo.db.Exec(query, imageID); err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+
+	return nil
+}
+
+// EnqueueOrphans adds a deletion message for every image older than minAge
+// that neither a product nor a variant references and that isn't queued for
+// deletion yet. It returns the number of queued images.
+func (repo *StoredImagePostgresqlRepository) EnqueueOrphans(minAge time.Duration) (int, error) {
+	query := fmt.Sprintf(`SELECT s.image_id FROM %[1]s s
+		WHERE s.created_at <= now() - make_interval(secs => $1)
+		AND NOT EXISTS (SELECT 1 FROM %[2]s i WHERE i.image_id = s.image_id)
+		AND NOT EXISTS (SELECT 1 FROM %[3]s v WHERE v.image_id = s.image_id)
+		AND NOT EXISTS (SELECT 1 FROM %[4]s o WHERE o.kind = '%[5]s' AND o.payload = s.image_id)`,
+		storedImagesTable, productImagesTable, productVariantsTable, outboxTable, model.OutboxImageDelete)
+
+	return enqueueImageDeletions(repo.db, query, minAge.Seconds())
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"market/internal/model"
+	"strings"
+)
+
+// keyset returns the condition selecting the page after (or before) the query
+// cursor and the matching ORDER BY expression. The condition is empty when the
+// query has no cursor.
+func keyset(q model.QueryInput, columns sortColumns, idColumn string, args []interface{}) (string, string, []interface{}, error) {
+	o, err := columns.ordering(q.Sort, idColumn)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	if q.Cursor == nil {
+		return "", o.String(), args, nil
+	}
+
+	if len(q.Cursor.Values) != len(q.Sort) {
+		return "", "", nil, model.ErrInvalidCursor
+	}
+
+	if q.Cursor.Backward {
+		o = o.reverse()
+	}
+
+	first := len(args) + 1
+	for _, value := range q.Cursor.Values {
+		args = append(args, value)
+	}
+	args = append(args, q.Cursor.ID)
+
+	return o.after(first), o.String(), args, nil
+}
+
+// pageOffset ignores the offset of cursor-paginated queries.
+func pageOffset(q model.QueryInput) int {
+	if q.Cursor != nil {
+		return 0
+	}
+	return q.Offset
+}
+
+// restoreOrder puts the rows of a backward page, which are selected in reverse,
+// back into the requested order.
+func restoreOrder[T any](q model.QueryInput, rows []T) []T {
+	if q.Cursor == nil || !q.Cursor.Backward {
+		return rows
+	}
+
+	for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+		rows[i], rows[j] = rows[j], rows[i]
+	}
+	return rows
+}
+
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(conditions, " AND ")
+}
@@ -2,13 +2,40 @@ package repository
 
 import (
 	"fmt"
+	"html"
 	"market/internal/model"
 	"market/pkg/database/postgres"
 	"strings"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
+// productColumns lists the product columns selected into model.Product, along
+// with the name of the product's category and the URL and thumbnails of its
+// primary image. The price is selected as a row of the amount and the currency,
+// which model.Money scans.
+// The generated search_vector column is left out on purpose.
+const productColumns = "id, user_id, title, ROW(price, currency) AS price, currency, tag, category_id, " +
+	"(SELECT name FROM " + categoriesTable + " WHERE " + categoriesTable + ".id = " + productsTable + ".category_id) AS category, " +
+	"description, amount, greatest(amount - reserved, 0) AS available, created_at, updated_at, views, " + productImageColumns
+
+// productCategoryColumn selects the category name in queries joining products as p.
+const productCategoryColumn = "(SELECT name FROM " + categoriesTable + " WHERE " + categoriesTable + ".id = p.category_id) AS category"
+
+// productLineColumns lists the columns of a cart line, given the alias of the
+// line table, in queries joining products as p and the chosen variant as v.
+// The variant's price, stock and image with its thumbnails take precedence
+// over the product's price, stock and primary image.
+func productLineColumns(line string) string {
+	return fmt.Sprintf(`p.id, p.user_id, p.title, ROW(coalesce(v.price, p.price), p.currency) AS price, p.currency, p.tag, p.category_id, %[1]s, p.description,
+		coalesce(v.amount, p.amount) AS amount, greatest(coalesce(v.amount - v.reserved, p.amount - p.reserved), 0) AS available,
+		%[2]s.purchased_amount, %[2]s.reserved_until, %[2]s.variant_id, v.sku, p.created_at, p.updated_at, p.views,
+		coalesce(v.image_url, (SELECT image_url FROM %[3]s WHERE product_id = p.id AND is_primary), '') AS image_url,
+		CASE WHEN v.image_url IS NULL THEN (SELECT thumbnails FROM %[3]s WHERE product_id = p.id AND is_primary) ELSE v.thumbnails END AS thumbnails`,
+		productCategoryColumn, line, productImagesTable)
+}
+
 type ProductPostgresqlRepository struct {
 	db *sqlx.DB
 }
@@ -17,85 +44,153 @@ func NewProductPostgresqlRepo(db *sqlx.DB) *ProductPostgresqlRepository {
 	return &ProductPostgresqlRepository{db: db}
 }
 
+// Create stores the product along with its images. The first image becomes the
+// primary one.
 func (repo *ProductPostgresqlRepository) Create(product model.Product) (int, error) {
-	var productID int
-	query := fmt.Sprintf("INSERT INTO %s (user_id, title, price, tag, category, description, amount, created_at, updated_at, views, image_url, image_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id", productsTable)
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return 0, postgres.ParsePostgresError(err)
+	}
+	defer tx.Rollback() //nolint:errcheck
 
-	row := repo.db.QueryRow(query, product.UserID, product.Title, product.Price, product.Tag, product.Category, product.Description, product.Amount, product.CreatedAt, product.UpdatedAt, product.Views, product.ImageURL, product.ImageID)
-	if err := row.Scan(&productID); err != nil {
+	query := fmt.Sprintf("INSERT INTO %s (user_id, title, price, currency, tag, category_id, description, amount, created_at, updated_at, views) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id", productsTable)
+
+	row := tx.QueryRow(query, product.UserID, product.Title, product.Price, product.Price.Currency, product.Tag, product.CategoryID, product.Description, product.Amount, product.CreatedAt, product.UpdatedAt, product.Views)
+	if err = row.Scan(&product.ID); err != nil {
 		return 0, postgres.ParsePostgresError(err)
 	}
 
-	return productID, nil
+	for i, image := range product.Images {
+		image.ProductID = product.ID
+		image.Position = i
+		image.Primary = i == 0
+		image.CreatedAt = product.CreatedAt
+		if _, err = insertProductImage(tx, image); err != nil {
+			return 0, err
+		}
+	}
+
+	return product.ID, postgres.ParsePostgresError(tx.Commit())
 }
 
 func (repo *ProductPostgresqlRepository) GetAll(q model.ProductQueryInput) ([]model.Product, error) {
-	var products []model.Product
+	return repo.getAll(q)
+}
 
-	query := fmt.Sprintf("SELECT * FROM %s ORDER BY %s %s LIMIT $1 OFFSET $2", productsTable, q.SortBy, q.SortOrder)
+func (repo *ProductPostgresqlRepository) GetProductsByUserID(userID int, q model.ProductQueryInput) ([]model.Product, error) {
+	q.SellerID = userID
+	return repo.getAll(q)
+}
 
-	if err := repo.db.Select(&products, query, q.Limit, q.Offset); err != nil {
-		return nil, postgres.ParsePostgresError(err)
+func (repo *ProductPostgresqlRepository) GetByID(productID int) (model.Product, error) {
+	var product model.Product
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE id = $1", productColumns, productsTable)
+
+	if err := repo.db.Get(&product, query, productID); err != nil {
+		return model.Product{}, postgres.ParsePostgresError(err)
 	}
 
-	return products, nil
+	return product, nil
 }
 
-func (repo *ProductPostgresqlRepository) GetProductsByUserID(userID int, q model.ProductQueryInput) ([]model.Product, error) {
-	var products []model.Product
-	var setValue string
-	argID := 2
-	args := make([]interface{}, 0)
-	args = append(args, userID)
-	if q.ProductID != 0 {
-		setValue = fmt.Sprintf("AND id!=$%d", argID)
-		args = append(args, q.ProductID)
-		argID++
-	}
+// GetProductsByCategory lists the products of the category and its descendants.
+func (repo *ProductPostgresqlRepository) GetProductsByCategory(categoryID int, q model.ProductQueryInput) ([]model.Product, error) {
+	q.CategoryID = categoryID
+	return repo.getAll(q)
+}
 
-	args = append(args, q.Limit, q.Offset)
+// GetFacets counts the products matching the filter per category, per tag and
+// per price bucket of model.PriceBucketBounds. Prices are bucketed in the
+// currency of the filter's price scale.
+func (repo *ProductPostgresqlRepository) GetFacets(f model.ProductFilter) (model.ProductFacets, error) {
+	conditions, args := productFilterConditions(f, 0)
+	where := whereClause(conditions)
 
-	query := fmt.Sprintf("SELECT * FROM %s WHERE user_id = $1 %s ORDER BY %s %s LIMIT $%d OFFSET $%d", productsTable, setValue, q.SortBy, q.SortOrder, argID, argID+1)
+	facets := model.ProductFacets{
+		Categories: make([]model.FacetCount, 0),
+		Tags:       make([]model.FacetCount, 0),
+	}
 
-	if err := repo.db.Select(&products, query, args...); err != nil {
-		return nil, postgres.ParsePostgresError(err)
+	query := fmt.Sprintf(`SELECT c.slug AS value, count(*) AS count FROM (SELECT category_id FROM %s %s) p
+		INNER JOIN %s c ON c.id = p.category_id
+		GROUP BY c.slug ORDER BY count DESC, value`, productsTable, where, categoriesTable)
+	if err := repo.db.Select(&facets.Categories, query, args...); err != nil {
+		return model.ProductFacets{}, postgres.ParsePostgresError(err)
 	}
 
-	return products, nil
-}
+	query = fmt.Sprintf(`SELECT tag AS value, count(*) AS count FROM %s %s
+		GROUP BY tag HAVING tag IS NOT NULL ORDER BY count DESC, value`, productsTable, where)
+	if err := repo.db.Select(&facets.Tags, query, args...); err != nil {
+		return model.ProductFacets{}, postgres.ParsePostgresError(err)
+	}
 
-func (repo *ProductPostgresqlRepository) GetByID(productID int) (model.Product, error) {
-	var product model.Product
-	query := fmt.Sprintf("SELECT * FROM %s WHERE id = $1", productsTable)
+	var buckets []struct {
+		Bucket int `db:"bucket"`
+		Count  int `db:"count"`
+	}
+	price, priced := convertedPrice(f.Prices, productsTable), where
+	if f.Prices.Currency != "" {
+		priced = whereClause(append(conditions[:len(conditions):len(conditions)], price+" IS NOT NULL"))
+	}
+	query = fmt.Sprintf(`SELECT width_bucket(%s, $%d::numeric[]) AS bucket, count(*) AS count FROM %s %s
+		GROUP BY bucket`, price, len(args)+1, productsTable, priced)
+	if err := repo.db.Select(&buckets, query, append(args, pq.Array(model.PriceBucketBounds))...); err != nil {
+		return model.ProductFacets{}, postgres.ParsePostgresError(err)
+	}
 
-	if err := repo.db.Get(&product, query, productID); err != nil {
-		return model.Product{}, postgres.ParsePostgresError(err)
+	facets.PriceBuckets = make([]model.PriceBucket, len(model.PriceBucketBounds)+1)
+	for i := range facets.PriceBuckets {
+		facets.PriceBuckets[i].Min = model.MajorUnits(0, f.Prices.Currency)
+		if i > 0 {
+			facets.PriceBuckets[i].Min = model.MajorUnits(model.PriceBucketBounds[i-1], f.Prices.Currency)
+		}
+		if i < len(model.PriceBucketBounds) {
+			max := model.MajorUnits(model.PriceBucketBounds[i], f.Prices.Currency)
+			facets.PriceBuckets[i].Max = &max
+		}
+	}
+	for _, bucket := range buckets {
+		facets.PriceBuckets[bucket.Bucket].Count = bucket.Count
 	}
 
-	return product, nil
+	return facets, nil
 }
 
-func (repo *ProductPostgresqlRepository) GetProductsByCategory(productCategory string, q model.ProductQueryInput) ([]model.Product, error) {
-	var products []model.Product
-	var setValue string
-	argID := 2
-	args := make([]interface{}, 0)
-	args = append(args, productCategory)
-	if q.ProductID != 0 {
-		setValue = fmt.Sprintf("AND id!=$%d", argID)
-		args = append(args, q.ProductID)
-		argID++
+// Search ranks products matching every search term. Each term also matches as a
+// prefix, so partially typed words find results while the user is typing.
+// The generated search_vector can't read the categories table, so the category
+// name is added to it at query time with the same weight as the tag.
+func (repo *ProductPostgresqlRepository) Search(q model.ProductSearchInput) ([]model.ProductSearchResult, error) {
+	var results []model.ProductSearchResult
+	price := convertedPrice(q.Prices, productsTable)
+	orderBy, err := productSearchSortColumns.withPrice(price).orderBy(q.Sort, "id")
+	if err != nil {
+		return nil, err
 	}
 
-	args = append(args, q.Limit, q.Offset)
+	where := "document @@ query"
+	if q.Prices.Currency != "" && q.Sort.Has(model.SortByPrice) {
+		where += " AND " + price + " IS NOT NULL"
+	}
 
-	query := fmt.Sprintf("SELECT * FROM %s WHERE category = $1 %s ORDER BY %s %s LIMIT $%d OFFSET $%d", productsTable, setValue, q.SortBy, q.SortOrder, argID, argID+1)
+	query := fmt.Sprintf(`SELECT %[1]s, ts_rank(document, query) AS rank,
+		ts_headline('simple', translate(title || ' ' || coalesce(description, ''), '%[2]s', ''), query, '%[3]s') AS snippet
+		FROM %[4]s, LATERAL (
+			SELECT search_vector || setweight(to_tsvector('simple', name), 'B') AS document
+			FROM %[5]s WHERE %[5]s.id = %[4]s.category_id
+		) d, to_tsquery('simple', $1) query
+		WHERE %[6]s
+		ORDER BY %[7]s LIMIT $2 OFFSET $3`, productColumns, headlineStart+headlineStop, headlineOptions, productsTable, categoriesTable, where, orderBy)
 
-	if err := repo.db.Select(&products, query, args...); err != nil {
+	if err := repo.db.Select(&results, query, prefixTSQuery(q.Terms()), q.Limit, q.Offset); err != nil {
 		return nil, postgres.ParsePostgresError(err)
 	}
 
-	return products, nil
+	for i := range results {
+		results[i].Snippet = highlightSnippet(results[i].Snippet)
+	}
+
+	return results, nil
 }
 
 func (repo *ProductPostgresqlRepository) Update(productID int, input model.UpdateProductInput) error {
@@ -121,9 +216,9 @@ func (repo *ProductPostgresqlRepository) Update(productID int, input model.Updat
 		argID++
 	}
 
-	if input.Type != nil {
-		setValues = append(setValues, fmt.Sprintf("category=$%d", argID))
-		args = append(args, *input.Type)
+	if input.CategoryID != nil {
+		setValues = append(setValues, fmt.Sprintf("category_id=$%d", argID))
+		args = append(args, *input.CategoryID)
 		argID++
 	}
 
@@ -143,18 +238,6 @@ func (repo *ProductPostgresqlRepository) Update(productID int, input model.Updat
 		setValues = append(setValues, "views=views+1")
 	}
 
-	if input.ImageURL != nil {
-		setValues = append(setValues, fmt.Sprintf("image_url=$%d", argID))
-		args = append(args, *input.ImageURL)
-		argID++
-	}
-
-	if input.ImageURL != nil {
-		setValues = append(setValues, fmt.Sprintf("image_id=$%d", argID))
-		args = append(args, *input.ImageID)
-		argID++
-	}
-
 	if input.UpdatedAt != nil {
 		setValues = append(setValues, fmt.Sprintf("updated_at=$%d", argID))
 		args = append(args, *input.UpdatedAt)
@@ -166,17 +249,170 @@ func (repo *ProductPostgresqlRepository) Update(productID int, input model.Updat
 	query := fmt.Sprintf(`UPDATE %s SET %s WHERE id = $%d`, productsTable, setQuery, argID)
 	args = append(args, productID)
 
-	if _, err := repo.db.Exec(query, args...); err != nil {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if input.Amount != nil {
+		if err = checkReserved(tx, newStockRow(productID, nil), *input.Amount); err != nil {
+			return err
+		}
+	}
+
+	if _, err = tx.Exec(query, args...); err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+
+	if err = tx.Commit(); err != nil {
 		return postgres.ParsePostgresError(err)
 	}
 	return nil
 }
 
+// Delete removes the product and queues the deletion of its images.
 func (repo *ProductPostgresqlRepository) Delete(productID int) error {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if _, err = enqueueImageDeletions(tx, productImagesQuery("id = $1"), productID); err != nil {
+		return err
+	}
+
 	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1", productsTable)
+	if err = execAffected(tx, query, productID); err != nil {
+		return err
+	}
 
-	if _, err := repo.db.Exec(query, productID); err != nil {
-		return postgres.ParsePostgresError(err)
+	return postgres.ParsePostgresError(tx.Commit())
+}
+
+func (repo *ProductPostgresqlRepository) getAll(q model.ProductQueryInput) ([]model.Product, error) {
+	var products []model.Product
+	conditions, args := productFilterConditions(q.ProductFilter, q.ProductID)
+
+	price := convertedPrice(q.Prices, productsTable)
+	if q.Prices.Currency != "" && q.Sort.Has(model.SortByPrice) {
+		conditions = append(conditions, price+" IS NOT NULL")
 	}
-	return nil
+
+	condition, orderBy, args, err := keyset(q.QueryInput, productSortColumns.withPrice(price), "id", args)
+	if err != nil {
+		return nil, err
+	}
+	if condition != "" {
+		conditions = append(conditions, condition)
+	}
+
+	query := fmt.Sprintf("SELECT %s, %s AS price_key FROM %s %s ORDER BY %s LIMIT $%d OFFSET $%d",
+		productColumns, price, productsTable, whereClause(conditions), orderBy, len(args)+1, len(args)+2)
+
+	if err := repo.db.Select(&products, query, append(args, q.Limit, pageOffset(q.QueryInput))...); err != nil {
+		return nil, postgres.ParsePostgresError(err)
+	}
+
+	return restoreOrder(q.QueryInput, products), nil
+}
+
+// productFilterConditions translates the filter into SQL conditions with
+// positional parameters starting at $1. excludeID, when set, leaves that
+// product out.
+func productFilterConditions(f model.ProductFilter, excludeID int) ([]string, []interface{}) {
+	conditions := make([]string, 0)
+	args := make([]interface{}, 0)
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if excludeID != 0 {
+		add("id != $%d", excludeID)
+	}
+	if f.Category != "" {
+		add("category_id IN ("+categorySubtree("slug = $%d")+")", f.Category)
+	}
+	if f.CategoryID != 0 {
+		add("category_id IN ("+categorySubtree("id = $%d")+")", f.CategoryID)
+	}
+	if f.SellerID != 0 {
+		add("user_id = $%d", f.SellerID)
+	}
+	if f.MinPrice != nil {
+		add(convertedPrice(f.Prices, productsTable)+" >= $%d", *f.MinPrice)
+	}
+	if f.MaxPrice != nil {
+		add(convertedPrice(f.Prices, productsTable)+" <= $%d", *f.MaxPrice)
+	}
+	if len(f.Tags) != 0 {
+		add("tag = ANY($%d)", pq.Array(f.Tags))
+	}
+	if f.InStock {
+		conditions = append(conditions, fmt.Sprintf(`CASE WHEN EXISTS (SELECT 1 FROM %[1]s v WHERE v.product_id = %[2]s.id)
+			THEN EXISTS (SELECT 1 FROM %[1]s v WHERE v.product_id = %[2]s.id AND v.amount > v.reserved)
+			ELSE amount > reserved END`, productVariantsTable, productsTable))
+	}
+	if f.CreatedAfter != nil {
+		add("created_at > $%d", *f.CreatedAfter)
+	}
+	if f.MinRating != nil {
+		add(fmt.Sprintf(`id IN (SELECT product_id FROM %s GROUP BY product_id
+			HAVING avg(CASE category WHEN '%s' THEN 5 WHEN '%s' THEN 3 ELSE 1 END) >= $%%d)`,
+			reviewsTable, model.POSITIVE, model.NEUTRAL), *f.MinRating)
+	}
+
+	return conditions, args
+}
+
+// convertedPrice returns an SQL expression of the price of the products in the
+// table converted into the currency of the scale and rounded to its minor unit,
+// which is NULL for products in a currency without a rate. The currency codes
+// are checked against the supported ones and the rates are numbers, so both go
+// into the query as literals.
+func convertedPrice(prices model.PriceScale, table string) string {
+	if prices.Currency == "" {
+		return table + ".price"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "round(%[1]s.price * CASE %[1]s.currency", table)
+	for _, currency := range model.Currencies() {
+		if rate, ok := prices.Rates[currency]; ok {
+			fmt.Fprintf(&b, " WHEN '%s' THEN %s::numeric / %s", currency, rate.Num(), rate.Denom())
+		}
+	}
+	fmt.Fprintf(&b, " END, %d)", model.Exponent(prices.Currency))
+
+	return b.String()
+}
+
+// ts_headline marks matches with control characters instead of tags, since
+// the title and description are written by sellers and have to be escaped
+// before the marks become <b> tags. The characters are removed from the text
+// beforehand, so sellers can't put marks of their own.
+const (
+	headlineStart   = "\x02"
+	headlineStop    = "\x03"
+	headlineOptions = "StartSel=" + headlineStart + ", StopSel=" + headlineStop + ", MaxWords=20, MinWords=5, MaxFragments=2"
+)
+
+var headlineTags = strings.NewReplacer(headlineStart, "<b>", headlineStop, "</b>")
+
+// highlightSnippet HTML-escapes a ts_headline snippet and wraps its matches in
+// <b> tags.
+func highlightSnippet(snippet string) string {
+	return headlineTags.Replace(html.EscapeString(snippet))
+}
+
+// prefixTSQuery joins search terms into a to_tsquery expression where every
+// term has to match as a prefix. Terms must be free of tsquery operators.
+func prefixTSQuery(terms []string) string {
+	parts := make([]string, 0, len(terms))
+	for _, term := range terms {
+		parts = append(parts, term+":*")
+	}
+	return strings.Join(parts, " & ")
 }
@@ -0,0 +1,288 @@
+package repository
+
+import (
+	"fmt"
+	"market/internal/model"
+	"math/big"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProductPostgres_Search(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %s", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	r := NewProductPostgresqlRepo(sqlxDB)
+
+	q := model.ProductSearchInput{
+		QueryInput: model.QueryInput{
+			Limit: 10,
+			Sort:  model.Sort{model.Desc(model.SortByRank)},
+		},
+		Query: "Red sho",
+	}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    []model.ProductSearchResult
+		wantErr bool
+	}{{
+		name: "OK",
+		mock: func() {
+			rows := sqlmock.NewRows([]string{"id", "title", "category", "rank", "snippet"}).
+				AddRow(1, "Red shoes", "shoes", 0.6, "\x02Red\x03 \x02shoes\x03")
+			mock.ExpectQuery("SELECT (.+) ts_rank\\(document, query\\) AS rank, (.+) FROM products, LATERAL \\( "+
+				"SELECT search_vector \\|\\| setweight\\(to_tsvector\\('simple', name\\), 'B'\\) AS document "+
+				"FROM categories WHERE categories.id = products.category_id \\) d, to_tsquery\\('simple', \\$1\\) query "+
+				"WHERE document @@ query ORDER BY rank DESC, id").
+				WithArgs("red:* & sho:*", 10, 0).WillReturnRows(rows)
+		},
+		want: []model.ProductSearchResult{{
+			Product: model.Product{ID: 1, Title: "Red shoes", Category: "shoes"},
+			Rank:    0.6,
+			Snippet: "<b>Red</b> <b>shoes</b>",
+		}},
+	}, {
+		name: "Markup In Title",
+		mock: func() {
+			rows := sqlmock.NewRows([]string{"id", "title", "category", "rank", "snippet"}).
+				AddRow(2, `<script>alert("x")</script> Red shoes`, "shoes", 0.4, `<script>alert("x")</script> `+"\x02Red\x03 & \x02shoes\x03")
+			mock.ExpectQuery("SELECT (.+) FROM products").
+				WithArgs("red:* & sho:*", 10, 0).WillReturnRows(rows)
+		},
+		want: []model.ProductSearchResult{{
+			Product: model.Product{ID: 2, Title: `<script>alert("x")</script> Red shoes`, Category: "shoes"},
+			Rank:    0.4,
+			Snippet: "&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; <b>Red</b> &amp; <b>shoes</b>",
+		}},
+	}, {
+		name: "No Results",
+		mock: func() {
+			mock.ExpectQuery("SELECT (.+) FROM products").
+				WithArgs("red:* & sho:*", 10, 0).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, err := r.Search(q)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPrefixTSQuery(t *testing.T) {
+	q := model.ProductSearchInput{Query: "  iPhone 15 & (pro | !max):* "}
+	assert.Equal(t, "iphone:* & 15:* & pro:* & max:*", prefixTSQuery(q.Terms()))
+}
+
+func TestProductPostgres_GetAllFiltered(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %s", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	r := NewProductPostgresqlRepo(sqlxDB)
+
+	minPrice, minRating := model.Money{Amount: 1000, Currency: "USD"}, float32(4)
+	q := model.ProductQueryInput{
+		QueryInput: model.QueryInput{
+			Limit: 25,
+			Sort:  model.Sort{model.Asc(model.SortByPrice)},
+		},
+		ProductFilter: model.ProductFilter{
+			Category: "phones",
+			MinPrice: &minPrice,
+			// A rouble costs 0.0105 dollars, yen have no rate.
+			Prices:    model.PriceScale{Currency: "USD", Rates: map[string]*big.Rat{"USD": big.NewRat(1, 1), "RUB": big.NewRat(21, 2000)}},
+			Tags:      []string{"new", "sale"},
+			InStock:   true,
+			MinRating: &minRating,
+		},
+	}
+
+	price := `round\(products\.price \* CASE products\.currency WHEN 'RUB' THEN 21::numeric / 2000 WHEN 'USD' THEN 1::numeric / 1 END, 2\)`
+	mock.ExpectQuery(`SELECT (.+), `+price+` AS price_key FROM products WHERE category_id IN \(WITH RECURSIVE tree AS \((.+)\) SELECT id FROM tree\) AND `+price+` >= \$2 AND tag = ANY\(\$3\) `+
+		`AND CASE WHEN EXISTS \(SELECT 1 FROM product_variants v WHERE v.product_id = products.id\)\s+`+
+		`THEN EXISTS \(SELECT 1 FROM product_variants v WHERE v.product_id = products.id AND v.amount > v.reserved\)\s+ELSE amount > reserved END `+
+		`AND id IN \(SELECT product_id FROM reviews GROUP BY product_id\s+HAVING (.+) >= \$4\) AND `+price+` IS NOT NULL ORDER BY `+price+` ASC, id ASC LIMIT \$5 OFFSET \$6`).
+		WithArgs("phones", "10.00", sqlmock.AnyArg(), minRating, 25, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow(1, "Phone"))
+
+	got, err := r.GetAll(q)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Product{{ID: 1, Title: "Phone"}}, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestProductPostgres_GetFacets(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %s", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	r := NewProductPostgresqlRepo(sqlxDB)
+
+	mock.ExpectQuery("SELECT c.slug AS value, count\\(\\*\\) AS count FROM \\(SELECT category_id FROM products WHERE user_id = \\$1\\) p").
+		WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"value", "count"}).AddRow("phones", 2))
+	mock.ExpectQuery("SELECT tag AS value, count\\(\\*\\) AS count FROM products WHERE user_id = \\$1").
+		WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"value", "count"}).AddRow("new", 1))
+	mock.ExpectQuery("SELECT width_bucket\\(round\\(products.price \\* CASE products.currency WHEN 'JPY' THEN 1::numeric / 1 END, 0\\), \\$2::numeric\\[\\]\\) AS bucket, "+
+		"count\\(\\*\\) AS count FROM products WHERE user_id = \\$1 AND (.+) IS NOT NULL").
+		WithArgs(3, sqlmock.AnyArg()).WillReturnRows(sqlmock.NewRows([]string{"bucket", "count"}).AddRow(0, 1).AddRow(4, 1))
+
+	got, err := r.GetFacets(model.ProductFilter{SellerID: 3, Prices: model.PriceScale{Currency: "JPY", Rates: map[string]*big.Rat{"JPY": big.NewRat(1, 1)}}})
+	assert.NoError(t, err)
+	assert.Equal(t, []model.FacetCount{{Value: "phones", Count: 2}}, got.Categories)
+	assert.Equal(t, []model.FacetCount{{Value: "new", Count: 1}}, got.Tags)
+
+	counts := make([]int, 0, len(got.PriceBuckets))
+	for _, bucket := range got.PriceBuckets {
+		counts = append(counts, bucket.Count)
+	}
+	assert.Equal(t, []int{1, 0, 0, 0, 1}, counts)
+	assert.Equal(t, model.Money{Amount: 0, Currency: "JPY"}, got.PriceBuckets[0].Min)
+	assert.Equal(t, model.Money{Amount: 10, Currency: "JPY"}, *got.PriceBuckets[0].Max)
+	assert.Equal(t, model.Money{Amount: 500, Currency: "JPY"}, got.PriceBuckets[4].Min)
+	assert.Nil(t, got.PriceBuckets[4].Max)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestProductPostgres_GetAllCursor(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %s", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	r := NewProductPostgresqlRepo(sqlxDB)
+
+	tests := []struct {
+		name   string
+		cursor model.Cursor
+		mock   func()
+		want   []int
+	}{{
+		name:   "Next Page",
+		cursor: model.Cursor{Sort: "-views", Values: []string{"7"}, ID: 4},
+		mock: func() {
+			mock.ExpectQuery(`SELECT (.+) FROM products WHERE category_id IN \(WITH RECURSIVE tree AS \((.+)\) SELECT id FROM tree\) AND \(views, id\) < \(\$2, \$3\) `+
+				`ORDER BY views DESC, id DESC LIMIT \$4 OFFSET \$5`).
+				WithArgs(7, "7", 4, 2, 0).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3).AddRow(2))
+		},
+		want: []int{3, 2},
+	}, {
+		name:   "Previous Page",
+		cursor: model.Cursor{Sort: "-views", Values: []string{"7"}, ID: 4, Backward: true},
+		mock: func() {
+			mock.ExpectQuery(`SELECT (.+) FROM products WHERE category_id IN \(WITH RECURSIVE tree AS \((.+)\) SELECT id FROM tree\) AND \(views, id\) > \(\$2, \$3\) `+
+				`ORDER BY views ASC, id ASC LIMIT \$4 OFFSET \$5`).
+				WithArgs(7, "7", 4, 2, 0).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5).AddRow(6))
+		},
+		want: []int{6, 5},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			cursor := tt.cursor
+			got, err := r.GetProductsByCategory(7, model.ProductQueryInput{
+				QueryInput: model.QueryInput{
+					Limit:  2,
+					Offset: 50,
+					Sort:   model.Sort{model.Desc(model.SortByViews)},
+					Cursor: &cursor,
+				},
+			})
+			assert.NoError(t, err)
+
+			ids := make([]int, 0, len(got))
+			for _, product := range got {
+				ids = append(ids, product.ID)
+			}
+			assert.Equal(t, tt.want, ids)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestProductPostgres_Update(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %s", err)
+	}
+	defer db.Close()
+
+	r := NewProductPostgresqlRepo(sqlx.NewDb(db, "sqlmock"))
+	lock := fmt.Sprintf("SELECT reserved FROM %s WHERE id = \\$1 FOR UPDATE", productsTable)
+	amount, views := 3, 1
+
+	tests := []struct {
+		name    string
+		mock    func()
+		input   model.UpdateProductInput
+		wantErr error
+	}{{
+		name: "Amount",
+		mock: func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(lock).WithArgs(5).WillReturnRows(sqlmock.NewRows([]string{"reserved"}).AddRow(3))
+			mock.ExpectExec(fmt.Sprintf("UPDATE %s SET amount=\\$1 WHERE id = \\$2", productsTable)).
+				WithArgs(3, 5).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+		},
+		input: model.UpdateProductInput{Amount: &amount},
+	}, {
+		name: "Below Reserved",
+		mock: func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(lock).WithArgs(5).WillReturnRows(sqlmock.NewRows([]string{"reserved"}).AddRow(4))
+			mock.ExpectRollback()
+		},
+		input:   model.UpdateProductInput{Amount: &amount},
+		wantErr: model.ErrBelowReserved,
+	}, {
+		name: "Without Amount",
+		mock: func() {
+			mock.ExpectBegin()
+			mock.ExpectExec(fmt.Sprintf("UPDATE %s SET views=views\\+1 WHERE id = \\$1", productsTable)).
+				WithArgs(5).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+		},
+		input: model.UpdateProductInput{Views: &views},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			err := r.Update(5, tt.input)
+			assert.Equal(t, tt.wantErr, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
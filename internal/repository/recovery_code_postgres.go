@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"fmt"
+	"market/internal/model"
+	"market/pkg/database/postgres"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type RecoveryCodePostgresqlRepository struct {
+	db *sqlx.DB
+}
+
+func NewRecoveryCodePostgresqlRepo(db *sqlx.DB) *RecoveryCodePostgresqlRepository {
+	return &RecoveryCodePostgresqlRepository{db: db}
+}
+
+// Replace drops all recovery codes of the user and stores the given ones.
+func (repo *RecoveryCodePostgresqlRepository) Replace(userID int, codes []string) error {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE user_id = $1", recoveryCodesTable)
+	if _, err = tx.Exec(query, userID); err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+
+	query = fmt.Sprintf("INSERT INTO %s (user_id, code) VALUES ($1, $2)", recoveryCodesTable)
+	for _, code := range codes {
+		if _, err = tx.Exec(query, userID, code); err != nil {
+			return postgres.ParsePostgresError(err)
+		}
+	}
+
+	return postgres.ParsePostgresError(tx.Commit())
+}
+
+func (repo *RecoveryCodePostgresqlRepository) GetUnused(userID int) ([]model.RecoveryCode, error) {
+	var codes []model.RecoveryCode
+	query := fmt.Sprintf("SELECT * FROM %s WHERE user_id = $1 AND used_at IS NULL", recoveryCodesTable)
+
+	if err := repo.db.Select(&codes, query, userID); err != nil {
+		return nil, postgres.ParsePostgresError(err)
+	}
+
+	return codes, nil
+}
+
+// Use marks the code as used. A code that was already used gives postgres.ErrNotFound.
+func (repo *RecoveryCodePostgresqlRepository) Use(codeID int, at time.Time) error {
+	query := fmt.Sprintf("UPDATE %s SET used_at = $1 WHERE id = $2 AND used_at IS NULL", recoveryCodesTable)
+
+	res, err := repo.db.Exec(query, at, codeID)
+	if err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+	if affected == 0 {
+		return postgres.ErrNotFound
+	}
+
+	return nil
+}
@@ -2,19 +2,36 @@ package repository
 
 import (
 	"market/internal/model"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
 
 const (
-	usersTable          = "users"
-	productsTable       = "products"
-	ordersTable         = "orders"
-	reviewsTable        = "reviews"
-	cartsTable          = "carts"
-	productsUsersTable  = "products_users"
-	productsOrdersTable = "products_orders"
-	productsCartsTable  = "products_carts"
+	usersTable           = "users"
+	productsTable        = "products"
+	ordersTable          = "orders"
+	reviewsTable         = "reviews"
+	cartsTable           = "carts"
+	productsUsersTable   = "products_users"
+	productsOrdersTable  = "products_orders"
+	productsCartsTable   = "products_carts"
+	sessionsTable        = "sessions"
+	loginAttemptsTable   = "login_attempts"
+	failedLoginsTable    = "failed_logins"
+	userTokensTable      = "user_tokens"
+	recoveryCodesTable   = "recovery_codes"
+	sellerAppsTable      = "seller_applications"
+	sellerProfilesTable  = "seller_profiles"
+	auditLogTable        = "admin_audit_log"
+	categoriesTable      = "categories"
+	productOptionsTable  = "product_options"
+	productVariantsTable = "product_variants"
+	variantValuesTable   = "product_variant_values"
+	productImagesTable   = "product_images"
+	outboxTable          = "outbox"
+	storedImagesTable    = "stored_images"
+	orderHistoryTable    = "order_status_history"
 )
 
 type ProductRepo interface {
@@ -22,7 +39,9 @@ type ProductRepo interface {
 	GetAll(q model.ProductQueryInput) ([]model.Product, error)
 	GetProductsByUserID(userID int, q model.ProductQueryInput) ([]model.Product, error)
 	GetByID(productID int) (model.Product, error)
-	GetProductsByCategory(productCategory string, q model.ProductQueryInput) ([]model.Product, error)
+	GetProductsByCategory(categoryID int, q model.ProductQueryInput) ([]model.Product, error)
+	Search(q model.ProductSearchInput) ([]model.ProductSearchResult, error)
+	GetFacets(f model.ProductFilter) (model.ProductFacets, error)
 	Update(productID int, input model.UpdateProductInput) error
 	Delete(productID int) error
 }
@@ -31,32 +50,139 @@ type OrderRepo interface {
 	Create(cartID, userID int, order model.Order) (int, error)
 	GetAll(userID int, q model.OrderQueryInput) ([]model.Order, error)
 	GetByID(orderID int) (model.Order, error)
-	GetProductsByOrderID(orderID int, q model.ProductQueryInput) ([]model.Product, error)
+	GetLines(orderID int) ([]model.OrderLine, error)
+	ChangeStatus(change model.OrderStatusChange) error
+	GetStatusHistory(orderID int) ([]model.OrderStatusChange, error)
 }
 
 type ReviewRepo interface {
 	Create(review model.Review) (int, error)
 	Delete(reviewID int) error
-	Update(userID, productID int, input model.UpdateReviewInput) error
+	Update(reviewID int, input model.UpdateReviewInput) error
+	GetByID(productID, reviewID int) (model.Review, error)
 	GetAll(productID int, q model.ReviewQueryInput) ([]model.Review, error)
+	GetAllByUserID(userID int, q model.ReviewQueryInput) ([]model.Review, error)
 	GetReviewIDByProductIDUserID(productID, userID int) (int, error)
 }
 
 type CartRepo interface {
 	Create(userID int) (int, error)
-	AddProduct(cartID, productID, amount int) (int, error)
+	AddProduct(cartID, productID int, variantID *int, amount int, reservedUntil *time.Time) (int, error)
 	GetByUserID(userID int) (model.Cart, error)
-	GetProductByID(cartID, productID int) (model.Product, error)
+	GetProductByID(cartID, productID int, variantID *int) (model.Product, error)
 	GetAllProducts(cartID int, q model.ProductQueryInput) ([]model.Product, error)
-	UpdateProductAmount(cartID, productID, amount int) error
-	DeleteProduct(cartID, productID int) error
+	UpdateProductAmount(cartID, productID int, variantID *int, amount int, reservedUntil *time.Time) error
+	DeleteProduct(cartID, productID int, variantID *int) error
 	DeleteAllProducts(cartID int) error
+	ReleaseExpired(before time.Time, limit int) (int, error)
 }
 
 type UserRepo interface {
 	GetUser(login string) (model.User, error)
 	GetUserByID(userID int) (model.User, error)
+	GetUserByEmail(email string) (model.User, error)
+	GetAll(q model.UserQueryInput) ([]model.User, error)
 	CreateUser(model.User) (int, error)
+	SetEmailVerified(userID int) error
+	UpdateTOTP(userID int, secret *string, enabled bool) error
+	UseTOTPStep(userID int, step int64) error
+	UpdatePassword(userID int, password string) error
+	ChangePassword(userID int, password string) error
+	Update(userID int, input model.UpdateUserInput) error
+	Delete(userID int) error
+}
+
+type SessionRepo interface {
+	Create(session model.Session) (int, error)
+	GetByID(sessionID int) (model.Session, error)
+	GetByRefreshToken(refreshToken string) (model.Session, error)
+	Rotate(sessionID int, session model.Session) (int, error)
+	RevokeFamily(familyID string) error
+	RevokeAll(userID int) error
+}
+
+type LoginAttemptRepo interface {
+	Get(key string) (model.LoginAttempts, error)
+	RegisterFailure(key string, at, since time.Time) (model.LoginAttempts, error)
+	Lock(key string, until time.Time) error
+	Reset(key string) error
+	CreateFailedLogin(login model.FailedLogin) error
+}
+
+type UserTokenRepo interface {
+	Create(token model.UserToken) (int, error)
+	Consume(token, purpose string, at time.Time) (model.UserToken, error)
+}
+
+type RecoveryCodeRepo interface {
+	Replace(userID int, codes []string) error
+	GetUnused(userID int) ([]model.RecoveryCode, error)
+	Use(codeID int, at time.Time) error
+}
+
+type SellerRepo interface {
+	CreateApplication(application model.SellerApplication) (int, error)
+	GetApplicationByID(applicationID int) (model.SellerApplication, error)
+	GetApplications(q model.SellerApplicationQueryInput) ([]model.SellerApplication, error)
+	Approve(applicationID, adminID int, comment *string, at time.Time) error
+	Reject(applicationID, adminID int, comment *string, at time.Time) error
+	GetProfile(userID int) (model.SellerProfile, error)
+}
+
+type CategoryRepo interface {
+	Create(category model.Category) (int, error)
+	GetAll() ([]model.Category, error)
+	GetByID(categoryID int) (model.Category, error)
+	GetBySlug(slug string) (model.Category, error)
+	Update(categoryID int, input model.UpdateCategoryInput) error
+	Delete(categoryID int) error
+}
+
+type ProductImageRepo interface {
+	Add(productID int, images []model.ProductImage, primary bool) error
+	GetAll(productID int) ([]model.ProductImage, error)
+	GetByID(imageID int) (model.ProductImage, error)
+	SetPrimary(productID, imageID int) error
+	Reorder(productID int, imageIDs []int) error
+	Delete(productID, imageID int) error
+}
+
+type VariantRepo interface {
+	CreateOption(option model.ProductOption) (int, error)
+	GetOptions(productID int) ([]model.ProductOption, error)
+	DeleteOption(productID, optionID int) error
+	Create(variant model.ProductVariant) (int, error)
+	GetByID(variantID int) (model.ProductVariant, error)
+	GetAll(productID int) ([]model.ProductVariant, error)
+	HasVariants(productID int) (bool, error)
+	Update(variantID int, input model.UpdateVariantInput) error
+	Delete(variantID int) error
+}
+
+type OutboxRepo interface {
+	Claim(limit int, lease time.Duration) ([]model.OutboxMessage, error)
+	Retry(messageID int, lastError string, delay time.Duration) error
+	Delete(messageID int) error
+}
+
+type StoredImageRepo interface {
+	Add(imageID string) error
+	Remove(imageID string) error
+	EnqueueOrphans(minAge time.Duration) (int, error)
+}
+
+type AuditRepo interface {
+	Create(entry model.AuditEntry) (int, error)
+	GetAll(q model.AuditQueryInput) ([]model.AuditEntry, error)
+}
+
+// AdminRepo applies admin actions together with their audit log entry.
+type AdminRepo interface {
+	ChangeRole(userID int, role string, entry model.AuditEntry) error
+	Ban(userID int, reason string, entry model.AuditEntry) error
+	Unban(userID int, entry model.AuditEntry) error
+	RequirePasswordReset(userID int, entry model.AuditEntry) error
+	Unlock(attempts LoginAttemptRepo, key string, entry model.AuditEntry) error
 }
 
 type Repository struct {
@@ -65,14 +191,38 @@ type Repository struct {
 	ProductRepo
 	UserRepo
 	ReviewRepo
+	SessionRepo
+	LoginAttemptRepo
+	UserTokenRepo
+	RecoveryCodeRepo
+	SellerRepo
+	AuditRepo
+	AdminRepo
+	CategoryRepo
+	VariantRepo
+	ProductImageRepo
+	OutboxRepo
+	StoredImageRepo
 }
 
 func NewRepository(db *sqlx.DB) *Repository {
 	return &Repository{
-		CartRepo:    NewCartPostgresqlRepo(db),
-		OrderRepo:   NewOrderPostgresqlRepo(db),
-		ProductRepo: NewProductPostgresqlRepo(db),
-		UserRepo:    NewUserPostgresqlRepo(db),
-		ReviewRepo:  NewReviewPostgresqlRepo(db),
+		CartRepo:         NewCartPostgresqlRepo(db),
+		OrderRepo:        NewOrderPostgresqlRepo(db),
+		ProductRepo:      NewProductPostgresqlRepo(db),
+		UserRepo:         NewUserPostgresqlRepo(db),
+		ReviewRepo:       NewReviewPostgresqlRepo(db),
+		SessionRepo:      NewSessionPostgresqlRepo(db),
+		LoginAttemptRepo: NewLoginAttemptPostgresqlRepo(db),
+		UserTokenRepo:    NewUserTokenPostgresqlRepo(db),
+		RecoveryCodeRepo: NewRecoveryCodePostgresqlRepo(db),
+		SellerRepo:       NewSellerPostgresqlRepo(db),
+		AuditRepo:        NewAuditPostgresqlRepo(db),
+		AdminRepo:        NewAdminPostgresqlRepo(db),
+		CategoryRepo:     NewCategoryPostgresqlRepo(db),
+		VariantRepo:      NewVariantPostgresqlRepo(db),
+		ProductImageRepo: NewProductImagePostgresqlRepo(db),
+		OutboxRepo:       NewOutboxPostgresqlRepo(db),
+		StoredImageRepo:  NewStoredImagePostgresqlRepo(db),
 	}
 }
@@ -39,7 +39,7 @@ func (repo *ReviewPostgresqlRepository) Delete(reviewID int) error {
 	return nil
 }
 
-func (repo *ReviewPostgresqlRepository) Update(userID, productID int, input model.UpdateReviewInput) error {
+func (repo *ReviewPostgresqlRepository) Update(reviewID int, input model.UpdateReviewInput) error {
 	setValues := make([]string, 0)
 	args := make([]interface{}, 0)
 	argID := 1
@@ -63,8 +63,8 @@ func (repo *ReviewPostgresqlRepository) Update(userID, productID int, input mode
 
 	setQuery := strings.Join(setValues, ", ")
 
-	query := fmt.Sprintf("UPDATE %s SET %s WHERE (user_id = $%d AND product_id = $%d)", reviewsTable, setQuery, argID, argID+1)
-	args = append(args, userID, productID)
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE id = $%d", reviewsTable, setQuery, argID)
+	args = append(args, reviewID)
 
 	if _, err := repo.db.Exec(query, args...); err != nil {
 		return postgres.ParsePostgresError(err)
@@ -72,15 +72,57 @@ func (repo *ReviewPostgresqlRepository) Update(userID, productID int, input mode
 	return nil
 }
 
+func (repo *ReviewPostgresqlRepository) GetByID(productID, reviewID int) (model.Review, error) {
+	var review model.Review
+	query := fmt.Sprintf("SELECT * FROM %s WHERE id = $1 AND product_id = $2", reviewsTable)
+
+	if err := repo.db.Get(&review, query, reviewID, productID); err != nil {
+		return model.Review{}, postgres.ParsePostgresError(err)
+	}
+
+	return review, nil
+}
+
 func (repo *ReviewPostgresqlRepository) GetAll(productID int, q model.ReviewQueryInput) ([]model.Review, error) {
-	var rewiews []model.Review
-	query := fmt.Sprintf("SELECT * FROM %s WHERE product_id = $1 ORDER BY %s %s LIMIT $2 OFFSET $3", reviewsTable, q.SortBy, q.SortOrder)
+	var reviews []model.Review
+	conditions := []string{"product_id = $1"}
+
+	condition, orderBy, args, err := keyset(q.QueryInput, reviewSortColumns, "id", []interface{}{productID})
+	if err != nil {
+		return nil, err
+	}
+	if condition != "" {
+		conditions = append(conditions, condition)
+	}
+
+	query := fmt.Sprintf("SELECT * FROM %s %s ORDER BY %s LIMIT $%d OFFSET $%d", reviewsTable, whereClause(conditions), orderBy, len(args)+1, len(args)+2)
+
+	if err := repo.db.Select(&reviews, query, append(args, q.Limit, pageOffset(q.QueryInput))...); err != nil {
+		return nil, postgres.ParsePostgresError(err)
+	}
+
+	return restoreOrder(q.QueryInput, reviews), nil
+}
+
+func (repo *ReviewPostgresqlRepository) GetAllByUserID(userID int, q model.ReviewQueryInput) ([]model.Review, error) {
+	var reviews []model.Review
+	conditions := []string{"user_id = $1"}
+
+	condition, orderBy, args, err := keyset(q.QueryInput, reviewSortColumns, "id", []interface{}{userID})
+	if err != nil {
+		return nil, err
+	}
+	if condition != "" {
+		conditions = append(conditions, condition)
+	}
+
+	query := fmt.Sprintf("SELECT * FROM %s %s ORDER BY %s LIMIT $%d OFFSET $%d", reviewsTable, whereClause(conditions), orderBy, len(args)+1, len(args)+2)
 
-	if err := repo.db.Select(&rewiews, query, productID, q.Limit, q.Offset); err != nil {
+	if err := repo.db.Select(&reviews, query, append(args, q.Limit, pageOffset(q.QueryInput))...); err != nil {
 		return nil, postgres.ParsePostgresError(err)
 	}
 
-	return rewiews, nil
+	return restoreOrder(q.QueryInput, reviews), nil
 }
 
 func (repo *ReviewPostgresqlRepository) GetReviewIDByProductIDUserID(productID, userID int) (int, error) {
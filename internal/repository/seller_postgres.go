@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"fmt"
+	"market/internal/model"
+	"market/pkg/database/postgres"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type SellerPostgresqlRepository struct {
+	db *sqlx.DB
+}
+
+func NewSellerPostgresqlRepo(db *sqlx.DB) *SellerPostgresqlRepository {
+	return &SellerPostgresqlRepository{db: db}
+}
+
+func (repo *SellerPostgresqlRepository) CreateApplication(application model.SellerApplication) (int, error) {
+	var id int
+	query := fmt.Sprintf(`INSERT INTO %s (user_id, shop_name, contact_email, contact_phone, description, currency, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`, sellerAppsTable)
+
+	row := repo.db.QueryRow(query, application.UserID, application.ShopName, application.ContactEmail, application.ContactPhone,
+		application.Description, application.Currency, model.ApplicationPending, application.CreatedAt)
+	if err := row.Scan(&id); err != nil {
+		return 0, postgres.ParsePostgresError(err)
+	}
+
+	return id, nil
+}
+
+func (repo *SellerPostgresqlRepository) GetApplicationByID(applicationID int) (model.SellerApplication, error) {
+	var application model.SellerApplication
+	query := fmt.Sprintf("SELECT * FROM %s WHERE id = $1", sellerAppsTable)
+
+	if err := repo.db.Get(&application, query, applicationID); err != nil {
+		return model.SellerApplication{}, postgres.ParsePostgresError(err)
+	}
+
+	return application, nil
+}
+
+func (repo *SellerPostgresqlRepository) GetApplications(q model.SellerApplicationQueryInput) ([]model.SellerApplication, error) {
+	var applications []model.SellerApplication
+	orderBy, err := sellerAppSortColumns.orderBy(q.Sort, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`SELECT * FROM %s WHERE ($1 = '' OR status = $1)
+		ORDER BY %s LIMIT $2 OFFSET $3`, sellerAppsTable, orderBy)
+
+	if err := repo.db.Select(&applications, query, q.Status, q.Limit, q.Offset); err != nil {
+		return []model.SellerApplication{}, postgres.ParsePostgresError(err)
+	}
+
+	return applications, nil
+}
+
+// Approve marks a pending application as approved, upgrades the applicant to
+// a seller and creates (or refreshes) their seller profile in one transaction.
+// An application that is missing or no longer pending gives postgres.ErrNotFound.
+func (repo *SellerPostgresqlRepository) Approve(applicationID, adminID int, comment *string, at time.Time) error {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	var application model.SellerApplication
+	query := fmt.Sprintf(`UPDATE %s SET status = $1, comment = $2, reviewed_by = $3, reviewed_at = $4
+		WHERE id = $5 AND status = $6 RETURNING *`, sellerAppsTable)
+	if err = tx.Get(&application, query, model.ApplicationApproved, comment, adminID, at, applicationID, model.ApplicationPending); err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+
+	query = fmt.Sprintf("UPDATE %s SET role = $1 WHERE id = $2 AND role = $3", usersTable)
+	if _, err = tx.Exec(query, model.SELLER, application.UserID, model.USER); err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+
+	query = fmt.Sprintf(`INSERT INTO %s (user_id, shop_name, contact_email, contact_phone, description, currency, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id) DO UPDATE SET shop_name = EXCLUDED.shop_name, contact_email = EXCLUDED.contact_email,
+		contact_phone = EXCLUDED.contact_phone, description = EXCLUDED.description, currency = EXCLUDED.currency`, sellerProfilesTable)
+	if _, err = tx.Exec(query, application.UserID, application.ShopName, application.ContactEmail, application.ContactPhone,
+		application.Description, application.Currency, at); err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+
+	return postgres.ParsePostgresError(tx.Commit())
+}
+
+func (repo *SellerPostgresqlRepository) Reject(applicationID, adminID int, comment *string, at time.Time) error {
+	query := fmt.Sprintf(`UPDATE %s SET status = $1, comment = $2, reviewed_by = $3, reviewed_at = $4
+		WHERE id = $5 AND status = $6`, sellerAppsTable)
+
+	res, err := repo.db.Exec(query, model.ApplicationRejected, comment, adminID, at, applicationID, model.ApplicationPending)
+	if err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+	if affected == 0 {
+		return postgres.ErrNotFound
+	}
+
+	return nil
+}
+
+func (repo *SellerPostgresqlRepository) GetProfile(userID int) (model.SellerProfile, error) {
+	var profile model.SellerProfile
+	query := fmt.Sprintf("SELECT * FROM %s WHERE user_id = $1", sellerProfilesTable)
+
+	if err := repo.db.Get(&profile, query, userID); err != nil {
+		return model.SellerProfile{}, postgres.ParsePostgresError(err)
+	}
+
+	return profile, nil
+}
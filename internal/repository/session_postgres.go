@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"market/internal/model"
+	"market/pkg/database/postgres"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var ErrSessionRevoked = errors.New("session already revoked")
+
+type SessionPostgresqlRepository struct {
+	db *sqlx.DB
+}
+
+func NewSessionPostgresqlRepo(db *sqlx.DB) *SessionPostgresqlRepository {
+	return &SessionPostgresqlRepository{db: db}
+}
+
+func (repo *SessionPostgresqlRepository) Create(session model.Session) (int, error) {
+	var id int
+	query := fmt.Sprintf("INSERT INTO %s (user_id, family_id, refresh_token, created_at, expires_at) VALUES ($1, $2, $3, $4, $5) RETURNING id", sessionsTable)
+
+	row := repo.db.QueryRow(query, session.UserID, session.FamilyID, session.RefreshToken, session.CreatedAt, session.ExpiresAt)
+	if err := row.Scan(&id); err != nil {
+		return 0, postgres.ParsePostgresError(err)
+	}
+
+	return id, nil
+}
+
+func (repo *SessionPostgresqlRepository) GetByID(sessionID int) (model.Session, error) {
+	var session model.Session
+	query := fmt.Sprintf("SELECT * FROM %s WHERE id = $1", sessionsTable)
+
+	if err := repo.db.Get(&session, query, sessionID); err != nil {
+		return model.Session{}, postgres.ParsePostgresError(err)
+	}
+
+	return session, nil
+}
+
+func (repo *SessionPostgresqlRepository) GetByRefreshToken(refreshToken string) (model.Session, error) {
+	var session model.Session
+	query := fmt.Sprintf("SELECT * FROM %s WHERE refresh_token = $1", sessionsTable)
+
+	if err := repo.db.Get(&session, query, refreshToken); err != nil {
+		return model.Session{}, postgres.ParsePostgresError(err)
+	}
+
+	return session, nil
+}
+
+func (repo *SessionPostgresqlRepository) Rotate(sessionID int, session model.Session) (int, error) {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return 0, postgres.ParsePostgresError(err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	query := fmt.Sprintf("UPDATE %s SET revoked = true WHERE id = $1 AND revoked = false", sessionsTable)
+	res, err := tx.Exec(query, sessionID)
+	if err != nil {
+		return 0, postgres.ParsePostgresError(err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, postgres.ParsePostgresError(err)
+	}
+	if affected == 0 {
+		return 0, ErrSessionRevoked
+	}
+
+	var id int
+	query = fmt.Sprintf("INSERT INTO %s (user_id, family_id, refresh_token, created_at, expires_at) VALUES ($1, $2, $3, $4, $5) RETURNING id", sessionsTable)
+	row := tx.QueryRow(query, session.UserID, session.FamilyID, session.RefreshToken, session.CreatedAt, session.ExpiresAt)
+	if err = row.Scan(&id); err != nil {
+		return 0, postgres.ParsePostgresError(err)
+	}
+
+	return id, postgres.ParsePostgresError(tx.Commit())
+}
+
+func (repo *SessionPostgresqlRepository) RevokeFamily(familyID string) error {
+	query := fmt.Sprintf("UPDATE %s SET revoked = true WHERE family_id = $1", sessionsTable)
+
+	if _, err := repo.db.Exec(query, familyID); err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+	return nil
+}
+
+func (repo *SessionPostgresqlRepository) RevokeAll(userID int) error {
+	query := fmt.Sprintf("UPDATE %s SET revoked = true WHERE user_id = $1", sessionsTable)
+
+	if _, err := repo.db.Exec(query, userID); err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"fmt"
+	"market/internal/model"
+	"strings"
+)
+
+// sortColumns is the allow-list of a listing: it maps the sort keys accepted by
+// the API to the expressions rows are ordered by. Sort keys reach SQL only
+// through it, so identifiers from a request are never put into a query.
+type sortColumns map[string]string
+
+var (
+	// The price column is qualified, since the selected price is a row of the
+	// amount and the currency, which would take precedence in ORDER BY.
+	productSortColumns = sortColumns{
+		model.SortByViews: "views",
+		model.SortByPrice: productsTable + ".price",
+		model.SortByDate:  "created_at",
+	}
+	// joinedProductSortColumns is used by queries joining products as p.
+	joinedProductSortColumns = sortColumns{
+		model.SortByViews: "p.views",
+		model.SortByPrice: "p.price",
+		model.SortByDate:  "p.created_at",
+	}
+	productSearchSortColumns = sortColumns{
+		model.SortByRank:  "rank",
+		model.SortByViews: "views",
+		model.SortByPrice: productsTable + ".price",
+		model.SortByDate:  "created_at",
+	}
+	orderSortColumns     = sortColumns{model.SortByDate: "o.created_at"}
+	reviewSortColumns    = sortColumns{model.SortByDate: "created_at"}
+	sellerAppSortColumns = sortColumns{model.SortByDate: "created_at"}
+	auditSortColumns     = sortColumns{model.SortByDate: "created_at"}
+	userSortColumns      = sortColumns{
+		model.SortByDate:     "created_at",
+		model.SortByUsername: "username",
+	}
+)
+
+// withPrice returns a copy of the columns that sorts by the price expression,
+// see convertedPrice.
+func (c sortColumns) withPrice(expr string) sortColumns {
+	columns := make(sortColumns, len(c))
+	for key, column := range c {
+		columns[key] = column
+	}
+	columns[model.SortByPrice] = expr
+	return columns
+}
+
+type orderColumn struct {
+	expr string
+	desc bool
+}
+
+// ordering is an ORDER BY clause made of allow-listed columns. It ends with the
+// ID column, which breaks ties in the direction of the last sort key, so every
+// row has a unique position.
+type ordering []orderColumn
+
+func (c sortColumns) ordering(sort model.Sort, idColumn string) (ordering, error) {
+	if len(sort) == 0 {
+		return nil, model.ErrInvalidSort
+	}
+
+	o := make(ordering, 0, len(sort)+1)
+	for _, field := range sort {
+		expr, ok := c[field.Key]
+		if !ok {
+			return nil, model.ErrInvalidSort
+		}
+		o = append(o, orderColumn{expr: expr, desc: field.Desc})
+	}
+
+	return append(o, orderColumn{expr: idColumn, desc: sort[len(sort)-1].Desc}), nil
+}
+
+// orderBy returns the ORDER BY expression for sort, or model.ErrInvalidSort if
+// it has a key that isn't allowed.
+func (c sortColumns) orderBy(sort model.Sort, idColumn string) (string, error) {
+	o, err := c.ordering(sort, idColumn)
+	if err != nil {
+		return "", err
+	}
+	return o.String(), nil
+}
+
+func (o ordering) String() string {
+	columns := make([]string, len(o))
+	for i, column := range o {
+		columns[i] = column.expr + " " + column.direction()
+	}
+	return strings.Join(columns, ", ")
+}
+
+func (o ordering) reverse() ordering {
+	reversed := make(ordering, len(o))
+	for i, column := range o {
+		reversed[i] = orderColumn{expr: column.expr, desc: !column.desc}
+	}
+	return reversed
+}
+
+// after returns the condition selecting the rows that follow the row whose
+// column values are passed as the placeholders starting at $first.
+func (o ordering) after(first int) string {
+	if o.uniform() {
+		columns := make([]string, len(o))
+		placeholders := make([]string, len(o))
+		for i, column := range o {
+			columns[i] = column.expr
+			placeholders[i] = fmt.Sprintf("$%d", first+i)
+		}
+		return fmt.Sprintf("(%s) %s (%s)", strings.Join(columns, ", "), o[0].operator(), strings.Join(placeholders, ", "))
+	}
+
+	// Mixed directions can't be compared as a row, so the condition is expanded
+	// into "a > $1 OR a = $1 AND (b < $2 OR b = $2 AND ...)".
+	last := len(o) - 1
+	condition := fmt.Sprintf("%s %s $%d", o[last].expr, o[last].operator(), first+last)
+	for i := last - 1; i >= 0; i-- {
+		condition = fmt.Sprintf("(%s %s $%d OR %s = $%d AND %s)",
+			o[i].expr, o[i].operator(), first+i, o[i].expr, first+i, condition)
+	}
+
+	return condition
+}
+
+func (o ordering) uniform() bool {
+	for _, column := range o {
+		if column.desc != o[0].desc {
+			return false
+		}
+	}
+	return true
+}
+
+func (c orderColumn) direction() string {
+	if c.desc {
+		return model.DESCENDING
+	}
+	return model.ASCENDING
+}
+
+func (c orderColumn) operator() string {
+	if c.desc {
+		return "<"
+	}
+	return ">"
+}
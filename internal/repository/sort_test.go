@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"market/internal/model"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortColumns_orderBy(t *testing.T) {
+	tests := []struct {
+		name    string
+		sort    model.Sort
+		want    string
+		wantErr error
+	}{{
+		name: "Single Key",
+		sort: model.Sort{model.Desc(model.SortByViews)},
+		want: "views DESC, id DESC",
+	}, {
+		name: "Multiple Keys",
+		sort: model.Sort{model.Desc(model.SortByPrice), model.Asc(model.SortByDate)},
+		want: "products.price DESC, created_at ASC, id ASC",
+	}, {
+		name:    "Unknown Key",
+		sort:    model.Sort{model.Asc("title")},
+		wantErr: model.ErrInvalidSort,
+	}, {
+		name:    "Injected Key",
+		sort:    model.Sort{model.Asc("price; DROP TABLE products")},
+		wantErr: model.ErrInvalidSort,
+	}, {
+		name:    "Empty",
+		wantErr: model.ErrInvalidSort,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := productSortColumns.orderBy(tt.sort, "id")
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestOrdering_after(t *testing.T) {
+	uniform, err := productSortColumns.ordering(model.Sort{model.Asc(model.SortByPrice), model.Asc(model.SortByDate)}, "id")
+	assert.NoError(t, err)
+	assert.Equal(t, "(products.price, created_at, id) > ($2, $3, $4)", uniform.after(2))
+
+	mixed, err := productSortColumns.ordering(model.Sort{model.Desc(model.SortByPrice), model.Asc(model.SortByDate)}, "id")
+	assert.NoError(t, err)
+	assert.Equal(t, "(products.price < $2 OR products.price = $2 AND (created_at > $3 OR created_at = $3 AND id > $4))", mixed.after(2))
+	assert.Equal(t, "(products.price > $2 OR products.price = $2 AND (created_at < $3 OR created_at = $3 AND id < $4))", mixed.reverse().after(2))
+}
+
+func TestProductPostgres_GetAllMultiSort(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %s", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	r := NewProductPostgresqlRepo(sqlxDB)
+
+	mock.ExpectQuery(`SELECT (.+) FROM products WHERE \(products\.price < \$1 OR products\.price = \$1 AND \(created_at > \$2 OR created_at = \$2 AND id > \$3\)\) `+
+		`ORDER BY products\.price DESC, created_at ASC, id ASC LIMIT \$4 OFFSET \$5`).
+		WithArgs("10", "2024-01-02T00:00:00Z", 4, 2, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+
+	_, err = r.GetAll(model.ProductQueryInput{
+		QueryInput: model.QueryInput{
+			Limit:  2,
+			Sort:   model.Sort{model.Desc(model.SortByPrice), model.Asc(model.SortByDate)},
+			Cursor: &model.Cursor{Sort: "-price,created_at", Values: []string{"10", "2024-01-02T00:00:00Z"}, ID: 4},
+		},
+	})
+	assert.NoError(t, err)
+
+	_, err = r.GetAll(model.ProductQueryInput{
+		QueryInput: model.QueryInput{Limit: 2, Sort: model.Sort{model.Asc("title")}},
+	})
+	assert.Equal(t, model.ErrInvalidSort, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
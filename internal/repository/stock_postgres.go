@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"market/internal/model"
+	"market/pkg/database/postgres"
+	"sort"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// stockRow is the row that keeps the stock of a product, or of its variant.
+type stockRow struct {
+	table string
+	id    int
+}
+
+func newStockRow(productID int, variantID *int) stockRow {
+	if variantID != nil {
+		return stockRow{table: productVariantsTable, id: *variantID}
+	}
+	return stockRow{table: productsTable, id: productID}
+}
+
+// less orders the rows the way every transaction locks them: products before
+// variants, each by ID. Transactions locking several rows in the same order
+// can't deadlock each other.
+func (r stockRow) less(other stockRow) bool {
+	if r.table != other.table {
+		return r.table == productsTable
+	}
+	return r.id < other.id
+}
+
+// reservation is the stock a cart line holds.
+type reservation struct {
+	ProductID int  `db:"product_id"`
+	VariantID *int `db:"variant_id"`
+	Amount    int  `db:"purchased_amount"`
+}
+
+func (r reservation) row() stockRow {
+	return newStockRow(r.ProductID, r.VariantID)
+}
+
+// reserveStock adds amount to the reserved stock of the row unless less is
+// available. A negative amount gives back a part of a reservation.
+func reserveStock(tx *sqlx.Tx, row stockRow, amount int) error {
+	query := fmt.Sprintf("UPDATE %s SET reserved = reserved + $1 WHERE id = $2 AND ($1 <= 0 OR amount - reserved >= $1)", row.table)
+	if err := execAffected(tx, query, amount, row.id); err != nil {
+		if err == postgres.ErrNotFound {
+			return model.ErrOutOfStock
+		}
+		return err
+	}
+
+	return nil
+}
+
+// releaseStock gives the stock held by the reservations back.
+func releaseStock(tx *sqlx.Tx, reservations []reservation) error {
+	sort.Slice(reservations, func(i, j int) bool { return reservations[i].row().less(reservations[j].row()) })
+
+	for _, r := range reservations {
+		row := r.row()
+		query := fmt.Sprintf("UPDATE %s SET reserved = reserved - $1 WHERE id = $2", row.table)
+		if _, err := tx.Exec(query, r.Amount, row.id); err != nil {
+			return postgres.ParsePostgresError(err)
+		}
+	}
+
+	return nil
+}
+
+// stockChange takes the items of an order line from stock and releases the
+// reservation its cart line held. Changes without a line only release.
+type stockChange struct {
+	row     stockRow
+	line    *model.OrderLine
+	release int
+}
+
+// takeStock decrements the stock of every line unless it's too low. Items
+// reserved by the cart lines of the order count as available to it and their
+// reservations are released, including the ones of cart lines that didn't
+// make it into the order. The decrement locks the row until the transaction
+// ends, so concurrent checkouts can't sell the same items twice.
+func takeStock(tx *sqlx.Tx, lines []model.OrderLine, held []reservation) error {
+	reserved := make(map[stockRow]int, len(held))
+	for _, r := range held {
+		reserved[r.row()] += r.Amount
+	}
+
+	changes := make([]stockChange, 0, len(lines)+len(reserved))
+	for i, line := range lines {
+		row := newStockRow(*line.ProductID, line.VariantID)
+		changes = append(changes, stockChange{row: row, line: &lines[i], release: reserved[row]})
+		delete(reserved, row)
+	}
+	for row, amount := range reserved {
+		changes = append(changes, stockChange{row: row, release: amount})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].row.less(changes[j].row) })
+
+	var shortages []model.StockShortage
+	for _, change := range changes {
+		if change.line == nil {
+			query := fmt.Sprintf("UPDATE %s SET reserved = reserved - $1 WHERE id = $2", change.row.table)
+			if _, err := tx.Exec(query, change.release, change.row.id); err != nil {
+				return postgres.ParsePostgresError(err)
+			}
+			continue
+		}
+
+		query := fmt.Sprintf("UPDATE %s SET amount = amount - $1, reserved = reserved - $2 WHERE id = $3 AND amount - reserved + $2 >= $1",
+			change.row.table)
+		err := execAffected(tx, query, change.line.Quantity, change.release, change.row.id)
+		if err == nil {
+			continue
+		}
+		if err != postgres.ErrNotFound {
+			return err
+		}
+
+		// A deleted product or variant has nothing left.
+		var available int
+		query = fmt.Sprintf("SELECT greatest(amount - reserved, 0) + $2 FROM %s WHERE id = $1", change.row.table)
+		if err = tx.Get(&available, query, change.row.id, change.release); err != nil && err != sql.ErrNoRows {
+			return postgres.ParsePostgresError(err)
+		}
+
+		shortages = append(shortages, model.StockShortage{
+			ProductID: *change.line.ProductID,
+			VariantID: change.line.VariantID,
+			Title:     change.line.Title,
+			Requested: change.line.Quantity,
+			Available: available,
+		})
+	}
+
+	if len(shortages) != 0 {
+		return &model.OutOfStockError{Lines: shortages}
+	}
+
+	return nil
+}
+
+// checkReserved locks the stock row and rejects amounts below what carts have
+// reserved, so the stock can't be set under reservations that are checked out
+// later.
+func checkReserved(tx *sqlx.Tx, row stockRow, amount int) error {
+	var reserved int
+	query := fmt.Sprintf("SELECT reserved FROM %s WHERE id = $1 FOR UPDATE", row.table)
+
+	if err := tx.Get(&reserved, query, row.id); err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+
+	if amount < reserved {
+		return model.ErrBelowReserved
+	}
+	return nil
+}
@@ -38,14 +38,157 @@ func (repo *UserPostgresqlRepository) GetUserByID(userID int) (model.User, error
 	return user, nil
 }
 
+func (repo *UserPostgresqlRepository) GetUserByEmail(email string) (model.User, error) {
+	var user model.User
+	query := fmt.Sprintf("SELECT * FROM %s WHERE email = $1", usersTable)
+
+	if err := repo.db.Get(&user, query, email); err != nil {
+		return model.User{}, postgres.ParsePostgresError(err)
+	}
+
+	return user, nil
+}
+
+func (repo *UserPostgresqlRepository) GetAll(q model.UserQueryInput) ([]model.User, error) {
+	var users []model.User
+	orderBy, err := userSortColumns.orderBy(q.Sort, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`SELECT * FROM %s WHERE ($1 = '' OR username ILIKE '%%' || $1 || '%%' OR email ILIKE '%%' || $1 || '%%')
+		AND ($2 = '' OR role = $2) ORDER BY %s LIMIT $3 OFFSET $4`, usersTable, orderBy)
+
+	if err := repo.db.Select(&users, query, q.Search, q.Role, q.Limit, q.Offset); err != nil {
+		return []model.User{}, postgres.ParsePostgresError(err)
+	}
+
+	return users, nil
+}
+
 func (repo *UserPostgresqlRepository) CreateUser(user model.User) (int, error) {
 	var id int
-	query := fmt.Sprintf("INSERT INTO %s (username, role, password) VALUES ($1, $2, $3) RETURNING id", usersTable)
+	query := fmt.Sprintf("INSERT INTO %s (username, role, email, password) VALUES ($1, $2, $3, $4) RETURNING id", usersTable)
 
-	row := repo.db.QueryRow(query, user.Username, user.Role, user.Password)
+	row := repo.db.QueryRow(query, user.Username, user.Role, user.Email, user.Password)
 	if err := row.Scan(&id); err != nil {
 		return 0, postgres.ParsePostgresError(err)
 	}
 
 	return id, nil
 }
+
+// UpdatePassword also clears the password reset requirement set by an admin.
+func (repo *UserPostgresqlRepository) UpdatePassword(userID int, password string) error {
+	query := fmt.Sprintf("UPDATE %s SET password = $1, password_reset_required = false WHERE id = $2", usersTable)
+
+	if _, err := repo.db.Exec(query, password, userID); err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+	return nil
+}
+
+// ChangePassword updates the password and revokes every session of the user in
+// one transaction, so a new password never leaves the old sessions valid.
+func (repo *UserPostgresqlRepository) ChangePassword(userID int, password string) error {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	query := fmt.Sprintf("UPDATE %s SET password = $1, password_reset_required = false WHERE id = $2", usersTable)
+	if err = execAffected(tx, query, password, userID); err != nil {
+		return err
+	}
+
+	if err = revokeSessions(tx, userID); err != nil {
+		return err
+	}
+
+	return postgres.ParsePostgresError(tx.Commit())
+}
+
+func (repo *UserPostgresqlRepository) SetEmailVerified(userID int) error {
+	query := fmt.Sprintf("UPDATE %s SET email_verified = true WHERE id = $1", usersTable)
+
+	if _, err := repo.db.Exec(query, userID); err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+	return nil
+}
+
+func (repo *UserPostgresqlRepository) UpdateTOTP(userID int, secret *string, enabled bool) error {
+	query := fmt.Sprintf("UPDATE %s SET totp_secret = $1, totp_enabled = $2 WHERE id = $3", usersTable)
+
+	if _, err := repo.db.Exec(query, secret, enabled, userID); err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+	return nil
+}
+
+// UseTOTPStep records step as the last accepted TOTP time step. It gives
+// postgres.ErrNotFound when the user already used this or a later step, so a
+// code can't be accepted twice even by concurrent sign-ins.
+func (repo *UserPostgresqlRepository) UseTOTPStep(userID int, step int64) error {
+	query := fmt.Sprintf(`UPDATE %s SET totp_last_step = $1
+		WHERE id = $2 AND (totp_last_step IS NULL OR totp_last_step < $1)`, usersTable)
+	return execAffected(repo.db, query, step, userID)
+}
+
+func (repo *UserPostgresqlRepository) Update(userID int, input model.UpdateUserInput) error {
+	query := fmt.Sprintf("UPDATE %s SET username = $1 WHERE id = $2", usersTable)
+
+	return execAffected(repo.db, query, *input.Username, userID)
+}
+
+// execAffected runs a statement changing a single row and reports
+// postgres.ErrNotFound when no row matched.
+func execAffected(db sqlx.Execer, query string, args ...interface{}) error {
+	res, err := db.Exec(query, args...)
+	if err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+	if affected == 0 {
+		return postgres.ErrNotFound
+	}
+
+	return nil
+}
+
+// Delete removes the user together with their products and queues the deletion
+// of the products' images. Carts, orders, reviews and sessions are removed by
+// the foreign key cascades.
+func (repo *UserPostgresqlRepository) Delete(userID int) error {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if _, err = enqueueImageDeletions(tx, productImagesQuery("user_id = $1"), userID); err != nil {
+		return err
+	}
+
+	// The cascade would drop the cart lines without releasing their reservations.
+	held, err := deleteCartLines(tx, fmt.Sprintf("cart_id IN (SELECT id FROM %s WHERE user_id = $1)", cartsTable), userID)
+	if err != nil {
+		return err
+	}
+
+	if err = releaseStock(tx, held); err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1", usersTable)
+	if err = execAffected(tx, query, userID); err != nil {
+		return err
+	}
+
+	return postgres.ParsePostgresError(tx.Commit())
+}
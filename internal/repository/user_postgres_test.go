@@ -1,8 +1,10 @@
 package repository
 
 import (
+	"errors"
 	"fmt"
 	"market/internal/model"
+	"market/pkg/database/postgres"
 	"testing"
 
 	sqlmock "github.com/DATA-DOG/go-sqlmock"
@@ -31,11 +33,12 @@ func TestUserPostgres_CreateUser(t *testing.T) {
 		mock: func() {
 			rows := sqlmock.NewRows([]string{"id"}).AddRow(1)
 			mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s", usersTable)).
-				WithArgs("Test", "User", "password").WillReturnRows(rows)
+				WithArgs("Test", "User", "test@example.com", "password").WillReturnRows(rows)
 		},
 		input: model.User{
 			Username: "Test",
 			Role:     "User",
+			Email:    "test@example.com",
 			Password: "password",
 		},
 		want: 1,
@@ -44,11 +47,12 @@ func TestUserPostgres_CreateUser(t *testing.T) {
 		mock: func() {
 			rows := sqlmock.NewRows([]string{"id"})
 			mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s", usersTable)).
-				WithArgs("Test", "User", "").WillReturnRows(rows)
+				WithArgs("Test", "User", "test@example.com", "").WillReturnRows(rows)
 		},
 		input: model.User{
 			Username: "Test",
 			Role:     "User",
+			Email:    "test@example.com",
 			Password: "",
 		},
 		wantErr: true,
@@ -189,3 +193,150 @@ func TestUserPostgres_GetUserById(t *testing.T) {
 		})
 	}
 }
+
+func TestUserPostgres_Delete(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %s", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	r := NewUserPostgresqlRepo(sqlxDB)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		input   int
+		wantErr bool
+	}{{
+		name: "OK",
+		mock: func() {
+			mock.ExpectBegin()
+			mock.ExpectExec(fmt.Sprintf("INSERT INTO %s \\(kind, payload\\) SELECT '%s', image_id FROM \\(SELECT image_id FROM %s",
+				outboxTable, model.OutboxImageDelete, productImagesTable)).
+				WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 2))
+			mock.ExpectQuery(fmt.Sprintf("WITH deleted AS \\(DELETE FROM %s", productsCartsTable)).
+				WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"product_id", "variant_id", "purchased_amount"}).AddRow(3, nil, 2))
+			mock.ExpectExec(fmt.Sprintf("UPDATE %s SET reserved = reserved - \\$1 WHERE id = \\$2", productsTable)).
+				WithArgs(2, 3).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(fmt.Sprintf("DELETE FROM %s", usersTable)).
+				WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+		},
+		input: 1,
+	}, {
+		name: "Not Found",
+		mock: func() {
+			mock.ExpectBegin()
+			mock.ExpectExec(fmt.Sprintf("INSERT INTO %s", outboxTable)).
+				WithArgs(404).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(fmt.Sprintf("WITH deleted AS \\(DELETE FROM %s", productsCartsTable)).
+				WithArgs(404).WillReturnRows(sqlmock.NewRows([]string{"product_id", "variant_id", "purchased_amount"}))
+			mock.ExpectExec(fmt.Sprintf("DELETE FROM %s", usersTable)).
+				WithArgs(404).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectRollback()
+		},
+		input:   404,
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			err := r.Delete(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUserPostgres_UseTOTPStep(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %s", err)
+	}
+	defer db.Close()
+
+	r := NewUserPostgresqlRepo(sqlx.NewDb(db, "sqlmock"))
+	query := fmt.Sprintf("UPDATE %s SET totp_last_step = \\$1 WHERE id = \\$2 AND \\(totp_last_step IS NULL OR totp_last_step < \\$1\\)", usersTable)
+
+	tests := []struct {
+		name     string
+		affected int64
+		wantErr  error
+	}{
+		{name: "OK", affected: 1},
+		// The step or a later one was used already.
+		{name: "Used", affected: 0, wantErr: postgres.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock.ExpectExec(query).WithArgs(int64(37037036), 1).WillReturnResult(sqlmock.NewResult(0, tt.affected))
+
+			err := r.UseTOTPStep(1, 37037036)
+			assert.Equal(t, tt.wantErr, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUserPostgres_ChangePassword(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %s", err)
+	}
+	defer db.Close()
+
+	r := NewUserPostgresqlRepo(sqlx.NewDb(db, "sqlmock"))
+	update := fmt.Sprintf("UPDATE %s SET password = \\$1, password_reset_required = false WHERE id = \\$2", usersTable)
+	revoke := fmt.Sprintf("UPDATE %s SET revoked = true WHERE user_id = \\$1", sessionsTable)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{{
+		name: "OK",
+		mock: func() {
+			mock.ExpectBegin()
+			mock.ExpectExec(update).WithArgs("hash", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(revoke).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 3))
+			mock.ExpectCommit()
+		},
+	}, {
+		// The new password isn't kept while the old sessions stay valid.
+		name: "Revoke Failed",
+		mock: func() {
+			mock.ExpectBegin()
+			mock.ExpectExec(update).WithArgs("hash", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(revoke).WithArgs(1).WillReturnError(errors.New("connection reset"))
+			mock.ExpectRollback()
+		},
+		wantErr: errors.New("connection reset"),
+	}, {
+		name: "Not Found",
+		mock: func() {
+			mock.ExpectBegin()
+			mock.ExpectExec(update).WithArgs("hash", 1).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectRollback()
+		},
+		wantErr: postgres.ErrNotFound,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			err := r.ChangePassword(1, "hash")
+			assert.Equal(t, tt.wantErr, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"fmt"
+	"market/internal/model"
+	"market/pkg/database/postgres"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type UserTokenPostgresqlRepository struct {
+	db *sqlx.DB
+}
+
+func NewUserTokenPostgresqlRepo(db *sqlx.DB) *UserTokenPostgresqlRepository {
+	return &UserTokenPostgresqlRepository{db: db}
+}
+
+func (repo *UserTokenPostgresqlRepository) Create(token model.UserToken) (int, error) {
+	var id int
+	query := fmt.Sprintf("INSERT INTO %s (user_id, token, purpose, created_at, expires_at) VALUES ($1, $2, $3, $4, $5) RETURNING id", userTokensTable)
+
+	row := repo.db.QueryRow(query, token.UserID, token.Token, token.Purpose, token.CreatedAt, token.ExpiresAt)
+	if err := row.Scan(&id); err != nil {
+		return 0, postgres.ParsePostgresError(err)
+	}
+
+	return id, nil
+}
+
+// Consume marks the token as used and returns it. Tokens that are unknown,
+// expired, already used or issued for another purpose give postgres.ErrNotFound.
+func (repo *UserTokenPostgresqlRepository) Consume(token, purpose string, at time.Time) (model.UserToken, error) {
+	var userToken model.UserToken
+	query := fmt.Sprintf(`UPDATE %s SET used_at = $1
+		WHERE token = $2 AND purpose = $3 AND used_at IS NULL AND expires_at > $1
+		RETURNING *`, userTokensTable)
+
+	if err := repo.db.Get(&userToken, query, at, token, purpose); err != nil {
+		return model.UserToken{}, postgres.ParsePostgresError(err)
+	}
+
+	return userToken, nil
+}
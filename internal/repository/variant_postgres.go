@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"fmt"
+	"market/internal/model"
+	"market/pkg/database/postgres"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// variantColumns select the price of a variant in the currency of its product.
+const variantColumns = "id, product_id, sku, " +
+	"CASE WHEN price IS NULL THEN NULL ELSE ROW(price, (SELECT currency FROM " + productsTable + " WHERE " + productsTable + ".id = product_id)) END AS price, " +
+	"amount, greatest(amount - reserved, 0) AS available, image_url, image_id, thumbnails, created_at, updated_at"
+
+// variantImageQuery selects the ID of the stored image of a variant.
+const variantImageQuery = "SELECT image_id FROM " + productVariantsTable + " WHERE id = $1 AND image_id IS NOT NULL"
+
+type VariantPostgresqlRepository struct {
+	db *sqlx.DB
+}
+
+func NewVariantPostgresqlRepo(db *sqlx.DB) *VariantPostgresqlRepository {
+	return &VariantPostgresqlRepository{db: db}
+}
+
+func (repo *VariantPostgresqlRepository) CreateOption(option model.ProductOption) (int, error) {
+	var id int
+	query := fmt.Sprintf("INSERT INTO %s (product_id, name, position) VALUES ($1, $2, $3) RETURNING id", productOptionsTable)
+
+	row := repo.db.QueryRow(query, option.ProductID, option.Name, option.Position)
+	if err := row.Scan(&id); err != nil {
+		return 0, postgres.ParsePostgresError(err)
+	}
+
+	return id, nil
+}
+
+func (repo *VariantPostgresqlRepository) GetOptions(productID int) ([]model.ProductOption, error) {
+	options := make([]model.ProductOption, 0)
+	query := fmt.Sprintf("SELECT id, product_id, name, position FROM %s WHERE product_id = $1 ORDER BY position, id", productOptionsTable)
+
+	if err := repo.db.Select(&options, query, productID); err != nil {
+		return nil, postgres.ParsePostgresError(err)
+	}
+
+	return options, nil
+}
+
+// DeleteOption fails with postgres.ErrForeignKey while variants have a value
+// for the option.
+func (repo *VariantPostgresqlRepository) DeleteOption(productID, optionID int) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1 AND product_id = $2", productOptionsTable)
+
+	return execAffected(repo.db, query, optionID, productID)
+}
+
+func (repo *VariantPostgresqlRepository) Create(variant model.ProductVariant) (int, error) {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return 0, postgres.ParsePostgresError(err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	query := fmt.Sprintf(`INSERT INTO %s (product_id, sku, price, amount, image_url, image_id, thumbnails, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`, productVariantsTable)
+	row := tx.QueryRow(query, variant.ProductID, variant.SKU, variant.Price, variant.Amount, variant.ImageURL, variant.ImageID,
+		variant.Thumbnails, variant.CreatedAt, variant.UpdatedAt)
+	if err = row.Scan(&variant.ID); err != nil {
+		return 0, postgres.ParsePostgresError(err)
+	}
+
+	if err = insertVariantAttributes(tx, variant.ID, variant.Attributes); err != nil {
+		return 0, err
+	}
+
+	return variant.ID, postgres.ParsePostgresError(tx.Commit())
+}
+
+func (repo *VariantPostgresqlRepository) GetByID(variantID int) (model.ProductVariant, error) {
+	var variant model.ProductVariant
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE id = $1", variantColumns, productVariantsTable)
+
+	if err := repo.db.Get(&variant, query, variantID); err != nil {
+		return model.ProductVariant{}, postgres.ParsePostgresError(err)
+	}
+
+	variants := []model.ProductVariant{variant}
+	if err := repo.loadAttributes(variants); err != nil {
+		return model.ProductVariant{}, err
+	}
+
+	return variants[0], nil
+}
+
+// GetAll returns the variants of the product in the order they were added.
+func (repo *VariantPostgresqlRepository) GetAll(productID int) ([]model.ProductVariant, error) {
+	variants := make([]model.ProductVariant, 0)
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE product_id = $1 ORDER BY id", variantColumns, productVariantsTable)
+
+	if err := repo.db.Select(&variants, query, productID); err != nil {
+		return nil, postgres.ParsePostgresError(err)
+	}
+
+	if err := repo.loadAttributes(variants); err != nil {
+		return nil, err
+	}
+
+	return variants, nil
+}
+
+func (repo *VariantPostgresqlRepository) HasVariants(productID int) (bool, error) {
+	var exists bool
+	query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE product_id = $1)", productVariantsTable)
+
+	if err := repo.db.Get(&exists, query, productID); err != nil {
+		return false, postgres.ParsePostgresError(err)
+	}
+
+	return exists, nil
+}
+
+// Update applies the set fields of the input. Attributes, when given, replace
+// the current ones. A new image replaces the current one, whose deletion is
+// queued.
+func (repo *VariantPostgresqlRepository) Update(variantID int, input model.UpdateVariantInput) error {
+	setValues := make([]string, 0)
+	args := make([]interface{}, 0)
+	set := func(column string, arg interface{}) {
+		args = append(args, arg)
+		setValues = append(setValues, fmt.Sprintf("%s=$%d", column, len(args)))
+	}
+
+	if input.SKU != nil {
+		set("sku", *input.SKU)
+	}
+	if input.Price != nil {
+		set("price", *input.Price)
+	}
+	if input.Amount != nil {
+		set("amount", *input.Amount)
+	}
+	if input.ImageURL != nil {
+		set("image_url", *input.ImageURL)
+		set("image_id", *input.ImageID)
+		set("thumbnails", input.Thumbnails)
+	}
+	if input.UpdatedAt != nil {
+		set("updated_at", *input.UpdatedAt)
+	}
+
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if input.Amount != nil {
+		if err = checkReserved(tx, stockRow{table: productVariantsTable, id: variantID}, *input.Amount); err != nil {
+			return err
+		}
+	}
+
+	if input.ImageID != nil {
+		if _, err = enqueueImageDeletions(tx, variantImageQuery, variantID); err != nil {
+			return err
+		}
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE id = $%d", productVariantsTable, strings.Join(setValues, ", "), len(args)+1)
+	if err = execAffected(tx, query, append(args, variantID)...); err != nil {
+		return err
+	}
+
+	if len(input.Attributes) != 0 {
+		query = fmt.Sprintf("DELETE FROM %s WHERE variant_id = $1", variantValuesTable)
+		if _, err = tx.Exec(query, variantID); err != nil {
+			return postgres.ParsePostgresError(err)
+		}
+
+		if err = insertVariantAttributes(tx, variantID, input.Attributes); err != nil {
+			return err
+		}
+	}
+
+	return postgres.ParsePostgresError(tx.Commit())
+}
+
+// Delete removes the variant and queues the deletion of its image.
+func (repo *VariantPostgresqlRepository) Delete(variantID int) error {
+	tx, err := repo.db.Beginx()
+	if err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if _, err = enqueueImageDeletions(tx, variantImageQuery, variantID); err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1", productVariantsTable)
+	if err = execAffected(tx, query, variantID); err != nil {
+		return err
+	}
+
+	return postgres.ParsePostgresError(tx.Commit())
+}
+
+// loadAttributes fills in the attributes of the variants, ordered like the
+// options of the product.
+func (repo *VariantPostgresqlRepository) loadAttributes(variants []model.ProductVariant) error {
+	if len(variants) == 0 {
+		return nil
+	}
+
+	ids := make([]interface{}, 0, len(variants))
+	placeholders := make([]string, 0, len(variants))
+	index := make(map[int]int, len(variants))
+	for i, variant := range variants {
+		ids = append(ids, variant.ID)
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(ids)))
+		index[variant.ID] = i
+		variants[i].Attributes = make([]model.VariantAttribute, 0)
+	}
+
+	var attributes []model.VariantAttribute
+	query := fmt.Sprintf(`SELECT vv.variant_id, vv.option_id, o.name AS option, vv.value FROM %s vv
+		INNER JOIN %s o ON o.id = vv.option_id
+		WHERE vv.variant_id IN (%s) ORDER BY o.position, o.id`, variantValuesTable, productOptionsTable, strings.Join(placeholders, ", "))
+	if err := repo.db.Select(&attributes, query, ids...); err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+
+	for _, attribute := range attributes {
+		i := index[attribute.VariantID]
+		variants[i].Attributes = append(variants[i].Attributes, attribute)
+	}
+
+	return nil
+}
+
+func insertVariantAttributes(tx *sqlx.Tx, variantID int, attributes []model.VariantAttribute) error {
+	if len(attributes) == 0 {
+		return nil
+	}
+
+	values := make([]string, 0, len(attributes))
+	args := make([]interface{}, 0, len(attributes)*3) //nolint:gomnd
+	for _, attribute := range attributes {
+		values = append(values, fmt.Sprintf("($%d, $%d, $%d)", len(args)+1, len(args)+2, len(args)+3)) //nolint:gomnd
+		args = append(args, variantID, attribute.OptionID, attribute.Value)
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (variant_id, option_id, value) VALUES %s", variantValuesTable, strings.Join(values, ", "))
+	if _, err := tx.Exec(query, args...); err != nil {
+		return postgres.ParsePostgresError(err)
+	}
+
+	return nil
+}
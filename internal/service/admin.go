@@ -0,0 +1,120 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"market/internal/model"
+	"market/internal/repository"
+	"time"
+)
+
+var ErrSelfAction = errors.New("admins can't perform this action on their own account")
+
+// AdminService manages user accounts on behalf of admins. Every change is
+// recorded in the audit log in the same transaction.
+type AdminService struct {
+	userRepo   repository.UserRepo
+	orderRepo  repository.OrderRepo
+	reviewRepo repository.ReviewRepo
+	auditRepo  repository.AuditRepo
+	adminRepo  repository.AdminRepo
+	users      User
+	throttle   *LoginThrottle
+}
+
+func NewAdminService(userRepo repository.UserRepo, orderRepo repository.OrderRepo, reviewRepo repository.ReviewRepo,
+	auditRepo repository.AuditRepo, adminRepo repository.AdminRepo, users User, throttle *LoginThrottle) *AdminService {
+	return &AdminService{
+		userRepo:   userRepo,
+		orderRepo:  orderRepo,
+		reviewRepo: reviewRepo,
+		auditRepo:  auditRepo,
+		adminRepo:  adminRepo,
+		users:      users,
+		throttle:   throttle,
+	}
+}
+
+func (s *AdminService) GetUsers(q model.UserQueryInput) ([]model.User, error) {
+	return s.userRepo.GetAll(q)
+}
+
+func (s *AdminService) GetUser(userID int) (model.User, error) {
+	return s.userRepo.GetUserByID(userID)
+}
+
+func (s *AdminService) GetUserOrders(userID int, q model.OrderQueryInput) ([]model.Order, error) {
+	return s.orderRepo.GetAll(userID, q)
+}
+
+func (s *AdminService) GetUserReviews(userID int, q model.ReviewQueryInput) ([]model.Review, error) {
+	return s.reviewRepo.GetAllByUserID(userID, q)
+}
+
+// ChangeRole signs the user out everywhere, so the new role is carried by the
+// next access token.
+func (s *AdminService) ChangeRole(adminID, userID int, role string) error {
+	if adminID == userID {
+		return ErrSelfAction
+	}
+
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	return s.adminRepo.ChangeRole(userID, role, auditEntry(adminID, userID, model.AuditChangeRole, fmt.Sprintf("%s -> %s", user.Role, role)))
+}
+
+func (s *AdminService) Ban(adminID, userID int, reason string) error {
+	if adminID == userID {
+		return ErrSelfAction
+	}
+
+	return s.adminRepo.Ban(userID, reason, auditEntry(adminID, userID, model.AuditBan, reason))
+}
+
+func (s *AdminService) Unban(adminID, userID int) error {
+	return s.adminRepo.Unban(userID, auditEntry(adminID, userID, model.AuditUnban, ""))
+}
+
+// ForcePasswordReset blocks sign-in until the user sets a new password with
+// the link sent to their email.
+func (s *AdminService) ForcePasswordReset(adminID, userID int) error {
+	if adminID == userID {
+		return ErrSelfAction
+	}
+
+	if err := s.adminRepo.RequirePasswordReset(userID, auditEntry(adminID, userID, model.AuditForceReset, "")); err != nil {
+		return err
+	}
+
+	return s.users.SendPasswordReset(userID)
+}
+
+func (s *AdminService) Unlock(adminID, userID int) error {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	return s.throttle.UnlockAudited(user.Username, auditEntry(adminID, userID, model.AuditUnlock, ""), s.adminRepo)
+}
+
+func (s *AdminService) GetAuditLog(q model.AuditQueryInput) ([]model.AuditEntry, error) {
+	return s.auditRepo.GetAll(q)
+}
+
+func auditEntry(adminID, userID int, action, details string) model.AuditEntry {
+	entry := model.AuditEntry{
+		AdminID:      adminID,
+		TargetUserID: userID,
+		Action:       action,
+		CreatedAt:    time.Now(),
+	}
+	if details != "" {
+		entry.Details = &details
+	}
+
+	return entry
+}
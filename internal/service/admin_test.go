@@ -0,0 +1,16 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminService_SelfAction(t *testing.T) {
+	// No repositories are needed, the actions are rejected before any change.
+	s := NewAdminService(nil, nil, nil, nil, nil, nil, nil)
+
+	assert.Equal(t, ErrSelfAction, s.ChangeRole(1, 1, "user"))
+	assert.Equal(t, ErrSelfAction, s.Ban(1, 1, "spam"))
+	assert.Equal(t, ErrSelfAction, s.ForcePasswordReset(1, 1))
+}
@@ -5,6 +5,7 @@ import (
 	"market/internal/model"
 	"market/internal/repository"
 	"market/pkg/database/postgres"
+	"time"
 )
 
 var (
@@ -12,110 +13,160 @@ var (
 	ErrInvalidAmount = errors.New("invalid amount")
 )
 
+type CartConfig struct {
+	// ReservationTTL is how long cart lines hold the stock they were added
+	// or last changed with. Zero turns reservations off.
+	ReservationTTL time.Duration
+}
+
 type CartService struct {
 	cartRepo    repository.CartRepo
 	productRepo repository.ProductRepo
-	userRepo    repository.UserRepo
+	variantRepo repository.VariantRepo
+	money       Money
+	config      CartConfig
 }
 
-func NewCartService(cartRepo repository.CartRepo, userRepo repository.UserRepo, productRepo repository.ProductRepo) *CartService {
-	return &CartService{cartRepo: cartRepo, userRepo: userRepo, productRepo: productRepo}
+func NewCartService(cartRepo repository.CartRepo, productRepo repository.ProductRepo, variantRepo repository.VariantRepo, money Money,
+	config CartConfig) *CartService {
+	return &CartService{cartRepo: cartRepo, productRepo: productRepo, variantRepo: variantRepo, money: money, config: config}
 }
 
 func (s *CartService) Create(userID int) (int, error) {
 	return s.cartRepo.Create(userID)
 }
 
-func (s *CartService) AddProduct(userID, cartID, productID, amountToPurchase int) (int, error) {
-	user, err := s.userRepo.GetUserByID(userID)
+// AddProduct puts the product into the cart. Products with variants are added
+// as one of their variants, which has its own stock. With reservations on the
+// amount is reserved for the buyer until the reservation expires.
+func (s *CartService) AddProduct(cartID, productID int, variantID *int, amountToPurchase int) (int, error) {
+	product, err := s.productRepo.GetByID(productID)
 	if err != nil {
 		return 0, err
 	}
-	if user.Role == model.ADMIN || user.ID == userID {
-		product, err := s.productRepo.GetByID(productID)
-		if err != nil {
-			return 0, err
-		}
-		if _, err = s.cartRepo.GetProductByID(cartID, productID); err != nil {
-			switch err {
-			case postgres.ErrNotFound:
-				if product.Amount < amountToPurchase {
-					return 0, ErrInvalidAmount
-				}
-				return s.cartRepo.AddProduct(cartID, productID, amountToPurchase)
-			default:
-				return 0, err
+
+	stock, err := s.stock(product, variantID)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err = s.cartRepo.GetProductByID(cartID, productID, variantID); err != nil {
+		switch err {
+		case postgres.ErrNotFound:
+			if stock < amountToPurchase {
+				return 0, ErrInvalidAmount
+			}
+			id, err := s.cartRepo.AddProduct(cartID, productID, variantID, amountToPurchase, s.reservedUntil())
+			// The stock was reserved by someone else meanwhile.
+			if err == model.ErrOutOfStock {
+				return 0, ErrInvalidAmount
 			}
+			return id, err
+		default:
+			return 0, err
 		}
-		return 0, ErrAddDuplicate
 	}
-
-	return 0, ErrPermissionDenied
+	return 0, ErrAddDuplicate
 }
 
 func (s *CartService) GetByUserID(userID int) (model.Cart, error) {
-	user, err := s.userRepo.GetUserByID(userID)
+	return s.cartRepo.GetByUserID(userID)
+}
+
+func (s *CartService) GetAllProducts(cartID int, q model.ProductQueryInput) ([]model.Product, error) {
+	scale, err := s.money.PriceScale(q.Prices.Currency)
 	if err != nil {
-		return model.Cart{}, err
-	}
-	if user.Role == model.ADMIN || user.ID == userID {
-		return s.cartRepo.GetByUserID(userID)
+		return nil, err
 	}
+	q.Prices = scale
 
-	return model.Cart{}, ErrPermissionDenied
+	return s.cartRepo.GetAllProducts(cartID, q)
 }
 
-func (s *CartService) GetAllProducts(userID, cartID int, q model.ProductQueryInput) ([]model.Product, error) {
-	user, err := s.userRepo.GetUserByID(userID)
+// Total sums up all lines of the cart, in the currency if it's set. See
+// MoneyService.Total.
+func (s *CartService) Total(cartID int, currency string) (model.Money, error) {
+	lines, err := s.cartRepo.GetAllProducts(cartID, model.ProductQueryInput{
+		QueryInput: model.QueryInput{Sort: model.Sort{model.Desc(model.SortByDate)}},
+	})
 	if err != nil {
-		return []model.Product{}, err
-	}
-	if user.Role == model.ADMIN || user.ID == userID {
-		return s.cartRepo.GetAllProducts(cartID, q)
+		return model.Money{}, err
 	}
 
-	return []model.Product{}, ErrPermissionDenied
+	return s.money.Total(lines, currency)
 }
 
-func (s *CartService) UpdateProductAmount(userID, cartID, productID, amountToPurchase int) error {
-	user, err := s.userRepo.GetUserByID(userID)
+func (s *CartService) UpdateProductAmount(cartID, productID int, variantID *int, amountToPurchase int) error {
+	product, err := s.cartRepo.GetProductByID(cartID, productID, variantID)
 	if err != nil {
 		return err
 	}
-	if user.Role == model.ADMIN || user.ID == userID {
-		product, err := s.cartRepo.GetProductByID(cartID, productID)
-		if err != nil {
-			return err
-		}
-		if product.Amount < amountToPurchase {
-			return ErrInvalidAmount
-		}
-		return s.cartRepo.UpdateProductAmount(cartID, productID, amountToPurchase)
+	// The line's own reservation is available to it.
+	available := product.Available
+	if product.ReservedUntil != nil {
+		available += product.PurchasedAmount
+	}
+	if available < amountToPurchase {
+		return ErrInvalidAmount
 	}
 
-	return ErrPermissionDenied
+	err = s.cartRepo.UpdateProductAmount(cartID, productID, variantID, amountToPurchase, s.reservedUntil())
+	if err == model.ErrOutOfStock {
+		return ErrInvalidAmount
+	}
+	return err
 }
 
-func (s *CartService) DeleteProduct(userID, cartID, productID int) error {
-	user, err := s.userRepo.GetUserByID(userID)
-	if err != nil {
-		return err
-	}
-	if user.Role == model.ADMIN || user.ID == userID {
-		return s.cartRepo.DeleteProduct(cartID, productID)
+func (s *CartService) DeleteProduct(cartID, productID int, variantID *int) error {
+	return s.cartRepo.DeleteProduct(cartID, productID, variantID)
+}
+
+func (s *CartService) DeleteAllProducts(cartID int) error {
+	return s.cartRepo.DeleteAllProducts(cartID)
+}
+
+// ReleaseExpiredReservations gives the stock of up to limit expired
+// reservations back and returns how many were released.
+func (s *CartService) ReleaseExpiredReservations(limit int) (int, error) {
+	return s.cartRepo.ReleaseExpired(time.Now(), limit)
+}
+
+// reservedUntil returns the expiry of a reservation made now, or nil when
+// reservations are off.
+func (s *CartService) reservedUntil() *time.Time {
+	if s.config.ReservationTTL <= 0 {
+		return nil
 	}
 
-	return ErrPermissionDenied
+	until := time.Now().Add(s.config.ReservationTTL)
+	return &until
 }
 
-func (s *CartService) DeleteAllProducts(userID, cartID int) error {
-	user, err := s.userRepo.GetUserByID(userID)
+// stock returns how many items of the product, or of its variant, are left
+// and not reserved.
+func (s *CartService) stock(product model.Product, variantID *int) (int, error) {
+	if variantID == nil {
+		hasVariants, err := s.variantRepo.HasVariants(product.ID)
+		if err != nil {
+			return 0, err
+		}
+		if hasVariants {
+			return 0, ErrVariantRequired
+		}
+		return product.Available, nil
+	}
+
+	variant, err := s.variantRepo.GetByID(*variantID)
 	if err != nil {
-		return err
+		if err == postgres.ErrNotFound {
+			return 0, ErrVariantNotFound
+		}
+		return 0, err
 	}
-	if user.Role == model.ADMIN || user.ID == userID {
-		return s.cartRepo.DeleteAllProducts(cartID)
+
+	if variant.ProductID != product.ID {
+		return 0, ErrVariantNotFound
 	}
 
-	return ErrPermissionDenied
+	return variant.Available, nil
 }
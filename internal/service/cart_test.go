@@ -0,0 +1,90 @@
+package service
+
+import (
+	"market/internal/model"
+	"market/internal/repository"
+	"market/pkg/database/postgres"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type cartRepoStub struct {
+	repository.CartRepo
+	// available is the stock the repository can still reserve.
+	available     int
+	reservedUntil *time.Time
+	added         bool
+}
+
+func (r *cartRepoStub) GetProductByID(cartID, productID int, variantID *int) (model.Product, error) {
+	return model.Product{}, postgres.ErrNotFound
+}
+
+func (r *cartRepoStub) AddProduct(cartID, productID int, variantID *int, amount int, reservedUntil *time.Time) (int, error) {
+	if reservedUntil != nil && amount > r.available {
+		return 0, model.ErrOutOfStock
+	}
+	r.reservedUntil, r.added = reservedUntil, true
+	return 1, nil
+}
+
+type cartProductRepoStub struct {
+	repository.ProductRepo
+	product model.Product
+}
+
+func (r *cartProductRepoStub) GetByID(productID int) (model.Product, error) {
+	return r.product, nil
+}
+
+type cartVariantRepoStub struct {
+	repository.VariantRepo
+}
+
+func (r *cartVariantRepoStub) HasVariants(productID int) (bool, error) {
+	return false, nil
+}
+
+func TestCartService_AddProduct(t *testing.T) {
+	tests := []struct {
+		name         string
+		ttl          time.Duration
+		available    int
+		reserved     int
+		wantErr      error
+		wantReserved bool
+	}{
+		{name: "Reserved", ttl: 15 * time.Minute, available: 5, reserved: 5, wantReserved: true},
+		{name: "Reservations Off", available: 5, reserved: 0},
+		{name: "Not Available", ttl: 15 * time.Minute, available: 1, reserved: 1, wantErr: ErrInvalidAmount},
+		// Another cart reserved the stock after it was checked.
+		{name: "Reserved Meanwhile", ttl: 15 * time.Minute, available: 5, reserved: 1, wantErr: ErrInvalidAmount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cartRepo := &cartRepoStub{available: tt.reserved}
+			productRepo := &cartProductRepoStub{product: model.Product{ID: 3, Amount: 5, Available: tt.available}}
+			s := NewCartService(cartRepo, productRepo, &cartVariantRepoStub{}, nil, CartConfig{ReservationTTL: tt.ttl})
+
+			before := time.Now()
+			_, err := s.AddProduct(1, 3, nil, 2)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.False(t, cartRepo.added)
+				return
+			}
+
+			require.True(t, cartRepo.added)
+			if !tt.wantReserved {
+				assert.Nil(t, cartRepo.reservedUntil)
+				return
+			}
+			require.NotNil(t, cartRepo.reservedUntil)
+			assert.WithinDuration(t, before.Add(tt.ttl), *cartRepo.reservedUntil, time.Second)
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"errors"
+	"market/internal/model"
+	"market/internal/repository"
+	"market/pkg/database/postgres"
+	"time"
+)
+
+var (
+	ErrCategoryNotFound = errors.New("category doesn't exist")
+	ErrCategoryExists   = errors.New("category with this slug already exists")
+	ErrCategoryInUse    = errors.New("category has subcategories or products")
+	ErrCategoryCycle    = errors.New("category can't be moved under itself or its subcategory")
+)
+
+type CategoryService struct {
+	categoryRepo repository.CategoryRepo
+}
+
+func NewCategoryService(categoryRepo repository.CategoryRepo) *CategoryService {
+	return &CategoryService{categoryRepo: categoryRepo}
+}
+
+func (s *CategoryService) Create(input model.CategoryInput) (int, error) {
+	slug := input.Slug
+	if slug == "" {
+		slug = model.Slugify(input.Name)
+	}
+
+	if !model.ValidSlug(slug) {
+		return 0, model.ErrInvalidSlug
+	}
+
+	if input.ParentID != nil {
+		if err := s.checkParent(0, *input.ParentID); err != nil {
+			return 0, err
+		}
+	}
+
+	id, err := s.categoryRepo.Create(model.Category{
+		ParentID:    input.ParentID,
+		Name:        input.Name,
+		Slug:        slug,
+		Description: input.Description,
+		CreatedAt:   time.Now(),
+	})
+	if err != nil {
+		if err == postgres.ErrAlreadyExists {
+			return 0, ErrCategoryExists
+		}
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// GetTree returns the root categories with their descendants nested.
+func (s *CategoryService) GetTree() ([]model.Category, error) {
+	categories, err := s.categoryRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	return model.CategoryTree(categories), nil
+}
+
+// GetBySlug returns the category with its descendants nested.
+func (s *CategoryService) GetBySlug(slug string) (model.Category, error) {
+	category, err := s.categoryRepo.GetBySlug(slug)
+	if err != nil {
+		if err == postgres.ErrNotFound {
+			return model.Category{}, ErrCategoryNotFound
+		}
+		return model.Category{}, err
+	}
+
+	categories, err := s.categoryRepo.GetAll()
+	if err != nil {
+		return model.Category{}, err
+	}
+
+	for _, root := range model.CategoryTree(categories) {
+		if found, ok := findCategory(root, category.ID); ok {
+			return found, nil
+		}
+	}
+
+	return category, nil
+}
+
+func (s *CategoryService) Update(categoryID int, input model.UpdateCategoryInput) error {
+	if err := input.Validate(); err != nil {
+		return err
+	}
+
+	if input.ParentID != nil && *input.ParentID != 0 {
+		if err := s.checkParent(categoryID, *input.ParentID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.categoryRepo.Update(categoryID, input); err != nil {
+		switch err {
+		case postgres.ErrNotFound:
+			return ErrCategoryNotFound
+		case postgres.ErrAlreadyExists:
+			return ErrCategoryExists
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Delete removes an empty category. Categories with subcategories or products
+// have to be emptied first, so products never lose their category.
+func (s *CategoryService) Delete(categoryID int) error {
+	if err := s.categoryRepo.Delete(categoryID); err != nil {
+		switch err {
+		case postgres.ErrNotFound:
+			return ErrCategoryNotFound
+		case postgres.ErrForeignKey:
+			return ErrCategoryInUse
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkParent makes sure the new parent exists and isn't the category itself
+// or one of its descendants, which would cut the subtree off the tree.
+func (s *CategoryService) checkParent(categoryID, parentID int) error {
+	if categoryID == parentID {
+		return ErrCategoryCycle
+	}
+
+	categories, err := s.categoryRepo.GetAll()
+	if err != nil {
+		return err
+	}
+
+	parents := make(map[int]*int, len(categories))
+	for _, category := range categories {
+		parents[category.ID] = category.ParentID
+	}
+
+	if _, ok := parents[parentID]; !ok {
+		return ErrCategoryNotFound
+	}
+
+	for id := &parentID; id != nil; id = parents[*id] {
+		if *id == categoryID {
+			return ErrCategoryCycle
+		}
+	}
+
+	return nil
+}
+
+func findCategory(node model.Category, categoryID int) (model.Category, bool) {
+	if node.ID == categoryID {
+		return node, true
+	}
+
+	for _, child := range node.Children {
+		if found, ok := findCategory(child, categoryID); ok {
+			return found, true
+		}
+	}
+
+	return model.Category{}, false
+}
@@ -1,38 +1,123 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"image"
+	"market/internal/model"
+	"market/internal/repository"
+	"market/pkg/imaging"
+	"market/pkg/storage"
 	"mime/multipart"
+	"path"
+	"strings"
 
-	"github.com/cloudinary/cloudinary-go/v2"
-	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+	"github.com/google/uuid"
 )
 
-type ImageServiceCloudinary struct {
-	cloudinary *cloudinary.Cloudinary
+const (
+	maxImageWidth  = 4096
+	maxImageHeight = 4096
+)
+
+// thumbnailSizes lists the thumbnails made for every uploaded image. A
+// thumbnail fits into a square with the given side.
+var thumbnailSizes = []struct {
+	Name string
+	Side int
+}{
+	{Name: "small", Side: 160},
+	{Name: "medium", Side: 480},
+	{Name: "large", Side: 1024},
+}
+
+var (
+	ErrInvalidImage  = errors.New("file must be a JPEG, PNG or WebP image")
+	ErrImageTooLarge = fmt.Errorf("image must be at most %dx%d pixels", maxImageWidth, maxImageHeight)
+)
+
+type ImageService struct {
+	storage         storage.Storage
+	storedImageRepo repository.StoredImageRepo
 }
 
 type ImageData struct {
-	ImageURL string
-	ImageID  string
+	ImageURL   string
+	ImageID    string
+	Thumbnails model.Thumbnails
 }
 
-func NewImageServiceCloudinary(cloudinary *cloudinary.Cloudinary) *ImageServiceCloudinary {
-	return &ImageServiceCloudinary{cloudinary: cloudinary}
+func NewImageService(storage storage.Storage, storedImageRepo repository.StoredImageRepo) *ImageService {
+	return &ImageService{storage: storage, storedImageRepo: storedImageRepo}
 }
 
-func (s *ImageServiceCloudinary) Upload(ctx context.Context, file multipart.File) (ImageData, error) {
-	resp, err := s.cloudinary.Upload.Upload(ctx, file, uploader.UploadParams{})
+// Upload checks that the file is an image, encodes it again to strip its
+// metadata and stores it along with its thumbnails. A thumbnail is stored under
+// the name of the image followed by the size, so Delete can find it by the
+// image ID. The image is recorded as stored, so it's deleted by the outbox
+// reconciler if it never gets referenced.
+func (s *ImageService) Upload(ctx context.Context, file multipart.File) (ImageData, error) {
+	img, format, err := imaging.Decode(file, maxImageWidth, maxImageHeight)
 	if err != nil {
+		switch err {
+		case imaging.ErrUnsupportedFormat:
+			return ImageData{}, ErrInvalidImage
+		case imaging.ErrTooLarge:
+			return ImageData{}, ErrImageTooLarge
+		default:
+			return ImageData{}, err
+		}
+	}
+
+	name := uuid.NewString()
+	object, err := s.put(ctx, name, img, format)
+	if err != nil {
+		return ImageData{}, err
+	}
+
+	data := ImageData{ImageURL: object.URL, ImageID: object.ID, Thumbnails: make(model.Thumbnails, len(thumbnailSizes))}
+	for _, size := range thumbnailSizes {
+		thumbnail, err := s.put(ctx, name+"_"+size.Name, imaging.Fit(img, size.Side), format)
+		if err != nil {
+			s.Delete(ctx, object.ID) //nolint:errcheck
+			return ImageData{}, err
+		}
+
+		data.Thumbnails[size.Name] = thumbnail.URL
+	}
+
+	if err = s.storedImageRepo.Add(object.ID); err != nil {
+		s.Delete(ctx, object.ID) //nolint:errcheck
 		return ImageData{}, err
 	}
 
-	return ImageData{ImageURL: resp.URL, ImageID: resp.PublicID}, nil
+	return data, nil
 }
 
-func (s *ImageServiceCloudinary) Delete(ctx context.Context, imageID string) error {
-	if _, err := s.cloudinary.Upload.Destroy(ctx, uploader.DestroyParams{PublicID: imageID}); err != nil {
+// Delete removes the image and its thumbnails. Deleting a missing image is not
+// an error.
+func (s *ImageService) Delete(ctx context.Context, imageID string) error {
+	if err := s.storage.Delete(ctx, imageID); err != nil {
 		return err
 	}
-	return nil
+
+	ext := path.Ext(imageID)
+	for _, size := range thumbnailSizes {
+		if err := s.storage.Delete(ctx, strings.TrimSuffix(imageID, ext)+"_"+size.Name+ext); err != nil {
+			return err
+		}
+	}
+
+	return s.storedImageRepo.Remove(imageID)
+}
+
+func (s *ImageService) put(ctx context.Context, name string, img image.Image, format string) (storage.Object, error) {
+	var buf bytes.Buffer
+	if err := imaging.Encode(&buf, img, format); err != nil {
+		return storage.Object{}, err
+	}
+
+	return s.storage.Put(ctx, name, &buf)
 }
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"errors"
+	"market/internal/model"
+	"market/internal/repository"
+	"market/pkg/database/postgres"
+	"time"
+)
+
+const (
+	usernameKeyPrefix = "username:"
+	ipKeyPrefix       = "ip:"
+)
+
+var (
+	ErrTooManyAttempts = errors.New("too many failed login attempts, try again later")
+	ErrAccountLocked   = errors.New("account is temporarily locked")
+)
+
+// ThrottleError is returned when a sign-in attempt is rejected before the
+// password is checked. RetryAt tells the client when to try again.
+type ThrottleError struct {
+	Err     error
+	RetryAt time.Time
+}
+
+func (e *ThrottleError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ThrottleError) Unwrap() error {
+	return e.Err
+}
+
+type LoginThrottleConfig struct {
+	MaxFailures     int
+	LockoutDuration time.Duration
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+}
+
+// LoginThrottle tracks failed sign-ins per username and per IP. Every failure
+// doubles the delay before the next attempt is accepted, and a username is
+// locked for LockoutDuration after MaxFailures consecutive failures. Failures
+// are forgotten after LockoutDuration without any. A successful sign-in only
+// resets the username, the IP keeps its failures until they expire, otherwise
+// signing into an account of one's own would clear the backoff of an IP
+// guessing passwords of others.
+type LoginThrottle struct {
+	repo repository.LoginAttemptRepo
+	cfg  LoginThrottleConfig
+}
+
+func NewLoginThrottle(repo repository.LoginAttemptRepo, cfg LoginThrottleConfig) *LoginThrottle {
+	return &LoginThrottle{repo: repo, cfg: cfg}
+}
+
+func (t *LoginThrottle) Check(username, ip string) error {
+	now := time.Now()
+	for _, key := range []string{usernameKeyPrefix + username, ipKeyPrefix + ip} {
+		attempts, err := t.repo.Get(key)
+		if err != nil {
+			if err == postgres.ErrNotFound {
+				continue
+			}
+			return err
+		}
+
+		if attempts.LockedUntil != nil && now.Before(*attempts.LockedUntil) {
+			return &ThrottleError{Err: ErrAccountLocked, RetryAt: *attempts.LockedUntil}
+		}
+
+		if retryAt := attempts.LastFailureAt.Add(t.delay(attempts.Failures)); now.Before(retryAt) {
+			return &ThrottleError{Err: ErrTooManyAttempts, RetryAt: retryAt}
+		}
+	}
+
+	return nil
+}
+
+func (t *LoginThrottle) Fail(username, ip, reason string) error {
+	now := time.Now()
+	if err := t.repo.CreateFailedLogin(model.FailedLogin{
+		Username:  username,
+		IP:        ip,
+		Reason:    reason,
+		CreatedAt: now,
+	}); err != nil {
+		return err
+	}
+
+	since := now.Add(-t.cfg.LockoutDuration)
+	if _, err := t.repo.RegisterFailure(ipKeyPrefix+ip, now, since); err != nil {
+		return err
+	}
+
+	attempts, err := t.repo.RegisterFailure(usernameKeyPrefix+username, now, since)
+	if err != nil {
+		return err
+	}
+
+	if attempts.Failures >= t.cfg.MaxFailures {
+		return t.repo.Lock(attempts.Key, now.Add(t.cfg.LockoutDuration))
+	}
+
+	return nil
+}
+
+func (t *LoginThrottle) Succeed(username string) error {
+	return t.repo.Reset(usernameKeyPrefix + username)
+}
+
+func (t *LoginThrottle) Unlock(username string) error {
+	return t.repo.Reset(usernameKeyPrefix + username)
+}
+
+// UnlockAudited unlocks the username on behalf of an admin and records entry
+// in the audit log through admins.
+func (t *LoginThrottle) UnlockAudited(username string, entry model.AuditEntry, admins repository.AdminRepo) error {
+	return admins.Unlock(t.repo, usernameKeyPrefix+username, entry)
+}
+
+func (t *LoginThrottle) delay(failures int) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+
+	delay := t.cfg.BaseDelay
+	for i := 1; i < failures && delay < t.cfg.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > t.cfg.MaxDelay {
+		return t.cfg.MaxDelay
+	}
+	return delay
+}
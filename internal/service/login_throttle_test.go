@@ -0,0 +1,101 @@
+package service
+
+import (
+	"errors"
+	"market/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginThrottle(t *testing.T) {
+	cfg := LoginThrottleConfig{
+		MaxFailures:     3,
+		LockoutDuration: time.Hour,
+		BaseDelay:       time.Hour,
+		MaxDelay:        time.Hour,
+	}
+
+	t.Run("Backoff After Failure", func(t *testing.T) {
+		throttle := NewLoginThrottle(repository.NewLoginAttemptMemoryRepo(), cfg)
+
+		assert.NoError(t, throttle.Check("user", "192.0.2.1"))
+		assert.NoError(t, throttle.Fail("user", "192.0.2.1", ErrBadPass.Error()))
+
+		err := throttle.Check("user", "198.51.100.1")
+		assert.ErrorIs(t, err, ErrTooManyAttempts)
+
+		var throttleErr *ThrottleError
+		if assert.True(t, errors.As(err, &throttleErr)) {
+			assert.WithinDuration(t, time.Now().Add(time.Hour), throttleErr.RetryAt, time.Minute)
+		}
+
+		assert.ErrorIs(t, throttle.Check("other", "192.0.2.1"), ErrTooManyAttempts)
+		assert.NoError(t, throttle.Check("other", "198.51.100.1"))
+	})
+
+	t.Run("Lockout", func(t *testing.T) {
+		repo := repository.NewLoginAttemptMemoryRepo()
+		throttle := NewLoginThrottle(repo, cfg)
+
+		for i := 0; i < cfg.MaxFailures; i++ {
+			assert.NoError(t, throttle.Fail("user", "192.0.2.1", ErrBadPass.Error()))
+		}
+
+		assert.ErrorIs(t, throttle.Check("user", "198.51.100.1"), ErrAccountLocked)
+		assert.Len(t, repo.FailedLogins, cfg.MaxFailures)
+
+		assert.NoError(t, throttle.Unlock("user"))
+		assert.NoError(t, throttle.Check("user", "198.51.100.1"))
+	})
+
+	t.Run("Success Resets Username", func(t *testing.T) {
+		throttle := NewLoginThrottle(repository.NewLoginAttemptMemoryRepo(), cfg)
+
+		assert.NoError(t, throttle.Fail("user", "192.0.2.1", ErrBadPass.Error()))
+		assert.NoError(t, throttle.Succeed("user"))
+		assert.NoError(t, throttle.Check("user", "198.51.100.1"))
+		assert.ErrorIs(t, throttle.Check("user", "192.0.2.1"), ErrTooManyAttempts)
+	})
+
+	// An attacker guessing passwords of others signs into an account of their
+	// own between guesses. The backoff of their IP must stay.
+	t.Run("Success Keeps IP Backoff", func(t *testing.T) {
+		repo := repository.NewLoginAttemptMemoryRepo()
+		throttle := NewLoginThrottle(repo, cfg)
+		ip := "203.0.113.7"
+
+		for _, victim := range []string{"alice", "bob", "carol"} {
+			assert.NoError(t, throttle.Fail(victim, ip, ErrBadPass.Error()))
+			assert.NoError(t, throttle.Succeed("attacker"))
+		}
+
+		attempts, err := repo.Get(ipKeyPrefix + ip)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, attempts.Failures)
+		assert.ErrorIs(t, throttle.Check("dave", ip), ErrTooManyAttempts)
+	})
+
+	t.Run("Failures Expire", func(t *testing.T) {
+		repo := repository.NewLoginAttemptMemoryRepo()
+		throttle := NewLoginThrottle(repo, cfg)
+
+		_, err := repo.RegisterFailure(ipKeyPrefix+"192.0.2.1", time.Now().Add(-2*cfg.LockoutDuration), time.Time{})
+		assert.NoError(t, err)
+		assert.NoError(t, throttle.Fail("user", "192.0.2.1", ErrBadPass.Error()))
+
+		attempts, err := repo.Get(ipKeyPrefix + "192.0.2.1")
+		assert.NoError(t, err)
+		assert.Equal(t, 1, attempts.Failures)
+	})
+}
+
+func TestLoginThrottle_delay(t *testing.T) {
+	throttle := NewLoginThrottle(nil, LoginThrottleConfig{BaseDelay: time.Second, MaxDelay: 10 * time.Second})
+
+	assert.Equal(t, time.Duration(0), throttle.delay(0))
+	assert.Equal(t, time.Second, throttle.delay(1))
+	assert.Equal(t, 4*time.Second, throttle.delay(3))
+	assert.Equal(t, 10*time.Second, throttle.delay(10))
+}
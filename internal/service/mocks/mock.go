@@ -10,6 +10,7 @@ import (
 	service "market/internal/service"
 	multipart "mime/multipart"
 	reflect "reflect"
+	time "time"
 
 	gomock "go.uber.org/mock/gomock"
 )
@@ -37,6 +38,65 @@ func (m *MockUser) EXPECT() *MockUserMockRecorder {
 	return m.recorder
 }
 
+// ChangePassword mocks base method.
+func (m *MockUser) ChangePassword(userID int, oldPassword, newPassword string) (model.Tokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", userID, oldPassword, newPassword)
+	ret0, _ := ret[0].(model.Tokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockUserMockRecorder) ChangePassword(userID, oldPassword, newPassword interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockUser)(nil).ChangePassword), userID, oldPassword, newPassword)
+}
+
+// CheckSession mocks base method.
+func (m *MockUser) CheckSession(sessionID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckSession", sessionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckSession indicates an expected call of CheckSession.
+func (mr *MockUserMockRecorder) CheckSession(sessionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckSession", reflect.TypeOf((*MockUser)(nil).CheckSession), sessionID)
+}
+
+// CompleteSignIn mocks base method.
+func (m *MockUser) CompleteSignIn(mfaToken, code, ip string) (model.Tokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteSignIn", mfaToken, code, ip)
+	ret0, _ := ret[0].(model.Tokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompleteSignIn indicates an expected call of CompleteSignIn.
+func (mr *MockUserMockRecorder) CompleteSignIn(mfaToken, code, ip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteSignIn", reflect.TypeOf((*MockUser)(nil).CompleteSignIn), mfaToken, code, ip)
+}
+
+// ConfirmTOTP mocks base method.
+func (m *MockUser) ConfirmTOTP(userID int, code string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmTOTP", userID, code)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmTOTP indicates an expected call of ConfirmTOTP.
+func (mr *MockUserMockRecorder) ConfirmTOTP(userID, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmTOTP", reflect.TypeOf((*MockUser)(nil).ConfirmTOTP), userID, code)
+}
+
 // CreateUser mocks base method.
 func (m *MockUser) CreateUser(arg0 model.User) (int, error) {
 	m.ctrl.T.Helper()
@@ -52,19 +112,190 @@ func (mr *MockUserMockRecorder) CreateUser(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUser)(nil).CreateUser), arg0)
 }
 
+// Delete mocks base method.
+func (m *MockUser) Delete(userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockUserMockRecorder) Delete(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockUser)(nil).Delete), userID)
+}
+
+// DisableTOTP mocks base method.
+func (m *MockUser) DisableTOTP(userID int, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableTOTP", userID, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableTOTP indicates an expected call of DisableTOTP.
+func (mr *MockUserMockRecorder) DisableTOTP(userID, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableTOTP", reflect.TypeOf((*MockUser)(nil).DisableTOTP), userID, code)
+}
+
+// EnrollTOTP mocks base method.
+func (m *MockUser) EnrollTOTP(userID int) (model.TOTPEnrollment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnrollTOTP", userID)
+	ret0, _ := ret[0].(model.TOTPEnrollment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnrollTOTP indicates an expected call of EnrollTOTP.
+func (mr *MockUserMockRecorder) EnrollTOTP(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnrollTOTP", reflect.TypeOf((*MockUser)(nil).EnrollTOTP), userID)
+}
+
+// ForgotPassword mocks base method.
+func (m *MockUser) ForgotPassword(email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForgotPassword", email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForgotPassword indicates an expected call of ForgotPassword.
+func (mr *MockUserMockRecorder) ForgotPassword(email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForgotPassword", reflect.TypeOf((*MockUser)(nil).ForgotPassword), email)
+}
+
 // GenerateToken mocks base method.
-func (m *MockUser) GenerateToken(username, password string) (string, error) {
+func (m *MockUser) GenerateToken(username, password, ip string) (model.Tokens, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GenerateToken", username, password)
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "GenerateToken", username, password, ip)
+	ret0, _ := ret[0].(model.Tokens)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GenerateToken indicates an expected call of GenerateToken.
-func (mr *MockUserMockRecorder) GenerateToken(username, password interface{}) *gomock.Call {
+func (mr *MockUserMockRecorder) GenerateToken(username, password, ip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateToken", reflect.TypeOf((*MockUser)(nil).GenerateToken), username, password, ip)
+}
+
+// GetByID mocks base method.
+func (m *MockUser) GetByID(userID int) (model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", userID)
+	ret0, _ := ret[0].(model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockUserMockRecorder) GetByID(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockUser)(nil).GetByID), userID)
+}
+
+// RefreshTokens mocks base method.
+func (m *MockUser) RefreshTokens(refreshToken string) (model.Tokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshTokens", refreshToken)
+	ret0, _ := ret[0].(model.Tokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefreshTokens indicates an expected call of RefreshTokens.
+func (mr *MockUserMockRecorder) RefreshTokens(refreshToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshTokens", reflect.TypeOf((*MockUser)(nil).RefreshTokens), refreshToken)
+}
+
+// ResetPassword mocks base method.
+func (m *MockUser) ResetPassword(token, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", token, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockUserMockRecorder) ResetPassword(token, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockUser)(nil).ResetPassword), token, password)
+}
+
+// SendPasswordReset mocks base method.
+func (m *MockUser) SendPasswordReset(userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendPasswordReset", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendPasswordReset indicates an expected call of SendPasswordReset.
+func (mr *MockUserMockRecorder) SendPasswordReset(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendPasswordReset", reflect.TypeOf((*MockUser)(nil).SendPasswordReset), userID)
+}
+
+// SendVerification mocks base method.
+func (m *MockUser) SendVerification(userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendVerification", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendVerification indicates an expected call of SendVerification.
+func (mr *MockUserMockRecorder) SendVerification(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendVerification", reflect.TypeOf((*MockUser)(nil).SendVerification), userID)
+}
+
+// SignOut mocks base method.
+func (m *MockUser) SignOut(sessionID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SignOut", sessionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SignOut indicates an expected call of SignOut.
+func (mr *MockUserMockRecorder) SignOut(sessionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignOut", reflect.TypeOf((*MockUser)(nil).SignOut), sessionID)
+}
+
+// Update mocks base method.
+func (m *MockUser) Update(userID int, input model.UpdateUserInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", userID, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockUserMockRecorder) Update(userID, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockUser)(nil).Update), userID, input)
+}
+
+// VerifyEmail mocks base method.
+func (m *MockUser) VerifyEmail(token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmail", token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyEmail indicates an expected call of VerifyEmail.
+func (mr *MockUserMockRecorder) VerifyEmail(token interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateToken", reflect.TypeOf((*MockUser)(nil).GenerateToken), username, password)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockUser)(nil).VerifyEmail), token)
 }
 
 // MockReview is a mock of Review interface.
@@ -106,17 +337,17 @@ func (mr *MockReviewMockRecorder) Create(review interface{}) *gomock.Call {
 }
 
 // Delete mocks base method.
-func (m *MockReview) Delete(userID, reviewID int) error {
+func (m *MockReview) Delete(reviewID int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", userID, reviewID)
+	ret := m.ctrl.Call(m, "Delete", reviewID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockReviewMockRecorder) Delete(userID, reviewID interface{}) *gomock.Call {
+func (mr *MockReviewMockRecorder) Delete(reviewID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockReview)(nil).Delete), userID, reviewID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockReview)(nil).Delete), reviewID)
 }
 
 // GetAll mocks base method.
@@ -134,18 +365,33 @@ func (mr *MockReviewMockRecorder) GetAll(productID, q interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockReview)(nil).GetAll), productID, q)
 }
 
+// GetByID mocks base method.
+func (m *MockReview) GetByID(productID, reviewID int) (model.Review, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", productID, reviewID)
+	ret0, _ := ret[0].(model.Review)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockReviewMockRecorder) GetByID(productID, reviewID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockReview)(nil).GetByID), productID, reviewID)
+}
+
 // Update mocks base method.
-func (m *MockReview) Update(userID, productID int, input model.UpdateReviewInput) error {
+func (m *MockReview) Update(reviewID int, input model.UpdateReviewInput) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", userID, productID, input)
+	ret := m.ctrl.Call(m, "Update", reviewID, input)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Update indicates an expected call of Update.
-func (mr *MockReviewMockRecorder) Update(userID, productID, input interface{}) *gomock.Call {
+func (mr *MockReviewMockRecorder) Update(reviewID, input interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockReview)(nil).Update), userID, productID, input)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockReview)(nil).Update), reviewID, input)
 }
 
 // MockProduct is a mock of Product interface.
@@ -187,17 +433,17 @@ func (mr *MockProductMockRecorder) Create(product interface{}) *gomock.Call {
 }
 
 // Delete mocks base method.
-func (m *MockProduct) Delete(userID, productID int) error {
+func (m *MockProduct) Delete(productID int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", userID, productID)
+	ret := m.ctrl.Call(m, "Delete", productID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockProductMockRecorder) Delete(userID, productID interface{}) *gomock.Call {
+func (mr *MockProductMockRecorder) Delete(productID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockProduct)(nil).Delete), userID, productID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockProduct)(nil).Delete), productID)
 }
 
 // GetAll mocks base method.
@@ -230,19 +476,34 @@ func (mr *MockProductMockRecorder) GetByID(productID interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockProduct)(nil).GetByID), productID)
 }
 
+// GetFacets mocks base method.
+func (m *MockProduct) GetFacets(f model.ProductFilter) (model.ProductFacets, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFacets", f)
+	ret0, _ := ret[0].(model.ProductFacets)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFacets indicates an expected call of GetFacets.
+func (mr *MockProductMockRecorder) GetFacets(f interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFacets", reflect.TypeOf((*MockProduct)(nil).GetFacets), f)
+}
+
 // GetProductsByCategory mocks base method.
-func (m *MockProduct) GetProductsByCategory(productCategory string, q model.ProductQueryInput) ([]model.Product, error) {
+func (m *MockProduct) GetProductsByCategory(slug string, q model.ProductQueryInput) ([]model.Product, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetProductsByCategory", productCategory, q)
+	ret := m.ctrl.Call(m, "GetProductsByCategory", slug, q)
 	ret0, _ := ret[0].([]model.Product)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetProductsByCategory indicates an expected call of GetProductsByCategory.
-func (mr *MockProductMockRecorder) GetProductsByCategory(productCategory, q interface{}) *gomock.Call {
+func (mr *MockProductMockRecorder) GetProductsByCategory(slug, q interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProductsByCategory", reflect.TypeOf((*MockProduct)(nil).GetProductsByCategory), productCategory, q)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProductsByCategory", reflect.TypeOf((*MockProduct)(nil).GetProductsByCategory), slug, q)
 }
 
 // GetProductsByUserID mocks base method.
@@ -274,171 +535,628 @@ func (mr *MockProductMockRecorder) IncreaseViewsCounter(productID interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncreaseViewsCounter", reflect.TypeOf((*MockProduct)(nil).IncreaseViewsCounter), productID)
 }
 
+// Search mocks base method.
+func (m *MockProduct) Search(q model.ProductSearchInput) ([]model.ProductSearchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", q)
+	ret0, _ := ret[0].([]model.ProductSearchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockProductMockRecorder) Search(q interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockProduct)(nil).Search), q)
+}
+
 // Update mocks base method.
-func (m *MockProduct) Update(userID, productID int, input model.UpdateProductInput) error {
+func (m *MockProduct) Update(productID int, input model.UpdateProductInput) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", userID, productID, input)
+	ret := m.ctrl.Call(m, "Update", productID, input)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Update indicates an expected call of Update.
-func (mr *MockProductMockRecorder) Update(userID, productID, input interface{}) *gomock.Call {
+func (mr *MockProductMockRecorder) Update(productID, input interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockProduct)(nil).Update), userID, productID, input)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockProduct)(nil).Update), productID, input)
 }
 
-// MockOrder is a mock of Order interface.
-type MockOrder struct {
+// MockProductImage is a mock of ProductImage interface.
+type MockProductImage struct {
 	ctrl     *gomock.Controller
-	recorder *MockOrderMockRecorder
+	recorder *MockProductImageMockRecorder
 }
 
-// MockOrderMockRecorder is the mock recorder for MockOrder.
-type MockOrderMockRecorder struct {
-	mock *MockOrder
+// MockProductImageMockRecorder is the mock recorder for MockProductImage.
+type MockProductImageMockRecorder struct {
+	mock *MockProductImage
 }
 
-// NewMockOrder creates a new mock instance.
-func NewMockOrder(ctrl *gomock.Controller) *MockOrder {
-	mock := &MockOrder{ctrl: ctrl}
-	mock.recorder = &MockOrderMockRecorder{mock}
+// NewMockProductImage creates a new mock instance.
+func NewMockProductImage(ctrl *gomock.Controller) *MockProductImage {
+	mock := &MockProductImage{ctrl: ctrl}
+	mock.recorder = &MockProductImageMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockOrder) EXPECT() *MockOrderMockRecorder {
+func (m *MockProductImage) EXPECT() *MockProductImageMockRecorder {
 	return m.recorder
 }
 
-// Create mocks base method.
-func (m *MockOrder) Create(userID int, order model.Order) (int, error) {
+// Add mocks base method.
+func (m *MockProductImage) Add(productID int, images []model.ProductImage, primary bool) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", userID, order)
-	ret0, _ := ret[0].(int)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "Add", productID, images, primary)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// Create indicates an expected call of Create.
-func (mr *MockOrderMockRecorder) Create(userID, order interface{}) *gomock.Call {
+// Add indicates an expected call of Add.
+func (mr *MockProductImageMockRecorder) Add(productID, images, primary interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockProductImage)(nil).Add), productID, images, primary)
+}
+
+// Delete mocks base method.
+func (m *MockProductImage) Delete(productID, imageID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", productID, imageID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockProductImageMockRecorder) Delete(productID, imageID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockOrder)(nil).Create), userID, order)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockProductImage)(nil).Delete), productID, imageID)
 }
 
 // GetAll mocks base method.
-func (m *MockOrder) GetAll(userID int, q model.OrderQueryInput) ([]model.Order, error) {
+func (m *MockProductImage) GetAll(productID int) ([]model.ProductImage, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAll", userID, q)
-	ret0, _ := ret[0].([]model.Order)
+	ret := m.ctrl.Call(m, "GetAll", productID)
+	ret0, _ := ret[0].([]model.ProductImage)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAll indicates an expected call of GetAll.
-func (mr *MockOrderMockRecorder) GetAll(userID, q interface{}) *gomock.Call {
+func (mr *MockProductImageMockRecorder) GetAll(productID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockOrder)(nil).GetAll), userID, q)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockProductImage)(nil).GetAll), productID)
 }
 
-// GetByID mocks base method.
-func (m *MockOrder) GetByID(userID, orderID int) (model.Order, error) {
+// Reorder mocks base method.
+func (m *MockProductImage) Reorder(productID int, imageIDs []int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetByID", userID, orderID)
-	ret0, _ := ret[0].(model.Order)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "Reorder", productID, imageIDs)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// GetByID indicates an expected call of GetByID.
-func (mr *MockOrderMockRecorder) GetByID(userID, orderID interface{}) *gomock.Call {
+// Reorder indicates an expected call of Reorder.
+func (mr *MockProductImageMockRecorder) Reorder(productID, imageIDs interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockOrder)(nil).GetByID), userID, orderID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reorder", reflect.TypeOf((*MockProductImage)(nil).Reorder), productID, imageIDs)
 }
 
-// GetProductsByOrderID mocks base method.
-func (m *MockOrder) GetProductsByOrderID(userID, orderID int, q model.ProductQueryInput) ([]model.Product, error) {
+// SetPrimary mocks base method.
+func (m *MockProductImage) SetPrimary(productID, imageID int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetProductsByOrderID", userID, orderID, q)
-	ret0, _ := ret[0].([]model.Product)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "SetPrimary", productID, imageID)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// GetProductsByOrderID indicates an expected call of GetProductsByOrderID.
-func (mr *MockOrderMockRecorder) GetProductsByOrderID(userID, orderID, q interface{}) *gomock.Call {
+// SetPrimary indicates an expected call of SetPrimary.
+func (mr *MockProductImageMockRecorder) SetPrimary(productID, imageID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProductsByOrderID", reflect.TypeOf((*MockOrder)(nil).GetProductsByOrderID), userID, orderID, q)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPrimary", reflect.TypeOf((*MockProductImage)(nil).SetPrimary), productID, imageID)
 }
 
-// MockImage is a mock of Image interface.
-type MockImage struct {
+// MockVariant is a mock of Variant interface.
+type MockVariant struct {
 	ctrl     *gomock.Controller
-	recorder *MockImageMockRecorder
+	recorder *MockVariantMockRecorder
 }
 
-// MockImageMockRecorder is the mock recorder for MockImage.
-type MockImageMockRecorder struct {
-	mock *MockImage
+// MockVariantMockRecorder is the mock recorder for MockVariant.
+type MockVariantMockRecorder struct {
+	mock *MockVariant
 }
 
-// NewMockImage creates a new mock instance.
-func NewMockImage(ctrl *gomock.Controller) *MockImage {
-	mock := &MockImage{ctrl: ctrl}
-	mock.recorder = &MockImageMockRecorder{mock}
+// NewMockVariant creates a new mock instance.
+func NewMockVariant(ctrl *gomock.Controller) *MockVariant {
+	mock := &MockVariant{ctrl: ctrl}
+	mock.recorder = &MockVariantMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockImage) EXPECT() *MockImageMockRecorder {
+func (m *MockVariant) EXPECT() *MockVariantMockRecorder {
 	return m.recorder
 }
 
-// Delete mocks base method.
-func (m *MockImage) Delete(ctx context.Context, imageID string) error {
+// Create mocks base method.
+func (m *MockVariant) Create(productID int, variant model.ProductVariant) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", ctx, imageID)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "Create", productID, variant)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// Delete indicates an expected call of Delete.
-func (mr *MockImageMockRecorder) Delete(ctx, imageID interface{}) *gomock.Call {
+// Create indicates an expected call of Create.
+func (mr *MockVariantMockRecorder) Create(productID, variant interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockImage)(nil).Delete), ctx, imageID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockVariant)(nil).Create), productID, variant)
 }
 
-// Upload mocks base method.
-func (m *MockImage) Upload(ctx context.Context, file multipart.File) (service.ImageData, error) {
+// CreateOption mocks base method.
+func (m *MockVariant) CreateOption(productID int, input model.OptionInput) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Upload", ctx, file)
-	ret0, _ := ret[0].(service.ImageData)
+	ret := m.ctrl.Call(m, "CreateOption", productID, input)
+	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// Upload indicates an expected call of Upload.
-func (mr *MockImageMockRecorder) Upload(ctx, file interface{}) *gomock.Call {
+// CreateOption indicates an expected call of CreateOption.
+func (mr *MockVariantMockRecorder) CreateOption(productID, input interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upload", reflect.TypeOf((*MockImage)(nil).Upload), ctx, file)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOption", reflect.TypeOf((*MockVariant)(nil).CreateOption), productID, input)
 }
 
-// MockCart is a mock of Cart interface.
-type MockCart struct {
-	ctrl     *gomock.Controller
-	recorder *MockCartMockRecorder
+// Delete mocks base method.
+func (m *MockVariant) Delete(productID, variantID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", productID, variantID)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// MockCartMockRecorder is the mock recorder for MockCart.
-type MockCartMockRecorder struct {
-	mock *MockCart
+// Delete indicates an expected call of Delete.
+func (mr *MockVariantMockRecorder) Delete(productID, variantID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockVariant)(nil).Delete), productID, variantID)
 }
 
-// NewMockCart creates a new mock instance.
-func NewMockCart(ctrl *gomock.Controller) *MockCart {
-	mock := &MockCart{ctrl: ctrl}
-	mock.recorder = &MockCartMockRecorder{mock}
-	return mock
+// DeleteOption mocks base method.
+func (m *MockVariant) DeleteOption(productID, optionID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOption", productID, optionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteOption indicates an expected call of DeleteOption.
+func (mr *MockVariantMockRecorder) DeleteOption(productID, optionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOption", reflect.TypeOf((*MockVariant)(nil).DeleteOption), productID, optionID)
+}
+
+// GetAll mocks base method.
+func (m *MockVariant) GetAll(productID int) ([]model.ProductVariant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll", productID)
+	ret0, _ := ret[0].([]model.ProductVariant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockVariantMockRecorder) GetAll(productID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockVariant)(nil).GetAll), productID)
+}
+
+// GetByID mocks base method.
+func (m *MockVariant) GetByID(productID, variantID int) (model.ProductVariant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", productID, variantID)
+	ret0, _ := ret[0].(model.ProductVariant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockVariantMockRecorder) GetByID(productID, variantID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockVariant)(nil).GetByID), productID, variantID)
+}
+
+// GetOptions mocks base method.
+func (m *MockVariant) GetOptions(productID int) ([]model.ProductOption, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOptions", productID)
+	ret0, _ := ret[0].([]model.ProductOption)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOptions indicates an expected call of GetOptions.
+func (mr *MockVariantMockRecorder) GetOptions(productID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOptions", reflect.TypeOf((*MockVariant)(nil).GetOptions), productID)
+}
+
+// Update mocks base method.
+func (m *MockVariant) Update(productID, variantID int, input model.UpdateVariantInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", productID, variantID, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockVariantMockRecorder) Update(productID, variantID, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockVariant)(nil).Update), productID, variantID, input)
+}
+
+// MockOrder is a mock of Order interface.
+type MockOrder struct {
+	ctrl     *gomock.Controller
+	recorder *MockOrderMockRecorder
+}
+
+// MockOrderMockRecorder is the mock recorder for MockOrder.
+type MockOrderMockRecorder struct {
+	mock *MockOrder
+}
+
+// NewMockOrder creates a new mock instance.
+func NewMockOrder(ctrl *gomock.Controller) *MockOrder {
+	mock := &MockOrder{ctrl: ctrl}
+	mock.recorder = &MockOrderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOrder) EXPECT() *MockOrderMockRecorder {
+	return m.recorder
+}
+
+// Cancel mocks base method.
+func (m *MockOrder) Cancel(orderID, userID int, input model.CancelOrderInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Cancel", orderID, userID, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Cancel indicates an expected call of Cancel.
+func (mr *MockOrderMockRecorder) Cancel(orderID, userID, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cancel", reflect.TypeOf((*MockOrder)(nil).Cancel), orderID, userID, input)
+}
+
+// ChangeStatus mocks base method.
+func (m *MockOrder) ChangeStatus(orderID, userID int, input model.ChangeOrderStatusInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeStatus", orderID, userID, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangeStatus indicates an expected call of ChangeStatus.
+func (mr *MockOrderMockRecorder) ChangeStatus(orderID, userID, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeStatus", reflect.TypeOf((*MockOrder)(nil).ChangeStatus), orderID, userID, input)
+}
+
+// Create mocks base method.
+func (m *MockOrder) Create(userID int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", userID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockOrderMockRecorder) Create(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockOrder)(nil).Create), userID)
+}
+
+// GetAll mocks base method.
+func (m *MockOrder) GetAll(userID int, q model.OrderQueryInput) ([]model.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll", userID, q)
+	ret0, _ := ret[0].([]model.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockOrderMockRecorder) GetAll(userID, q interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockOrder)(nil).GetAll), userID, q)
+}
+
+// GetByID mocks base method.
+func (m *MockOrder) GetByID(orderID int) (model.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", orderID)
+	ret0, _ := ret[0].(model.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockOrderMockRecorder) GetByID(orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockOrder)(nil).GetByID), orderID)
+}
+
+// GetLines mocks base method.
+func (m *MockOrder) GetLines(orderID int) ([]model.OrderLine, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLines", orderID)
+	ret0, _ := ret[0].([]model.OrderLine)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLines indicates an expected call of GetLines.
+func (mr *MockOrderMockRecorder) GetLines(orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLines", reflect.TypeOf((*MockOrder)(nil).GetLines), orderID)
+}
+
+// GetStatusHistory mocks base method.
+func (m *MockOrder) GetStatusHistory(orderID int) ([]model.OrderStatusChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStatusHistory", orderID)
+	ret0, _ := ret[0].([]model.OrderStatusChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStatusHistory indicates an expected call of GetStatusHistory.
+func (mr *MockOrderMockRecorder) GetStatusHistory(orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatusHistory", reflect.TypeOf((*MockOrder)(nil).GetStatusHistory), orderID)
+}
+
+// MockImage is a mock of Image interface.
+type MockImage struct {
+	ctrl     *gomock.Controller
+	recorder *MockImageMockRecorder
+}
+
+// MockImageMockRecorder is the mock recorder for MockImage.
+type MockImageMockRecorder struct {
+	mock *MockImage
+}
+
+// NewMockImage creates a new mock instance.
+func NewMockImage(ctrl *gomock.Controller) *MockImage {
+	mock := &MockImage{ctrl: ctrl}
+	mock.recorder = &MockImageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockImage) EXPECT() *MockImageMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockImage) Delete(ctx context.Context, imageID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, imageID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockImageMockRecorder) Delete(ctx, imageID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockImage)(nil).Delete), ctx, imageID)
+}
+
+// Upload mocks base method.
+func (m *MockImage) Upload(ctx context.Context, file multipart.File) (service.ImageData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Upload", ctx, file)
+	ret0, _ := ret[0].(service.ImageData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Upload indicates an expected call of Upload.
+func (mr *MockImageMockRecorder) Upload(ctx, file interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upload", reflect.TypeOf((*MockImage)(nil).Upload), ctx, file)
+}
+
+// MockMoney is a mock of Money interface.
+type MockMoney struct {
+	ctrl     *gomock.Controller
+	recorder *MockMoneyMockRecorder
+}
+
+// MockMoneyMockRecorder is the mock recorder for MockMoney.
+type MockMoneyMockRecorder struct {
+	mock *MockMoney
+}
+
+// NewMockMoney creates a new mock instance.
+func NewMockMoney(ctrl *gomock.Controller) *MockMoney {
+	mock := &MockMoney{ctrl: ctrl}
+	mock.recorder = &MockMoneyMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMoney) EXPECT() *MockMoneyMockRecorder {
+	return m.recorder
+}
+
+// Convert mocks base method.
+func (m *MockMoney) Convert(amount model.Money, currency string) (model.Money, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Convert", amount, currency)
+	ret0, _ := ret[0].(model.Money)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Convert indicates an expected call of Convert.
+func (mr *MockMoneyMockRecorder) Convert(amount, currency interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Convert", reflect.TypeOf((*MockMoney)(nil).Convert), amount, currency)
+}
+
+// DefaultCurrency mocks base method.
+func (m *MockMoney) DefaultCurrency() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DefaultCurrency")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// DefaultCurrency indicates an expected call of DefaultCurrency.
+func (mr *MockMoneyMockRecorder) DefaultCurrency() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DefaultCurrency", reflect.TypeOf((*MockMoney)(nil).DefaultCurrency))
+}
+
+// PriceScale mocks base method.
+func (m *MockMoney) PriceScale(currency string) (model.PriceScale, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PriceScale", currency)
+	ret0, _ := ret[0].(model.PriceScale)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PriceScale indicates an expected call of PriceScale.
+func (mr *MockMoneyMockRecorder) PriceScale(currency interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PriceScale", reflect.TypeOf((*MockMoney)(nil).PriceScale), currency)
+}
+
+// SellerCurrency mocks base method.
+func (m *MockMoney) SellerCurrency(userID int) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SellerCurrency", userID)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SellerCurrency indicates an expected call of SellerCurrency.
+func (mr *MockMoneyMockRecorder) SellerCurrency(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SellerCurrency", reflect.TypeOf((*MockMoney)(nil).SellerCurrency), userID)
+}
+
+// SetDisplayPrice mocks base method.
+func (m *MockMoney) SetDisplayPrice(product *model.Product, currency string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDisplayPrice", product, currency)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDisplayPrice indicates an expected call of SetDisplayPrice.
+func (mr *MockMoneyMockRecorder) SetDisplayPrice(product, currency interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDisplayPrice", reflect.TypeOf((*MockMoney)(nil).SetDisplayPrice), product, currency)
+}
+
+// Total mocks base method.
+func (m *MockMoney) Total(lines []model.Product, currency string) (model.Money, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Total", lines, currency)
+	ret0, _ := ret[0].(model.Money)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Total indicates an expected call of Total.
+func (mr *MockMoneyMockRecorder) Total(lines, currency interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Total", reflect.TypeOf((*MockMoney)(nil).Total), lines, currency)
+}
+
+// MockOutbox is a mock of Outbox interface.
+type MockOutbox struct {
+	ctrl     *gomock.Controller
+	recorder *MockOutboxMockRecorder
+}
+
+// MockOutboxMockRecorder is the mock recorder for MockOutbox.
+type MockOutboxMockRecorder struct {
+	mock *MockOutbox
+}
+
+// NewMockOutbox creates a new mock instance.
+func NewMockOutbox(ctrl *gomock.Controller) *MockOutbox {
+	mock := &MockOutbox{ctrl: ctrl}
+	mock.recorder = &MockOutboxMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOutbox) EXPECT() *MockOutboxMockRecorder {
+	return m.recorder
+}
+
+// Process mocks base method.
+func (m *MockOutbox) Process(ctx context.Context, limit int) (int, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Process", ctx, limit)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Process indicates an expected call of Process.
+func (mr *MockOutboxMockRecorder) Process(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Process", reflect.TypeOf((*MockOutbox)(nil).Process), ctx, limit)
+}
+
+// ReconcileImages mocks base method.
+func (m *MockOutbox) ReconcileImages(minAge time.Duration) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcileImages", minAge)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReconcileImages indicates an expected call of ReconcileImages.
+func (mr *MockOutboxMockRecorder) ReconcileImages(minAge interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileImages", reflect.TypeOf((*MockOutbox)(nil).ReconcileImages), minAge)
+}
+
+// MockCart is a mock of Cart interface.
+type MockCart struct {
+	ctrl     *gomock.Controller
+	recorder *MockCartMockRecorder
+}
+
+// MockCartMockRecorder is the mock recorder for MockCart.
+type MockCartMockRecorder struct {
+	mock *MockCart
+}
+
+// NewMockCart creates a new mock instance.
+func NewMockCart(ctrl *gomock.Controller) *MockCart {
+	mock := &MockCart{ctrl: ctrl}
+	mock.recorder = &MockCartMockRecorder{mock}
+	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
@@ -447,18 +1165,18 @@ func (m *MockCart) EXPECT() *MockCartMockRecorder {
 }
 
 // AddProduct mocks base method.
-func (m *MockCart) AddProduct(userID, cartID, productID, amountToPurchase int) (int, error) {
+func (m *MockCart) AddProduct(cartID, productID int, variantID *int, amountToPurchase int) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddProduct", userID, cartID, productID, amountToPurchase)
+	ret := m.ctrl.Call(m, "AddProduct", cartID, productID, variantID, amountToPurchase)
 	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // AddProduct indicates an expected call of AddProduct.
-func (mr *MockCartMockRecorder) AddProduct(userID, cartID, productID, amountToPurchase interface{}) *gomock.Call {
+func (mr *MockCartMockRecorder) AddProduct(cartID, productID, variantID, amountToPurchase interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddProduct", reflect.TypeOf((*MockCart)(nil).AddProduct), userID, cartID, productID, amountToPurchase)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddProduct", reflect.TypeOf((*MockCart)(nil).AddProduct), cartID, productID, variantID, amountToPurchase)
 }
 
 // Create mocks base method.
@@ -477,46 +1195,46 @@ func (mr *MockCartMockRecorder) Create(userID interface{}) *gomock.Call {
 }
 
 // DeleteAllProducts mocks base method.
-func (m *MockCart) DeleteAllProducts(userID, cartID int) error {
+func (m *MockCart) DeleteAllProducts(cartID int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteAllProducts", userID, cartID)
+	ret := m.ctrl.Call(m, "DeleteAllProducts", cartID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteAllProducts indicates an expected call of DeleteAllProducts.
-func (mr *MockCartMockRecorder) DeleteAllProducts(userID, cartID interface{}) *gomock.Call {
+func (mr *MockCartMockRecorder) DeleteAllProducts(cartID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAllProducts", reflect.TypeOf((*MockCart)(nil).DeleteAllProducts), userID, cartID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAllProducts", reflect.TypeOf((*MockCart)(nil).DeleteAllProducts), cartID)
 }
 
 // DeleteProduct mocks base method.
-func (m *MockCart) DeleteProduct(userID, cartID, productID int) error {
+func (m *MockCart) DeleteProduct(cartID, productID int, variantID *int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteProduct", userID, cartID, productID)
+	ret := m.ctrl.Call(m, "DeleteProduct", cartID, productID, variantID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteProduct indicates an expected call of DeleteProduct.
-func (mr *MockCartMockRecorder) DeleteProduct(userID, cartID, productID interface{}) *gomock.Call {
+func (mr *MockCartMockRecorder) DeleteProduct(cartID, productID, variantID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteProduct", reflect.TypeOf((*MockCart)(nil).DeleteProduct), userID, cartID, productID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteProduct", reflect.TypeOf((*MockCart)(nil).DeleteProduct), cartID, productID, variantID)
 }
 
 // GetAllProducts mocks base method.
-func (m *MockCart) GetAllProducts(userID, CartID int, q model.ProductQueryInput) ([]model.Product, error) {
+func (m *MockCart) GetAllProducts(cartID int, q model.ProductQueryInput) ([]model.Product, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllProducts", userID, CartID, q)
+	ret := m.ctrl.Call(m, "GetAllProducts", cartID, q)
 	ret0, _ := ret[0].([]model.Product)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAllProducts indicates an expected call of GetAllProducts.
-func (mr *MockCartMockRecorder) GetAllProducts(userID, CartID, q interface{}) *gomock.Call {
+func (mr *MockCartMockRecorder) GetAllProducts(cartID, q interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllProducts", reflect.TypeOf((*MockCart)(nil).GetAllProducts), userID, CartID, q)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllProducts", reflect.TypeOf((*MockCart)(nil).GetAllProducts), cartID, q)
 }
 
 // GetByUserID mocks base method.
@@ -534,16 +1252,421 @@ func (mr *MockCartMockRecorder) GetByUserID(userID interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUserID", reflect.TypeOf((*MockCart)(nil).GetByUserID), userID)
 }
 
+// ReleaseExpiredReservations mocks base method.
+func (m *MockCart) ReleaseExpiredReservations(limit int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseExpiredReservations", limit)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReleaseExpiredReservations indicates an expected call of ReleaseExpiredReservations.
+func (mr *MockCartMockRecorder) ReleaseExpiredReservations(limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseExpiredReservations", reflect.TypeOf((*MockCart)(nil).ReleaseExpiredReservations), limit)
+}
+
+// Total mocks base method.
+func (m *MockCart) Total(cartID int, currency string) (model.Money, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Total", cartID, currency)
+	ret0, _ := ret[0].(model.Money)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Total indicates an expected call of Total.
+func (mr *MockCartMockRecorder) Total(cartID, currency interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Total", reflect.TypeOf((*MockCart)(nil).Total), cartID, currency)
+}
+
 // UpdateProductAmount mocks base method.
-func (m *MockCart) UpdateProductAmount(userID, CartID, productID, amountToPurchase int) error {
+func (m *MockCart) UpdateProductAmount(cartID, productID int, variantID *int, amountToPurchase int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateProductAmount", userID, CartID, productID, amountToPurchase)
+	ret := m.ctrl.Call(m, "UpdateProductAmount", cartID, productID, variantID, amountToPurchase)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateProductAmount indicates an expected call of UpdateProductAmount.
-func (mr *MockCartMockRecorder) UpdateProductAmount(userID, CartID, productID, amountToPurchase interface{}) *gomock.Call {
+func (mr *MockCartMockRecorder) UpdateProductAmount(cartID, productID, variantID, amountToPurchase interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProductAmount", reflect.TypeOf((*MockCart)(nil).UpdateProductAmount), cartID, productID, variantID, amountToPurchase)
+}
+
+// MockSeller is a mock of Seller interface.
+type MockSeller struct {
+	ctrl     *gomock.Controller
+	recorder *MockSellerMockRecorder
+}
+
+// MockSellerMockRecorder is the mock recorder for MockSeller.
+type MockSellerMockRecorder struct {
+	mock *MockSeller
+}
+
+// NewMockSeller creates a new mock instance.
+func NewMockSeller(ctrl *gomock.Controller) *MockSeller {
+	mock := &MockSeller{ctrl: ctrl}
+	mock.recorder = &MockSellerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSeller) EXPECT() *MockSellerMockRecorder {
+	return m.recorder
+}
+
+// Apply mocks base method.
+func (m *MockSeller) Apply(userID int, application model.SellerApplication) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Apply", userID, application)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Apply indicates an expected call of Apply.
+func (mr *MockSellerMockRecorder) Apply(userID, application interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Apply", reflect.TypeOf((*MockSeller)(nil).Apply), userID, application)
+}
+
+// Approve mocks base method.
+func (m *MockSeller) Approve(applicationID, adminID int, input model.ReviewApplicationInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Approve", applicationID, adminID, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Approve indicates an expected call of Approve.
+func (mr *MockSellerMockRecorder) Approve(applicationID, adminID, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Approve", reflect.TypeOf((*MockSeller)(nil).Approve), applicationID, adminID, input)
+}
+
+// GetApplicationByID mocks base method.
+func (m *MockSeller) GetApplicationByID(applicationID int) (model.SellerApplication, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetApplicationByID", applicationID)
+	ret0, _ := ret[0].(model.SellerApplication)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetApplicationByID indicates an expected call of GetApplicationByID.
+func (mr *MockSellerMockRecorder) GetApplicationByID(applicationID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetApplicationByID", reflect.TypeOf((*MockSeller)(nil).GetApplicationByID), applicationID)
+}
+
+// GetApplications mocks base method.
+func (m *MockSeller) GetApplications(q model.SellerApplicationQueryInput) ([]model.SellerApplication, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetApplications", q)
+	ret0, _ := ret[0].([]model.SellerApplication)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetApplications indicates an expected call of GetApplications.
+func (mr *MockSellerMockRecorder) GetApplications(q interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetApplications", reflect.TypeOf((*MockSeller)(nil).GetApplications), q)
+}
+
+// GetProfile mocks base method.
+func (m *MockSeller) GetProfile(userID int) (model.SellerProfile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProfile", userID)
+	ret0, _ := ret[0].(model.SellerProfile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProfile indicates an expected call of GetProfile.
+func (mr *MockSellerMockRecorder) GetProfile(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProfile", reflect.TypeOf((*MockSeller)(nil).GetProfile), userID)
+}
+
+// Reject mocks base method.
+func (m *MockSeller) Reject(applicationID, adminID int, input model.ReviewApplicationInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reject", applicationID, adminID, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reject indicates an expected call of Reject.
+func (mr *MockSellerMockRecorder) Reject(applicationID, adminID, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reject", reflect.TypeOf((*MockSeller)(nil).Reject), applicationID, adminID, input)
+}
+
+// MockCategory is a mock of Category interface.
+type MockCategory struct {
+	ctrl     *gomock.Controller
+	recorder *MockCategoryMockRecorder
+}
+
+// MockCategoryMockRecorder is the mock recorder for MockCategory.
+type MockCategoryMockRecorder struct {
+	mock *MockCategory
+}
+
+// NewMockCategory creates a new mock instance.
+func NewMockCategory(ctrl *gomock.Controller) *MockCategory {
+	mock := &MockCategory{ctrl: ctrl}
+	mock.recorder = &MockCategoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCategory) EXPECT() *MockCategoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockCategory) Create(input model.CategoryInput) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", input)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockCategoryMockRecorder) Create(input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockCategory)(nil).Create), input)
+}
+
+// Delete mocks base method.
+func (m *MockCategory) Delete(categoryID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", categoryID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockCategoryMockRecorder) Delete(categoryID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockCategory)(nil).Delete), categoryID)
+}
+
+// GetBySlug mocks base method.
+func (m *MockCategory) GetBySlug(slug string) (model.Category, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBySlug", slug)
+	ret0, _ := ret[0].(model.Category)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBySlug indicates an expected call of GetBySlug.
+func (mr *MockCategoryMockRecorder) GetBySlug(slug interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBySlug", reflect.TypeOf((*MockCategory)(nil).GetBySlug), slug)
+}
+
+// GetTree mocks base method.
+func (m *MockCategory) GetTree() ([]model.Category, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTree")
+	ret0, _ := ret[0].([]model.Category)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTree indicates an expected call of GetTree.
+func (mr *MockCategoryMockRecorder) GetTree() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTree", reflect.TypeOf((*MockCategory)(nil).GetTree))
+}
+
+// Update mocks base method.
+func (m *MockCategory) Update(categoryID int, input model.UpdateCategoryInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", categoryID, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockCategoryMockRecorder) Update(categoryID, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockCategory)(nil).Update), categoryID, input)
+}
+
+// MockAdmin is a mock of Admin interface.
+type MockAdmin struct {
+	ctrl     *gomock.Controller
+	recorder *MockAdminMockRecorder
+}
+
+// MockAdminMockRecorder is the mock recorder for MockAdmin.
+type MockAdminMockRecorder struct {
+	mock *MockAdmin
+}
+
+// NewMockAdmin creates a new mock instance.
+func NewMockAdmin(ctrl *gomock.Controller) *MockAdmin {
+	mock := &MockAdmin{ctrl: ctrl}
+	mock.recorder = &MockAdminMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAdmin) EXPECT() *MockAdminMockRecorder {
+	return m.recorder
+}
+
+// Ban mocks base method.
+func (m *MockAdmin) Ban(adminID, userID int, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ban", adminID, userID, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ban indicates an expected call of Ban.
+func (mr *MockAdminMockRecorder) Ban(adminID, userID, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ban", reflect.TypeOf((*MockAdmin)(nil).Ban), adminID, userID, reason)
+}
+
+// ChangeRole mocks base method.
+func (m *MockAdmin) ChangeRole(adminID, userID int, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeRole", adminID, userID, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangeRole indicates an expected call of ChangeRole.
+func (mr *MockAdminMockRecorder) ChangeRole(adminID, userID, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeRole", reflect.TypeOf((*MockAdmin)(nil).ChangeRole), adminID, userID, role)
+}
+
+// ForcePasswordReset mocks base method.
+func (m *MockAdmin) ForcePasswordReset(adminID, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForcePasswordReset", adminID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForcePasswordReset indicates an expected call of ForcePasswordReset.
+func (mr *MockAdminMockRecorder) ForcePasswordReset(adminID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForcePasswordReset", reflect.TypeOf((*MockAdmin)(nil).ForcePasswordReset), adminID, userID)
+}
+
+// GetAuditLog mocks base method.
+func (m *MockAdmin) GetAuditLog(q model.AuditQueryInput) ([]model.AuditEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuditLog", q)
+	ret0, _ := ret[0].([]model.AuditEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuditLog indicates an expected call of GetAuditLog.
+func (mr *MockAdminMockRecorder) GetAuditLog(q interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuditLog", reflect.TypeOf((*MockAdmin)(nil).GetAuditLog), q)
+}
+
+// GetUser mocks base method.
+func (m *MockAdmin) GetUser(userID int) (model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", userID)
+	ret0, _ := ret[0].(model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockAdminMockRecorder) GetUser(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockAdmin)(nil).GetUser), userID)
+}
+
+// GetUserOrders mocks base method.
+func (m *MockAdmin) GetUserOrders(userID int, q model.OrderQueryInput) ([]model.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserOrders", userID, q)
+	ret0, _ := ret[0].([]model.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserOrders indicates an expected call of GetUserOrders.
+func (mr *MockAdminMockRecorder) GetUserOrders(userID, q interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserOrders", reflect.TypeOf((*MockAdmin)(nil).GetUserOrders), userID, q)
+}
+
+// GetUserReviews mocks base method.
+func (m *MockAdmin) GetUserReviews(userID int, q model.ReviewQueryInput) ([]model.Review, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserReviews", userID, q)
+	ret0, _ := ret[0].([]model.Review)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserReviews indicates an expected call of GetUserReviews.
+func (mr *MockAdminMockRecorder) GetUserReviews(userID, q interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserReviews", reflect.TypeOf((*MockAdmin)(nil).GetUserReviews), userID, q)
+}
+
+// GetUsers mocks base method.
+func (m *MockAdmin) GetUsers(q model.UserQueryInput) ([]model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsers", q)
+	ret0, _ := ret[0].([]model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsers indicates an expected call of GetUsers.
+func (mr *MockAdminMockRecorder) GetUsers(q interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockAdmin)(nil).GetUsers), q)
+}
+
+// Unban mocks base method.
+func (m *MockAdmin) Unban(adminID, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unban", adminID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unban indicates an expected call of Unban.
+func (mr *MockAdminMockRecorder) Unban(adminID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unban", reflect.TypeOf((*MockAdmin)(nil).Unban), adminID, userID)
+}
+
+// Unlock mocks base method.
+func (m *MockAdmin) Unlock(adminID, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unlock", adminID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unlock indicates an expected call of Unlock.
+func (mr *MockAdminMockRecorder) Unlock(adminID, userID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProductAmount", reflect.TypeOf((*MockCart)(nil).UpdateProductAmount), userID, CartID, productID, amountToPurchase)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unlock", reflect.TypeOf((*MockAdmin)(nil).Unlock), adminID, userID)
 }
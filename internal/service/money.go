@@ -0,0 +1,156 @@
+package service
+
+import (
+	"errors"
+	"market/internal/model"
+	"market/internal/repository"
+	"market/pkg/database/postgres"
+	"market/pkg/exchange"
+	"math/big"
+)
+
+var ErrUnsupportedCurrency = errors.New("unsupported currency")
+
+type MoneyService struct {
+	sellerRepo      repository.SellerRepo
+	rates           exchange.Rates
+	defaultCurrency string
+}
+
+func NewMoneyService(sellerRepo repository.SellerRepo, rates exchange.Rates, defaultCurrency string) *MoneyService {
+	return &MoneyService{sellerRepo: sellerRepo, rates: rates, defaultCurrency: defaultCurrency}
+}
+
+// SellerCurrency returns the currency the user lists products in: the one of
+// their seller profile, or the default currency of the market.
+func (s *MoneyService) SellerCurrency(userID int) (string, error) {
+	profile, err := s.sellerRepo.GetProfile(userID)
+	if err != nil && err != postgres.ErrNotFound {
+		return "", err
+	}
+
+	if profile.Currency != nil {
+		return *profile.Currency, nil
+	}
+
+	return s.defaultCurrency, nil
+}
+
+// DefaultCurrency returns the currency of the market, which prices are shown
+// and compared in unless another one is asked for.
+func (s *MoneyService) DefaultCurrency() string {
+	return s.defaultCurrency
+}
+
+// PriceScale returns the current rates of every supported currency into the
+// currency, the default one when it's empty. Currencies without a rate are left
+// out.
+func (s *MoneyService) PriceScale(currency string) (model.PriceScale, error) {
+	if currency == "" {
+		currency = s.defaultCurrency
+	}
+
+	if !model.ValidCurrency(currency) {
+		return model.PriceScale{}, ErrUnsupportedCurrency
+	}
+
+	scale := model.PriceScale{Currency: currency, Rates: map[string]*big.Rat{currency: big.NewRat(1, 1)}}
+	for _, from := range model.Currencies() {
+		if from == currency {
+			continue
+		}
+
+		rate, err := s.rates.Rate(from, currency)
+		if err != nil {
+			if errors.Is(err, exchange.ErrNoRate) {
+				continue
+			}
+			return model.PriceScale{}, err
+		}
+		scale.Rates[from] = rate
+	}
+
+	return scale, nil
+}
+
+// Convert exchanges the amount into the currency at the current rate.
+func (s *MoneyService) Convert(amount model.Money, currency string) (model.Money, error) {
+	if !model.ValidCurrency(currency) {
+		return model.Money{}, ErrUnsupportedCurrency
+	}
+
+	if amount.Currency == currency {
+		return amount, nil
+	}
+
+	rate, err := s.rates.Rate(amount.Currency, currency)
+	if err != nil {
+		if errors.Is(err, exchange.ErrNoRate) {
+			return model.Money{}, ErrUnsupportedCurrency
+		}
+		return model.Money{}, err
+	}
+
+	return amount.Convert(currency, rate)
+}
+
+// SetDisplayPrice converts the price of the product and of its variants into
+// the currency the buyer wants to see. The prices themselves are left alone.
+func (s *MoneyService) SetDisplayPrice(product *model.Product, currency string) error {
+	price, err := s.Convert(product.Price, currency)
+	if err != nil {
+		return err
+	}
+	product.DisplayPrice = &price
+
+	for i, variant := range product.Variants {
+		if variant.Price == nil {
+			continue
+		}
+
+		price, err := s.Convert(*variant.Price, currency)
+		if err != nil {
+			return err
+		}
+		product.Variants[i].DisplayPrice = &price
+	}
+
+	return nil
+}
+
+// Total sums the prices of the cart or order lines times their purchased
+// amounts. Without a currency the total is in the currency all lines share,
+// or in the default currency when they differ.
+func (s *MoneyService) Total(lines []model.Product, currency string) (model.Money, error) {
+	if currency == "" {
+		currency = s.sharedCurrency(lines)
+	}
+
+	total := model.Money{Currency: currency}
+	for _, line := range lines {
+		price, err := s.Convert(line.Price, currency)
+		if err != nil {
+			return model.Money{}, err
+		}
+
+		if total, err = total.Add(price.Mul(line.PurchasedAmount)); err != nil {
+			return model.Money{}, err
+		}
+	}
+
+	return total, nil
+}
+
+func (s *MoneyService) sharedCurrency(lines []model.Product) string {
+	if len(lines) == 0 {
+		return s.defaultCurrency
+	}
+
+	for _, line := range lines[1:] {
+		if line.Price.Currency != lines[0].Price.Currency {
+			return s.defaultCurrency
+		}
+	}
+
+	return lines[0].Price.Currency
+}
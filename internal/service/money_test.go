@@ -0,0 +1,139 @@
+package service
+
+import (
+	"market/internal/model"
+	"market/pkg/exchange"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMoneyService(t *testing.T) *MoneyService {
+	rates, err := exchange.NewStatic("RUB", map[string]string{"USD": "0.0105", "JPY": "1.57"})
+	require.NoError(t, err)
+
+	return NewMoneyService(nil, rates, "RUB")
+}
+
+func TestMoneyService_Convert(t *testing.T) {
+	s := newTestMoneyService(t)
+
+	tests := []struct {
+		name    string
+		amount  string
+		from    string
+		to      string
+		want    string
+		wantErr error
+	}{
+		{name: "Same Currency", amount: "19.99", from: "USD", to: "USD", want: "19.99"},
+		{name: "From Base", amount: "1999.99", from: "RUB", to: "USD", want: "21.00"},
+		{name: "To Base", amount: "19.99", from: "USD", to: "RUB", want: "1903.81"},
+		{name: "Without Minor Units", amount: "10.50", from: "RUB", to: "JPY", want: "16"},
+		{name: "Cross Rate", amount: "1000", from: "JPY", to: "USD", want: "6.69"},
+		{name: "Unknown Currency", amount: "1", from: "RUB", to: "XXX", wantErr: ErrUnsupportedCurrency},
+		{name: "No Rate", amount: "1", from: "RUB", to: "EUR", wantErr: ErrUnsupportedCurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amount, err := model.ParseMoney(tt.amount, tt.from)
+			require.NoError(t, err)
+
+			got, err := s.Convert(amount, tt.to)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr == nil {
+				assert.Equal(t, tt.want, got.Decimal())
+				assert.Equal(t, tt.to, got.Currency)
+			}
+		})
+	}
+}
+
+func TestMoneyService_Total(t *testing.T) {
+	s := newTestMoneyService(t)
+
+	line := func(price, currency string, amount int) model.Product {
+		money, err := model.ParseMoney(price, currency)
+		require.NoError(t, err)
+		return model.Product{Price: money, PurchasedAmount: amount}
+	}
+
+	// 0.1 + 0.2 adds up exactly, unlike with floating point prices.
+	total, err := s.Total([]model.Product{line("0.10", "USD", 1), line("0.2", "USD", 1), line("19.99", "USD", 3)}, "")
+	require.NoError(t, err)
+	assert.Equal(t, model.Money{Amount: 6027, Currency: "USD"}, total)
+
+	total, err = s.Total([]model.Product{line("10", "USD", 1), line("100", "RUB", 2)}, "")
+	require.NoError(t, err)
+	assert.Equal(t, "1152.38 RUB", total.String())
+
+	total, err = s.Total([]model.Product{line("100", "RUB", 2)}, "USD")
+	require.NoError(t, err)
+	assert.Equal(t, "2.10 USD", total.String())
+
+	total, err = s.Total(nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, model.Money{Currency: "RUB"}, total)
+}
+
+func TestMoneyService_PriceScale(t *testing.T) {
+	s := newTestMoneyService(t)
+
+	scale, err := s.PriceScale("USD")
+	require.NoError(t, err)
+	assert.Equal(t, "USD", scale.Currency)
+	// Currencies without a rate, e.g. EUR, are left out.
+	assert.Len(t, scale.Rates, 3)
+	assert.Equal(t, "1", scale.Rates["USD"].RatString())
+	assert.Equal(t, "21/2000", scale.Rates["RUB"].RatString())
+	// 1 JPY is 1/1.57 RUB, which is 0.0105/1.57 USD.
+	assert.Equal(t, "21/3140", scale.Rates["JPY"].RatString())
+
+	scale, err = s.PriceScale("")
+	require.NoError(t, err)
+	assert.Equal(t, "RUB", scale.Currency)
+
+	_, err = s.PriceScale("XXX")
+	assert.Equal(t, ErrUnsupportedCurrency, err)
+}
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		value    string
+		currency string
+		want     model.Money
+		wantErr  error
+	}{
+		{value: "19.99", currency: "USD", want: model.Money{Amount: 1999, Currency: "USD"}},
+		{value: "19.9", currency: "USD", want: model.Money{Amount: 1990, Currency: "USD"}},
+		{value: "19", currency: "USD", want: model.Money{Amount: 1900, Currency: "USD"}},
+		{value: "1500", currency: "JPY", want: model.Money{Amount: 1500, Currency: "JPY"}},
+		{value: "19.999", currency: "USD", wantErr: model.ErrInvalidAmount},
+		{value: "15.5", currency: "JPY", wantErr: model.ErrInvalidAmount},
+		{value: "1e3", currency: "USD", wantErr: model.ErrInvalidAmount},
+		{value: ".5", currency: "USD", wantErr: model.ErrInvalidAmount},
+		{value: "", currency: "USD", wantErr: model.ErrInvalidAmount},
+		{value: "1", currency: "usd", wantErr: model.ErrUnknownCurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value+" "+tt.currency, func(t *testing.T) {
+			got, err := model.ParseMoney(tt.value, tt.currency)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMoney_Scan(t *testing.T) {
+	var price model.Money
+	require.NoError(t, price.Scan([]byte("(19.90,USD)")))
+	assert.Equal(t, model.Money{Amount: 1990, Currency: "USD"}, price)
+
+	require.NoError(t, price.Scan("(1500.00,JPY)"))
+	assert.Equal(t, model.Money{Amount: 1500, Currency: "JPY"}, price)
+
+	assert.Error(t, price.Scan([]byte("19.90")))
+}
@@ -4,98 +4,187 @@ import (
 	"errors"
 	"market/internal/model"
 	"market/internal/repository"
+	"market/pkg/database/postgres"
+	"math/big"
+	"time"
 )
 
 var (
-	ErrNoOrder    = errors.New("order doesn't exists")
-	ErrNoProducts = errors.New("no products in cart")
+	ErrNoOrder            = errors.New("order doesn't exists")
+	ErrNoProducts         = errors.New("no products in cart")
+	ErrEmailNotVerified   = errors.New("email is not verified")
+	ErrInvalidTransition  = errors.New("order can't move to this status")
+	ErrOrderStatusChanged = errors.New("order status was changed concurrently")
 )
 
+type OrderConfig struct {
+	// TaxRate is the share of the subtotal charged as tax, e.g. 1/5 for 20%.
+	// A nil rate charges no tax.
+	TaxRate *big.Rat
+	// ShippingFee is charged once per order, converted into its currency.
+	ShippingFee model.Money
+}
+
 type OrderService struct {
 	orderRepo repository.OrderRepo
 	cartRepo  repository.CartRepo
 	userRepo  repository.UserRepo
+	money     Money
+	config    OrderConfig
 }
 
-func NewOrderService(orderRepo repository.OrderRepo, cartRepo repository.CartRepo, userRepo repository.UserRepo) *OrderService {
-	return &OrderService{orderRepo: orderRepo, cartRepo: cartRepo, userRepo: userRepo}
+func NewOrderService(orderRepo repository.OrderRepo, cartRepo repository.CartRepo, userRepo repository.UserRepo, money Money,
+	config OrderConfig) *OrderService {
+	return &OrderService{orderRepo: orderRepo, cartRepo: cartRepo, userRepo: userRepo, money: money, config: config}
 }
 
-func (s *OrderService) Create(userID int, order model.Order) (int, error) {
+// Create places a pending order for the contents of the user's cart. The lines
+// keep the title, SKU, seller and price of the products at checkout, prices
+// converted into the currency of the order.
+func (s *OrderService) Create(userID int) (int, error) {
 	user, err := s.userRepo.GetUserByID(userID)
 	if err != nil {
 		return 0, err
 	}
-	if user.Role == model.ADMIN || user.ID == userID {
-		cart, err := s.cartRepo.GetByUserID(userID)
-		if err != nil {
-			return 0, err
-		}
 
-		q := model.ProductQueryInput{
-			QueryInput: model.QueryInput{
-				Limit:     0,
-				Offset:    0,
-				SortBy:    model.SortByDate,
-				SortOrder: model.DESCENDING,
-			},
-		}
+	if !user.EmailVerified {
+		return 0, ErrEmailNotVerified
+	}
 
-		order.Products, err = s.cartRepo.GetAllProducts(cart.ID, q)
-		if err != nil {
-			return 0, err
-		}
+	cart, err := s.cartRepo.GetByUserID(userID)
+	if err != nil {
+		return 0, err
+	}
 
-		if order.Products == nil {
-			return 0, ErrNoProducts
-		}
+	q := model.ProductQueryInput{
+		QueryInput: model.QueryInput{
+			Limit:  0,
+			Offset: 0,
+			Sort:   model.Sort{model.Desc(model.SortByDate)},
+		},
+	}
 
-		lastID, err := s.orderRepo.Create(cart.ID, userID, order)
-		if err != nil {
-			return 0, err
-		}
-		return lastID, nil
+	products, err := s.cartRepo.GetAllProducts(cart.ID, q)
+	if err != nil {
+		return 0, err
 	}
 
-	return 0, ErrPermissionDenied
-}
+	if len(products) == 0 {
+		return 0, ErrNoProducts
+	}
 
-func (s *OrderService) GetAll(userID int, q model.OrderQueryInput) ([]model.Order, error) {
-	user, err := s.userRepo.GetUserByID(userID)
-	if err != nil {
-		return []model.Order{}, err
+	order := model.Order{
+		UserID:    userID,
+		Status:    model.OrderPending,
+		CreatedAt: time.Now(),
 	}
-	if user.Role == model.ADMIN || user.ID == userID {
-		return s.orderRepo.GetAll(userID, q)
+
+	if err = s.setLines(&order, products); err != nil {
+		return 0, err
 	}
 
-	return []model.Order{}, ErrPermissionDenied
+	return s.orderRepo.Create(cart.ID, userID, order)
 }
 
-func (s *OrderService) GetByID(userID, orderID int) (model.Order, error) {
-	user, err := s.userRepo.GetUserByID(userID)
+// setLines snapshots the cart products as order lines and sums up the order.
+func (s *OrderService) setLines(order *model.Order, products []model.Product) error {
+	total, err := s.money.Total(products, "")
 	if err != nil {
-		return model.Order{}, err
+		return err
 	}
-	if user.Role == model.ADMIN || user.ID == userID {
-		order, err := s.orderRepo.GetByID(orderID)
+	currency := total.Currency
+
+	order.Subtotal = model.Money{Currency: currency}
+	order.Lines = make([]model.OrderLine, 0, len(products))
+	for _, product := range products {
+		price, err := s.money.Convert(product.Price, currency)
 		if err != nil {
-			return model.Order{}, err
+			return err
+		}
+
+		productID, sellerID := product.ID, product.UserID
+		order.Lines = append(order.Lines, model.OrderLine{
+			ProductID: &productID,
+			VariantID: product.VariantID,
+			SellerID:  &sellerID,
+			Title:     product.Title,
+			SKU:       product.SKU,
+			UnitPrice: price,
+			Quantity:  product.PurchasedAmount,
+		})
+
+		if order.Subtotal, err = order.Subtotal.Add(price.Mul(product.PurchasedAmount)); err != nil {
+			return err
 		}
-		return order, nil
 	}
 
-	return model.Order{}, ErrPermissionDenied
+	order.Tax = model.Money{Currency: currency}
+	if s.config.TaxRate != nil {
+		if order.Tax, err = order.Subtotal.Convert(currency, s.config.TaxRate); err != nil {
+			return err
+		}
+	}
+
+	order.Shipping = model.Money{Currency: currency}
+	if s.config.ShippingFee.Amount != 0 {
+		if order.Shipping, err = s.money.Convert(s.config.ShippingFee, currency); err != nil {
+			return err
+		}
+	}
+
+	if order.Total, err = order.Subtotal.Add(order.Tax); err != nil {
+		return err
+	}
+	order.Total, err = order.Total.Add(order.Shipping)
+
+	return err
 }
 
-func (s *OrderService) GetProductsByOrderID(userID, orderID int, q model.ProductQueryInput) ([]model.Product, error) {
-	user, err := s.userRepo.GetUserByID(userID)
+func (s *OrderService) GetAll(userID int, q model.OrderQueryInput) ([]model.Order, error) {
+	return s.orderRepo.GetAll(userID, q)
+}
+
+func (s *OrderService) GetByID(orderID int) (model.Order, error) {
+	return s.orderRepo.GetByID(orderID)
+}
+
+func (s *OrderService) GetLines(orderID int) ([]model.OrderLine, error) {
+	return s.orderRepo.GetLines(orderID)
+}
+
+// ChangeStatus moves the order to the status of the input if the lifecycle
+// allows it and records who did it.
+func (s *OrderService) ChangeStatus(orderID, userID int, input model.ChangeOrderStatusInput) error {
+	order, err := s.orderRepo.GetByID(orderID)
 	if err != nil {
-		return []model.Product{}, err
+		return err
+	}
+
+	if !model.CanTransition(order.Status, input.Status) {
+		return ErrInvalidTransition
 	}
-	if user.Role == model.ADMIN || user.ID == userID {
-		return s.orderRepo.GetProductsByOrderID(orderID, q)
+
+	err = s.orderRepo.ChangeStatus(model.OrderStatusChange{
+		OrderID:    orderID,
+		FromStatus: &order.Status,
+		ToStatus:   input.Status,
+		ChangedBy:  &userID,
+		Comment:    input.Comment,
+		CreatedAt:  time.Now(),
+	})
+	// The status was changed between reading and updating the order.
+	if err == postgres.ErrNotFound {
+		return ErrOrderStatusChanged
 	}
 
-	return []model.Product{}, ErrPermissionDenied
+	return err
+}
+
+// Cancel cancels a pending order.
+func (s *OrderService) Cancel(orderID, userID int, input model.CancelOrderInput) error {
+	return s.ChangeStatus(orderID, userID, model.ChangeOrderStatusInput{Status: model.OrderCancelled, Comment: input.Comment})
+}
+
+func (s *OrderService) GetStatusHistory(orderID int) ([]model.OrderStatusChange, error) {
+	return s.orderRepo.GetStatusHistory(orderID)
 }
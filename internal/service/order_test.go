@@ -0,0 +1,116 @@
+package service
+
+import (
+	"market/internal/model"
+	"market/internal/repository"
+	"market/pkg/database/postgres"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type orderRepoStub struct {
+	repository.OrderRepo
+	order   model.Order
+	changes []model.OrderStatusChange
+	// raced makes ChangeStatus behave as if the status changed meanwhile.
+	raced bool
+}
+
+func (r *orderRepoStub) GetByID(orderID int) (model.Order, error) {
+	if orderID != r.order.ID {
+		return model.Order{}, postgres.ErrNotFound
+	}
+	return r.order, nil
+}
+
+func (r *orderRepoStub) ChangeStatus(change model.OrderStatusChange) error {
+	if r.raced {
+		return postgres.ErrNotFound
+	}
+	r.changes = append(r.changes, change)
+	return nil
+}
+
+func TestOrderService_ChangeStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		raced   bool
+		wantErr error
+	}{
+		{name: "Pay", from: model.OrderPending, to: model.OrderPaid},
+		{name: "Ship", from: model.OrderPaid, to: model.OrderShipped},
+		{name: "Deliver", from: model.OrderShipped, to: model.OrderDelivered},
+		{name: "Refund Delivered", from: model.OrderDelivered, to: model.OrderRefunded},
+		{name: "Cancel Pending", from: model.OrderPending, to: model.OrderCancelled},
+		{name: "Cancel Paid", from: model.OrderPaid, to: model.OrderCancelled, wantErr: ErrInvalidTransition},
+		{name: "Skip Shipping", from: model.OrderPaid, to: model.OrderDelivered, wantErr: ErrInvalidTransition},
+		{name: "Back To Pending", from: model.OrderPaid, to: model.OrderPending, wantErr: ErrInvalidTransition},
+		{name: "Reopen Cancelled", from: model.OrderCancelled, to: model.OrderPaid, wantErr: ErrInvalidTransition},
+		{name: "Same Status", from: model.OrderShipped, to: model.OrderShipped, wantErr: ErrInvalidTransition},
+		{name: "Changed Concurrently", from: model.OrderPending, to: model.OrderPaid, raced: true, wantErr: ErrOrderStatusChanged},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &orderRepoStub{order: model.Order{ID: 1, Status: tt.from}, raced: tt.raced}
+			s := NewOrderService(repo, nil, nil, nil, OrderConfig{})
+
+			err := s.ChangeStatus(1, 10, model.ChangeOrderStatusInput{Status: tt.to})
+			assert.Equal(t, tt.wantErr, err)
+
+			if tt.wantErr == nil {
+				require.Len(t, repo.changes, 1)
+				assert.Equal(t, tt.from, *repo.changes[0].FromStatus)
+				assert.Equal(t, tt.to, repo.changes[0].ToStatus)
+				assert.Equal(t, 10, *repo.changes[0].ChangedBy)
+			} else {
+				assert.Empty(t, repo.changes)
+			}
+		})
+	}
+}
+
+func TestOrderService_ChangeStatus_NoOrder(t *testing.T) {
+	s := NewOrderService(&orderRepoStub{order: model.Order{ID: 1}}, nil, nil, nil, OrderConfig{})
+
+	err := s.ChangeStatus(2, 10, model.ChangeOrderStatusInput{Status: model.OrderPaid})
+	assert.Equal(t, postgres.ErrNotFound, err)
+}
+
+func TestOrderService_setLines(t *testing.T) {
+	shippingFee, err := model.ParseMoney("300", "RUB")
+	require.NoError(t, err)
+
+	s := NewOrderService(nil, nil, nil, newTestMoneyService(t), OrderConfig{TaxRate: big.NewRat(1, 5), ShippingFee: shippingFee})
+
+	sku := "TEE-M"
+	variantID := 7
+	products := []model.Product{
+		{ID: 1, UserID: 2, Title: "Tee", SKU: &sku, VariantID: &variantID, Price: model.Money{Amount: 1999, Currency: "USD"}, PurchasedAmount: 2},
+		{ID: 3, UserID: 4, Title: "Mug", Price: model.Money{Amount: 505, Currency: "USD"}, PurchasedAmount: 1},
+	}
+
+	var order model.Order
+	require.NoError(t, s.setLines(&order, products))
+
+	require.Len(t, order.Lines, 2)
+	assert.Equal(t, "Tee", order.Lines[0].Title)
+	assert.Equal(t, &sku, order.Lines[0].SKU)
+	assert.Equal(t, &variantID, order.Lines[0].VariantID)
+	assert.Equal(t, 2, *order.Lines[0].SellerID)
+	assert.Equal(t, model.Money{Amount: 1999, Currency: "USD"}, order.Lines[0].UnitPrice)
+	assert.Equal(t, 2, order.Lines[0].Quantity)
+	assert.Equal(t, 4, *order.Lines[1].SellerID)
+
+	assert.Equal(t, "45.03 USD", order.Subtotal.String())
+	// 20% of 45.03 is 9.006, rounded to whole cents.
+	assert.Equal(t, "9.01 USD", order.Tax.String())
+	// 300 RUB at 0.0105 USD per rouble.
+	assert.Equal(t, "3.15 USD", order.Shipping.String())
+	assert.Equal(t, "57.19 USD", order.Total.String())
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"market/internal/model"
+	"market/internal/repository"
+	"time"
+)
+
+const (
+	outboxLease          = 5 * time.Minute
+	outboxBaseRetryDelay = 30 * time.Second
+	outboxMaxRetryDelay  = 6 * time.Hour
+)
+
+// OutboxService performs the side effects recorded in the outbox, like
+// deleting images whose products were deleted.
+type OutboxService struct {
+	outboxRepo      repository.OutboxRepo
+	storedImageRepo repository.StoredImageRepo
+	images          Image
+}
+
+func NewOutboxService(outboxRepo repository.OutboxRepo, storedImageRepo repository.StoredImageRepo, images Image) *OutboxService {
+	return &OutboxService{outboxRepo: outboxRepo, storedImageRepo: storedImageRepo, images: images}
+}
+
+// Process performs up to limit due messages and returns the number of
+// performed and failed ones. A failed message is retried later with an
+// exponential backoff.
+func (s *OutboxService) Process(ctx context.Context, limit int) (done, failed int, err error) {
+	messages, err := s.outboxRepo.Claim(limit, outboxLease)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, message := range messages {
+		if err = s.perform(ctx, message); err != nil {
+			failed++
+			if err = s.outboxRepo.Retry(message.ID, err.Error(), outboxRetryDelay(message.Attempts)); err != nil {
+				return done, failed, err
+			}
+			continue
+		}
+
+		if err = s.outboxRepo.Delete(message.ID); err != nil {
+			return done, failed, err
+		}
+		done++
+	}
+
+	return done, failed, nil
+}
+
+// ReconcileImages queues the deletion of stored images that aren't referenced
+// anywhere. Images younger than minAge are left alone, since the product or
+// variant they were uploaded for may not be saved yet.
+func (s *OutboxService) ReconcileImages(minAge time.Duration) (int, error) {
+	return s.storedImageRepo.EnqueueOrphans(minAge)
+}
+
+func (s *OutboxService) perform(ctx context.Context, message model.OutboxMessage) error {
+	switch message.Kind {
+	case model.OutboxImageDelete:
+		return s.images.Delete(ctx, message.Payload)
+	default:
+		return fmt.Errorf("unknown outbox message kind %q", message.Kind)
+	}
+}
+
+// outboxRetryDelay doubles the delay with every attempt.
+func outboxRetryDelay(attempts int) time.Duration {
+	delay := outboxBaseRetryDelay
+	for i := 1; i < attempts && delay < outboxMaxRetryDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > outboxMaxRetryDelay {
+		return outboxMaxRetryDelay
+	}
+
+	return delay
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"market/internal/model"
+	"mime/multipart"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type outboxRepoStub struct {
+	messages []model.OutboxMessage
+	retried  map[int]time.Duration
+	deleted  []int
+}
+
+func (r *outboxRepoStub) Claim(limit int, lease time.Duration) ([]model.OutboxMessage, error) {
+	return r.messages, nil
+}
+
+func (r *outboxRepoStub) Retry(messageID int, lastError string, delay time.Duration) error {
+	r.retried[messageID] = delay
+	return nil
+}
+
+func (r *outboxRepoStub) Delete(messageID int) error {
+	r.deleted = append(r.deleted, messageID)
+	return nil
+}
+
+type imageStub struct {
+	failing map[string]bool
+	deleted []string
+}
+
+func (s *imageStub) Upload(ctx context.Context, file multipart.File) (ImageData, error) {
+	return ImageData{}, nil
+}
+
+func (s *imageStub) Delete(ctx context.Context, imageID string) error {
+	if s.failing[imageID] {
+		return errors.New("storage is down")
+	}
+	s.deleted = append(s.deleted, imageID)
+	return nil
+}
+
+func TestOutboxService_Process(t *testing.T) {
+	repo := &outboxRepoStub{
+		messages: []model.OutboxMessage{
+			{ID: 1, Kind: model.OutboxImageDelete, Payload: "a.png", Attempts: 1},
+			{ID: 2, Kind: model.OutboxImageDelete, Payload: "b.png", Attempts: 3},
+			{ID: 3, Kind: "unknown", Payload: "c", Attempts: 1},
+		},
+		retried: make(map[int]time.Duration),
+	}
+	images := &imageStub{failing: map[string]bool{"b.png": true}}
+	s := NewOutboxService(repo, nil, images)
+
+	done, failed, err := s.Process(context.Background(), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, done)
+	assert.Equal(t, 2, failed)
+	assert.Equal(t, []string{"a.png"}, images.deleted)
+	assert.Equal(t, []int{1}, repo.deleted)
+	assert.Equal(t, map[int]time.Duration{2: 4 * outboxBaseRetryDelay, 3: outboxBaseRetryDelay}, repo.retried)
+}
+
+func TestOutboxRetryDelay(t *testing.T) {
+	assert.Equal(t, outboxBaseRetryDelay, outboxRetryDelay(1))
+	assert.Equal(t, 2*outboxBaseRetryDelay, outboxRetryDelay(2))
+	assert.Equal(t, outboxMaxRetryDelay, outboxRetryDelay(100))
+}
@@ -4,24 +4,37 @@ import (
 	"errors"
 	"market/internal/model"
 	"market/internal/repository"
+	"market/pkg/database/postgres"
 )
 
 var (
-	ErrPermissionDenied = errors.New("you have no access")
-	ErrNoProduct        = errors.New("product doesn't exists")
-	ErrProductExists    = errors.New("product already exists")
+	ErrNoProduct     = errors.New("product doesn't exists")
+	ErrProductExists = errors.New("product already exists")
 )
 
 type ProductService struct {
-	productRepo repository.ProductRepo
-	userRepo    repository.UserRepo
+	productRepo  repository.ProductRepo
+	categoryRepo repository.CategoryRepo
+	money        Money
 }
 
-func NewProductService(productRepo repository.ProductRepo, userRepo repository.UserRepo) *ProductService {
-	return &ProductService{productRepo: productRepo, userRepo: userRepo}
+func NewProductService(productRepo repository.ProductRepo, categoryRepo repository.CategoryRepo, money Money) *ProductService {
+	return &ProductService{productRepo: productRepo, categoryRepo: categoryRepo, money: money}
 }
 
 func (s *ProductService) Create(product model.Product) (int, error) {
+	if len(product.Images) == 0 {
+		return 0, ErrNoImages
+	}
+
+	if len(product.Images) > model.MaxProductImages {
+		return 0, ErrTooManyImages
+	}
+
+	if err := s.checkCategory(product.CategoryID); err != nil {
+		return 0, err
+	}
+
 	id, err := s.productRepo.Create(product)
 	if err != nil {
 		return 0, err
@@ -30,14 +43,48 @@ func (s *ProductService) Create(product model.Product) (int, error) {
 }
 
 func (s *ProductService) GetAll(q model.ProductQueryInput) ([]model.Product, error) {
+	if err := s.setPriceScale(&q.Prices); err != nil {
+		return nil, err
+	}
 	return s.productRepo.GetAll(q)
 }
 func (s *ProductService) GetProductsByUserID(userID int, q model.ProductQueryInput) ([]model.Product, error) {
+	if err := s.setPriceScale(&q.Prices); err != nil {
+		return nil, err
+	}
 	return s.productRepo.GetProductsByUserID(userID, q)
 }
 
-func (s *ProductService) GetProductsByCategory(productType string, q model.ProductQueryInput) ([]model.Product, error) {
-	return s.productRepo.GetProductsByCategory(productType, q)
+// GetProductsByCategory lists the products of the category with the slug and
+// of its descendants.
+func (s *ProductService) GetProductsByCategory(slug string, q model.ProductQueryInput) ([]model.Product, error) {
+	category, err := s.categoryRepo.GetBySlug(slug)
+	if err != nil {
+		if err == postgres.ErrNotFound {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, err
+	}
+
+	if err = s.setPriceScale(&q.Prices); err != nil {
+		return nil, err
+	}
+
+	return s.productRepo.GetProductsByCategory(category.ID, q)
+}
+
+func (s *ProductService) Search(q model.ProductSearchInput) ([]model.ProductSearchResult, error) {
+	if err := s.setPriceScale(&q.Prices); err != nil {
+		return nil, err
+	}
+	return s.productRepo.Search(q)
+}
+
+func (s *ProductService) GetFacets(f model.ProductFilter) (model.ProductFacets, error) {
+	if err := s.setPriceScale(&f.Prices); err != nil {
+		return model.ProductFacets{}, err
+	}
+	return s.productRepo.GetFacets(f)
 }
 
 func (s *ProductService) GetByID(productID int) (model.Product, error) {
@@ -48,19 +95,18 @@ func (s *ProductService) GetByID(productID int) (model.Product, error) {
 	return product, nil
 }
 
-func (s *ProductService) Update(userID, productID int, input model.UpdateProductInput) error {
-	user, err := s.userRepo.GetUserByID(userID)
-	if err != nil {
+func (s *ProductService) Update(productID int, input model.UpdateProductInput) error {
+	if err := input.Validate(); err != nil {
 		return err
 	}
-	if user.Role == model.ADMIN || user.ID == userID {
-		if err := input.Validate(); err != nil {
+
+	if input.CategoryID != nil {
+		if err := s.checkCategory(*input.CategoryID); err != nil {
 			return err
 		}
-		return s.productRepo.Update(productID, input)
 	}
 
-	return ErrPermissionDenied
+	return s.productRepo.Update(productID, input)
 }
 
 func (s *ProductService) IncreaseViewsCounter(productID int) error {
@@ -74,14 +120,30 @@ func (s *ProductService) IncreaseViewsCounter(productID int) error {
 	return s.productRepo.Update(productID, input)
 }
 
-func (s *ProductService) Delete(userID, productID int) error {
-	user, err := s.userRepo.GetUserByID(userID)
+// setPriceScale fills in the rates prices of products are compared with in the
+// currency of the scale, the default one when it's not set.
+func (s *ProductService) setPriceScale(prices *model.PriceScale) error {
+	scale, err := s.money.PriceScale(prices.Currency)
 	if err != nil {
 		return err
 	}
-	if user.Role == model.ADMIN || user.ID == userID {
-		return s.productRepo.Delete(productID)
-	}
 
-	return ErrPermissionDenied
+	*prices = scale
+	return nil
+}
+
+// Delete removes the product. Its gallery and variant images are deleted from
+// the image service by the outbox worker.
+func (s *ProductService) Delete(productID int) error {
+	return s.productRepo.Delete(productID)
+}
+
+func (s *ProductService) checkCategory(categoryID int) error {
+	if _, err := s.categoryRepo.GetByID(categoryID); err != nil {
+		if err == postgres.ErrNotFound {
+			return ErrCategoryNotFound
+		}
+		return err
+	}
+	return nil
 }
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"errors"
+	"market/internal/model"
+	"market/internal/repository"
+	"market/pkg/database/postgres"
+	"time"
+)
+
+var (
+	ErrImageNotFound     = errors.New("image doesn't exist")
+	ErrNoImages          = errors.New("product must have at least one image")
+	ErrTooManyImages     = errors.New("too many images")
+	ErrInvalidImageOrder = errors.New("image order must list every image of the product once")
+)
+
+type ProductImageService struct {
+	imageRepo repository.ProductImageRepo
+}
+
+func NewProductImageService(imageRepo repository.ProductImageRepo) *ProductImageService {
+	return &ProductImageService{imageRepo: imageRepo}
+}
+
+// Add appends the images to the end of the product's gallery. With primary
+// set, the first of them becomes the primary image. A product without images
+// gets its first image as the primary one anyway. The images are added all
+// together or not at all.
+func (s *ProductImageService) Add(productID int, images []model.ProductImage, primary bool) error {
+	if len(images) == 0 {
+		return ErrNoImages
+	}
+
+	now := time.Now()
+	for i := range images {
+		images[i].CreatedAt = now
+	}
+
+	if err := s.imageRepo.Add(productID, images, primary); err != nil {
+		if err == repository.ErrTooManyImages {
+			return ErrTooManyImages
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *ProductImageService) GetAll(productID int) ([]model.ProductImage, error) {
+	return s.imageRepo.GetAll(productID)
+}
+
+func (s *ProductImageService) Reorder(productID int, imageIDs []int) error {
+	gallery, err := s.imageRepo.GetAll(productID)
+	if err != nil {
+		return err
+	}
+
+	if len(imageIDs) != len(gallery) {
+		return ErrInvalidImageOrder
+	}
+
+	listed := make(map[int]bool, len(imageIDs))
+	for _, id := range imageIDs {
+		listed[id] = true
+	}
+
+	for _, image := range gallery {
+		if !listed[image.ID] {
+			return ErrInvalidImageOrder
+		}
+	}
+
+	return s.imageRepo.Reorder(productID, imageIDs)
+}
+
+func (s *ProductImageService) SetPrimary(productID, imageID int) error {
+	if err := s.imageRepo.SetPrimary(productID, imageID); err != nil {
+		if err == postgres.ErrNotFound {
+			return ErrImageNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Delete removes the image from the gallery. The image itself is deleted from
+// the image service by the outbox worker. The last image of a product can't be
+// deleted. When the primary image is deleted, the next one in order takes its
+// place.
+func (s *ProductImageService) Delete(productID, imageID int) error {
+	if err := s.imageRepo.Delete(productID, imageID); err != nil {
+		switch err {
+		case repository.ErrLastImage:
+			return ErrNoImages
+		case postgres.ErrNotFound:
+			return ErrImageNotFound
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
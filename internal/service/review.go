@@ -13,13 +13,11 @@ var (
 )
 
 type ReviewService struct {
-	reviewRepo  repository.ReviewRepo
-	userRepo    repository.UserRepo
-	productRepo repository.ProductRepo
+	reviewRepo repository.ReviewRepo
 }
 
-func NewReviewService(reviewRepo repository.ReviewRepo, userRepo repository.UserRepo, productRepo repository.ProductRepo) *ReviewService {
-	return &ReviewService{reviewRepo: reviewRepo, userRepo: userRepo, productRepo: productRepo}
+func NewReviewService(reviewRepo repository.ReviewRepo) *ReviewService {
+	return &ReviewService{reviewRepo: reviewRepo}
 }
 
 func (s *ReviewService) Create(review model.Review) (int, error) {
@@ -34,33 +32,22 @@ func (s *ReviewService) Create(review model.Review) (int, error) {
 	return 0, ErrReviewExists
 }
 
+// GetByID gives postgres.ErrNotFound for reviews of other products.
+func (s *ReviewService) GetByID(productID, reviewID int) (model.Review, error) {
+	return s.reviewRepo.GetByID(productID, reviewID)
+}
+
 func (s *ReviewService) GetAll(productID int, q model.ReviewQueryInput) ([]model.Review, error) {
 	return s.reviewRepo.GetAll(productID, q)
 }
 
-func (s *ReviewService) Update(userID, productID int, input model.UpdateReviewInput) error {
-	user, err := s.userRepo.GetUserByID(userID)
-	if err != nil {
+func (s *ReviewService) Update(reviewID int, input model.UpdateReviewInput) error {
+	if err := input.Validate(); err != nil { // TODO: refactor
 		return err
 	}
-	if user.Role == model.ADMIN || user.ID == userID {
-		if err := input.Validate(); err != nil { // TODO: refactor
-			return err
-		}
-		return s.reviewRepo.Update(userID, productID, input)
-	}
-
-	return ErrPermissionDenied
+	return s.reviewRepo.Update(reviewID, input)
 }
 
-func (s *ReviewService) Delete(userID, reviewID int) error {
-	user, err := s.userRepo.GetUserByID(userID)
-	if err != nil {
-		return err
-	}
-	if user.Role == model.ADMIN || user.ID == userID {
-		return s.reviewRepo.Delete(reviewID)
-	}
-
-	return ErrPermissionDenied
+func (s *ReviewService) Delete(reviewID int) error {
+	return s.reviewRepo.Delete(reviewID)
 }
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"errors"
+	"market/internal/model"
+	"market/internal/repository"
+	"market/pkg/database/postgres"
+	"time"
+)
+
+var (
+	ErrAlreadySeller       = errors.New("user is already a seller")
+	ErrApplicationPending  = errors.New("application is already pending")
+	ErrApplicationReviewed = errors.New("application is already reviewed")
+)
+
+type SellerService struct {
+	sellerRepo repository.SellerRepo
+	userRepo   repository.UserRepo
+}
+
+func NewSellerService(sellerRepo repository.SellerRepo, userRepo repository.UserRepo) *SellerService {
+	return &SellerService{sellerRepo: sellerRepo, userRepo: userRepo}
+}
+
+func (s *SellerService) Apply(userID int, application model.SellerApplication) (int, error) {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	if user.Role != model.USER {
+		return 0, ErrAlreadySeller
+	}
+
+	application.UserID = userID
+	application.CreatedAt = time.Now()
+
+	id, err := s.sellerRepo.CreateApplication(application)
+	if err != nil {
+		if err == postgres.ErrAlreadyExists {
+			return 0, ErrApplicationPending
+		}
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (s *SellerService) GetApplicationByID(applicationID int) (model.SellerApplication, error) {
+	return s.sellerRepo.GetApplicationByID(applicationID)
+}
+
+func (s *SellerService) GetApplications(q model.SellerApplicationQueryInput) ([]model.SellerApplication, error) {
+	return s.sellerRepo.GetApplications(q)
+}
+
+// Approve upgrades the applicant to a seller. The new role is carried by
+// access tokens issued after the next sign-in or token refresh.
+func (s *SellerService) Approve(applicationID, adminID int, input model.ReviewApplicationInput) error {
+	return s.review(applicationID, func() error {
+		return s.sellerRepo.Approve(applicationID, adminID, input.Comment, time.Now())
+	})
+}
+
+func (s *SellerService) Reject(applicationID, adminID int, input model.ReviewApplicationInput) error {
+	return s.review(applicationID, func() error {
+		return s.sellerRepo.Reject(applicationID, adminID, input.Comment, time.Now())
+	})
+}
+
+func (s *SellerService) GetProfile(userID int) (model.SellerProfile, error) {
+	return s.sellerRepo.GetProfile(userID)
+}
+
+func (s *SellerService) review(applicationID int, update func() error) error {
+	application, err := s.sellerRepo.GetApplicationByID(applicationID)
+	if err != nil {
+		return err
+	}
+
+	if application.Status != model.ApplicationPending {
+		return ErrApplicationReviewed
+	}
+
+	if err = update(); err != nil {
+		// The application was reviewed concurrently.
+		if err == postgres.ErrNotFound {
+			return ErrApplicationReviewed
+		}
+		return err
+	}
+
+	return nil
+}
@@ -5,41 +5,85 @@ import (
 	"market/internal/model"
 	"market/internal/repository"
 	"market/pkg/auth"
+	"market/pkg/exchange"
 	"market/pkg/hash"
+	"market/pkg/mail"
+	"market/pkg/storage"
 	"mime/multipart"
 	"time"
 
-	"github.com/cloudinary/cloudinary-go/v2"
+	"go.uber.org/zap"
 )
 
 type User interface {
 	CreateUser(model.User) (int, error)
-	GenerateToken(username, password string) (string, error)
+	GenerateToken(username, password, ip string) (model.Tokens, error)
+	RefreshTokens(refreshToken string) (model.Tokens, error)
+	CheckSession(sessionID int) error
+	SignOut(sessionID int) error
+	GetByID(userID int) (model.User, error)
+	Update(userID int, input model.UpdateUserInput) error
+	ChangePassword(userID int, oldPassword, newPassword string) (model.Tokens, error)
+	Delete(userID int) error
+	SendVerification(userID int) error
+	VerifyEmail(token string) error
+	ForgotPassword(email string) error
+	ResetPassword(token, password string) error
+	SendPasswordReset(userID int) error
+	EnrollTOTP(userID int) (model.TOTPEnrollment, error)
+	ConfirmTOTP(userID int, code string) ([]string, error)
+	DisableTOTP(userID int, code string) error
+	CompleteSignIn(mfaToken, code, ip string) (model.Tokens, error)
 }
 
 type Review interface {
 	Create(review model.Review) (int, error)
+	GetByID(productID, reviewID int) (model.Review, error)
 	GetAll(productID int, q model.ReviewQueryInput) ([]model.Review, error)
-	Update(userID, productID int, input model.UpdateReviewInput) error
-	Delete(userID, reviewID int) error
+	Update(reviewID int, input model.UpdateReviewInput) error
+	Delete(reviewID int) error
 }
 
 type Product interface {
 	Create(product model.Product) (int, error)
 	GetAll(q model.ProductQueryInput) ([]model.Product, error)
 	GetProductsByUserID(userID int, q model.ProductQueryInput) ([]model.Product, error)
-	GetProductsByCategory(productCategory string, q model.ProductQueryInput) ([]model.Product, error)
+	GetProductsByCategory(slug string, q model.ProductQueryInput) ([]model.Product, error)
+	Search(q model.ProductSearchInput) ([]model.ProductSearchResult, error)
+	GetFacets(f model.ProductFilter) (model.ProductFacets, error)
 	GetByID(productID int) (model.Product, error)
-	Update(userID, productID int, input model.UpdateProductInput) error
+	Update(productID int, input model.UpdateProductInput) error
 	IncreaseViewsCounter(productID int) error
-	Delete(userID, productID int) error
+	Delete(productID int) error
+}
+
+type ProductImage interface {
+	Add(productID int, images []model.ProductImage, primary bool) error
+	GetAll(productID int) ([]model.ProductImage, error)
+	Reorder(productID int, imageIDs []int) error
+	SetPrimary(productID, imageID int) error
+	Delete(productID, imageID int) error
+}
+
+type Variant interface {
+	CreateOption(productID int, input model.OptionInput) (int, error)
+	GetOptions(productID int) ([]model.ProductOption, error)
+	DeleteOption(productID, optionID int) error
+	Create(productID int, variant model.ProductVariant) (int, error)
+	GetByID(productID, variantID int) (model.ProductVariant, error)
+	GetAll(productID int) ([]model.ProductVariant, error)
+	Update(productID, variantID int, input model.UpdateVariantInput) error
+	Delete(productID, variantID int) error
 }
 
 type Order interface {
-	Create(userID int, order model.Order) (int, error)
+	Create(userID int) (int, error)
 	GetAll(userID int, q model.OrderQueryInput) ([]model.Order, error)
-	GetByID(userID, orderID int) (model.Order, error)
-	GetProductsByOrderID(userID, orderID int, q model.ProductQueryInput) ([]model.Product, error)
+	GetByID(orderID int) (model.Order, error)
+	GetLines(orderID int) ([]model.OrderLine, error)
+	ChangeStatus(orderID, userID int, input model.ChangeOrderStatusInput) error
+	Cancel(orderID, userID int, input model.CancelOrderInput) error
+	GetStatusHistory(orderID int) ([]model.OrderStatusChange, error)
 }
 
 type Image interface {
@@ -47,14 +91,60 @@ type Image interface {
 	Delete(ctx context.Context, imageID string) error
 }
 
+type Money interface {
+	DefaultCurrency() string
+	PriceScale(currency string) (model.PriceScale, error)
+	SellerCurrency(userID int) (string, error)
+	Convert(amount model.Money, currency string) (model.Money, error)
+	SetDisplayPrice(product *model.Product, currency string) error
+	Total(lines []model.Product, currency string) (model.Money, error)
+}
+
+type Outbox interface {
+	Process(ctx context.Context, limit int) (done, failed int, err error)
+	ReconcileImages(minAge time.Duration) (int, error)
+}
+
 type Cart interface {
 	Create(userID int) (int, error)
-	AddProduct(userID, cartID, productID, amountToPurchase int) (int, error)
+	AddProduct(cartID, productID int, variantID *int, amountToPurchase int) (int, error)
 	GetByUserID(userID int) (model.Cart, error)
-	GetAllProducts(userID, CartID int, q model.ProductQueryInput) ([]model.Product, error)
-	UpdateProductAmount(userID, CartID, productID, amountToPurchase int) error
-	DeleteProduct(userID, cartID, productID int) error
-	DeleteAllProducts(userID, cartID int) error
+	GetAllProducts(cartID int, q model.ProductQueryInput) ([]model.Product, error)
+	Total(cartID int, currency string) (model.Money, error)
+	UpdateProductAmount(cartID, productID int, variantID *int, amountToPurchase int) error
+	DeleteProduct(cartID, productID int, variantID *int) error
+	DeleteAllProducts(cartID int) error
+	ReleaseExpiredReservations(limit int) (int, error)
+}
+
+type Seller interface {
+	Apply(userID int, application model.SellerApplication) (int, error)
+	GetApplicationByID(applicationID int) (model.SellerApplication, error)
+	GetApplications(q model.SellerApplicationQueryInput) ([]model.SellerApplication, error)
+	Approve(applicationID, adminID int, input model.ReviewApplicationInput) error
+	Reject(applicationID, adminID int, input model.ReviewApplicationInput) error
+	GetProfile(userID int) (model.SellerProfile, error)
+}
+
+type Category interface {
+	Create(input model.CategoryInput) (int, error)
+	GetTree() ([]model.Category, error)
+	GetBySlug(slug string) (model.Category, error)
+	Update(categoryID int, input model.UpdateCategoryInput) error
+	Delete(categoryID int) error
+}
+
+type Admin interface {
+	GetUsers(q model.UserQueryInput) ([]model.User, error)
+	GetUser(userID int) (model.User, error)
+	GetUserOrders(userID int, q model.OrderQueryInput) ([]model.Order, error)
+	GetUserReviews(userID int, q model.ReviewQueryInput) ([]model.Review, error)
+	ChangeRole(adminID, userID int, role string) error
+	Ban(adminID, userID int, reason string) error
+	Unban(adminID, userID int) error
+	ForcePasswordReset(adminID, userID int) error
+	Unlock(adminID, userID int) error
+	GetAuditLog(q model.AuditQueryInput) ([]model.AuditEntry, error)
 }
 
 type Service struct {
@@ -64,15 +154,61 @@ type Service struct {
 	Review
 	User
 	Image
+	Seller
+	Admin
+	Category
+	Variant
+	ProductImage
+	Outbox
+	Money
 }
 
-func NewService(repos *repository.Repository, cloudinary *cloudinary.Cloudinary, hasher hash.PasswordHasher, tokenManager auth.TokenManager, accessTTL time.Duration) *Service {
+type Deps struct {
+	Repos           *repository.Repository
+	Storage         storage.Storage
+	Hasher          hash.PasswordHasher
+	TokenManager    auth.TokenManager
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	LoginThrottle   LoginThrottleConfig
+	Mailer          mail.Mailer
+	Email           EmailConfig
+	TwoFactor       TwoFactorConfig
+	Rates           exchange.Rates
+	DefaultCurrency string
+	Order           OrderConfig
+	Cart            CartConfig
+	Logger          *zap.SugaredLogger
+}
+
+func NewService(deps Deps) *Service {
+	repos := deps.Repos
+	loginThrottle := NewLoginThrottle(repos.LoginAttemptRepo, deps.LoginThrottle)
+
+	user := NewUserService(repos.UserRepo, repos.SessionRepo, repos.UserTokenRepo, repos.RecoveryCodeRepo, deps.Hasher, deps.TokenManager,
+		loginThrottle, deps.Mailer, deps.Logger, UserConfig{
+			AccessTokenTTL:  deps.AccessTokenTTL,
+			RefreshTokenTTL: deps.RefreshTokenTTL,
+			Email:           deps.Email,
+			TwoFactor:       deps.TwoFactor,
+		})
+
+	image := NewImageService(deps.Storage, repos.StoredImageRepo)
+	money := NewMoneyService(repos.SellerRepo, deps.Rates, deps.DefaultCurrency)
+
 	return &Service{
-		Product: NewProductService(repos.ProductRepo, repos.UserRepo),
-		Cart:    NewCartService(repos.CartRepo, repos.UserRepo, repos.ProductRepo),
-		Order:   NewOrderService(repos.OrderRepo, repos.CartRepo, repos.UserRepo),
-		Review:  NewReviewService(repos.ReviewRepo, repos.UserRepo, repos.ProductRepo),
-		User:    NewUserService(repos.UserRepo, hasher, tokenManager, accessTTL),
-		Image:   NewImageServiceCloudinary(cloudinary),
+		Product:      NewProductService(repos.ProductRepo, repos.CategoryRepo, money),
+		Cart:         NewCartService(repos.CartRepo, repos.ProductRepo, repos.VariantRepo, money, deps.Cart),
+		Order:        NewOrderService(repos.OrderRepo, repos.CartRepo, repos.UserRepo, money, deps.Order),
+		Review:       NewReviewService(repos.ReviewRepo),
+		User:         user,
+		Image:        image,
+		Seller:       NewSellerService(repos.SellerRepo, repos.UserRepo),
+		Admin:        NewAdminService(repos.UserRepo, repos.OrderRepo, repos.ReviewRepo, repos.AuditRepo, repos.AdminRepo, user, loginThrottle),
+		Category:     NewCategoryService(repos.CategoryRepo),
+		Variant:      NewVariantService(repos.VariantRepo),
+		ProductImage: NewProductImageService(repos.ProductImageRepo),
+		Outbox:       NewOutboxService(repos.OutboxRepo, repos.StoredImageRepo, image),
+		Money:        money,
 	}
 }
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"market/internal/model"
+	"market/pkg/database/postgres"
+	"market/pkg/totp"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	recoveryCodeCount = 10
+	recoveryCodeBytes = 5
+)
+
+var (
+	ErrTwoFactorEnabled     = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnrolled = errors.New("two-factor authentication is not enrolled")
+	ErrTwoFactorDisabled    = errors.New("two-factor authentication is not enabled")
+	ErrInvalidOTP           = errors.New("invalid one-time code")
+	ErrInvalidMFAToken      = errors.New("invalid or expired MFA token")
+)
+
+type TwoFactorConfig struct {
+	// Issuer is shown next to the account name in authenticator apps.
+	Issuer string
+	// ChallengeTTL limits the time between the password and the code step of a sign-in.
+	ChallengeTTL time.Duration
+}
+
+// EnrollTOTP generates a new secret for the user. Two-factor authentication
+// stays disabled until the first code is confirmed with ConfirmTOTP.
+func (s *UserService) EnrollTOTP(userID int) (model.TOTPEnrollment, error) {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return model.TOTPEnrollment{}, err
+	}
+
+	if user.TOTPEnabled {
+		return model.TOTPEnrollment{}, ErrTwoFactorEnabled
+	}
+
+	secret, err := totp.NewSecret()
+	if err != nil {
+		return model.TOTPEnrollment{}, err
+	}
+
+	if err = s.userRepo.UpdateTOTP(user.ID, &secret, false); err != nil {
+		return model.TOTPEnrollment{}, err
+	}
+
+	return model.TOTPEnrollment{
+		Secret: secret,
+		URI:    totp.URI(s.cfg.TwoFactor.Issuer, user.Username, secret),
+	}, nil
+}
+
+// ConfirmTOTP enables two-factor authentication and returns recovery codes.
+// The codes are stored hashed and can't be shown again.
+func (s *UserService) ConfirmTOTP(userID int, code string) ([]string, error) {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TOTPEnabled {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	if user.TOTPSecret == nil {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+
+	ok, err := s.useTOTPCode(user.ID, *user.TOTPSecret, code)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, ErrInvalidOTP
+	}
+
+	codes, err := s.newRecoveryCodes(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.userRepo.UpdateTOTP(user.ID, user.TOTPSecret, true); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func (s *UserService) DisableTOTP(userID int, code string) error {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if !user.TOTPEnabled {
+		return ErrTwoFactorDisabled
+	}
+
+	ok, err := s.verifySecondFactor(user, code)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return ErrInvalidOTP
+	}
+
+	if err = s.recoveryCodeRepo.Replace(user.ID, nil); err != nil {
+		return err
+	}
+
+	return s.userRepo.UpdateTOTP(user.ID, nil, false)
+}
+
+// CompleteSignIn exchanges the MFA token returned by GenerateToken and a TOTP
+// or recovery code for access and refresh tokens. Wrong codes count as failed
+// sign-ins for the login throttle. The MFA token is spent by the first correct
+// code, so it can be retried after a typo but not replayed.
+func (s *UserService) CompleteSignIn(mfaToken, code, ip string) (model.Tokens, error) {
+	challenge, err := s.tokenManager.ParseMFAToken(mfaToken)
+	if err != nil {
+		return model.Tokens{}, ErrInvalidMFAToken
+	}
+
+	user, err := s.userRepo.GetUserByID(challenge.UserID)
+	if err != nil {
+		return model.Tokens{}, err
+	}
+
+	if err = s.loginThrottle.Check(user.Username, ip); err != nil {
+		return model.Tokens{}, err
+	}
+
+	ok, err := s.verifySecondFactor(user, code)
+	if err != nil {
+		return model.Tokens{}, err
+	}
+
+	if !ok {
+		if err = s.loginThrottle.Fail(user.Username, ip, ErrInvalidOTP.Error()); err != nil {
+			return model.Tokens{}, err
+		}
+		return model.Tokens{}, ErrInvalidOTP
+	}
+
+	userToken, err := s.userTokenRepo.Consume(hashToken(challenge.ID), model.TokenPurposeMFA, time.Now())
+	if err != nil {
+		if err == postgres.ErrNotFound {
+			return model.Tokens{}, ErrInvalidMFAToken
+		}
+		return model.Tokens{}, err
+	}
+
+	if userToken.UserID != user.ID {
+		return model.Tokens{}, ErrInvalidMFAToken
+	}
+
+	if err = s.loginThrottle.Succeed(user.Username); err != nil {
+		return model.Tokens{}, err
+	}
+
+	if err = checkAccess(user); err != nil {
+		return model.Tokens{}, err
+	}
+
+	return s.createSession(user, uuid.NewString())
+}
+
+// verifySecondFactor accepts a current TOTP code or an unused recovery code.
+// Either is spent on success.
+func (s *UserService) verifySecondFactor(user model.User, code string) (bool, error) {
+	if !user.TOTPEnabled || user.TOTPSecret == nil {
+		return false, ErrTwoFactorDisabled
+	}
+
+	if len(code) == totp.Digits {
+		return s.useTOTPCode(user.ID, *user.TOTPSecret, code)
+	}
+
+	recoveryCodes, err := s.recoveryCodeRepo.GetUnused(user.ID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, recoveryCode := range recoveryCodes {
+		match, err := s.hasher.Verify(code, recoveryCode.Code)
+		if err != nil {
+			return false, err
+		}
+		if !match {
+			continue
+		}
+
+		if err = s.recoveryCodeRepo.Use(recoveryCode.ID, time.Now()); err != nil {
+			if err == postgres.ErrNotFound {
+				return false, nil
+			}
+			return false, err
+		}
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// useTOTPCode accepts a current TOTP code once. Codes of the time step that
+// was last accepted or an earlier one are rejected, even while still valid.
+func (s *UserService) useTOTPCode(userID int, secret, code string) (bool, error) {
+	step, ok := totp.Match(secret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+
+	if err := s.userRepo.UseTOTPStep(userID, step); err != nil {
+		if err == postgres.ErrNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (s *UserService) newRecoveryCodes(userID int) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, recoveryCodeBytes)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		code := hex.EncodeToString(b)
+
+		encodedHash, err := s.hasher.Hash(code)
+		if err != nil {
+			return nil, err
+		}
+
+		codes = append(codes, code)
+		hashes = append(hashes, encodedHash)
+	}
+
+	if err := s.recoveryCodeRepo.Replace(userID, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
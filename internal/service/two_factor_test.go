@@ -0,0 +1,112 @@
+package service
+
+import (
+	"market/internal/model"
+	"market/internal/repository"
+	"market/pkg/auth"
+	"market/pkg/database/postgres"
+	"market/pkg/totp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type userTokenRepoStub struct {
+	repository.UserTokenRepo
+	tokens map[string]model.UserToken
+}
+
+func (r *userTokenRepoStub) Create(token model.UserToken) (int, error) {
+	r.tokens[token.Token] = token
+	return len(r.tokens), nil
+}
+
+func (r *userTokenRepoStub) Consume(token, purpose string, at time.Time) (model.UserToken, error) {
+	userToken, ok := r.tokens[token]
+	if !ok || userToken.Purpose != purpose || userToken.UsedAt != nil || !userToken.ExpiresAt.After(at) {
+		return model.UserToken{}, postgres.ErrNotFound
+	}
+	userToken.UsedAt = &at
+	r.tokens[token] = userToken
+	return userToken, nil
+}
+
+func TestUserService_CompleteSignIn(t *testing.T) {
+	key, err := auth.NewHMACKey("default", "secret")
+	require.NoError(t, err)
+	manager, err := auth.NewManager(auth.Options{Issuer: "market"}, key)
+	require.NoError(t, err)
+
+	secret, err := totp.NewSecret()
+	require.NoError(t, err)
+
+	newService := func() *UserService {
+		userRepo := &userRepoStub{user: model.User{ID: 1, Username: "user", Password: "new:qwerty", TOTPSecret: &secret, TOTPEnabled: true}}
+		throttle := NewLoginThrottle(repository.NewLoginAttemptMemoryRepo(), LoginThrottleConfig{MaxFailures: 5})
+		return NewUserService(userRepo, &sessionRepoStub{}, &userTokenRepoStub{tokens: map[string]model.UserToken{}}, nil,
+			outdatedHasher{}, manager, throttle, nil, zap.NewNop().Sugar(),
+			UserConfig{AccessTokenTTL: time.Minute, TwoFactor: TwoFactorConfig{ChallengeTTL: time.Minute}})
+	}
+
+	signIn := func(t *testing.T, s *UserService) string {
+		t.Helper()
+		tokens, err := s.GenerateToken("user", "qwerty", "192.0.2.1")
+		require.NoError(t, err)
+		require.NotEmpty(t, tokens.MFAToken)
+		return tokens.MFAToken
+	}
+
+	code, err := totp.Code(secret, time.Now())
+	require.NoError(t, err)
+
+	t.Run("MFA Token Replayed", func(t *testing.T) {
+		s := newService()
+		mfaToken := signIn(t, s)
+
+		_, err := s.CompleteSignIn(mfaToken, code, "192.0.2.1")
+		require.NoError(t, err)
+
+		next, err := totp.Code(secret, time.Now().Add(totp.Period))
+		require.NoError(t, err)
+		_, err = s.CompleteSignIn(mfaToken, next, "192.0.2.1")
+		assert.ErrorIs(t, err, ErrInvalidMFAToken)
+	})
+
+	t.Run("TOTP Code Reused", func(t *testing.T) {
+		s := newService()
+
+		tokens, err := s.CompleteSignIn(signIn(t, s), code, "192.0.2.1")
+		require.NoError(t, err)
+		assert.NotEmpty(t, tokens.AccessToken)
+
+		_, err = s.CompleteSignIn(signIn(t, s), code, "192.0.2.1")
+		assert.ErrorIs(t, err, ErrInvalidOTP)
+	})
+
+	t.Run("Earlier TOTP Code", func(t *testing.T) {
+		s := newService()
+
+		_, err := s.CompleteSignIn(signIn(t, s), code, "192.0.2.1")
+		require.NoError(t, err)
+
+		previous, err := totp.Code(secret, time.Now().Add(-totp.Period))
+		require.NoError(t, err)
+		_, err = s.CompleteSignIn(signIn(t, s), previous, "192.0.2.1")
+		assert.ErrorIs(t, err, ErrInvalidOTP)
+	})
+
+	t.Run("Retry After Wrong Code", func(t *testing.T) {
+		s := newService()
+		mfaToken := signIn(t, s)
+
+		wrong := code[:totp.Digits-1] + string('0'+(code[totp.Digits-1]-'0'+1)%10)
+		_, err := s.CompleteSignIn(mfaToken, wrong, "192.0.2.1")
+		assert.ErrorIs(t, err, ErrInvalidOTP)
+
+		_, err = s.CompleteSignIn(mfaToken, code, "192.0.2.1")
+		assert.NoError(t, err)
+	})
+}
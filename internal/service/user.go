@@ -1,30 +1,69 @@
 package service
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"market/internal/model"
 	"market/internal/repository"
 	"market/pkg/auth"
+	"market/pkg/database/postgres"
 	"market/pkg/hash"
+	"market/pkg/mail"
+	"strings"
 	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
 )
 
 var (
-	ErrBadPass    = errors.New("wrong password")
-	ErrNoUser     = errors.New("no user found")
-	ErrUserExists = errors.New("user already exists")
+	ErrBadPass           = errors.New("wrong password")
+	ErrNoUser            = errors.New("no user found")
+	ErrUserExists        = errors.New("user already exists")
+	ErrInvalidRefresh    = errors.New("invalid refresh token")
+	ErrRefreshExpired    = errors.New("refresh token expired")
+	ErrRefreshTokenReuse = errors.New("refresh token reuse detected")
+	ErrSessionRevoked    = errors.New("session revoked")
+	ErrUserBanned        = errors.New("user is banned")
+	ErrPasswordReset     = errors.New("password reset required, check your email")
 )
 
 type UserService struct {
-	userRepo     repository.UserRepo
-	hasher       hash.PasswordHasher
-	tokenManager auth.TokenManager
+	userRepo         repository.UserRepo
+	sessionRepo      repository.SessionRepo
+	userTokenRepo    repository.UserTokenRepo
+	recoveryCodeRepo repository.RecoveryCodeRepo
+	hasher           hash.PasswordHasher
+	tokenManager     auth.TokenManager
+	loginThrottle    *LoginThrottle
+	mailer           mail.Mailer
+	logger           *zap.SugaredLogger
+	cfg              UserConfig
+}
 
-	accessTokenTTL time.Duration
+type UserConfig struct {
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	Email           EmailConfig
+	TwoFactor       TwoFactorConfig
 }
 
-func NewUserService(userRepo repository.UserRepo, hasher hash.PasswordHasher, tokenManager auth.TokenManager, accessTTL time.Duration) *UserService {
-	return &UserService{userRepo: userRepo, hasher: hasher, tokenManager: tokenManager, accessTokenTTL: accessTTL}
+func NewUserService(userRepo repository.UserRepo, sessionRepo repository.SessionRepo, userTokenRepo repository.UserTokenRepo,
+	recoveryCodeRepo repository.RecoveryCodeRepo, hasher hash.PasswordHasher, tokenManager auth.TokenManager, loginThrottle *LoginThrottle,
+	mailer mail.Mailer, logger *zap.SugaredLogger, cfg UserConfig) *UserService {
+	return &UserService{
+		userRepo:         userRepo,
+		sessionRepo:      sessionRepo,
+		userTokenRepo:    userTokenRepo,
+		recoveryCodeRepo: recoveryCodeRepo,
+		hasher:           hasher,
+		tokenManager:     tokenManager,
+		loginThrottle:    loginThrottle,
+		mailer:           mailer,
+		logger:           logger,
+		cfg:              cfg,
+	}
 }
 
 func (s *UserService) CreateUser(user model.User) (int, error) {
@@ -34,28 +73,289 @@ func (s *UserService) CreateUser(user model.User) (int, error) {
 	}
 
 	user.Password = password
+	user.Email = normalizeEmail(user.Email)
 
 	id, err := s.userRepo.CreateUser(user)
 	if err != nil {
+		if err == postgres.ErrAlreadyExists {
+			return 0, ErrUserExists
+		}
 		return 0, err
 	}
 	return id, nil
 }
 
-func (s *UserService) GenerateToken(username, password string) (string, error) {
+func (s *UserService) GenerateToken(username, password, ip string) (model.Tokens, error) {
+	if err := s.loginThrottle.Check(username, ip); err != nil {
+		return model.Tokens{}, err
+	}
+
 	user, err := s.userRepo.GetUser(username)
 	if err != nil {
-		return "", err
+		if err == postgres.ErrNotFound {
+			if err = s.loginThrottle.Fail(username, ip, ErrNoUser.Error()); err != nil {
+				return model.Tokens{}, err
+			}
+			return model.Tokens{}, ErrNoUser
+		}
+		return model.Tokens{}, err
 	}
 
 	match, err := s.hasher.Verify(password, user.Password)
 	if err != nil {
-		return "", err
+		return model.Tokens{}, err
 	}
 
 	if !match {
-		return "", ErrBadPass
+		if err = s.loginThrottle.Fail(username, ip, ErrBadPass.Error()); err != nil {
+			return model.Tokens{}, err
+		}
+		return model.Tokens{}, ErrBadPass
+	}
+
+	if err = s.loginThrottle.Succeed(username); err != nil {
+		return model.Tokens{}, err
+	}
+
+	if err = checkAccess(user); err != nil {
+		return model.Tokens{}, err
+	}
+
+	// The password is correct either way, so a failed upgrade is retried on
+	// the next sign-in instead of failing this one.
+	if s.hasher.NeedsRehash(user.Password) {
+		if err = s.rehashPassword(user.ID, password); err != nil {
+			s.logger.Errorf("Error occurred while rehashing password of user %d: %s", user.ID, err.Error())
+		}
+	}
+
+	if user.TOTPEnabled {
+		challengeID, err := s.issueUserToken(user.ID, model.TokenPurposeMFA, s.cfg.TwoFactor.ChallengeTTL)
+		if err != nil {
+			return model.Tokens{}, err
+		}
+
+		mfaToken, err := s.tokenManager.NewMFAToken(auth.MFAChallenge{UserID: user.ID, ID: challengeID}, s.cfg.TwoFactor.ChallengeTTL)
+		if err != nil {
+			return model.Tokens{}, err
+		}
+		return model.Tokens{MFAToken: mfaToken}, nil
+	}
+
+	return s.createSession(user, uuid.NewString())
+}
+
+func (s *UserService) RefreshTokens(refreshToken string) (model.Tokens, error) {
+	session, err := s.sessionRepo.GetByRefreshToken(hashToken(refreshToken))
+	if err != nil {
+		if err == postgres.ErrNotFound {
+			return model.Tokens{}, ErrInvalidRefresh
+		}
+		return model.Tokens{}, err
+	}
+
+	if session.Revoked {
+		if err = s.sessionRepo.RevokeFamily(session.FamilyID); err != nil {
+			return model.Tokens{}, err
+		}
+		return model.Tokens{}, ErrRefreshTokenReuse
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		return model.Tokens{}, ErrRefreshExpired
+	}
+
+	user, err := s.userRepo.GetUserByID(session.UserID)
+	if err != nil {
+		return model.Tokens{}, err
+	}
+
+	if err = checkAccess(user); err != nil {
+		return model.Tokens{}, err
 	}
 
-	return s.tokenManager.NewJWT(user.ID, username, s.accessTokenTTL)
+	newRefreshToken, err := s.tokenManager.NewRefreshToken()
+	if err != nil {
+		return model.Tokens{}, err
+	}
+
+	sessionID, err := s.sessionRepo.Rotate(session.ID, s.newSession(user.ID, session.FamilyID, newRefreshToken))
+	if err != nil {
+		if err == repository.ErrSessionRevoked {
+			if err = s.sessionRepo.RevokeFamily(session.FamilyID); err != nil {
+				return model.Tokens{}, err
+			}
+			return model.Tokens{}, ErrRefreshTokenReuse
+		}
+		return model.Tokens{}, err
+	}
+
+	accessToken, err := s.newAccessToken(user, sessionID)
+	if err != nil {
+		return model.Tokens{}, err
+	}
+
+	return model.Tokens{AccessToken: accessToken, RefreshToken: newRefreshToken}, nil
+}
+
+func (s *UserService) CheckSession(sessionID int) error {
+	session, err := s.sessionRepo.GetByID(sessionID)
+	if err != nil {
+		if err == postgres.ErrNotFound {
+			return ErrSessionRevoked
+		}
+		return err
+	}
+
+	if session.Revoked {
+		return ErrSessionRevoked
+	}
+
+	user, err := s.userRepo.GetUserByID(session.UserID)
+	if err != nil {
+		if err == postgres.ErrNotFound {
+			return ErrSessionRevoked
+		}
+		return err
+	}
+
+	if user.BannedAt != nil {
+		return ErrUserBanned
+	}
+
+	return nil
+}
+
+func (s *UserService) SignOut(sessionID int) error {
+	session, err := s.sessionRepo.GetByID(sessionID)
+	if err != nil {
+		return err
+	}
+
+	return s.sessionRepo.RevokeFamily(session.FamilyID)
+}
+
+func (s *UserService) GetByID(userID int) (model.User, error) {
+	return s.userRepo.GetUserByID(userID)
+}
+
+func (s *UserService) Update(userID int, input model.UpdateUserInput) error {
+	if err := input.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.userRepo.Update(userID, input); err != nil {
+		if err == postgres.ErrAlreadyExists {
+			return ErrUserExists
+		}
+		return err
+	}
+
+	return nil
+}
+
+// ChangePassword revokes every session of the user and returns tokens for a
+// new one, so only the client that changed the password stays signed in.
+func (s *UserService) ChangePassword(userID int, oldPassword, newPassword string) (model.Tokens, error) {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return model.Tokens{}, err
+	}
+
+	match, err := s.hasher.Verify(oldPassword, user.Password)
+	if err != nil {
+		return model.Tokens{}, err
+	}
+
+	if !match {
+		return model.Tokens{}, ErrBadPass
+	}
+
+	encodedHash, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		return model.Tokens{}, err
+	}
+
+	if err = s.userRepo.ChangePassword(user.ID, encodedHash); err != nil {
+		return model.Tokens{}, err
+	}
+
+	return s.createSession(user, uuid.NewString())
+}
+
+// Delete removes the account together with the user's products. The images of
+// the products are queued in the outbox and deleted from storage later.
+func (s *UserService) Delete(userID int) error {
+	return s.userRepo.Delete(userID)
+}
+
+// rehashPassword replaces a hash produced with outdated parameters (or a legacy
+// algorithm) once the plain password is known after a successful login.
+func (s *UserService) rehashPassword(userID int, password string) error {
+	encodedHash, err := s.hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	return s.userRepo.UpdatePassword(userID, encodedHash)
+}
+
+func (s *UserService) createSession(user model.User, familyID string) (model.Tokens, error) {
+	refreshToken, err := s.tokenManager.NewRefreshToken()
+	if err != nil {
+		return model.Tokens{}, err
+	}
+
+	sessionID, err := s.sessionRepo.Create(s.newSession(user.ID, familyID, refreshToken))
+	if err != nil {
+		return model.Tokens{}, err
+	}
+
+	accessToken, err := s.newAccessToken(user, sessionID)
+	if err != nil {
+		return model.Tokens{}, err
+	}
+
+	return model.Tokens{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+func (s *UserService) newAccessToken(user model.User, sessionID int) (string, error) {
+	return s.tokenManager.NewJWT(auth.Token{
+		UserID:    user.ID,
+		Username:  user.Username,
+		SessionID: sessionID,
+		Role:      user.Role,
+	}, s.cfg.AccessTokenTTL)
+}
+
+func (s *UserService) newSession(userID int, familyID, refreshToken string) model.Session {
+	return model.Session{
+		UserID:       userID,
+		FamilyID:     familyID,
+		RefreshToken: hashToken(refreshToken),
+		CreatedAt:    time.Now(),
+		ExpiresAt:    time.Now().Add(s.cfg.RefreshTokenTTL),
+	}
+}
+
+// checkAccess rejects accounts that may not start or continue a session.
+func checkAccess(user model.User) error {
+	if user.BannedAt != nil {
+		return ErrUserBanned
+	}
+
+	if user.PasswordResetRequired {
+		return ErrPasswordReset
+	}
+
+	return nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
 }
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"market/internal/model"
+	"market/pkg/database/postgres"
+	"market/pkg/mail"
+	"time"
+)
+
+const (
+	userTokenLength = 32
+	mailSendTimeout = 10 * time.Second
+)
+
+var (
+	ErrInvalidEmailToken    = errors.New("invalid or expired token")
+	ErrEmailAlreadyVerified = errors.New("email already verified")
+)
+
+// EmailConfig configures the single-use tokens sent by email. The URLs are
+// format strings that receive the token, e.g. "https://market.local/verify?token=%s".
+type EmailConfig struct {
+	VerificationTTL  time.Duration
+	ResetTTL         time.Duration
+	VerifyEmailURL   string
+	ResetPasswordURL string
+}
+
+func (s *UserService) SendVerification(userID int) error {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if user.EmailVerified {
+		return ErrEmailAlreadyVerified
+	}
+
+	token, err := s.issueUserToken(user.ID, model.TokenPurposeVerifyEmail, s.cfg.Email.VerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.sendMail(mail.Message{
+		To:      user.Email,
+		Subject: "Confirm your email",
+		Body: fmt.Sprintf("Hi %s,\n\nconfirm your email by following the link:\n%s\n\nThe link is valid for %s.",
+			user.Username, fmt.Sprintf(s.cfg.Email.VerifyEmailURL, token), s.cfg.Email.VerificationTTL),
+	})
+}
+
+func (s *UserService) VerifyEmail(token string) error {
+	userToken, err := s.userTokenRepo.Consume(hashToken(token), model.TokenPurposeVerifyEmail, time.Now())
+	if err != nil {
+		if err == postgres.ErrNotFound {
+			return ErrInvalidEmailToken
+		}
+		return err
+	}
+
+	return s.userRepo.SetEmailVerified(userToken.UserID)
+}
+
+// ForgotPassword sends a reset link if the email belongs to a user. Unknown
+// emails are not reported, so the endpoint can't be used to enumerate accounts.
+func (s *UserService) ForgotPassword(email string) error {
+	user, err := s.userRepo.GetUserByEmail(normalizeEmail(email))
+	if err != nil {
+		if err == postgres.ErrNotFound {
+			return nil
+		}
+		return err
+	}
+
+	return s.sendPasswordReset(user)
+}
+
+// SendPasswordReset emails a reset link to the user, e.g. after an admin has
+// required a password reset.
+func (s *UserService) SendPasswordReset(userID int) error {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	return s.sendPasswordReset(user)
+}
+
+func (s *UserService) sendPasswordReset(user model.User) error {
+	token, err := s.issueUserToken(user.ID, model.TokenPurposeResetPassword, s.cfg.Email.ResetTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.sendMail(mail.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nreset your password by following the link:\n%s\n\nThe link is valid for %s. "+
+			"If you didn't request a reset, ignore this email.",
+			user.Username, fmt.Sprintf(s.cfg.Email.ResetPasswordURL, token), s.cfg.Email.ResetTTL),
+	})
+}
+
+// ResetPassword sets a new password, signs the user out everywhere and lifts
+// a sign-in lockout, since the user has just proven access to their email.
+func (s *UserService) ResetPassword(token, password string) error {
+	userToken, err := s.userTokenRepo.Consume(hashToken(token), model.TokenPurposeResetPassword, time.Now())
+	if err != nil {
+		if err == postgres.ErrNotFound {
+			return ErrInvalidEmailToken
+		}
+		return err
+	}
+
+	user, err := s.userRepo.GetUserByID(userToken.UserID)
+	if err != nil {
+		return err
+	}
+
+	encodedHash, err := s.hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	if err = s.userRepo.ChangePassword(user.ID, encodedHash); err != nil {
+		return err
+	}
+
+	return s.loginThrottle.Unlock(user.Username)
+}
+
+func (s *UserService) issueUserToken(userID int, purpose string, ttl time.Duration) (string, error) {
+	b := make([]byte, userTokenLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+
+	now := time.Now()
+	if _, err := s.userTokenRepo.Create(model.UserToken{
+		UserID:    userID,
+		Token:     hashToken(token),
+		Purpose:   purpose,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func (s *UserService) sendMail(msg mail.Message) error {
+	ctx, cancel := context.WithTimeout(context.Background(), mailSendTimeout)
+	defer cancel()
+
+	return s.mailer.Send(ctx, msg)
+}
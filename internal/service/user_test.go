@@ -0,0 +1,103 @@
+package service
+
+import (
+	"errors"
+	"market/internal/model"
+	"market/internal/repository"
+	"market/pkg/auth"
+	"market/pkg/database/postgres"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type userRepoStub struct {
+	repository.UserRepo
+	user      model.User
+	updateErr error
+	updated   string
+}
+
+func (r *userRepoStub) GetUser(username string) (model.User, error) {
+	return r.user, nil
+}
+
+func (r *userRepoStub) GetUserByID(userID int) (model.User, error) {
+	return r.user, nil
+}
+
+func (r *userRepoStub) UseTOTPStep(userID int, step int64) error {
+	if r.user.TOTPLastStep != nil && *r.user.TOTPLastStep >= step {
+		return postgres.ErrNotFound
+	}
+	r.user.TOTPLastStep = &step
+	return nil
+}
+
+func (r *userRepoStub) UpdatePassword(userID int, password string) error {
+	if r.updateErr != nil {
+		return r.updateErr
+	}
+	r.updated = password
+	return nil
+}
+
+type sessionRepoStub struct {
+	repository.SessionRepo
+}
+
+func (r *sessionRepoStub) Create(session model.Session) (int, error) {
+	return 1, nil
+}
+
+// outdatedHasher accepts any password and reports every hash as outdated.
+type outdatedHasher struct{}
+
+func (outdatedHasher) Hash(password string) (string, error)              { return "new:" + password, nil }
+func (outdatedHasher) Verify(password, encodedHash string) (bool, error) { return true, nil }
+func (outdatedHasher) NeedsRehash(encodedHash string) bool               { return encodedHash != "new:qwerty" }
+
+func TestUserService_GenerateToken_Rehash(t *testing.T) {
+	key, err := auth.NewHMACKey("default", "secret")
+	require.NoError(t, err)
+	manager, err := auth.NewManager(auth.Options{Issuer: "market"}, key)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		totp        bool
+		updateErr   error
+		wantUpdated string
+	}{
+		{name: "Rehashed", wantUpdated: "new:qwerty"},
+		{name: "Rehash Failed", updateErr: errors.New("connection reset")},
+		{name: "Rehash Failed With MFA", totp: true, updateErr: errors.New("connection reset")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userRepo := &userRepoStub{
+				user:      model.User{ID: 1, Username: "user", Password: "old", TOTPEnabled: tt.totp},
+				updateErr: tt.updateErr,
+			}
+			throttle := NewLoginThrottle(repository.NewLoginAttemptMemoryRepo(), LoginThrottleConfig{MaxFailures: 5})
+			userTokenRepo := &userTokenRepoStub{tokens: map[string]model.UserToken{}}
+			s := NewUserService(userRepo, &sessionRepoStub{}, userTokenRepo, nil, outdatedHasher{}, manager, throttle, nil,
+				zap.NewNop().Sugar(), UserConfig{AccessTokenTTL: time.Minute, TwoFactor: TwoFactorConfig{ChallengeTTL: time.Minute}})
+
+			tokens, err := s.GenerateToken("user", "qwerty", "192.0.2.1")
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantUpdated, userRepo.updated)
+			if tt.totp {
+				assert.NotEmpty(t, tokens.MFAToken)
+				assert.Empty(t, tokens.AccessToken)
+				return
+			}
+			assert.NotEmpty(t, tokens.AccessToken)
+			assert.NotEmpty(t, tokens.RefreshToken)
+		})
+	}
+}
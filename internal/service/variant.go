@@ -0,0 +1,242 @@
+package service
+
+import (
+	"errors"
+	"market/internal/model"
+	"market/internal/repository"
+	"market/pkg/database/postgres"
+	"strings"
+	"time"
+)
+
+var (
+	ErrVariantNotFound    = errors.New("variant doesn't exist")
+	ErrVariantExists      = errors.New("variant with these attributes already exists")
+	ErrVariantRequired    = errors.New("product has variants, variant_id is required")
+	ErrSKUExists          = errors.New("variant with this SKU already exists")
+	ErrInvalidAttributes  = errors.New("variant must have exactly one value for every option of the product")
+	ErrOptionNotFound     = errors.New("option doesn't exist")
+	ErrOptionExists       = errors.New("option with this name already exists")
+	ErrOptionInUse        = errors.New("option is used by variants")
+	ErrProductHasVariants = errors.New("options can't be added to a product with variants")
+)
+
+type VariantService struct {
+	variantRepo repository.VariantRepo
+}
+
+func NewVariantService(variantRepo repository.VariantRepo) *VariantService {
+	return &VariantService{variantRepo: variantRepo}
+}
+
+// CreateOption adds an option to a product. Options are fixed once the product
+// has variants, since the existing variants would lack a value for it.
+func (s *VariantService) CreateOption(productID int, input model.OptionInput) (int, error) {
+	hasVariants, err := s.variantRepo.HasVariants(productID)
+	if err != nil {
+		return 0, err
+	}
+
+	if hasVariants {
+		return 0, ErrProductHasVariants
+	}
+
+	id, err := s.variantRepo.CreateOption(model.ProductOption{
+		ProductID: productID,
+		Name:      input.Name,
+		Position:  input.Position,
+	})
+	if err != nil {
+		if err == postgres.ErrAlreadyExists {
+			return 0, ErrOptionExists
+		}
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (s *VariantService) GetOptions(productID int) ([]model.ProductOption, error) {
+	return s.variantRepo.GetOptions(productID)
+}
+
+func (s *VariantService) DeleteOption(productID, optionID int) error {
+	if err := s.variantRepo.DeleteOption(productID, optionID); err != nil {
+		switch err {
+		case postgres.ErrNotFound:
+			return ErrOptionNotFound
+		case postgres.ErrForeignKey:
+			return ErrOptionInUse
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Create adds a variant to the product. Its attributes must name every option
+// of the product once and differ from the attributes of the other variants.
+func (s *VariantService) Create(productID int, variant model.ProductVariant) (int, error) {
+	attributes, err := s.resolveAttributes(productID, 0, variant.Attributes)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	variant.ProductID = productID
+	variant.Attributes = attributes
+	variant.CreatedAt = now
+	variant.UpdatedAt = now
+
+	id, err := s.variantRepo.Create(variant)
+	if err != nil {
+		if err == postgres.ErrAlreadyExists {
+			return 0, ErrSKUExists
+		}
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// GetByID returns the variant if it belongs to the product.
+func (s *VariantService) GetByID(productID, variantID int) (model.ProductVariant, error) {
+	variant, err := s.variantRepo.GetByID(variantID)
+	if err != nil {
+		if err == postgres.ErrNotFound {
+			return model.ProductVariant{}, ErrVariantNotFound
+		}
+		return model.ProductVariant{}, err
+	}
+
+	if variant.ProductID != productID {
+		return model.ProductVariant{}, ErrVariantNotFound
+	}
+
+	return variant, nil
+}
+
+func (s *VariantService) GetAll(productID int) ([]model.ProductVariant, error) {
+	return s.variantRepo.GetAll(productID)
+}
+
+func (s *VariantService) Update(productID, variantID int, input model.UpdateVariantInput) error {
+	if err := input.Validate(); err != nil {
+		return err
+	}
+
+	if _, err := s.GetByID(productID, variantID); err != nil {
+		return err
+	}
+
+	if len(input.Attributes) != 0 {
+		attributes, err := s.resolveAttributes(productID, variantID, input.Attributes)
+		if err != nil {
+			return err
+		}
+		input.Attributes = attributes
+	}
+
+	now := time.Now()
+	input.UpdatedAt = &now
+
+	if err := s.variantRepo.Update(variantID, input); err != nil {
+		switch err {
+		case postgres.ErrNotFound:
+			return ErrVariantNotFound
+		case postgres.ErrAlreadyExists:
+			return ErrSKUExists
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *VariantService) Delete(productID, variantID int) error {
+	if _, err := s.GetByID(productID, variantID); err != nil {
+		return err
+	}
+
+	if err := s.variantRepo.Delete(variantID); err != nil {
+		if err == postgres.ErrNotFound {
+			return ErrVariantNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// resolveAttributes matches the attributes to the options of the product by
+// name and checks that no other variant than variantID has the same values.
+func (s *VariantService) resolveAttributes(productID, variantID int, attributes []model.VariantAttribute) ([]model.VariantAttribute, error) {
+	options, err := s.variantRepo.GetOptions(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(attributes) != len(options) {
+		return nil, ErrInvalidAttributes
+	}
+
+	values := make(map[int]string, len(options))
+	for _, attribute := range attributes {
+		optionID := 0
+		for _, option := range options {
+			if option.Name == attribute.Option {
+				optionID = option.ID
+			}
+		}
+
+		if _, ok := values[optionID]; ok || optionID == 0 {
+			return nil, ErrInvalidAttributes
+		}
+		values[optionID] = attribute.Value
+	}
+
+	variants, err := s.variantRepo.GetAll(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	key := attributesKey(options, values)
+	for _, variant := range variants {
+		if variant.ID == variantID {
+			continue
+		}
+
+		existing := make(map[int]string, len(variant.Attributes))
+		for _, attribute := range variant.Attributes {
+			existing[attribute.OptionID] = attribute.Value
+		}
+
+		if attributesKey(options, existing) == key {
+			return nil, ErrVariantExists
+		}
+	}
+
+	resolved := make([]model.VariantAttribute, 0, len(options))
+	for _, option := range options {
+		resolved = append(resolved, model.VariantAttribute{
+			VariantID: variantID,
+			OptionID:  option.ID,
+			Option:    option.Name,
+			Value:     values[option.ID],
+		})
+	}
+
+	return resolved, nil
+}
+
+// attributesKey joins the values of a variant in the order of the options.
+func attributesKey(options []model.ProductOption, values map[int]string) string {
+	parts := make([]string, 0, len(options))
+	for _, option := range options {
+		parts = append(parts, values[option.ID])
+	}
+
+	return strings.Join(parts, "\x00")
+}
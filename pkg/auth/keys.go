@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+var (
+	ErrUnsupportedKey = errors.New("unsupported key type")
+	ErrNoPrivateKey   = errors.New("key can't be used for signing")
+	errInvalidPEM     = errors.New("failed to decode PEM block")
+)
+
+// Key is a single signing or verification key identified by kid.
+// Keys without a private part (or secret) can only verify tokens; ExpiresAt
+// limits how long a retired key is still accepted.
+type Key struct {
+	ID         string
+	Algorithm  string
+	Secret     []byte
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+	ExpiresAt  time.Time
+}
+
+func NewHMACKey(id, secret string) (Key, error) {
+	if secret == "" {
+		return Key{}, errors.New("empty signing key")
+	}
+	return Key{ID: id, Algorithm: AlgHS256, Secret: []byte(secret)}, nil
+}
+
+// LoadKey reads a PKCS#8 private key or a PKIX public key from a PEM file.
+// The signing algorithm is derived from the key type.
+func LoadKey(id, path string, expiresAt time.Time) (Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Key{}, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return Key{}, errInvalidPEM
+	}
+
+	key := Key{ID: id, ExpiresAt: expiresAt}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return Key{}, err
+		}
+		signer, ok := parsed.(crypto.Signer)
+		if !ok {
+			return Key{}, ErrUnsupportedKey
+		}
+		key.PrivateKey = signer
+		key.PublicKey = signer.Public()
+	case "PUBLIC KEY":
+		key.PublicKey, err = x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return Key{}, err
+		}
+	default:
+		return Key{}, ErrUnsupportedKey
+	}
+
+	switch key.PublicKey.(type) {
+	case *rsa.PublicKey:
+		key.Algorithm = AlgRS256
+	case ed25519.PublicKey:
+		key.Algorithm = AlgEdDSA
+	default:
+		return Key{}, ErrUnsupportedKey
+	}
+
+	return key, nil
+}
+
+func (k Key) signingMethod() jwt.SigningMethod {
+	switch k.Algorithm {
+	case AlgRS256:
+		return jwt.SigningMethodRS256
+	case AlgEdDSA:
+		return jwt.SigningMethodEdDSA
+	default:
+		return jwt.SigningMethodHS256
+	}
+}
+
+func (k Key) signingKey() (interface{}, error) {
+	if k.Algorithm == AlgHS256 {
+		return k.Secret, nil
+	}
+	if k.PrivateKey == nil {
+		return nil, ErrNoPrivateKey
+	}
+	return k.PrivateKey, nil
+}
+
+func (k Key) verificationKey() interface{} {
+	if k.Algorithm == AlgHS256 {
+		return k.Secret
+	}
+	return k.PublicKey
+}
+
+func (k Key) expired() bool {
+	return !k.ExpiresAt.IsZero() && time.Now().After(k.ExpiresAt)
+}
+
+// JWK is a public key in the JSON Web Key format (RFC 7517).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+func (k Key) jwk() (JWK, bool) {
+	jwk := JWK{KeyID: k.ID, Use: "sig", Algorithm: k.Algorithm}
+
+	switch pub := k.PublicKey.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	default:
+		return JWK{}, false
+	}
+
+	return jwk, true
+}
@@ -2,79 +2,292 @@ package auth
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"sort"
+	"strconv"
 	"time"
 
-	"github.com/dgrijalva/jwt-go"
+	"github.com/golang-jwt/jwt/v5"
 )
 
-const TokenKey tokenKey = "token"
+const (
+	TokenKey           tokenKey = "token"
+	refreshTokenLength          = 32
+	purposeMFA                  = "mfa"
+)
 
 type tokenKey string
 
 type tokenClaims struct {
-	jwt.StandardClaims
-	UserID   int    `json:"user_id"`
-	Username string `json:"username"`
+	jwt.RegisteredClaims
+	UserID    int    `json:"user_id"`
+	Username  string `json:"username"`
+	SessionID int    `json:"sid"`
+	Role      string `json:"role"`
+	// Purpose is empty for access tokens and set for tokens that grant
+	// nothing but the next step of a flow, like the MFA challenge.
+	Purpose string `json:"pur,omitempty"`
 }
 
-var ErrNoAuth = errors.New("no token found")
+var (
+	ErrNoAuth       = errors.New("no token found")
+	ErrUnknownKeyID = errors.New("unknown key id")
+	ErrKeyExpired   = errors.New("signing key expired")
+
+	ErrTokenExpired   = errors.New("token is expired")
+	ErrTokenMalformed = errors.New("token is malformed")
+	ErrTokenSignature = errors.New("token signature is invalid")
+	ErrTokenClaims    = errors.New("token claims are invalid")
+)
 
 type TokenManager interface {
-	NewJWT(userID int, username string, ttl time.Duration) (string, error)
+	NewJWT(token Token, ttl time.Duration) (string, error)
+	NewRefreshToken() (string, error)
 	Parse(accessToken string) (*Token, error)
+	NewMFAToken(challenge MFAChallenge, ttl time.Duration) (string, error)
+	ParseMFAToken(mfaToken string) (MFAChallenge, error)
+	JWKS() JWKS
+}
+
+// Options configure the registered claims written to and required from every token.
+// Tokens are issued for every audience and must name all of them to be accepted.
+type Options struct {
+	Issuer   string
+	Audience []string
+	Leeway   time.Duration
 }
 
 type Manager struct {
-	signingKey string
+	opts      Options
+	activeKey Key
+	keys      map[string]Key
+	parser    *jwt.Parser
 }
 
-func NewManager(signingKey string) (*Manager, error) {
-	if signingKey == "" {
-		return nil, errors.New("empty signing key")
+// NewManager signs tokens with the active key and accepts tokens signed by
+// the active key or any of the verification keys.
+func NewManager(opts Options, active Key, verification ...Key) (*Manager, error) {
+	if _, err := active.signingKey(); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]Key, len(verification)+1)
+	methods := []string{active.Algorithm}
+	for _, key := range verification {
+		keys[key.ID] = key
+		methods = append(methods, key.Algorithm)
+	}
+	keys[active.ID] = active
+
+	parserOpts := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(opts.Leeway),
+	}
+	if opts.Issuer != "" {
+		parserOpts = append(parserOpts, jwt.WithIssuer(opts.Issuer))
 	}
-	return &Manager{signingKey: signingKey}, nil
+
+	return &Manager{
+		opts:      opts,
+		activeKey: active,
+		keys:      keys,
+		parser:    jwt.NewParser(parserOpts...),
+	}, nil
 }
 
-func (m *Manager) NewJWT(userID int, username string, ttl time.Duration) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &tokenClaims{
-		jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(ttl).Unix(),
-			IssuedAt:  time.Now().Unix(),
-		},
-		userID,
-		username,
+func (m *Manager) NewJWT(t Token, ttl time.Duration) (string, error) {
+	return m.sign(&tokenClaims{
+		RegisteredClaims: m.registeredClaims(t.UserID, ttl),
+		UserID:           t.UserID,
+		Username:         t.Username,
+		SessionID:        t.SessionID,
+		Role:             t.Role,
 	})
+}
+
+// NewMFAToken returns a token proving that the user passed the password step
+// of a sign-in. It is rejected by Parse and only accepted by ParseMFAToken.
+// The challenge ID is written to the jti claim, so the caller can store it
+// and accept the token only once.
+func (m *Manager) NewMFAToken(challenge MFAChallenge, ttl time.Duration) (string, error) {
+	claims := &tokenClaims{
+		RegisteredClaims: m.registeredClaims(challenge.UserID, ttl),
+		UserID:           challenge.UserID,
+		Purpose:          purposeMFA,
+	}
+	claims.ID = challenge.ID
+
+	return m.sign(claims)
+}
+
+func (m *Manager) registeredClaims(userID int, ttl time.Duration) jwt.RegisteredClaims {
+	now := time.Now()
+	return jwt.RegisteredClaims{
+		Issuer:    m.opts.Issuer,
+		Subject:   strconv.Itoa(userID),
+		Audience:  m.opts.Audience,
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		NotBefore: jwt.NewNumericDate(now),
+		IssuedAt:  jwt.NewNumericDate(now),
+	}
+}
+
+func (m *Manager) sign(claims *tokenClaims) (string, error) {
+	token := jwt.NewWithClaims(m.activeKey.signingMethod(), claims)
+	token.Header["kid"] = m.activeKey.ID
+
+	key, err := m.activeKey.signingKey()
+	if err != nil {
+		return "", err
+	}
 
-	return token.SignedString([]byte(m.signingKey))
+	return token.SignedString(key)
+}
+
+func (m *Manager) NewRefreshToken() (string, error) {
+	b := make([]byte, refreshTokenLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
 }
 
 func (m *Manager) Parse(accessToken string) (*Token, error) {
-	token, err := jwt.ParseWithClaims(accessToken, &tokenClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+	claims, err := m.parse(accessToken)
+	if err != nil {
+		return &Token{}, err
+	}
+
+	if claims.Purpose != "" {
+		return &Token{}, fmt.Errorf("%w: not an access token", ErrTokenClaims)
+	}
+
+	return &Token{
+		Username:  claims.Username,
+		UserID:    claims.UserID,
+		SessionID: claims.SessionID,
+		Role:      claims.Role,
+	}, nil
+}
+
+func (m *Manager) ParseMFAToken(mfaToken string) (MFAChallenge, error) {
+	claims, err := m.parse(mfaToken)
+	if err != nil {
+		return MFAChallenge{}, err
+	}
+
+	if claims.Purpose != purposeMFA {
+		return MFAChallenge{}, fmt.Errorf("%w: not an MFA token", ErrTokenClaims)
+	}
+
+	if claims.ID == "" {
+		return MFAChallenge{}, fmt.Errorf("%w: missing challenge id", ErrTokenClaims)
+	}
+
+	return MFAChallenge{UserID: claims.UserID, ID: claims.ID}, nil
+}
+
+func (m *Manager) parse(tokenString string) (*tokenClaims, error) {
+	token, err := m.parser.ParseWithClaims(tokenString, &tokenClaims{}, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := m.keys[kid]
+		if !ok {
+			return nil, ErrUnknownKeyID
+		}
+
+		if token.Method.Alg() != key.Algorithm {
 			return nil, errors.New("invalid signing method")
 		}
 
-		return []byte(m.signingKey), nil
+		if key.expired() {
+			return nil, ErrKeyExpired
+		}
+
+		return key.verificationKey(), nil
 	})
 	if err != nil {
-		return &Token{}, err
+		return nil, parseError(err)
 	}
 
 	claims, ok := token.Claims.(*tokenClaims)
 	if !ok {
-		return &Token{}, errors.New("token claims are not of type *tokenClaims")
+		return nil, fmt.Errorf("%w: claims are not of type *tokenClaims", ErrTokenMalformed)
 	}
 
-	return &Token{
-		Username: claims.Username,
-		UserID:   claims.UserID,
-	}, nil
+	if err := m.verifyAudience(claims.Audience); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// verifyAudience checks the aud claim itself, since jwt.WithAudience only
+// keeps the last of several expected audiences.
+func (m *Manager) verifyAudience(audience jwt.ClaimStrings) error {
+	for _, want := range m.opts.Audience {
+		found := false
+		for _, aud := range audience {
+			if aud == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%w: %w: missing %q", ErrTokenClaims, jwt.ErrTokenInvalidAudience, want)
+		}
+	}
+
+	return nil
+}
+
+func parseError(err error) error {
+	switch {
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return fmt.Errorf("%w: %w", ErrTokenMalformed, err)
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid), errors.Is(err, jwt.ErrTokenUnverifiable):
+		return fmt.Errorf("%w: %w", ErrTokenSignature, err)
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return ErrTokenExpired
+	default:
+		return fmt.Errorf("%w: %w", ErrTokenClaims, err)
+	}
+}
+
+func (m *Manager) JWKS() JWKS {
+	jwks := JWKS{Keys: make([]JWK, 0, len(m.keys))}
+	for _, key := range m.keys {
+		if key.expired() {
+			continue
+		}
+		if jwk, ok := key.jwk(); ok {
+			jwks.Keys = append(jwks.Keys, jwk)
+		}
+	}
+
+	sort.Slice(jwks.Keys, func(i, j int) bool {
+		return jwks.Keys[i].KeyID < jwks.Keys[j].KeyID
+	})
+
+	return jwks
 }
 
 type Token struct {
-	Username string
-	UserID   int
+	Username  string
+	UserID    int
+	SessionID int
+	Role      string
+}
+
+// MFAChallenge identifies a pending second step of a sign-in.
+type MFAChallenge struct {
+	UserID int
+	ID     string
 }
 
 func TokenFromContext(ctx context.Context) (*Token, error) {
@@ -0,0 +1,205 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testOptions = Options{Issuer: "market", Audience: []string{"market"}, Leeway: time.Second}
+
+func newRSAKey(t *testing.T, id string) Key {
+	t.Helper()
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return Key{ID: id, Algorithm: AlgRS256, PrivateKey: priv, PublicKey: priv.Public()}
+}
+
+func newEdDSAKey(t *testing.T, id string) Key {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return Key{ID: id, Algorithm: AlgEdDSA, PrivateKey: priv, PublicKey: pub}
+}
+
+func TestManager_SignAndParse(t *testing.T) {
+	hmacKey, err := NewHMACKey("default", "secret")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		key  Key
+	}{
+		{name: "HS256", key: hmacKey},
+		{name: "RS256", key: newRSAKey(t, "rsa-1")},
+		{name: "EdDSA", key: newEdDSAKey(t, "ed-1")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewManager(testOptions, tt.key)
+			require.NoError(t, err)
+
+			want := Token{UserID: 1, Username: "test", SessionID: 2, Role: "user"}
+			accessToken, err := m.NewJWT(want, time.Minute)
+			require.NoError(t, err)
+
+			got, err := m.Parse(accessToken)
+			assert.NoError(t, err)
+			assert.Equal(t, &want, got)
+		})
+	}
+}
+
+func TestManager_KeyRotation(t *testing.T) {
+	oldKey := newRSAKey(t, "key-1")
+	newKey := newEdDSAKey(t, "key-2")
+
+	oldManager, err := NewManager(testOptions, oldKey)
+	require.NoError(t, err)
+	oldToken, err := oldManager.NewJWT(Token{UserID: 1}, time.Minute)
+	require.NoError(t, err)
+
+	retired := Key{ID: oldKey.ID, Algorithm: oldKey.Algorithm, PublicKey: oldKey.PublicKey}
+	m, err := NewManager(testOptions, newKey, retired)
+	require.NoError(t, err)
+
+	_, err = m.Parse(oldToken)
+	assert.NoError(t, err)
+	assert.Len(t, m.JWKS().Keys, 2)
+
+	retired.ExpiresAt = time.Now().Add(-time.Minute)
+	m, err = NewManager(testOptions, newKey, retired)
+	require.NoError(t, err)
+
+	_, err = m.Parse(oldToken)
+	assert.ErrorIs(t, err, ErrKeyExpired)
+	assert.Len(t, m.JWKS().Keys, 1)
+
+	m, err = NewManager(testOptions, newKey)
+	require.NoError(t, err)
+
+	_, err = m.Parse(oldToken)
+	assert.ErrorIs(t, err, ErrTokenSignature)
+}
+
+func TestNewManager_VerificationOnlyKey(t *testing.T) {
+	key := newRSAKey(t, "key-1")
+	key.PrivateKey = nil
+
+	_, err := NewManager(testOptions, key)
+	assert.ErrorIs(t, err, ErrNoPrivateKey)
+}
+
+func TestManager_ParseErrors(t *testing.T) {
+	key, err := NewHMACKey("default", "secret")
+	require.NoError(t, err)
+
+	m, err := NewManager(testOptions, key)
+	require.NoError(t, err)
+
+	expired, err := m.NewJWT(Token{UserID: 1}, -time.Minute)
+	require.NoError(t, err)
+
+	other, err := NewManager(Options{Issuer: "other", Audience: []string{"market"}}, key)
+	require.NoError(t, err)
+	foreignIssuer, err := other.NewJWT(Token{UserID: 1}, time.Minute)
+	require.NoError(t, err)
+
+	otherKey, err := NewHMACKey("default", "other-secret")
+	require.NoError(t, err)
+	forger, err := NewManager(testOptions, otherKey)
+	require.NoError(t, err)
+	forged, err := forger.NewJWT(Token{UserID: 1}, time.Minute)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{name: "Expired", token: expired, wantErr: ErrTokenExpired},
+		{name: "Malformed", token: "not.a.token", wantErr: ErrTokenMalformed},
+		{name: "Bad Signature", token: forged, wantErr: ErrTokenSignature},
+		{name: "Wrong Issuer", token: foreignIssuer, wantErr: ErrTokenClaims},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := m.Parse(tt.token)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestManager_Audience(t *testing.T) {
+	key, err := NewHMACKey("default", "secret")
+	require.NoError(t, err)
+
+	opts := Options{Issuer: "market", Audience: []string{"market", "admin"}}
+	m, err := NewManager(opts, key)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		audience []string
+		wantErr  error
+	}{
+		{name: "All Audiences", audience: []string{"market", "admin"}},
+		{name: "Extra Audience", audience: []string{"admin", "market", "mobile"}},
+		{name: "First Missing", audience: []string{"admin"}, wantErr: ErrTokenClaims},
+		{name: "Last Missing", audience: []string{"market"}, wantErr: ErrTokenClaims},
+		{name: "No Audience", wantErr: ErrTokenClaims},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer, err := NewManager(Options{Issuer: "market", Audience: tt.audience}, key)
+			require.NoError(t, err)
+			token, err := issuer.NewJWT(Token{UserID: 1}, time.Minute)
+			require.NoError(t, err)
+
+			_, err = m.Parse(token)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestManager_MFAToken(t *testing.T) {
+	key, err := NewHMACKey("default", "secret")
+	require.NoError(t, err)
+	m, err := NewManager(testOptions, key)
+	require.NoError(t, err)
+
+	challenge := MFAChallenge{UserID: 1, ID: "challenge-1"}
+	mfaToken, err := m.NewMFAToken(challenge, time.Minute)
+	require.NoError(t, err)
+
+	got, err := m.ParseMFAToken(mfaToken)
+	assert.NoError(t, err)
+	assert.Equal(t, challenge, got)
+
+	noID, err := m.NewMFAToken(MFAChallenge{UserID: 1}, time.Minute)
+	require.NoError(t, err)
+
+	_, err = m.ParseMFAToken(noID)
+	assert.ErrorIs(t, err, ErrTokenClaims)
+
+	_, err = m.Parse(mfaToken)
+	assert.ErrorIs(t, err, ErrTokenClaims)
+
+	accessToken, err := m.NewJWT(Token{UserID: 1, Username: "test", SessionID: 2, Role: "user"}, time.Minute)
+	require.NoError(t, err)
+
+	_, err = m.ParseMFAToken(accessToken)
+	assert.ErrorIs(t, err, ErrTokenClaims)
+}
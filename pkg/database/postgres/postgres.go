@@ -12,6 +12,7 @@ import (
 var (
 	ErrAlreadyExists = errors.New("already exists")
 	ErrNotFound      = errors.New("not found")
+	ErrForeignKey    = errors.New("violates foreign key constraint")
 )
 
 func NewPostgresqlDB(host, port, user, dbname, password, sslmode string) (*sqlx.DB, error) {
@@ -35,8 +36,11 @@ func ParsePostgresError(err error) error {
 
 	pgErr, ok := err.(*pq.Error)
 	if ok {
-		if pgErr.Code == "23505" {
+		switch pgErr.Code {
+		case "23505":
 			return ErrAlreadyExists
+		case "23503":
+			return ErrForeignKey
 		}
 	}
 
@@ -0,0 +1,87 @@
+// Package exchange provides exchange rates between currencies.
+package exchange
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+var ErrNoRate = errors.New("no exchange rate for currency")
+
+// Rates tells the price of one major unit of a currency in major units of
+// another one. Rates are exact fractions, so conversions don't accumulate
+// floating point errors.
+type Rates interface {
+	Rate(from, to string) (*big.Rat, error)
+}
+
+// Static holds fixed rates against a base currency. It needs no network
+// access, which suits development and offline deployments.
+type Static struct {
+	base  string
+	rates map[string]*big.Rat
+}
+
+// staticFile is the format of rate files, e.g.
+//
+//	{"base": "RUB", "rates": {"USD": "0.0105", "EUR": "0.0098"}}
+//
+// where a rate is the amount of the currency one unit of base buys. Rates may
+// be given as strings or numbers.
+type staticFile struct {
+	Base  string                 `json:"base"`
+	Rates map[string]json.Number `json:"rates"`
+}
+
+// NewStaticFromFile reads rates from the JSON file at path.
+func NewStaticFromFile(path string) (*Static, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file staticFile
+	if err = json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	rates := make(map[string]string, len(file.Rates))
+	for currency, rate := range file.Rates {
+		rates[currency] = rate.String()
+	}
+
+	return NewStatic(file.Base, rates)
+}
+
+// NewStatic builds rates from decimal strings, each the amount of the
+// currency one unit of base buys.
+func NewStatic(base string, rates map[string]string) (*Static, error) {
+	s := &Static{base: base, rates: map[string]*big.Rat{base: big.NewRat(1, 1)}}
+	for currency, value := range rates {
+		rate, ok := new(big.Rat).SetString(value)
+		if !ok || rate.Sign() <= 0 {
+			return nil, fmt.Errorf("invalid rate %q of %s", value, currency)
+		}
+		s.rates[currency] = rate
+	}
+
+	return s, nil
+}
+
+// Rate goes through the base currency when neither currency is the base.
+func (s *Static) Rate(from, to string) (*big.Rat, error) {
+	fromRate, ok := s.rates[from]
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrNoRate, from)
+	}
+
+	toRate, ok := s.rates[to]
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrNoRate, to)
+	}
+
+	return new(big.Rat).Quo(toRate, fromRate), nil
+}
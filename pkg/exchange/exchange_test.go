@@ -0,0 +1,42 @@
+package exchange
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatic_Rate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"base": "RUB", "rates": {"USD": "0.01", "EUR": 0.008}}`), 0o600))
+
+	rates, err := NewStaticFromFile(path)
+	require.NoError(t, err)
+
+	rate, err := rates.Rate("RUB", "USD")
+	require.NoError(t, err)
+	assert.Equal(t, big.NewRat(1, 100), rate)
+
+	rate, err = rates.Rate("USD", "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, big.NewRat(4, 5), rate)
+
+	rate, err = rates.Rate("EUR", "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, big.NewRat(1, 1), rate)
+
+	_, err = rates.Rate("USD", "JPY")
+	assert.ErrorIs(t, err, ErrNoRate)
+}
+
+func TestNewStatic_InvalidRate(t *testing.T) {
+	_, err := NewStatic("RUB", map[string]string{"USD": "-1"})
+	assert.Error(t, err)
+
+	_, err = NewStatic("RUB", map[string]string{"USD": "cheap"})
+	assert.Error(t, err)
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
 )
 
 var (
@@ -19,6 +20,7 @@ var (
 type PasswordHasher interface {
 	Hash(password string) (string, error)
 	Verify(password, encodedHash string) (bool, error)
+	NeedsRehash(encodedHash string) bool
 }
 
 type Argon2Hasher struct {
@@ -63,7 +65,17 @@ func (h *Argon2Hasher) Hash(password string) (encodedHash string, err error) {
 	return encodedHash, nil
 }
 
+// Verify also accepts bcrypt hashes of imported users, NeedsRehash reports them
+// so they are replaced with argon2id on the next successful login.
 func (h *Argon2Hasher) Verify(password, encodedHash string) (match bool, err error) {
+	if isBcryptHash(encodedHash) {
+		err = bcrypt.CompareHashAndPassword([]byte(encodedHash), []byte(password))
+		if err == bcrypt.ErrMismatchedHashAndPassword {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
 	p, salt, hash, err := decodeHash(encodedHash)
 	if err != nil {
 		return false, err
@@ -77,6 +89,23 @@ func (h *Argon2Hasher) Verify(password, encodedHash string) (match bool, err err
 	return false, nil
 }
 
+func (h *Argon2Hasher) NeedsRehash(encodedHash string) bool {
+	if isBcryptHash(encodedHash) {
+		return true
+	}
+
+	p, _, _, err := decodeHash(encodedHash)
+	if err != nil {
+		return false
+	}
+
+	return *p != h.parameters
+}
+
+func isBcryptHash(encodedHash string) bool {
+	return strings.HasPrefix(encodedHash, "$2a$") || strings.HasPrefix(encodedHash, "$2b$") || strings.HasPrefix(encodedHash, "$2y$")
+}
+
 func decodeHash(encodedHash string) (p *parameters, salt, hash []byte, err error) {
 	vals := strings.Split(encodedHash, "$")
 	if len(vals) != 6 { //nolint:gomnd
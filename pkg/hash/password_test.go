@@ -0,0 +1,67 @@
+package hash
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestArgon2Hasher_NeedsRehash(t *testing.T) {
+	hasher := NewArgon2Hasher(64*1024, 3, 16, 32, 2)
+
+	current, err := hasher.Hash("password")
+	require.NoError(t, err)
+
+	legacy, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		encodedHash string
+		want        bool
+	}{
+		{
+			name:        "Current Parameters",
+			encodedHash: current,
+			want:        false,
+		},
+		{
+			name:        "Weaker Parameters",
+			encodedHash: "$argon2id$v=19$m=65536,t=3,p=1$kMwiCJlyCi2xXKy/U1c8hA$FtPqNnpdWNc7cD0hOcbTxMav4s/HyGUhew6bhlWqy5c",
+			want:        true,
+		},
+		{
+			name:        "Bcrypt",
+			encodedHash: string(legacy),
+			want:        true,
+		},
+		{
+			name:        "Invalid Format",
+			encodedHash: "plain",
+			want:        false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, hasher.NeedsRehash(tt.encodedHash))
+		})
+	}
+}
+
+func TestArgon2Hasher_VerifyBcrypt(t *testing.T) {
+	hasher := NewArgon2Hasher(64*1024, 3, 16, 32, 2)
+
+	legacy, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	match, err := hasher.Verify("password", string(legacy))
+	assert.NoError(t, err)
+	assert.True(t, match)
+
+	match, err = hasher.Verify("wrong", string(legacy))
+	assert.NoError(t, err)
+	assert.False(t, match)
+}
@@ -0,0 +1,102 @@
+// Package imaging decodes, checks and resizes uploaded images.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // registers the WebP decoder
+)
+
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatWebP = "webp"
+
+	jpegQuality = 85
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+	ErrTooLarge          = errors.New("image dimensions are too large")
+)
+
+// Decode reads a JPEG, PNG or WebP image of at most maxWidth x maxHeight
+// pixels and returns it with its format. The dimensions are checked before the
+// pixels are decoded. JPEG images are rotated according to their EXIF
+// orientation, since the metadata is lost once the image is encoded again.
+func Decode(r io.Reader, maxWidth, maxHeight int) (image.Image, string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", ErrUnsupportedFormat
+	}
+
+	if format != FormatJPEG && format != FormatPNG && format != FormatWebP {
+		return nil, "", ErrUnsupportedFormat
+	}
+
+	if cfg.Width > maxWidth || cfg.Height > maxHeight {
+		return nil, "", ErrTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", ErrUnsupportedFormat
+	}
+
+	if format == FormatJPEG {
+		img = orient(img, jpegOrientation(data))
+	}
+
+	return img, format, nil
+}
+
+// Encode writes the image without any metadata. WebP images are written as PNG,
+// since there is no WebP encoder in the standard library.
+func Encode(w io.Writer, img image.Image, format string) error {
+	if format == FormatJPEG {
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: jpegQuality})
+	}
+
+	return png.Encode(w, img)
+}
+
+// Fit scales the image down to fit into a maxSide x maxSide square, keeping its
+// aspect ratio. Smaller images are returned as they are.
+func Fit(img image.Image, maxSide int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= maxSide && height <= maxSide {
+		return img
+	}
+
+	if width >= height {
+		height = max(1, height*maxSide/width)
+		width = maxSide
+	} else {
+		width = max(1, width*maxSide/height)
+		height = maxSide
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+
+	return dst
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
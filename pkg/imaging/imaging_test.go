@@ -0,0 +1,87 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))))
+	return buf.Bytes()
+}
+
+func TestDecode(t *testing.T) {
+	var gifData bytes.Buffer
+	require.NoError(t, gif.Encode(&gifData, image.NewPaletted(image.Rect(0, 0, 2, 2), color.Palette{color.Black}), nil))
+
+	tests := []struct {
+		name    string
+		data    []byte
+		format  string
+		wantErr error
+	}{
+		{name: "PNG", data: encodePNG(t, 20, 10), format: FormatPNG},
+		{name: "Too Large", data: encodePNG(t, 101, 10), wantErr: ErrTooLarge},
+		{name: "GIF", data: gifData.Bytes(), wantErr: ErrUnsupportedFormat},
+		{name: "Not An Image", data: []byte("<script></script>"), wantErr: ErrUnsupportedFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, format, err := Decode(bytes.NewReader(tt.data), 100, 100)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.format, format)
+			assert.Equal(t, 20, img.Bounds().Dx())
+		})
+	}
+}
+
+func TestFit(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 400, 100))
+
+	assert.Equal(t, image.Rect(0, 0, 160, 40), Fit(img, 160).Bounds())
+	assert.Equal(t, image.Rect(0, 0, 100, 400), Fit(image.NewRGBA(image.Rect(0, 0, 100, 400)), 1000).Bounds())
+}
+
+func TestOrient(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, color.White)
+
+	rotated := orient(img, 6)
+	assert.Equal(t, image.Rect(0, 0, 1, 2), rotated.Bounds())
+	assert.Equal(t, color.RGBAModel.Convert(color.White), rotated.At(0, 0))
+
+	rotated = orient(img, 8)
+	assert.Equal(t, color.RGBAModel.Convert(color.White), rotated.At(0, 1))
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	// SOI, APP1 with a big-endian TIFF header holding orientation 6, SOS.
+	exif := []byte{
+		0xFF, 0xD8,
+		0xFF, 0xE1, 0x00, 0x22,
+		'E', 'x', 'i', 'f', 0, 0,
+		'M', 'M', 0x00, 0x2A, 0x00, 0x00, 0x00, 0x08,
+		0x00, 0x01,
+		0x01, 0x12, 0x00, 0x03, 0x00, 0x00, 0x00, 0x01, 0x00, 0x06, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00,
+		0xFF, 0xDA,
+	}
+
+	assert.Equal(t, 6, jpegOrientation(exif))
+	assert.Equal(t, 1, jpegOrientation([]byte{0xFF, 0xD8, 0xFF, 0xDA, 0x00, 0x02}))
+	assert.Equal(t, 1, jpegOrientation([]byte("not a jpeg")))
+}
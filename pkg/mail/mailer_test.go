@@ -0,0 +1,27 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogMailer_Send(t *testing.T) {
+	var buf bytes.Buffer
+	m := NewLogMailer(&buf)
+
+	err := m.Send(context.Background(), Message{To: "user@example.com", Subject: "Hello", Body: "body"})
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "To: user@example.com\nSubject: Hello\n\nbody")
+}
+
+func TestSMTPMailer_build(t *testing.T) {
+	m := NewSMTPMailer("localhost", 25, "", "", "market <no-reply@market.local>")
+
+	got := string(m.build(Message{To: "user@example.com", Subject: "Hello", Body: "body"}))
+	assert.Equal(t, "From: market <no-reply@market.local>\r\nTo: user@example.com\r\nSubject: Hello\r\n"+
+		"MIME-Version: 1.0\r\nContent-Type: text/plain; charset=\"utf-8\"\r\n\r\nbody", got)
+	assert.Equal(t, "localhost:25", m.addr)
+}
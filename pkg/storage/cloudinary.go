@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/cloudinary/cloudinary-go/v2"
+	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+)
+
+const pingTimeout = 5 * time.Second
+
+type Cloudinary struct {
+	cld *cloudinary.Cloudinary
+}
+
+// NewCloudinary fails if Cloudinary can't be reached with the credentials.
+func NewCloudinary(cloud, key, secret string) (*Cloudinary, error) {
+	cld, err := cloudinary.NewFromParams(cloud, key, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+	if _, err = cld.Admin.Ping(ctx); err != nil {
+		return nil, err
+	}
+
+	return &Cloudinary{cld: cld}, nil
+}
+
+// Put uses name as the public ID of the file and returns its HTTPS URL, so
+// images load on pages served over HTTPS.
+func (s *Cloudinary) Put(ctx context.Context, name string, r io.Reader) (Object, error) {
+	resp, err := s.cld.Upload.Upload(ctx, r, uploader.UploadParams{PublicID: name})
+	if err != nil {
+		return Object{}, err
+	}
+
+	return Object{URL: resp.SecureURL, ID: resp.PublicID}, nil
+}
+
+// Delete removes the file. Cloudinary reports a missing file in the response
+// only, so it's not an error either.
+func (s *Cloudinary) Delete(ctx context.Context, id string) error {
+	_, err := s.cld.Upload.Destroy(ctx, uploader.DestroyParams{PublicID: id})
+	return err
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Local keeps files in a directory, which the application serves under
+// baseURL.
+type Local struct {
+	dir     string
+	baseURL string
+}
+
+func NewLocal(dir, baseURL string) (*Local, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil { //nolint:gomnd
+		return nil, err
+	}
+
+	return &Local{dir: dir, baseURL: strings.TrimSuffix(baseURL, "/")}, nil
+}
+
+func (s *Local) Put(ctx context.Context, name string, r io.Reader) (Object, error) {
+	if err := ctx.Err(); err != nil {
+		return Object{}, err
+	}
+
+	if name == "" || filepath.Base(name) != name {
+		return Object{}, fmt.Errorf("invalid object name %q", name)
+	}
+
+	r, contentType, err := sniff(r)
+	if err != nil {
+		return Object{}, err
+	}
+
+	name = objectName(name, contentType)
+	f, err := os.OpenFile(filepath.Join(s.dir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644) //nolint:gomnd
+	if err != nil {
+		return Object{}, err
+	}
+
+	if _, err = io.Copy(f, r); err != nil {
+		f.Close()           //nolint:errcheck
+		os.Remove(f.Name()) //nolint:errcheck
+		return Object{}, err
+	}
+
+	if err = f.Close(); err != nil {
+		os.Remove(f.Name()) //nolint:errcheck
+		return Object{}, err
+	}
+
+	return Object{URL: s.baseURL + "/" + name, ID: name}, nil
+}
+
+// Delete removes the file. A missing file is not an error.
+func (s *Local) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if id == "" || filepath.Base(id) != id {
+		return fmt.Errorf("invalid object id %q", id)
+	}
+
+	if err := os.Remove(filepath.Join(s.dir, id)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
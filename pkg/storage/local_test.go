@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocal_PutDelete(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewLocal(dir, "http://localhost:8080/static/")
+	require.NoError(t, err)
+
+	png := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 16)...)
+	object, err := s.Put(context.Background(), "image", bytes.NewReader(png))
+	require.NoError(t, err)
+
+	assert.Equal(t, "image.png", object.ID)
+	assert.Equal(t, "http://localhost:8080/static/"+object.ID, object.URL)
+
+	data, err := os.ReadFile(filepath.Join(dir, object.ID))
+	require.NoError(t, err)
+	assert.Equal(t, png, data)
+
+	assert.NoError(t, s.Delete(context.Background(), object.ID))
+	assert.NoFileExists(t, filepath.Join(dir, object.ID))
+	assert.NoError(t, s.Delete(context.Background(), object.ID))
+}
+
+func TestLocal_OutsideDir(t *testing.T) {
+	s, err := NewLocal(t.TempDir(), "http://localhost:8080/static")
+	require.NoError(t, err)
+
+	assert.Error(t, s.Delete(context.Background(), "../config.yml"))
+	assert.Error(t, s.Delete(context.Background(), ""))
+
+	_, err = s.Put(context.Background(), "../image", bytes.NewReader([]byte("data")))
+	assert.Error(t, err)
+}
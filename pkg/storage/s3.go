@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+type S3Options struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+	// PublicURL is prepended to object names in URLs. It defaults to the
+	// path-style URL of the bucket.
+	PublicURL string
+}
+
+// S3 keeps files in a bucket of Amazon S3 or of a compatible service like
+// MinIO.
+type S3 struct {
+	client    *minio.Client
+	bucket    string
+	publicURL string
+}
+
+// NewS3 fails if the bucket doesn't exist or can't be reached.
+func NewS3(opts S3Options) (*S3, error) {
+	client, err := minio.New(opts.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(opts.AccessKey, opts.SecretKey, ""),
+		Secure: opts.UseSSL,
+		Region: opts.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+	exists, err := client.BucketExists(ctx, opts.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("bucket %q doesn't exist", opts.Bucket)
+	}
+
+	publicURL := opts.PublicURL
+	if publicURL == "" {
+		publicURL = client.EndpointURL().String() + "/" + opts.Bucket
+	}
+
+	return &S3{client: client, bucket: opts.Bucket, publicURL: strings.TrimSuffix(publicURL, "/")}, nil
+}
+
+func (s *S3) Put(ctx context.Context, name string, r io.Reader) (Object, error) {
+	r, contentType, err := sniff(r)
+	if err != nil {
+		return Object{}, err
+	}
+
+	name = objectName(name, contentType)
+	if _, err = s.client.PutObject(ctx, s.bucket, name, r, -1, minio.PutObjectOptions{ContentType: contentType}); err != nil {
+		return Object{}, err
+	}
+
+	return Object{URL: s.publicURL + "/" + name, ID: name}, nil
+}
+
+// Delete removes the object. A missing object is not an error.
+func (s *S3) Delete(ctx context.Context, id string) error {
+	return s.client.RemoveObject(ctx, s.bucket, id, minio.RemoveObjectOptions{})
+}
@@ -0,0 +1,49 @@
+// Package storage keeps uploaded files in Cloudinary, on the local filesystem
+// or in an S3-compatible bucket.
+package storage
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"net/http"
+)
+
+const sniffLen = 512
+
+// Object is a stored file. ID is what Delete expects, URL is where the file is
+// served from.
+type Object struct {
+	URL string
+	ID  string
+}
+
+// Storage keeps files under names chosen by the caller. Backends may append an
+// extension matching the content type, so the ID of an object is name plus
+// that extension.
+type Storage interface {
+	Put(ctx context.Context, name string, r io.Reader) (Object, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// sniff detects the content type of r. The returned reader still yields the
+// whole content.
+func sniff(r io.Reader) (io.Reader, string, error) {
+	br := bufio.NewReaderSize(r, sniffLen)
+	head, err := br.Peek(sniffLen)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, "", err
+	}
+
+	return br, http.DetectContentType(head), nil
+}
+
+// objectName appends an extension matching the content type to name.
+func objectName(name, contentType string) string {
+	if exts, err := mime.ExtensionsByType(contentType); err == nil && len(exts) != 0 {
+		name += exts[0]
+	}
+
+	return name
+}
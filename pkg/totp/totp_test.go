@@ -0,0 +1,72 @@
+package totp
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test vectors from RFC 6238, appendix B, truncated to 6 digits.
+func TestCode(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		got, err := Code(secret, time.Unix(tt.unix, 0))
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := NewSecret()
+	assert.NoError(t, err)
+
+	now := time.Now()
+	code, err := Code(secret, now)
+	assert.NoError(t, err)
+
+	assert.True(t, Validate(secret, code, now))
+	assert.True(t, Validate(secret, code, now.Add(Period)))
+	assert.False(t, Validate(secret, code, now.Add(3*Period)))
+	assert.False(t, Validate(secret, "12345", now))
+	assert.False(t, Validate("not base32!", code, now))
+}
+
+func TestMatch(t *testing.T) {
+	secret, err := NewSecret()
+	assert.NoError(t, err)
+
+	now := time.Unix(1111111109, 0)
+	code, err := Code(secret, now)
+	assert.NoError(t, err)
+
+	step, ok := Match(secret, code, now)
+	assert.True(t, ok)
+	assert.Equal(t, int64(1111111109/30), step)
+
+	// The same code matches the same step in the next period.
+	next, ok := Match(secret, code, now.Add(Period))
+	assert.True(t, ok)
+	assert.Equal(t, step, next)
+
+	_, ok = Match(secret, code, now.Add(3*Period))
+	assert.False(t, ok)
+}
+
+func TestURI(t *testing.T) {
+	got := URI("market", "seller", "JBSWY3DPEHPK3PXP")
+	assert.Equal(t, "otpauth://totp/market:seller?algorithm=SHA1&digits=6&issuer=market&period=30&secret=JBSWY3DPEHPK3PXP", got)
+}
@@ -1,21 +1,46 @@
 DROP TABLE IF EXISTS users;
 DROP TABLE IF EXISTS carts;
 DROP TABLE IF EXISTS products;
+DROP TABLE IF EXISTS categories;
+DROP TABLE IF EXISTS product_images;
+DROP TABLE IF EXISTS product_options;
+DROP TABLE IF EXISTS product_variants;
+DROP TABLE IF EXISTS product_variant_values;
 DROP TABLE IF EXISTS orders;
+DROP TABLE IF EXISTS order_status_history;
 DROP TABLE IF EXISTS reviews;
 DROP TABLE IF EXISTS products_carts;
 DROP TABLE IF EXISTS products_users;
 DROP TABLE IF EXISTS products_orders;
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS login_attempts;
+DROP TABLE IF EXISTS failed_logins;
+DROP TABLE IF EXISTS user_tokens;
+DROP TABLE IF EXISTS recovery_codes;
+DROP TABLE IF EXISTS seller_applications;
+DROP TABLE IF EXISTS seller_profiles;
+DROP TABLE IF EXISTS admin_audit_log;
+DROP TABLE IF EXISTS outbox;
+DROP TABLE IF EXISTS stored_images;
 
 CREATE TABLE users 
 (
-  id        serial       not null unique,
-  role      varchar(255) not null,
-  username  varchar(255) not null unique,
-  password  varchar(255) not null
+  id                       serial       not null unique,
+  role                     varchar(255) not null,
+  username                 varchar(255) not null unique,
+  email                    varchar(255) not null unique,
+  email_verified           boolean      not null default false,
+  password                 varchar(255) not null,
+  totp_secret              varchar(255),
+  totp_enabled             boolean      not null default false,
+  totp_last_step           bigint,
+  password_reset_required  boolean      not null default false,
+  banned_at                timestamp,
+  ban_reason               varchar(255),
+  created_at               timestamp    not null default now()
 );
-INSERT INTO users (role, username, password) VALUES
-('admin',	'admin',	'$argon2id$v=19$m=65536,t=3,p=1$kMwiCJlyCi2xXKy/U1c8hA$FtPqNnpdWNc7cD0hOcbTxMav4s/HyGUhew6bhlWqy5c');
+INSERT INTO users (role, username, email, email_verified, password) VALUES
+('admin',	'admin',	'admin@market.local',	true,	'$argon2id$v=19$m=65536,t=3,p=1$kMwiCJlyCi2xXKy/U1c8hA$FtPqNnpdWNc7cD0hOcbTxMav4s/HyGUhew6bhlWqy5c');
 
 CREATE TABLE carts
 (
@@ -25,30 +50,113 @@ CREATE TABLE carts
 INSERT INTO carts (user_id) VALUES
 (1);
 
+CREATE TABLE categories
+(
+  id           serial                                              not null unique,
+  parent_id    int references categories (id) on delete restrict,
+  name         varchar(255)                                        not null,
+  slug         varchar(255)                                        not null unique,
+  description  varchar(255),
+  created_at   timestamp                                           not null
+);
+CREATE INDEX categories_parent_id_idx ON categories (parent_id);
+
 CREATE TABLE products
 (
   id            serial                                                        not null unique,
   user_id       int references users(id) on delete cascade                    not null,
   title         varchar(255)                                                  not null,
   price         numeric                                    check (price > 0)  not null, 
+  currency      char(3)                                                       not null,
   tag           varchar(255), 
-  category      varchar(255)                                                  not null,
+  category_id   int references categories (id) on delete restrict             not null,
   description   varchar(255), 
-  amount        int                                        check (amount > 0) not null,
+  amount        int                                        check (amount >= 0) not null,
+  reserved      int                                        not null default 0 check (reserved >= 0),
   created_at    timestamp                                                     not null,
   updated_at    timestamp                                                     not null,
   views         int                                                           not null,
-  image_url     varchar(255)                                                  not null,
-  image_id      varchar(255)                                                  not null unique
+  search_vector tsvector generated always as (
+    setweight(to_tsvector('simple', title), 'A') ||
+    setweight(to_tsvector('simple', coalesce(tag, '')), 'B') ||
+    setweight(to_tsvector('simple', coalesce(description, '')), 'C')
+  ) stored
+);
+CREATE INDEX products_search_idx ON products USING GIN (search_vector);
+CREATE INDEX products_category_id_idx ON products (category_id);
+
+CREATE TABLE product_images
+(
+  id          serial                                          not null unique,
+  product_id  int references products (id) on delete cascade  not null,
+  image_url   varchar(255)                                    not null,
+  image_id    varchar(255)                                    not null unique,
+  position    int                                             not null,
+  is_primary  boolean                                         not null default false,
+  thumbnails  jsonb                                           not null default '{}',
+  created_at  timestamp                                       not null
+);
+CREATE INDEX product_images_product_id_idx ON product_images (product_id, position);
+CREATE UNIQUE INDEX product_images_primary_idx ON product_images (product_id) WHERE is_primary;
+
+CREATE TABLE product_options
+(
+  id          serial                                          not null unique,
+  product_id  int references products (id) on delete cascade  not null,
+  name        varchar(255)                                    not null,
+  position    int                                             not null default 0,
+  unique (product_id, name)
+);
+
+CREATE TABLE product_variants
+(
+  id          serial                                                        not null unique,
+  product_id  int references products (id) on delete cascade                not null,
+  sku         varchar(64)                                                   not null unique,
+  price       numeric                                    check (price > 0),
+  amount      int                                        check (amount >= 0) not null,
+  reserved    int                                        not null default 0 check (reserved >= 0),
+  image_url   varchar(255),
+  image_id    varchar(255)                                                  unique,
+  thumbnails  jsonb,
+  created_at  timestamp                                                     not null,
+  updated_at  timestamp                                                     not null
+);
+CREATE INDEX product_variants_product_id_idx ON product_variants (product_id);
+
+CREATE TABLE product_variant_values
+(
+  variant_id  int references product_variants (id) on delete cascade  not null,
+  option_id   int references product_options (id)                     not null,
+  value       varchar(255)                                            not null,
+  primary key (variant_id, option_id)
 );
 
 CREATE TABLE orders
 (
   id            serial                                        not null unique,
   user_id       int references users (id) on delete cascade   not null,
+  status        varchar(32)                                   not null default 'pending',
   created_at    timestamp                                     not null,
-  delivered_at  timestamp                                     not null
+  delivered_at  timestamp,
+  subtotal      numeric                                       not null,
+  tax           numeric                                       not null,
+  shipping      numeric                                       not null,
+  total         numeric                                       not null,
+  currency      char(3)                                       not null
+);
+
+CREATE TABLE order_status_history
+(
+  id           serial                                        not null unique,
+  order_id     int references orders (id) on delete cascade  not null,
+  from_status  varchar(32),
+  to_status    varchar(32)                                   not null,
+  changed_by   int references users (id) on delete set null,
+  comment      varchar(255),
+  created_at   timestamp                                     not null
 );
+CREATE INDEX order_status_history_order_id_idx ON order_status_history (order_id);
 
 CREATE TABLE reviews
 (
@@ -56,7 +164,7 @@ CREATE TABLE reviews
   created_at      timestamp                                      not null,
   updated_at      timestamp                                      not null,
   product_id      int references products (id) on delete cascade not null,
-  user_id         int references users (id)                      not null,
+  user_id         int references users (id) on delete cascade    not null,
   text            varchar(255)                                   not null,
   category        varchar(255)                                   not null
 );
@@ -65,9 +173,13 @@ CREATE TABLE products_carts
 (
   id               serial                                                                      not null unique,
   product_id       int references products (id) on delete cascade                              not null,
+  variant_id       int references product_variants (id) on delete cascade,
   cart_id          int references carts (id) on delete cascade                                 not null,
-  purchased_amount int                                            check (purchased_amount > 0) not null
+  purchased_amount int                                            check (purchased_amount > 0) not null,
+  reserved_until   timestamp
 );
+CREATE UNIQUE INDEX products_carts_line_idx ON products_carts (cart_id, product_id, coalesce(variant_id, 0));
+CREATE INDEX products_carts_reserved_until_idx ON products_carts (reserved_until) WHERE reserved_until IS NOT NULL;
 
 CREATE TABLE products_users 
 (
@@ -79,8 +191,118 @@ CREATE TABLE products_users
 CREATE TABLE products_orders
 (
   id               serial                                                                      not null unique,
-  product_id       int references products (id) on delete cascade                              not null,
+  product_id       int references products (id) on delete set null,
+  variant_id       int references product_variants (id) on delete set null,
   order_id         int references orders (id) on delete cascade                                not null,
-  purchased_amount int                                            check (purchased_amount > 0) not null
+  purchased_amount int                                            check (purchased_amount > 0) not null,
+  seller_id        int references users (id) on delete set null,
+  title            varchar(255)                                                                not null,
+  sku              varchar(64),
+  unit_price       numeric                                                                     not null,
+  currency         char(3)                                                                     not null
+);
+
+CREATE TABLE sessions
+(
+  id             serial                                         not null unique,
+  user_id        int references users (id) on delete cascade    not null,
+  family_id      varchar(255)                                   not null,
+  refresh_token  varchar(255)                                   not null unique,
+  created_at     timestamp                                      not null,
+  expires_at     timestamp                                      not null,
+  revoked        boolean                                        not null default false
+);
+CREATE INDEX sessions_family_id_idx ON sessions (family_id);
+
+CREATE TABLE login_attempts
+(
+  attempt_key      varchar(255)  not null unique,
+  failures         int           not null default 0,
+  last_failure_at  timestamp     not null,
+  locked_until     timestamp
+);
+
+CREATE TABLE failed_logins
+(
+  id          serial        not null unique,
+  username    varchar(255)  not null,
+  ip          varchar(255)  not null,
+  reason      varchar(255)  not null,
+  created_at  timestamp     not null
+);
+
+CREATE TABLE user_tokens
+(
+  id          serial                                       not null unique,
+  user_id     int references users (id) on delete cascade  not null,
+  token       varchar(255)                                 not null unique,
+  purpose     varchar(255)                                 not null,
+  created_at  timestamp                                    not null,
+  expires_at  timestamp                                    not null,
+  used_at     timestamp
+);
+
+CREATE TABLE recovery_codes
+(
+  id       serial                                       not null unique,
+  user_id  int references users (id) on delete cascade  not null,
+  code     varchar(255)                                 not null,
+  used_at  timestamp
+);
+
+CREATE TABLE seller_applications
+(
+  id             serial                                       not null unique,
+  user_id        int references users (id) on delete cascade  not null,
+  shop_name      varchar(255)                                 not null,
+  contact_email  varchar(255)                                 not null,
+  contact_phone  varchar(32)                                  not null,
+  description    varchar(255),
+  currency       char(3),
+  status         varchar(32)                                  not null,
+  comment        varchar(255),
+  reviewed_by    int references users (id) on delete set null,
+  created_at     timestamp                                    not null,
+  reviewed_at    timestamp
+);
+CREATE UNIQUE INDEX seller_applications_pending_idx ON seller_applications (user_id) WHERE status = 'pending';
+
+CREATE TABLE seller_profiles
+(
+  id             serial                                       not null unique,
+  user_id        int references users (id) on delete cascade  not null unique,
+  shop_name      varchar(255)                                 not null,
+  contact_email  varchar(255)                                 not null,
+  contact_phone  varchar(32)                                  not null,
+  description    varchar(255),
+  currency       char(3),
+  created_at     timestamp                                    not null
+);
+
+CREATE TABLE admin_audit_log
+(
+  id              serial        not null unique,
+  admin_id        int           not null,
+  target_user_id  int           not null,
+  action          varchar(64)   not null,
+  details         varchar(255),
+  created_at      timestamp     not null
+);
+
+CREATE TABLE outbox
+(
+  id            serial        not null unique,
+  kind          varchar(64)   not null,
+  payload       varchar(255)  not null,
+  attempts      int           not null default 0,
+  last_error    text,
+  available_at  timestamptz   not null default now(),
+  created_at    timestamptz   not null default now()
 );
+CREATE INDEX outbox_available_at_idx ON outbox (available_at);
 
+CREATE TABLE stored_images
+(
+  image_id    varchar(255)  not null primary key,
+  created_at  timestamptz   not null default now()
+);
@@ -0,0 +1,54 @@
+-- Moves the free-text product categories into the categories table. Run it
+-- once on databases created before categories existed, fresh databases get the
+-- new schema from init_db.sql.
+--
+-- Categories that only differ in case, spacing or punctuation ("Phones",
+-- " phones", "PHONES!") share a slug and are merged into one root category.
+-- Synonyms like "Smartphones" stay separate and can be moved under a common
+-- parent with the admin category endpoints afterwards.
+BEGIN;
+
+CREATE TABLE categories
+(
+  id           serial                                              not null unique,
+  parent_id    int references categories (id) on delete restrict,
+  name         varchar(255)                                        not null,
+  slug         varchar(255)                                        not null unique,
+  description  varchar(255),
+  created_at   timestamp                                           not null
+);
+CREATE INDEX categories_parent_id_idx ON categories (parent_id);
+
+-- The slug matches model.Slugify: lowercase words of letters and digits
+-- joined with dashes.
+CREATE FUNCTION pg_temp.slugify(name text) RETURNS text AS $$
+  SELECT coalesce(nullif(trim(both '-' from regexp_replace(lower(name), '[^[:alnum:]]+', '-', 'g')), ''), 'uncategorized')
+$$ LANGUAGE sql IMMUTABLE;
+
+INSERT INTO categories (name, slug, created_at)
+SELECT DISTINCT ON (pg_temp.slugify(category)) trim(category), pg_temp.slugify(category), now()
+FROM products
+ORDER BY pg_temp.slugify(category), trim(category);
+
+ALTER TABLE products ADD COLUMN category_id int references categories (id) on delete restrict;
+
+UPDATE products p SET category_id = c.id
+FROM categories c
+WHERE c.slug = pg_temp.slugify(p.category);
+
+ALTER TABLE products ALTER COLUMN category_id SET NOT NULL;
+CREATE INDEX products_category_id_idx ON products (category_id);
+
+-- Generated columns can't read other tables, so the search vector no longer
+-- covers the category name, the product search adds it from categories at
+-- query time. Dropping the column also drops products_search_idx.
+ALTER TABLE products DROP COLUMN search_vector;
+ALTER TABLE products DROP COLUMN category;
+ALTER TABLE products ADD COLUMN search_vector tsvector generated always as (
+  setweight(to_tsvector('simple', title), 'A') ||
+  setweight(to_tsvector('simple', coalesce(tag, '')), 'B') ||
+  setweight(to_tsvector('simple', coalesce(description, '')), 'C')
+) stored;
+CREATE INDEX products_search_idx ON products USING GIN (search_vector);
+
+COMMIT;
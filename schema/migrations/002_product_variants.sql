@@ -0,0 +1,43 @@
+-- Adds product options and variants. Existing products keep working without
+-- variants: their cart and order lines have no variant_id and use the
+-- product's own price and stock.
+BEGIN;
+
+CREATE TABLE product_options
+(
+  id          serial                                          not null unique,
+  product_id  int references products (id) on delete cascade  not null,
+  name        varchar(255)                                    not null,
+  position    int                                             not null default 0,
+  unique (product_id, name)
+);
+
+CREATE TABLE product_variants
+(
+  id          serial                                                        not null unique,
+  product_id  int references products (id) on delete cascade                not null,
+  sku         varchar(64)                                                   not null unique,
+  price       numeric                                    check (price > 0),
+  amount      int                                        check (amount >= 0) not null,
+  image_url   varchar(255),
+  image_id    varchar(255)                                                  unique,
+  created_at  timestamp                                                     not null,
+  updated_at  timestamp                                                     not null
+);
+CREATE INDEX product_variants_product_id_idx ON product_variants (product_id);
+
+CREATE TABLE product_variant_values
+(
+  variant_id  int references product_variants (id) on delete cascade  not null,
+  option_id   int references product_options (id)                     not null,
+  value       varchar(255)                                            not null,
+  primary key (variant_id, option_id)
+);
+
+ALTER TABLE products_carts ADD COLUMN variant_id int references product_variants (id) on delete cascade;
+ALTER TABLE products_orders ADD COLUMN variant_id int references product_variants (id) on delete cascade;
+
+-- The cart service never added a product twice, so existing carts satisfy the index.
+CREATE UNIQUE INDEX products_carts_line_idx ON products_carts (cart_id, product_id, coalesce(variant_id, 0));
+
+COMMIT;
@@ -0,0 +1,23 @@
+-- Moves the single image of every product into the product_images gallery,
+-- where it becomes the primary image.
+BEGIN;
+
+CREATE TABLE product_images
+(
+  id          serial                                          not null unique,
+  product_id  int references products (id) on delete cascade  not null,
+  image_url   varchar(255)                                    not null,
+  image_id    varchar(255)                                    not null unique,
+  position    int                                             not null,
+  is_primary  boolean                                         not null default false,
+  created_at  timestamp                                       not null
+);
+CREATE INDEX product_images_product_id_idx ON product_images (product_id, position);
+CREATE UNIQUE INDEX product_images_primary_idx ON product_images (product_id) WHERE is_primary;
+
+INSERT INTO product_images (product_id, image_url, image_id, position, is_primary, created_at)
+SELECT id, image_url, image_id, 0, true, created_at FROM products;
+
+ALTER TABLE products DROP COLUMN image_url, DROP COLUMN image_id;
+
+COMMIT;
@@ -0,0 +1,8 @@
+-- Stores the URLs of the thumbnails made for uploaded images. Images uploaded
+-- before have no thumbnails.
+BEGIN;
+
+ALTER TABLE product_images ADD COLUMN thumbnails jsonb NOT NULL DEFAULT '{}';
+ALTER TABLE product_variants ADD COLUMN thumbnails jsonb;
+
+COMMIT;
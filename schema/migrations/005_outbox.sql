@@ -0,0 +1,28 @@
+-- Adds the outbox for image deletions and the ledger of stored images. The
+-- images referenced so far are recorded in the ledger.
+BEGIN;
+
+CREATE TABLE outbox
+(
+  id            serial        not null unique,
+  kind          varchar(64)   not null,
+  payload       varchar(255)  not null,
+  attempts      int           not null default 0,
+  last_error    text,
+  available_at  timestamptz   not null default now(),
+  created_at    timestamptz   not null default now()
+);
+CREATE INDEX outbox_available_at_idx ON outbox (available_at);
+
+CREATE TABLE stored_images
+(
+  image_id    varchar(255)  not null primary key,
+  created_at  timestamptz   not null default now()
+);
+
+INSERT INTO stored_images (image_id)
+SELECT image_id FROM product_images
+UNION
+SELECT image_id FROM product_variants WHERE image_id IS NOT NULL;
+
+COMMIT;
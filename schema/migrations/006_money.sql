@@ -0,0 +1,27 @@
+-- Adds currencies to products, seller profiles and applications, and the total
+-- to orders. Prices were written from float32 values, so they are rounded to
+-- whole kopecks. Existing products and orders are taken to be in roubles,
+-- replace RUB below if the market used a different currency.
+BEGIN;
+
+UPDATE products SET price = round(price, 2);
+UPDATE product_variants SET price = round(price, 2) WHERE price IS NOT NULL;
+
+ALTER TABLE products ADD COLUMN currency char(3);
+UPDATE products SET currency = 'RUB';
+ALTER TABLE products ALTER COLUMN currency SET NOT NULL;
+
+ALTER TABLE seller_applications ADD COLUMN currency char(3);
+ALTER TABLE seller_profiles ADD COLUMN currency char(3);
+
+ALTER TABLE orders ADD COLUMN total numeric, ADD COLUMN currency char(3);
+UPDATE orders o SET currency = 'RUB', total = coalesce((
+  SELECT sum(coalesce(v.price, p.price) * po.purchased_amount)
+  FROM products_orders po
+  INNER JOIN products p ON p.id = po.product_id
+  LEFT JOIN product_variants v ON v.id = po.variant_id
+  WHERE po.order_id = o.id
+), 0);
+ALTER TABLE orders ALTER COLUMN total SET NOT NULL, ALTER COLUMN currency SET NOT NULL;
+
+COMMIT;
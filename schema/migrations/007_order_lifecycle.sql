@@ -0,0 +1,64 @@
+-- Snapshots the title, unit price, SKU and seller of order lines, so past
+-- orders no longer follow later changes of products or lose lines of deleted
+-- products. Orders get a status with its history and the subtotal, tax and
+-- shipping amounts. Existing lines take the current prices, existing orders
+-- are taken to be paid, or delivered once their delivery date has passed, and
+-- had neither tax nor shipping.
+BEGIN;
+
+ALTER TABLE products_orders
+  ADD COLUMN seller_id int REFERENCES users (id) ON DELETE SET NULL,
+  ADD COLUMN title varchar(255),
+  ADD COLUMN sku varchar(64),
+  ADD COLUMN unit_price numeric,
+  ADD COLUMN currency char(3);
+
+UPDATE products_orders po
+SET seller_id = p.user_id, title = p.title, currency = p.currency,
+  sku = (SELECT v.sku FROM product_variants v WHERE v.id = po.variant_id),
+  unit_price = coalesce((SELECT v.price FROM product_variants v WHERE v.id = po.variant_id), p.price)
+FROM products p
+WHERE p.id = po.product_id;
+
+ALTER TABLE products_orders
+  ALTER COLUMN title SET NOT NULL,
+  ALTER COLUMN unit_price SET NOT NULL,
+  ALTER COLUMN currency SET NOT NULL,
+  ALTER COLUMN product_id DROP NOT NULL,
+  DROP CONSTRAINT products_orders_product_id_fkey,
+  ADD CONSTRAINT products_orders_product_id_fkey FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE SET NULL,
+  DROP CONSTRAINT products_orders_variant_id_fkey,
+  ADD CONSTRAINT products_orders_variant_id_fkey FOREIGN KEY (variant_id) REFERENCES product_variants (id) ON DELETE SET NULL;
+
+ALTER TABLE orders
+  ADD COLUMN status varchar(32) NOT NULL DEFAULT 'pending',
+  ADD COLUMN subtotal numeric,
+  ADD COLUMN tax numeric NOT NULL DEFAULT 0,
+  ADD COLUMN shipping numeric NOT NULL DEFAULT 0,
+  ALTER COLUMN delivered_at DROP NOT NULL;
+
+UPDATE orders SET subtotal = total,
+  status = CASE WHEN delivered_at <= now() THEN 'delivered' ELSE 'paid' END;
+UPDATE orders SET delivered_at = NULL WHERE status <> 'delivered';
+
+ALTER TABLE orders
+  ALTER COLUMN subtotal SET NOT NULL,
+  ALTER COLUMN tax DROP DEFAULT,
+  ALTER COLUMN shipping DROP DEFAULT;
+
+CREATE TABLE order_status_history
+(
+  id           serial                                        not null unique,
+  order_id     int references orders (id) on delete cascade  not null,
+  from_status  varchar(32),
+  to_status    varchar(32)                                   not null,
+  changed_by   int references users (id) on delete set null,
+  comment      varchar(255),
+  created_at   timestamp                                     not null
+);
+CREATE INDEX order_status_history_order_id_idx ON order_status_history (order_id);
+
+INSERT INTO order_status_history (order_id, to_status, changed_by, created_at)
+SELECT id, status, NULL, created_at FROM orders;
+
+COMMIT;
@@ -0,0 +1,9 @@
+-- Lets the stock of products reach zero, so the last item can be sold.
+-- Fresh databases get the same constraint from init_db.sql.
+BEGIN;
+
+ALTER TABLE products
+  DROP CONSTRAINT IF EXISTS products_amount_check,
+  ADD CONSTRAINT products_amount_check CHECK (amount >= 0);
+
+COMMIT;
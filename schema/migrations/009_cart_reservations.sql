@@ -0,0 +1,13 @@
+-- Lets cart lines reserve stock until reserved_until. products.reserved and
+-- product_variants.reserved hold the sum of the reservations, so the stock
+-- available to other buyers is amount - reserved. Existing cart lines reserve
+-- nothing.
+BEGIN;
+
+ALTER TABLE products ADD COLUMN reserved int NOT NULL DEFAULT 0 CHECK (reserved >= 0);
+ALTER TABLE product_variants ADD COLUMN reserved int NOT NULL DEFAULT 0 CHECK (reserved >= 0);
+
+ALTER TABLE products_carts ADD COLUMN reserved_until timestamp;
+CREATE INDEX products_carts_reserved_until_idx ON products_carts (reserved_until) WHERE reserved_until IS NOT NULL;
+
+COMMIT;
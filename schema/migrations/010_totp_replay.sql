@@ -0,0 +1,7 @@
+-- Remembers the time step of the last accepted TOTP code per user, so a code
+-- can't be used again while it is still valid.
+BEGIN;
+
+ALTER TABLE users ADD COLUMN totp_last_step bigint;
+
+COMMIT;